@@ -25,33 +25,45 @@ type Service interface {
 }
 
 type service struct {
-	db *sql.DB
+	db   *sql.DB
+	name string
 }
 
-var (
-	database   = os.Getenv("BLUEPRINT_DB_DATABASE")
-	password   = os.Getenv("BLUEPRINT_DB_PASSWORD")
-	username   = os.Getenv("BLUEPRINT_DB_USERNAME")
-	port       = os.Getenv("BLUEPRINT_DB_PORT")
-	host       = os.Getenv("BLUEPRINT_DB_HOST")
-	schema     = os.Getenv("BLUEPRINT_DB_SCHEMA")
-	dbInstance *service
-)
+// Config holds the connection settings for a Postgres database.
+type Config struct {
+	Database string
+	Password string
+	Username string
+	Port     string
+	Host     string
+	Schema   string
+}
 
-func New() Service {
-	// Reuse Connection
-	if dbInstance != nil {
-		return dbInstance
+// ConfigFromEnv reads the connection settings from the BLUEPRINT_DB_* environment variables.
+func ConfigFromEnv() Config {
+	return Config{
+		Database: os.Getenv("BLUEPRINT_DB_DATABASE"),
+		Password: os.Getenv("BLUEPRINT_DB_PASSWORD"),
+		Username: os.Getenv("BLUEPRINT_DB_USERNAME"),
+		Port:     os.Getenv("BLUEPRINT_DB_PORT"),
+		Host:     os.Getenv("BLUEPRINT_DB_HOST"),
+		Schema:   os.Getenv("BLUEPRINT_DB_SCHEMA"),
 	}
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
+}
+
+// New opens a new connection pool for cfg.
+// Every call returns an independent Service; the caller owns it and must Close it.
+func New(cfg Config) (Service, error) {
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s",
+		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database, cfg.Schema)
 	db, err := sql.Open("pgx", connStr)
 	if err != nil {
-		log.Fatal(err)
-	}
-	dbInstance = &service{
-		db: db,
+		return nil, err
 	}
-	return dbInstance
+	return &service{
+		db:   db,
+		name: cfg.Database,
+	}, nil
 }
 
 // Health checks the health of the database connection by pinging the database.
@@ -110,6 +122,6 @@ func (s *service) Health() map[string]string {
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
-	log.Printf("Disconnected from database: %s", database)
+	log.Printf("Disconnected from database: %s", s.name)
 	return s.db.Close()
 }
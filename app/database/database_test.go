@@ -85,7 +85,10 @@ func TestNew(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := New()
+			got, err := New(ConfigFromEnv())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			if got == nil && !tt.wantErr {
 				t.Error("New() returned nil, expected valid connection")
 			}
@@ -95,19 +98,24 @@ func TestNew(t *testing.T) {
 }
 
 func TestClose(t *testing.T) {
-	srv := New()
+	srv, err := New(ConfigFromEnv())
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
 
 	if srv.Close() != nil {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
 
-func TestSingletonPattern(t *testing.T) {
-	// Test that New() returns the same instance
-	db1 := New()
-	db2 := New()
+func TestNewReturnsIndependentInstances(t *testing.T) {
+	// Each caller owns its own connection pool, so tests can use isolated databases
+	db1, _ := New(ConfigFromEnv())
+	db2, _ := New(ConfigFromEnv())
+	defer db1.Close()
+	defer db2.Close()
 
-	if db1 != db2 {
-		t.Error("New() should return the same instance (singleton pattern)")
+	if db1 == db2 {
+		t.Error("New() should not share a package-level instance")
 	}
 }
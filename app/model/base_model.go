@@ -1,17 +1,14 @@
 package model
 
 import (
-	"os"
+	"fmt"
 	"time"
 
-	"github.com/sirupsen/logrus"
 	"github.com/twotwo/go-blueprint/app/global/variable"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DBConn *gorm.DB
-
 type BaseModel struct {
 	*gorm.DB  `gorm:"-" json:"-"`
 	Id        int64 `gorm:"primaryKey;comment:Primary Key" json:"id"`
@@ -20,29 +17,29 @@ type BaseModel struct {
 	//DeletedAt gorm.DeletedAt `json:"deleted_at"`   // 使用软删除功能，打开本行注释掉的代码即可；同时需要在数据库的所有表增加字段deleted_at 类型为 datetime
 }
 
-func initConnection() *gorm.DB {
-	db, err := gorm.Open(postgres.Open(variable.DSN), &gorm.Config{})
+// Open 连接 Postgres 并按 variable 中的参数设置连接池
+// 连接由调用方持有并显式传给需要的模块，本包不保存全局连接
+func Open(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		panic("failed to connect to db: " + err.Error())
+		return nil, fmt.Errorf("failed to connect to db: %w", err)
+	}
+	if err := setConnectionPool(db); err != nil {
+		return nil, err
 	}
-	setConnectionPool(db)
 
-	return db
+	return db, nil
 }
 
-func setConnectionPool(db *gorm.DB) {
+func setConnectionPool(db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
-		logrus.Errorf("failed to get db connection: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get db connection: %w", err)
 	}
 
 	sqlDB.SetMaxIdleConns(variable.ConnMaxIdleConns)
 	sqlDB.SetMaxOpenConns(variable.ConnMaxOpenConns)
 	sqlDB.SetConnMaxLifetime(time.Second * time.Duration(variable.ConnMaxLifetime))
 	sqlDB.SetConnMaxIdleTime(time.Second * time.Duration(variable.ConnMaxIdleTime))
-}
-
-func init() {
-	DBConn = initConnection()
+	return nil
 }
@@ -1,24 +1,9 @@
 package model
 
 import (
-	"gorm.io/datatypes"
+	"github.com/twotwo/go-blueprint/domain"
 )
 
-type UsersModel struct {
-	BaseModel
-	Username                 string `gorm:"default:null;comment:Username" json:"username"`
-	PrimaryEmail             string `gorm:"default:null;comment:邮箱"`
-	PrimaryPhone             string `gorm:"default:null;comment:电话"`
-	PasswordEncrypted        string
-	PasswordEncryptionMethod string
-	Name                     string
-	Avatar                   string
-	Profile                  datatypes.JSON `gorm:"default:'{}'::jsonb"`
-	ApplicationID            string
-	IsSuspended              bool `gorm:"default:false"`
-}
-
-// 表名
-func (u *UsersModel) TableName() string {
-	return "users"
-}
+// UsersModel 用户模型，与 server/user 共用统一的用户领域模型
+// 表结构迁移见 domain.MigrateUsers
+type UsersModel = domain.User
@@ -17,7 +17,8 @@ type Server struct {
 	db   database.Service
 }
 
-func NewServer() *http.Server {
+// NewServer 使用调用方创建的数据库服务构造 HTTP 服务
+func NewServer(db database.Service) *http.Server {
 	cfg, err := utils.GetConfigInstance()
 	if err != nil {
 		panic(err)
@@ -25,7 +26,7 @@ func NewServer() *http.Server {
 	NewServer := &Server{
 		port: cfg.ServicePort,
 		auth: BasicAuth("example", map[string]string{"admin": "admin"}),
-		db:   database.New(),
+		db:   db,
 	}
 
 	// Declare Server config
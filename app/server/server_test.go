@@ -13,7 +13,7 @@ var server *http.Server
 
 func TestMain(m *testing.M) {
 	fmt.Println("Set up stuff for tests here")
-	server = NewServer()
+	server = NewServer(nil)
 	defer server.Close()
 	fmt.Println("Clean up stuff after tests here")
 }
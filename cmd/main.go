@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/twotwo/go-blueprint/app/database"
 	"github.com/twotwo/go-blueprint/app/server"
 )
 
@@ -38,7 +39,13 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 
 func main() {
 
-	server := server.NewServer()
+	db, err := database.New(database.ConfigFromEnv())
+	if err != nil {
+		panic(fmt.Sprintf("database error: %s", err))
+	}
+	defer db.Close()
+
+	server := server.NewServer(db)
 	log.Println(fmt.Sprintf("server listening at %s, press ctrl+c to stop", server.Addr))
 
 	// Create a done channel to signal when the shutdown is complete
@@ -47,7 +54,7 @@ func main() {
 	// Run graceful shutdown in a separate goroutine
 	go gracefulShutdown(server, done)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("http server error: %s", err))
 	}
@@ -2,32 +2,118 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"gorm.io/gorm"
 
 	"github.com/twotwo/go-blueprint/pkg/database"
+	"github.com/twotwo/go-blueprint/pkg/idempotency"
+	"github.com/twotwo/go-blueprint/pkg/jsonschema"
+	"github.com/twotwo/go-blueprint/pkg/lease"
+	"github.com/twotwo/go-blueprint/pkg/module"
+	"github.com/twotwo/go-blueprint/pkg/queue"
+	"github.com/twotwo/go-blueprint/pkg/storage"
+	"github.com/twotwo/go-blueprint/pkg/variables"
 	"github.com/twotwo/go-blueprint/server"
+	"github.com/twotwo/go-blueprint/server/message"
 	"github.com/twotwo/go-blueprint/server/user"
 )
 
 func main() {
-	// 初始化数据库
-	db, err := database.Setup()
+	// 初始化数据库，同时迁移各模块的表
+	models := append(message.Models(), queue.Models()...)
+	models = append(models, lease.Models()...)
+	models = append(models, idempotency.Models()...)
+	models = append(models, user.Models()...)
+	db, err := database.Setup(models...)
 	if err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
+	// 消息内容的检索索引不是普通的表结构，随自动迁移单独创建
+	if variables.GetEnvBool("DB_AUTO_MIGRATE", true) {
+		if err := message.MigrateSearch(db); err != nil {
+			log.Fatalf("消息检索索引创建失败: %v", err)
+		}
+	}
+
+	// 初始化文件存储
+	store, err := storage.Setup()
+	if err != nil {
+		log.Fatalf("文件存储初始化失败: %v", err)
+	}
+
+	userCfg := user.Config{AvatarStorage: store}
+
+	// 加载用户资料的 JSON Schema（可选）
+	if path := os.Getenv("USER_PROFILE_SCHEMA"); path != "" {
+		schema, err := jsonschema.Load(path)
+		if err != nil {
+			log.Fatalf("用户资料 Schema 加载失败: %v", err)
+		}
+		userCfg.ProfileSchema = schema
+	}
+
+	// 新用户的欢迎站内信（可选），{username} 替换为用户名
+	// 与用户在同一事务中写入发件箱，注册失败时不会发出
+	if welcome := os.Getenv("USER_WELCOME_MESSAGE"); welcome != "" {
+		userCfg.AfterCreate = func(tx *gorm.DB, u *user.UserModel) error {
+			return message.CreateInTx(tx, &message.MessageModel{
+				Type:    message.Sitemessage,
+				UserID:  int64(u.ID),
+				Content: strings.ReplaceAll(welcome, "{username}", u.Username),
+			})
+		}
+	}
+
+	// 初始化消息投递通道
+	providers, err := message.SetupProviders()
+	if err != nil {
+		log.Fatalf("消息投递通道初始化失败: %v", err)
+	}
+
+	// 各投递通道回执回调的签名密钥，格式 provider:secret,provider:secret
+	callbackSecrets, err := message.ParseCallbackSecrets(os.Getenv("MESSAGE_CALLBACK_SECRETS"))
+	if err != nil {
+		log.Fatalf("投递回执密钥配置错误: %v", err)
+	}
+
+	// 发送频率限制，格式 scope:limit/window，如 phone_number:1/minute,phone_number:10/day
+	rateLimits, err := message.ParseRateLimits(os.Getenv("MESSAGE_RATE_LIMITS"))
+	if err != nil {
+		log.Fatalf("发送频率限制配置错误: %v", err)
+	}
 
-	// 设置用户模块的数据库连接
-	user.DB = db
+	// 短信免打扰时段，格式 HH:MM-HH:MM，按接收方时区计算
+	quietHours, err := message.ParseQuietHours(os.Getenv("MESSAGE_QUIET_HOURS"), os.Getenv("MESSAGE_QUIET_HOURS_TIMEZONE"))
+	if err != nil {
+		log.Fatalf("免打扰时段配置错误: %v", err)
+	}
+
+	// 验证码哈希密钥必须显式配置，随机密钥会让重启前或其他副本发送的验证码无法校验
+	otpSecret := os.Getenv("MESSAGE_OTP_SECRET")
+	if otpSecret == "" {
+		log.Fatal("未配置验证码密钥 MESSAGE_OTP_SECRET")
+	}
+
+	// 各资源模块共享的依赖
+	deps := module.Deps{
+		DB:     db,
+		Logger: slog.Default(),
+		Clock:  module.SystemClock,
+	}
 
 	// 创建根路由
 	r := chi.NewRouter()
@@ -37,18 +123,83 @@ func main() {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	// SSE、WebSocket 等长连接推送不受请求超时限制
+	r.Use(middleware.Maybe(middleware.Timeout(60*time.Second), func(r *http.Request) bool {
+		return !isStreamRequest(r)
+	}))
 	// CORS 中间件,允许跨域请求
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*", "vscode-webview://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"}, // 限制可以跨域的 请求头
+		AllowedHeaders:   []string{"Authorization", "Content-Type", idempotency.Header}, // 限制可以跨域的 请求头
+		ExposedHeaders:   []string{"Retry-After", idempotency.ReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           300, // 缓存预检请求 300 秒
 	}))
 
+	// 消息投递队列，worker 随服务启动，关闭时等待其退出
+	deliveryQueue := queue.New(deps, queue.Config{
+		Workers:     variables.GetEnvInt("QUEUE_WORKERS", 2),
+		MaxAttempts: variables.GetEnvInt("QUEUE_MAX_ATTEMPTS", 5),
+	})
+
+	users := user.New(deps, userCfg)
+	messages := message.New(deps, message.Config{
+		Providers:       providers,
+		Queue:           deliveryQueue,
+		CallbackSecrets: callbackSecrets,
+		RateLimits:      rateLimits,
+		QuietHours:      quietHours,
+		// 多副本部署时各副本需配置相同的验证码密钥
+		OTP: message.OTPConfig{
+			Secret:     otpSecret,
+			TemplateID: os.Getenv("MESSAGE_OTP_TEMPLATE"),
+		},
+		// 投递前按接收方的通知偏好过滤，消息类型即送达媒介
+		Preferences: func(ctx context.Context, msg *message.MessageModel) (bool, error) {
+			target := user.NotificationTarget{Phone: msg.PhoneNumber, Email: msg.Email}
+			if msg.Type == message.Sitemessage {
+				target = user.NotificationTarget{UserID: uint(msg.UserID)}
+			}
+			return users.AllowsNotification(ctx, target, msg.Category, string(msg.Type))
+		},
+		// /auth/login 使用用户模块的账号校验
+		Login: func(ctx context.Context, username, password string) (string, error) {
+			token, err := users.Login(ctx, username, password)
+			if errors.Is(err, user.ErrInvalidCredentials) {
+				return "", message.ErrInvalidCredentials
+			}
+			return token, err
+		},
+	})
+
 	// 注册API路由
-	server.RegisterRoutes(r)
+	server.RegisterRoutes(r,
+		users,
+		messages,
+	)
+
+	// 投递队列的 worker、定时发送调度器和发件箱中继随服务启动，关闭时等待其退出
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(3)
+	go func() {
+		defer workers.Done()
+		deliveryQueue.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		messages.RunScheduler(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		messages.RunOutboxRelay(workerCtx)
+	}()
+
+	// 本地存储需要由服务自身提供签名下载
+	if local, ok := store.(*storage.Local); ok {
+		r.Handle("/files/*", http.StripPrefix("/files", local.Handler()))
+	}
 
 	// 服务配置
 	port := os.Getenv("PORT")
@@ -82,5 +233,14 @@ func main() {
 		log.Fatalf("服务关闭错误: %s\n", err)
 	}
 
+	stopWorkers()
+	workers.Wait()
+
 	log.Println("服务已关闭")
 }
+
+// isStreamRequest 判断是否为 SSE 或 WebSocket 长连接请求
+func isStreamRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
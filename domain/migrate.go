@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyUserColumns 旧表结构中需要合并到新字段的列
+// app/model.UsersModel 使用 primary_email/primary_phone，server/user.UserModel 使用明文 password
+var legacyUserColumns = []struct {
+	from, to string
+}{
+	{"primary_email", "email"},
+	{"primary_phone", "phone"},
+}
+
+// MigrateUsers 迁移 users 表到统一的 User 结构
+//
+// 兼容两种旧结构：
+//   - app/model.UsersModel：int 类型的 created_at/updated_at（Unix 秒），primary_email/primary_phone
+//   - server/user.UserModel：明文存储的 password 列
+//
+// 旧数据会被合并到新列后删除旧列，明文密码标记为 plain，首次登录成功后升级为 bcrypt；
+// 手机号按 NormalizePhone 规范化。
+// 每一步都可以重复执行，迁移中断后重新运行即可。
+func MigrateUsers(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&User{}) {
+		return db.AutoMigrate(&User{})
+	}
+
+	// 1. 整数时间戳转换为时间类型，必须在 AutoMigrate 之前完成
+	for _, column := range []string{"created_at", "updated_at"} {
+		if err := convertUnixTimestamp(db, column); err != nil {
+			return fmt.Errorf("domain: convert users.%s: %w", column, err)
+		}
+	}
+
+	// 2. 补齐新增的列和索引
+	if err := db.AutoMigrate(&User{}); err != nil {
+		return err
+	}
+
+	// 3. 合并旧列
+	for _, c := range legacyUserColumns {
+		if !m.HasColumn(&User{}, c.from) {
+			continue
+		}
+		err := db.Exec("UPDATE users SET ? = ? WHERE (? IS NULL OR ? = '') AND ? IS NOT NULL",
+			clause.Column{Name: c.to}, clause.Column{Name: c.from},
+			clause.Column{Name: c.to}, clause.Column{Name: c.to}, clause.Column{Name: c.from},
+		).Error
+		if err != nil {
+			return fmt.Errorf("domain: copy users.%s: %w", c.from, err)
+		}
+		if err := dropColumn(db, c.from); err != nil {
+			return err
+		}
+	}
+
+	// 4. 明文密码迁移到 password_encrypted
+	if m.HasColumn(&User{}, "password") {
+		err := db.Exec(
+			"UPDATE users SET password_encrypted = password, password_encryption_method = ? WHERE (password_encrypted IS NULL OR password_encrypted = '') AND password IS NOT NULL",
+			PasswordPlain,
+		).Error
+		if err != nil {
+			return fmt.Errorf("domain: copy users.password: %w", err)
+		}
+		if err := dropColumn(db, "password"); err != nil {
+			return err
+		}
+	}
+
+	// 5. 规范化手机号，与保存时的 NormalizePhone 一致
+	err := db.Exec(
+		"UPDATE users SET phone = REPLACE(REPLACE(REPLACE(phone, '+', ''), ' ', ''), '-', '') WHERE phone LIKE '%+%' OR phone LIKE '% %' OR phone LIKE '%-%'",
+	).Error
+	if err != nil {
+		return fmt.Errorf("domain: normalize users.phone: %w", err)
+	}
+
+	return nil
+}
+
+// convertUnixTimestamp 将存储 Unix 秒数的整数列转换为时间类型
+func convertUnixTimestamp(db *gorm.DB, column string) error {
+	columnTypes, err := db.Migrator().ColumnTypes(&User{})
+	if err != nil {
+		return err
+	}
+
+	isInteger := false
+	for _, ct := range columnTypes {
+		if ct.Name() == column {
+			isInteger = strings.Contains(strings.ToUpper(ct.DatabaseTypeName()), "INT")
+		}
+	}
+	if !isInteger {
+		return nil
+	}
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Exec("ALTER TABLE users ALTER COLUMN ? TYPE timestamptz USING to_timestamp(?)",
+			clause.Column{Name: column}, clause.Column{Name: column}).Error
+	case "sqlite":
+		// SQLite 列类型只影响读取时的解析，先转换数据再重建列定义
+		err := db.Exec("UPDATE users SET ? = datetime(?, 'unixepoch') WHERE typeof(?) = 'integer'",
+			clause.Column{Name: column}, clause.Column{Name: column}, clause.Column{Name: column}).Error
+		if err != nil {
+			return err
+		}
+		return db.Migrator().AlterColumn(&User{}, column)
+	default:
+		return fmt.Errorf("integer timestamps are not supported on %s", db.Dialector.Name())
+	}
+}
+
+// dropColumn 删除旧列（SQLite 需要 3.35 及以上版本）
+func dropColumn(db *gorm.DB, column string) error {
+	if err := db.Exec("ALTER TABLE users DROP COLUMN ?", clause.Column{Name: column}).Error; err != nil {
+		return fmt.Errorf("domain: drop users.%s: %w", column, err)
+	}
+	return nil
+}
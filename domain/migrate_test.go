@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	BcryptCost = bcrypt.MinCost
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	// 每个测试使用独立的命名内存库，连接池中的连接共享同一份数据
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func TestMigrateUsersFreshTable(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, MigrateUsers(db))
+
+	u := User{Username: "fresh"}
+	require.NoError(t, u.SetPassword("secret"))
+	require.NoError(t, db.Create(&u).Error)
+
+	var found User
+	require.NoError(t, db.First(&found, "username = ?", "fresh").Error)
+	assert.NoError(t, found.CheckPassword("secret"))
+	assert.ErrorIs(t, found.CheckPassword("wrong"), ErrPasswordMismatch)
+}
+
+// 旧版 server/user.UserModel 的表结构
+func TestMigrateUsersFromServerSchema(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.Exec("CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT," +
+		"`created_at` datetime,`updated_at` datetime,`deleted_at` datetime," +
+		"`username` text NOT NULL UNIQUE,`first_name` text,`last_name` text,`email` text," +
+		"`password` text NOT NULL,`phone` text,`user_status` integer DEFAULT 0)").Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (created_at, updated_at, username, email, password, user_status)
+		VALUES (datetime('now'), datetime('now'), 'legacy', 'legacy@example.com', 'plain-pwd', 1)`).Error)
+
+	require.NoError(t, MigrateUsers(db))
+	// 重复执行不应出错
+	require.NoError(t, MigrateUsers(db))
+
+	assert.False(t, db.Migrator().HasColumn(&User{}, "password"))
+
+	var u User
+	require.NoError(t, db.First(&u, "username = ?", "legacy").Error)
+	assert.Equal(t, "legacy@example.com", u.Email)
+	assert.Equal(t, PasswordPlain, u.PasswordEncryptionMethod)
+	assert.NoError(t, u.CheckPassword("plain-pwd"))
+	assert.True(t, u.NeedsRehash())
+
+	// 迁移后 password 列不再是 NOT NULL 约束的阻碍
+	require.NoError(t, db.Create(&User{Username: "new"}).Error)
+}
+
+// 旧版 app/model.UsersModel 的表结构（整数时间戳）
+func TestMigrateUsersFromAppSchema(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.Exec("CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT," +
+		"`created_at` integer,`updated_at` integer," +
+		"`username` text,`primary_email` text,`primary_phone` text," +
+		"`password_encrypted` text,`password_encryption_method` text," +
+		"`name` text,`avatar` text,`profile` text DEFAULT '{}',`application_id` text," +
+		"`is_suspended` numeric DEFAULT false)").Error)
+
+	created := time.Date(2025, 2, 17, 15, 4, 5, 0, time.UTC)
+	require.NoError(t, db.Exec(`INSERT INTO users (created_at, updated_at, username, primary_email, primary_phone, name, profile)
+		VALUES (?, ?, 'appuser', 'app@example.com', '13800138000', 'App User', '{"city":"Beijing"}')`,
+		created.Unix(), created.Unix()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (created_at, updated_at, username, primary_phone)
+		VALUES (?, ?, 'formatted', '+86 139-0013-9000')`, created.Unix(), created.Unix()).Error)
+
+	require.NoError(t, MigrateUsers(db))
+	require.NoError(t, MigrateUsers(db))
+
+	assert.False(t, db.Migrator().HasColumn(&User{}, "primary_email"))
+	assert.False(t, db.Migrator().HasColumn(&User{}, "primary_phone"))
+
+	var u User
+	require.NoError(t, db.First(&u, "username = ?", "appuser").Error)
+	assert.Equal(t, "app@example.com", u.Email)
+	assert.Equal(t, "13800138000", u.Phone)
+	assert.Equal(t, "App User", u.Name)
+	assert.JSONEq(t, `{"city":"Beijing"}`, string(u.Profile))
+	assert.True(t, created.Equal(u.CreatedAt), "created_at = %s", u.CreatedAt)
+
+	// 手机号按 NormalizePhone 规范化，并建立索引供按手机号查找
+	var formatted User
+	require.NoError(t, db.First(&formatted, "username = ?", "formatted").Error)
+	assert.Equal(t, "8613900139000", formatted.Phone)
+	assert.True(t, db.Migrator().HasIndex(&User{}, "Phone"))
+}
@@ -0,0 +1,117 @@
+// Package domain 定义各资源共享的领域模型
+// app/ 与 server/ 两套服务都基于这里的模型读写数据库，保证同一张表只有一种结构
+package domain
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 密码的加密方式
+const (
+	PasswordBcrypt = "bcrypt"
+	PasswordPlain  = "plain" // 仅用于迁移前遗留的明文密码，首次登录成功后会升级为 bcrypt
+)
+
+// 用户状态
+const (
+	UserInactive int32 = 0
+	UserActive   int32 = 1
+)
+
+// BcryptCost 密码哈希的计算强度，测试中可以调低以加快速度
+var BcryptCost = bcrypt.DefaultCost
+
+// ErrPasswordMismatch 密码不匹配
+var ErrPasswordMismatch = errors.New("domain: password mismatch")
+
+// User 用户领域模型，对应 users 表
+type User struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 账号信息
+	Username                 string `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	PasswordEncrypted        string `gorm:"size:100" json:"-"`
+	PasswordEncryptionMethod string `gorm:"size:20" json:"-"`
+	ApplicationID            string `gorm:"size:64" json:"application_id"`
+	UserStatus               int32  `gorm:"default:0" json:"user_status"` // 用户状态，0=非活跃, 1=活跃
+	IsSuspended              bool   `gorm:"default:false" json:"is_suspended"`
+
+	// 个人信息
+	Name      string `gorm:"size:100" json:"name"` // 显示名称
+	FirstName string `gorm:"size:50" json:"first_name"`
+	LastName  string `gorm:"size:50" json:"last_name"`
+	Email     string `gorm:"size:100" json:"email"`
+	Phone     string `gorm:"size:20;index" json:"phone"` // 保存时经 NormalizePhone 规范化，按手机号查找接收方时走索引
+	Avatar    string `gorm:"size:255" json:"-"`          // 头像原图在存储后端中的 key，为空表示未上传
+
+	// 自由格式的用户资料
+	Profile datatypes.JSON `gorm:"default:'{}'" json:"profile"`
+}
+
+// TableName 指定用户表名
+func (User) TableName() string {
+	return "users"
+}
+
+// phoneReplacer 去掉手机号中的格式字符
+var phoneReplacer = strings.NewReplacer("+", "", " ", "", "-", "")
+
+// NormalizePhone 去掉手机号中的 "+"、空格和连字符，
+// 使 "+86 138-0013-8000" 与 8613800138000 是同一个号码；消息模块对接收方手机号使用同样的规则
+func NormalizePhone(number string) string {
+	return phoneReplacer.Replace(number)
+}
+
+// BeforeSave 保存前规范化手机号，使按手机号查找与消息中的号码一致
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Phone = NormalizePhone(u.Phone)
+	return nil
+}
+
+// SetPassword 使用 bcrypt 保存密码哈希
+func (u *User) SetPassword(plain string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), BcryptCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordEncrypted = string(hash)
+	u.PasswordEncryptionMethod = PasswordBcrypt
+	return nil
+}
+
+// CheckPassword 校验密码，不匹配时返回 ErrPasswordMismatch
+func (u *User) CheckPassword(plain string) error {
+	switch u.PasswordEncryptionMethod {
+	case PasswordBcrypt:
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordEncrypted), []byte(plain)); err != nil {
+			return ErrPasswordMismatch
+		}
+		return nil
+	case PasswordPlain:
+		if subtle.ConstantTimeCompare([]byte(u.PasswordEncrypted), []byte(plain)) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	default:
+		return ErrPasswordMismatch
+	}
+}
+
+// NeedsRehash 判断密码是否需要升级为当前的哈希方式
+func (u *User) NeedsRehash() bool {
+	if u.PasswordEncryptionMethod != PasswordBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(u.PasswordEncrypted))
+	return err != nil || cost != BcryptCost
+}
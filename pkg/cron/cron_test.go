@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * FOO *",
+	} {
+		_, err := Parse(expr)
+		assert.ErrorIs(t, err, ErrInvalid, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 31, 10, 17, 30, 0, time.UTC) // 周五
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 31, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"30 8-10 * * *", time.Date(2025, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2025, 1, 31, 10, 25, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)},
+		// 日和周都受限时满足任意一个即可：2 月 1 日不是 13 号但是周六
+		{"0 0 13 * SAT", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 31, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.want, s.Next(base), c.expr)
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(base).IsZero())
+}
+
+func TestNextInLocation(t *testing.T) {
+	t.Parallel()
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	s, err := Parse("0 9 * * *")
+	require.NoError(t, err)
+
+	// 按 after 所在时区计算：UTC 02:00 是上海 10:00，下一次是上海次日 09:00
+	next := s.Next(time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC).In(shanghai))
+	assert.Equal(t, time.Date(2025, 1, 2, 1, 0, 0, 0, time.UTC), next.UTC())
+
+	india := time.FixedZone("IST", 5*3600+1800)
+	next = s.Next(time.Date(2025, 1, 1, 8, 0, 0, 0, india))
+	assert.Equal(t, time.Date(2025, 1, 1, 9, 0, 0, 0, india), next)
+}
@@ -5,8 +5,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/twotwo/go-blueprint/domain"
 	"github.com/twotwo/go-blueprint/pkg/variables"
-	"github.com/twotwo/go-blueprint/server/user"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -16,7 +16,8 @@ import (
 )
 
 // Setup 初始化数据库连接并返回GORM DB实例
-func Setup() (*gorm.DB, error) {
+// 开启自动迁移时，除 users 表外还会迁移各资源模块通过 models 传入的模型
+func Setup(models ...interface{}) (*gorm.DB, error) {
 	dbType := variables.GetEnv("DB_TYPE", "sqlite")
 
 	var dialector gorm.Dialector
@@ -72,13 +73,16 @@ func Setup() (*gorm.DB, error) {
 	// 自动迁移模式
 	if variables.GetEnvBool("DB_AUTO_MIGRATE", true) {
 		log.Println("正在自动迁移数据库模式...")
-		err = db.AutoMigrate(
-			&user.UserModel{},
-			// 添加其他需要迁移的模型
-		)
-		if err != nil {
+		// users 表需要兼容旧结构，单独迁移
+		if err := domain.MigrateUsers(db); err != nil {
 			return nil, err
 		}
+		// 各资源模块自己的模型
+		if len(models) > 0 {
+			if err := db.AutoMigrate(models...); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return db, nil
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func TestSetupMigratesModels(t *testing.T) {
+	t.Setenv("DB_TYPE", "sqlite")
+	t.Setenv("DB_FILE", filepath.Join(t.TempDir(), "setup.db"))
+
+	db, err := Setup(&widget{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	assert.True(t, db.Migrator().HasTable("users"))
+	assert.True(t, db.Migrator().HasTable(&widget{}))
+}
+
+func TestSetupSkipsMigration(t *testing.T) {
+	t.Setenv("DB_TYPE", "sqlite")
+	t.Setenv("DB_FILE", filepath.Join(t.TempDir(), "setup.db"))
+	t.Setenv("DB_AUTO_MIGRATE", "false")
+
+	db, err := Setup(&widget{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	assert.False(t, db.Migrator().HasTable("users"))
+	assert.False(t, db.Migrator().HasTable(&widget{}))
+}
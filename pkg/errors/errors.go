@@ -2,13 +2,30 @@ package errors
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // APIError 表示API错误响应
 type APIError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+
+	// RetryAfter 客户端需要等待的秒数，大于 0 时同时写入 Retry-After 响应头
+	RetryAfter int `json:"retry_after,omitempty"`
+
+	// Fields 请求体中各字段的校验错误
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError 请求体中单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，嵌套字段用 . 连接，数组元素带下标，如 attachments[0].filename
+	Message string `json:"message"` // 错误说明
 }
 
 // Error 实现error接口
@@ -56,9 +73,47 @@ func InternalServer(message string) APIError {
 	return New(http.StatusInternalServerError, message)
 }
 
+// Validation 返回带字段错误的400错误
+func Validation(fields ...FieldError) APIError {
+	err := New(http.StatusBadRequest, "请求参数校验失败")
+	err.Fields = fields
+	return err
+}
+
+// TooManyRequests 返回429错误，retryAfter 向上取整为秒，至少为 1 秒
+func TooManyRequests(message string, retryAfter time.Duration) APIError {
+	if message == "" {
+		message = "请求过于频繁"
+	}
+	err := New(http.StatusTooManyRequests, message)
+	err.RetryAfter = max(int(math.Ceil(retryAfter.Seconds())), 1)
+	return err
+}
+
 // WriteJSON 将错误写入HTTP响应
 func WriteJSON(w http.ResponseWriter, err APIError) {
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(err.RetryAfter))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(err.Code)
 	json.NewEncoder(w).Encode(err)
 }
+
+// HandleRequestError 生成的严格模式处理器解析请求体失败时的处理函数，返回 400
+func HandleRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	WriteJSON(w, BadRequest("无效的请求体"))
+}
+
+// HandleResponseError 返回生成的严格模式处理器返回错误时的处理函数：
+// APIError 按其状态码写出，其他错误视为未预期的失败，记录日志后返回 500
+func HandleResponseError(logger *slog.Logger) func(w http.ResponseWriter, r *http.Request, err error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		var apiErr APIError
+		if !stderrors.As(err, &apiErr) {
+			logger.Error("处理请求失败", "method", r.Method, "path", r.URL.Path, "error", err)
+			apiErr = InternalServer("")
+		}
+		WriteJSON(w, apiErr)
+	}
+}
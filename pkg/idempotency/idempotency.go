@@ -0,0 +1,284 @@
+// Package idempotency 实现 Idempotency-Key 请求头：同一个键的重试请求重放第一次的响应
+//
+// 第一次请求执行处理器并在 idempotency_keys 表中保存响应，有效期内带相同键的请求
+// 不再执行处理器，直接返回保存的响应并带上 Idempotent-Replayed: true。
+// 相同的键配上不同的请求（方法、路径或请求体不同）返回 409；第一次请求仍在处理时
+// 重试同样返回 409，客户端稍后重试即可拿到结果。
+//
+// 键按 Authorization 请求头隔离，不同调用方使用相同的键互不影响。
+// 只保存 2xx 响应：4xx（如 409、429）和 5xx 都不保存，客户端可以用同一个键重试。
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+const (
+	// Header 客户端提供幂等键的请求头
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader 重放的响应带有该响应头
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// MaxKeyLength 幂等键的最大长度
+	MaxKeyLength = 255
+
+	// DefaultTTL 响应的默认保存时间
+	DefaultTTL = 24 * time.Hour
+
+	// DefaultLockTimeout 默认的处理超时，超过后认为第一次请求已中断，允许重试接手
+	DefaultLockTimeout = 2 * time.Minute
+)
+
+var (
+	// ErrMismatch 表示幂等键已用于不同的请求
+	ErrMismatch = stderrors.New("idempotency: key reused with a different request")
+
+	// ErrInProgress 表示使用该幂等键的请求仍在处理
+	ErrInProgress = stderrors.New("idempotency: request in progress")
+)
+
+// Record 一个幂等键及其保存的响应，StatusCode 为 0 表示请求仍在处理
+type Record struct {
+	ID          string      `gorm:"primaryKey;size:64" json:"id"` // 调用方与幂等键的哈希
+	Fingerprint string      `gorm:"size:64;not null" json:"fingerprint"`
+	StatusCode  int         `gorm:"not null;default:0" json:"status_code"`
+	Header      http.Header `gorm:"serializer:json;type:text" json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	ExpiresAt   time.Time   `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TableName 指定幂等键表名
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// Models 返回幂等键需要自动迁移的数据库模型
+func Models() []interface{} {
+	return []interface{}{&Record{}}
+}
+
+// Config 幂等键配置，零值字段使用默认值
+type Config struct {
+	TTL         time.Duration // 响应的保存时间，默认 DefaultTTL
+	LockTimeout time.Duration // 第一次请求的处理超时，默认 DefaultLockTimeout
+}
+
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = DefaultTTL
+	}
+	if c.LockTimeout <= 0 {
+		c.LockTimeout = DefaultLockTimeout
+	}
+	return c
+}
+
+// Store 基于数据库保存幂等键，多个副本共享
+type Store struct {
+	db     *gorm.DB
+	clock  module.Clock
+	config Config
+}
+
+// New 创建使用 deps.DB 的幂等键存储，表结构见 Models
+func New(deps module.Deps, cfg Config) *Store {
+	deps = deps.WithDefaults()
+	return &Store{db: deps.DB, clock: deps.Clock, config: cfg.withDefaults()}
+}
+
+// Middleware 为处理器启用 Idempotency-Key，没有该请求头的请求直接交给处理器
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength {
+			errors.WriteJSON(w, errors.BadRequest("Idempotency-Key 过长"))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			errors.WriteJSON(w, errors.BadRequest("读取请求体失败"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		id := hash(r.Header.Get("Authorization"), key)
+		fingerprint := hash(r.Method, r.URL.Path, string(body))
+		record, err := s.begin(r.Context(), id, fingerprint)
+		switch {
+		case stderrors.Is(err, ErrMismatch):
+			errors.WriteJSON(w, errors.New(http.StatusConflict, "Idempotency-Key 已用于不同的请求"))
+			return
+		case stderrors.Is(err, ErrInProgress):
+			errors.WriteJSON(w, errors.New(http.StatusConflict, "使用该 Idempotency-Key 的请求正在处理，请稍后重试"))
+			return
+		case err != nil:
+			errors.WriteJSON(w, errors.InternalServer("读取幂等键失败"))
+			return
+		}
+		if record != nil {
+			replay(w, record)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// 请求已经返回给客户端，保存失败时只能放弃该键，让重试重新执行
+		ctx := context.WithoutCancel(r.Context())
+		if !succeeded(rec.status) {
+			s.release(ctx, id)
+			return
+		}
+		if err := s.complete(ctx, id, rec); err != nil {
+			s.release(ctx, id)
+		}
+	})
+}
+
+// begin 占用幂等键：第一次使用时返回 nil, nil；已有保存的响应时返回该记录
+// 占用期间的重试返回 ErrInProgress，请求不一致时返回 ErrMismatch
+func (s *Store) begin(ctx context.Context, id, fingerprint string) (*Record, error) {
+	now := s.now()
+	lock := Record{ID: id, Fingerprint: fingerprint, ExpiresAt: now.Add(s.config.LockTimeout)}
+
+	// 顺带清理过期的键，表的大小只与有效期内的请求数有关
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&Record{}).Error; err != nil {
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&lock)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	// 键可能恰好在清理之后过期，条件更新保证只有一个请求接手
+	result = s.db.WithContext(ctx).Model(&Record{}).
+		Where("id = ? AND expires_at < ?", id, now).
+		Updates(map[string]interface{}{
+			"fingerprint": fingerprint,
+			"status_code": 0,
+			"header":      nil,
+			"body":        nil,
+			"expires_at":  lock.ExpiresAt,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var record Record
+	if err := s.db.WithContext(ctx).Where("id = ?", id).Take(&record).Error; err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			// 占用者刚刚放弃了该键
+			return nil, ErrInProgress
+		}
+		return nil, err
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	if record.StatusCode == 0 {
+		return nil, ErrInProgress
+	}
+	return &record, nil
+}
+
+// complete 保存处理器的响应
+func (s *Store) complete(ctx context.Context, id string, rec *recorder) error {
+	header, err := json.Marshal(rec.Header())
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&Record{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status_code": rec.status,
+			"header":      string(header),
+			"body":        rec.body.Bytes(),
+			"expires_at":  s.now().Add(s.config.TTL),
+		}).Error
+}
+
+// succeeded 判断响应是否需要保存，限流、冲突等失败在稍后重试时可能成功
+func succeeded(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// release 放弃幂等键，之后的重试会重新执行处理器
+func (s *Store) release(ctx context.Context, id string) {
+	s.db.WithContext(ctx).Where("id = ?", id).Delete(&Record{})
+}
+
+// now 返回 UTC 时间，SQLite 以字符串比较时间，统一时区才能正确比较
+func (s *Store) now() time.Time {
+	return s.clock.Now().UTC()
+}
+
+// replay 写出保存的响应
+func replay(w http.ResponseWriter, record *Record) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// hash 返回各部分依次拼接后的 SHA-256，各部分以 0 字节分隔
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder 在写出响应的同时记录状态码和响应体
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
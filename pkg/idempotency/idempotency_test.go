@@ -0,0 +1,204 @@
+package idempotency
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// fakeClock 可手动拨动的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// setupStore 使用临时文件数据库，并发请求时由 busy_timeout 排队写入
+func setupStore(t *testing.T, cfg Config) (*Store, *fakeClock) {
+	dsn := filepath.Join(t.TempDir(), "idempotency.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(Models()...))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)}
+	return New(module.Deps{DB: db, Clock: clock}, cfg), clock
+}
+
+// counter 每次调用返回递增编号的处理器
+type counter struct {
+	calls  atomic.Int64
+	status int
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := c.calls.Add(1)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/items/%d", n))
+	w.WriteHeader(c.status)
+	fmt.Fprintf(w, `{"id":%d}`, n)
+}
+
+func post(h http.Handler, path, key, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	req.Header.Set("Authorization", token)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestMiddlewareReplays(t *testing.T) {
+	t.Parallel()
+
+	store, clock := setupStore(t, Config{TTL: time.Hour})
+	next := &counter{status: http.StatusCreated}
+	h := store.Middleware(next)
+
+	first := post(h, "/items", "k1", "Bearer a", `{"name":"x"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(ReplayedHeader))
+
+	again := post(h, "/items", "k1", "Bearer a", `{"name":"x"}`)
+	assert.Equal(t, http.StatusCreated, again.Code)
+	assert.Equal(t, first.Body.String(), again.Body.String())
+	assert.Equal(t, "/items/1", again.Header().Get("Location"))
+	assert.Equal(t, "application/json", again.Header().Get("Content-Type"))
+	assert.Equal(t, "true", again.Header().Get(ReplayedHeader))
+	assert.EqualValues(t, 1, next.calls.Load())
+
+	// 同一个键用于不同的请求
+	resp := post(h, "/items", "k1", "Bearer a", `{"name":"y"}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	resp = post(h, "/other", "k1", "Bearer a", `{"name":"x"}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	// 不同调用方的键互不影响，没有键的请求每次都执行
+	resp = post(h, "/items", "k1", "Bearer b", `{"name":"y"}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	post(h, "/items", "", "Bearer a", `{"name":"x"}`)
+	post(h, "/items", "", "Bearer a", `{"name":"x"}`)
+	assert.EqualValues(t, 4, next.calls.Load())
+
+	// 过期后重新执行
+	clock.Advance(time.Hour + time.Second)
+	resp = post(h, "/items", "k1", "Bearer a", `{"name":"y"}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, `{"id":5}`, resp.Body.String())
+}
+
+func TestMiddlewareStoresOnlySuccess(t *testing.T) {
+	t.Parallel()
+
+	store, _ := setupStore(t, Config{})
+	next := &counter{status: http.StatusServiceUnavailable}
+	h := store.Middleware(next)
+
+	post(h, "/items", "k1", "Bearer a", `{}`)
+	post(h, "/items", "k1", "Bearer a", `{}`)
+	assert.EqualValues(t, 2, next.calls.Load())
+
+	// 限流、冲突等 4xx 稍后重试可能成功，同样不保存
+	for i, status := range []int{http.StatusTooManyRequests, http.StatusConflict, http.StatusBadRequest} {
+		next.status = status
+		key := fmt.Sprintf("k%d", i+2)
+		post(h, "/items", key, "Bearer a", `{}`)
+		next.status = http.StatusCreated
+		resp := post(h, "/items", key, "Bearer a", `{}`)
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Empty(t, resp.Header().Get(ReplayedHeader))
+	}
+	assert.EqualValues(t, 8, next.calls.Load())
+
+	resp := post(h, "/items", strings.Repeat("k", MaxKeyLength+1), "Bearer a", `{}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	t.Parallel()
+
+	store, clock := setupStore(t, Config{LockTimeout: time.Minute})
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int64
+	h := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(entered)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(h, "/items", "k1", "Bearer a", `{}`) }()
+	<-entered
+
+	// 第一次请求仍在处理
+	resp := post(h, "/items", "k1", "Bearer a", `{}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	// 处理超时后认为第一次请求已中断，重试可以接手
+	clock.Advance(time.Minute + time.Second)
+	resp = post(h, "/items", "k1", "Bearer a", `{}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Empty(t, resp.Header().Get(ReplayedHeader))
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestMiddlewareConcurrentRetries(t *testing.T) {
+	t.Parallel()
+
+	store, _ := setupStore(t, Config{})
+	next := &counter{status: http.StatusCreated}
+	h := store.Middleware(next)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 8)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = post(h, "/items", "k1", "Bearer a", `{}`).Code
+		}()
+	}
+	wg.Wait()
+
+	// 只有一个请求执行处理器，其余的重放结果或提示稍后重试
+	assert.EqualValues(t, 1, next.calls.Load())
+	for _, code := range codes {
+		assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+	}
+}
@@ -0,0 +1,305 @@
+// Package jsonschema 实现 JSON Schema (draft 7) 的常用子集校验
+//
+// 支持的关键字：type、enum、const、properties、required、additionalProperties、
+// items、minItems、maxItems、minLength、maxLength、pattern、minimum、maximum。
+// 未识别的关键字会被忽略。
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema 表示一个已解析的 JSON Schema
+type Schema struct {
+	Type                 Types              `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Additional        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Types 对应 type 关键字，可以是单个字符串或字符串数组
+type Types []string
+
+// UnmarshalJSON 兼容 "string" 和 ["string", "null"] 两种写法
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("jsonschema: invalid type: %s", data)
+	}
+	*t = list
+	return nil
+}
+
+// Additional 对应 additionalProperties，可以是布尔值或子 schema
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON 解析布尔值或 schema 对象
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.Allowed = allowed
+		return nil
+	}
+	a.Allowed = true
+	a.Schema = new(Schema)
+	return json.Unmarshal(data, a.Schema)
+}
+
+// Parse 从 JSON 文本解析 schema
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Load 从文件读取并解析 schema
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// compile 预编译正则表达式
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return s.AdditionalProperties.Schema.compile()
+	}
+	return nil
+}
+
+// FieldError 描述单个字段的校验失败
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError 包含所有校验失败的字段
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error 实现error接口
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %s", fe.Path, fe.Message))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validate 校验一个由 encoding/json 解码得到的值
+// 校验失败时返回 *ValidationError
+func (s *Schema) Validate(v any) error {
+	var errs []FieldError
+	s.validate("$", normalize(v), &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// normalize 将 json.Number 等数值统一为 float64，便于比较
+func normalize(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		f, _ := x.Float64()
+		return f
+	case int:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, e := range x {
+			out[k] = normalize(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = normalize(e)
+		}
+		return out
+	}
+	return v
+}
+
+func (s *Schema) validate(path string, v any, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(normalize(e), v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value is not one of the allowed values")
+		}
+	}
+	if s.Const != nil && !reflect.DeepEqual(normalize(s.Const), v) {
+		fail("value must be %v", s.Const)
+	}
+
+	switch x := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(x)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("length must be >= %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("length must be <= %d", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(x) {
+			fail("does not match pattern %q", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && x < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && x > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(x) < *s.MinItems {
+			fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(x) > *s.MaxItems {
+			fail("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, e := range x {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), e, errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := x[name]; !ok {
+				*errs = append(*errs, FieldError{Path: path + "." + name, Message: "is required"})
+			}
+		}
+
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			child := path + "." + k
+			if p, ok := s.Properties[k]; ok {
+				p.validate(child, x[k], errs)
+				continue
+			}
+			if s.AdditionalProperties == nil {
+				continue
+			}
+			if !s.AdditionalProperties.Allowed {
+				*errs = append(*errs, FieldError{Path: child, Message: "additional property is not allowed"})
+			} else if s.AdditionalProperties.Schema != nil {
+				s.AdditionalProperties.Schema.validate(child, x[k], errs)
+			}
+		}
+	}
+}
+
+// matches 判断值是否符合任一声明的类型
+func (t Types) matches(v any) bool {
+	actual := typeOf(v)
+	for _, want := range t {
+		if want == actual {
+			return true
+		}
+		if want == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf 返回值对应的 JSON Schema 类型名
+func typeOf(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if x == float64(int64(x)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
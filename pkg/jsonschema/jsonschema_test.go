@@ -0,0 +1,80 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profileSchema = `{
+	"type": "object",
+	"required": ["nickname"],
+	"additionalProperties": false,
+	"properties": {
+		"nickname": {"type": "string", "minLength": 2, "maxLength": 20},
+		"age":      {"type": "integer", "minimum": 0, "maximum": 150},
+		"gender":   {"enum": ["male", "female", "other"]},
+		"website":  {"type": ["string", "null"], "pattern": "^https?://"},
+		"tags":     {"type": "array", "maxItems": 3, "items": {"type": "string"}},
+		"address":  {
+			"type": "object",
+			"properties": {"city": {"type": "string"}},
+			"additionalProperties": {"type": "string"}
+		}
+	}
+}`
+
+func decode(t *testing.T, s string) any {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := Parse([]byte(profileSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		value string
+		paths []string // 期望失败的字段路径，为空表示校验通过
+	}{
+		{"valid", `{"nickname":"tom","age":18,"gender":"male","website":null,"tags":["a"],"address":{"city":"bj","zip":"100000"}}`, nil},
+		{"missing required", `{"age":18}`, []string{"$.nickname"}},
+		{"wrong type", `{"nickname":"tom","age":"18"}`, []string{"$.age"}},
+		{"not integer", `{"nickname":"tom","age":1.5}`, []string{"$.age"}},
+		{"out of range", `{"nickname":"tom","age":200}`, []string{"$.age"}},
+		{"enum", `{"nickname":"tom","gender":"x"}`, []string{"$.gender"}},
+		{"pattern", `{"nickname":"tom","website":"ftp://x"}`, []string{"$.website"}},
+		{"too short", `{"nickname":"t"}`, []string{"$.nickname"}},
+		{"items", `{"nickname":"tom","tags":["a",1,"c","d"]}`, []string{"$.tags", "$.tags[1]"}},
+		{"additional", `{"nickname":"tom","extra":1}`, []string{"$.extra"}},
+		{"additional schema", `{"nickname":"tom","address":{"zip":100000}}`, []string{"$.address.zip"}},
+		{"root type", `[]`, []string{"$"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(decode(t, tt.value))
+			if len(tt.paths) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			var paths []string
+			for _, fe := range verr.Errors {
+				paths = append(paths, fe.Path)
+			}
+			assert.Equal(t, tt.paths, paths)
+		})
+	}
+}
+
+func TestParseInvalidPattern(t *testing.T) {
+	_, err := Parse([]byte(`{"properties":{"a":{"pattern":"("}}}`))
+	assert.Error(t, err)
+}
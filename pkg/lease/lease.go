@@ -0,0 +1,114 @@
+// Package lease 基于数据库的租约，用于在多个服务副本中选出唯一的执行者
+//
+// 每个租约是 leases 表中的一行，持有者需要在到期前续约；持有者崩溃后租约到期，
+// 其他副本即可接手。获取和续约都是条件更新，不依赖数据库的行锁。
+package lease
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// Lease 一个具名租约
+type Lease struct {
+	Name      string    `gorm:"primaryKey;size:128" json:"name"`
+	Holder    string    `gorm:"size:128;not null" json:"holder"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定租约表名
+func (Lease) TableName() string {
+	return "leases"
+}
+
+// Models 返回租约需要自动迁移的数据库模型
+func Models() []interface{} {
+	return []interface{}{&Lease{}}
+}
+
+// Manager 租约管理
+type Manager struct {
+	db    *gorm.DB
+	clock module.Clock
+}
+
+// New 创建使用 deps.DB 的租约管理，表结构见 Models
+func New(deps module.Deps) *Manager {
+	deps = deps.WithDefaults()
+	return &Manager{db: deps.DB, clock: deps.Clock}
+}
+
+// Acquire 获取或续约名为 name 的租约，成功时租约在 ttl 后到期
+// 租约由其他持有者持有且未到期时返回 false
+func (m *Manager) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := m.now()
+	expiresAt := now.Add(ttl)
+
+	// 租约不存在时直接创建
+	result := m.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Lease{Name: name, Holder: holder, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	// 自己持有时续约，已到期时接手
+	result = m.db.WithContext(ctx).Model(&Lease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Release 释放自己持有的租约，使其他副本无需等待到期即可接手
+func (m *Manager) Release(ctx context.Context, name, holder string) error {
+	return m.db.WithContext(ctx).
+		Where("name = ? AND holder = ?", name, holder).
+		Delete(&Lease{}).Error
+}
+
+// Find 查询租约的当前状态，不存在时返回 nil
+func (m *Manager) Find(ctx context.Context, name string) (*Lease, error) {
+	var l Lease
+	err := m.db.WithContext(ctx).Where("name = ?", name).Take(&l).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// NewHolder 生成持有者标识：主机名、进程号加随机后缀，同一主机上的多个进程也不会重复
+func NewHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// now 返回 UTC 时间，SQLite 以字符串比较时间，统一时区才能正确比较
+func (m *Manager) now() time.Time {
+	return m.clock.Now().UTC()
+}
@@ -0,0 +1,144 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// fakeClock 可手动拨动的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// setupManager 使用临时文件数据库，并发获取时由 busy_timeout 排队写入
+func setupManager(t *testing.T) (*Manager, *fakeClock) {
+	dsn := filepath.Join(t.TempDir(), "lease.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(Models()...))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)}
+	return New(module.Deps{DB: db, Clock: clock}), clock
+}
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+
+	m, clock := setupManager(t)
+	ctx := context.Background()
+
+	ok, err := m.Acquire(ctx, "scheduler", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 未到期时其他持有者拿不到，持有者可以续约
+	ok, err = m.Acquire(ctx, "scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	clock.Advance(50 * time.Second)
+	ok, err = m.Acquire(ctx, "scheduler", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	clock.Advance(50 * time.Second)
+	ok, err = m.Acquire(ctx, "scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// 到期后被接手
+	clock.Advance(time.Minute)
+	ok, err = m.Acquire(ctx, "scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	l, err := m.Find(ctx, "scheduler")
+	require.NoError(t, err)
+	assert.Equal(t, "b", l.Holder)
+
+	// 不同名称的租约互不影响
+	ok, err = m.Acquire(ctx, "other", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRelease(t *testing.T) {
+	t.Parallel()
+
+	m, _ := setupManager(t)
+	ctx := context.Background()
+
+	ok, err := m.Acquire(ctx, "scheduler", "a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// 只能释放自己持有的租约
+	require.NoError(t, m.Release(ctx, "scheduler", "b"))
+	ok, err = m.Acquire(ctx, "scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, m.Release(ctx, "scheduler", "a"))
+	l, err := m.Find(ctx, "scheduler")
+	require.NoError(t, err)
+	assert.Nil(t, l)
+	ok, err = m.Acquire(ctx, "scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestAcquireConcurrent(t *testing.T) {
+	t.Parallel()
+
+	m, _ := setupManager(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var winners atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := m.Acquire(ctx, "scheduler", fmt.Sprintf("holder-%d", i), time.Minute)
+			assert.NoError(t, err)
+			if ok {
+				winners.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), winners.Load())
+}
+
+func TestNewHolder(t *testing.T) {
+	t.Parallel()
+
+	assert.NotEqual(t, NewHolder(), NewHolder())
+}
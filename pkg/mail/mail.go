@@ -0,0 +1,267 @@
+// Package mail 组装 MIME 邮件并通过 SMTP 发送
+//
+// 只有纯文本时生成 text/plain 邮件；同时有 HTML 时生成 multipart/alternative，
+// 客户端优先显示 HTML；有附件时外层再包一层 multipart/mixed。
+// 正文使用 quoted-printable 编码，附件使用 base64 编码，非 ASCII 的主题、
+// 显示名和附件文件名按 RFC 2047/2231 编码。
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMessage 表示邮件缺少必要的内容或包含非法的头部
+var ErrInvalidMessage = stderrors.New("mail: invalid message")
+
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string // 附件文件名
+	ContentType string // 为空时按文件扩展名推断，无法推断时为 application/octet-stream
+	Data        []byte
+}
+
+// Message 一封待发送的邮件
+type Message struct {
+	From        string   // 发件人，可带显示名，例如 "通知 <noreply@example.com>"
+	To          []string // 收件人，格式同 From
+	Subject     string
+	Text        string // 纯文本正文
+	HTML        string // HTML 正文，可选
+	Attachments []Attachment
+
+	MessageID string    // Message-ID，不含尖括号；为空时由 Bytes 的调用方生成，见 NewMessageID
+	Date      time.Time // 为零值时使用当前时间
+}
+
+// NewMessageID 为发件人生成随机的 Message-ID，不含尖括号
+func NewMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndexByte(addr.Address, '@'); i >= 0 {
+			domain = addr.Address[i+1:]
+		}
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b) + "@" + domain
+}
+
+// Envelope 返回 SMTP 信封的发件人与收件人地址
+func (m *Message) Envelope() (string, []string, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: from %q: %s", ErrInvalidMessage, m.From, err)
+	}
+	if len(m.To) == 0 {
+		return "", nil, fmt.Errorf("%w: no recipients", ErrInvalidMessage)
+	}
+	to := make([]string, 0, len(m.To))
+	for _, s := range m.To {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: to %q: %s", ErrInvalidMessage, s, err)
+		}
+		to = append(to, addr.Address)
+	}
+	return from.Address, to, nil
+}
+
+// Bytes 按 RFC 5322 组装邮件
+func (m *Message) Bytes() ([]byte, error) {
+	if strings.ContainsAny(m.Subject, "\r\n") || strings.ContainsAny(m.MessageID, "\r\n<>") {
+		return nil, fmt.Errorf("%w: subject or message id contains invalid characters", ErrInvalidMessage)
+	}
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from %q: %s", ErrInvalidMessage, m.From, err)
+	}
+	to := make([]string, 0, len(m.To))
+	for _, s := range m.To {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to %q: %s", ErrInvalidMessage, s, err)
+		}
+		to = append(to, addr.String())
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", ErrInvalidMessage)
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", strings.Join(to, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "Date", date.Format(time.RFC1123Z))
+	if m.MessageID != "" {
+		writeHeader(&buf, "Message-ID", "<"+m.MessageID+">")
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	header, body, err := m.body()
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Attachments) == 0 {
+		for _, name := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if value := header.Get(name); value != "" {
+				writeHeader(&buf, name, value)
+			}
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixed.Boundary()}))
+	buf.WriteString("\r\n")
+	part, err := mixed.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(body)
+	for _, a := range m.Attachments {
+		if err := writeAttachment(mixed, a); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body 返回正文的头部和内容：纯文本，或纯文本与 HTML 的 multipart/alternative
+func (m *Message) body() (textproto.MIMEHeader, []byte, error) {
+	var buf bytes.Buffer
+	if m.HTML == "" {
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, nil, err
+		}
+		return textproto.MIMEHeader{
+			"Content-Type":              {"text/plain; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		}, buf.Bytes(), nil
+	}
+
+	alt := multipart.NewWriter(&buf)
+	for _, body := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		part, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQuotedPrintable(part, body.content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return nil, nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alt.Boundary()})},
+	}, buf.Bytes(), nil
+}
+
+// writeAttachment 以 base64 写入一个附件
+func writeAttachment(w *multipart.Writer, a Attachment) error {
+	if a.Filename == "" || strings.ContainsAny(a.Filename, "\r\n") {
+		return fmt.Errorf("%w: invalid attachment filename %q", ErrInvalidMessage, a.Filename)
+	}
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(extension(a.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("%w: attachment %q: invalid content type %q", ErrInvalidMessage, a.Filename, a.ContentType)
+	}
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(mediaType, params)},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	enc := base64.NewEncoder(base64.StdEncoding, &lineWriter{w: part})
+	enc.Write(a.Data)
+	return enc.Close()
+}
+
+// writeHeader 写入一行头部，调用方负责对取值编码
+func writeHeader(w *bytes.Buffer, name, value string) {
+	w.WriteString(name)
+	w.WriteString(": ")
+	w.WriteString(value)
+	w.WriteString("\r\n")
+}
+
+// writeQuotedPrintable 以 quoted-printable 写入正文，统一使用 CRLF 换行
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// extension 返回文件名的扩展名（含点）
+func extension(filename string) string {
+	if i := strings.LastIndexByte(filename, '.'); i >= 0 {
+		return filename[i:]
+	}
+	return ""
+}
+
+// lineWriter 每 76 个字符插入一个 CRLF，满足 RFC 2045 对行长的要求
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		chunk := min(76-l.col, len(p))
+		if _, err := l.w.Write(p[:chunk]); err != nil {
+			return 0, err
+		}
+		l.col += chunk
+		p = p[chunk:]
+		if l.col == 76 {
+			if _, err := l.w.Write([]byte("\r\n")); err != nil {
+				return 0, err
+			}
+			l.col = 0
+		}
+	}
+	return n, nil
+}
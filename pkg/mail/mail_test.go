@@ -0,0 +1,230 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/mail/mailtest"
+)
+
+// part 解析后的 MIME 部分
+type part struct {
+	contentType string
+	params      map[string]string
+	disposition map[string]string
+	body        []byte
+}
+
+// parseParts 解析 multipart 邮件体，嵌套的 multipart 展开为其子部分
+func parseParts(t *testing.T, contentType string, body io.Reader) []part {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(mediaType, "multipart/"), mediaType)
+
+	var parts []part
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		require.NoError(t, err)
+		partType, partParams, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		if strings.HasPrefix(partType, "multipart/") {
+			parts = append(parts, parseParts(t, p.Header.Get("Content-Type"), p)...)
+			continue
+		}
+		// multipart.Reader 自动解码 quoted-printable，base64 需要手动解码
+		data, err := io.ReadAll(p)
+		require.NoError(t, err)
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			data, err = io.ReadAll(base64Decoder(data))
+			require.NoError(t, err)
+		}
+		var disposition map[string]string
+		if d := p.Header.Get("Content-Disposition"); d != "" {
+			_, disposition, err = mime.ParseMediaType(d)
+			require.NoError(t, err)
+		}
+		parts = append(parts, part{contentType: partType, params: partParams, disposition: disposition, body: data})
+	}
+}
+
+// base64Decoder 解码折行的 base64，解码器会忽略换行
+func base64Decoder(data []byte) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, bytes.NewReader(data))
+}
+
+func TestMessageBytes(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{
+		From:      "通知 <noreply@example.com>",
+		To:        []string{"alice@example.com", "Bob <bob@example.com>"},
+		Subject:   "订单已发货",
+		Text:      "您好，\n订单 1001 已发货。",
+		HTML:      "<p>您好，</p><p>订单 <b>1001</b> 已发货。</p>",
+		MessageID: "abc@example.com",
+		Date:      time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC),
+		Attachments: []Attachment{
+			{Filename: "发票.pdf", Data: bytes.Repeat([]byte{0, 1, 2, 0xff}, 100)},
+			{Filename: "notes", ContentType: "text/plain; charset=utf-8", Data: []byte("hello")},
+		},
+	}
+	data, err := msg.Bytes()
+	require.NoError(t, err)
+
+	// 每行不超过 RFC 5322 的 998 字符，base64 按 76 字符折行
+	for _, line := range strings.Split(string(data), "\r\n") {
+		assert.LessOrEqual(t, len(line), 998)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "订单已发货", subject)
+	from, err := parsed.Header.AddressList("From")
+	require.NoError(t, err)
+	assert.Equal(t, []*mail.Address{{Name: "通知", Address: "noreply@example.com"}}, from)
+	to, err := parsed.Header.AddressList("To")
+	require.NoError(t, err)
+	require.Len(t, to, 2)
+	assert.Equal(t, "bob@example.com", to[1].Address)
+	assert.Equal(t, "<abc@example.com>", parsed.Header.Get("Message-ID"))
+	assert.Equal(t, "1.0", parsed.Header.Get("MIME-Version"))
+
+	parts := parseParts(t, parsed.Header.Get("Content-Type"), parsed.Body)
+	require.Len(t, parts, 4)
+	assert.Equal(t, "text/plain", parts[0].contentType)
+	assert.Equal(t, "您好，\r\n订单 1001 已发货。", string(parts[0].body))
+	assert.Equal(t, "text/html", parts[1].contentType)
+	assert.Equal(t, msg.HTML, string(parts[1].body))
+	assert.Equal(t, "application/pdf", parts[2].contentType)
+	assert.Equal(t, "发票.pdf", parts[2].disposition["filename"])
+	assert.Equal(t, msg.Attachments[0].Data, parts[2].body)
+	assert.Equal(t, "text/plain", parts[3].contentType)
+	assert.Equal(t, "notes", parts[3].disposition["filename"])
+	assert.Equal(t, "hello", string(parts[3].body))
+}
+
+func TestMessageBytesPlainText(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{From: "noreply@example.com", To: []string{"alice@example.com"}, Subject: "Hi", Text: "hello"}
+	data, err := msg.Bytes()
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", parsed.Header.Get("Content-Type"))
+	assert.Empty(t, parsed.Header.Get("Message-ID"))
+	body, err := io.ReadAll(parsed.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	for _, bad := range []*Message{
+		{From: "noreply@example.com", To: []string{"alice@example.com"}, Subject: "Hi\r\nBcc: eve@example.com"},
+		{From: "not an address", To: []string{"alice@example.com"}},
+		{From: "noreply@example.com"},
+		{From: "noreply@example.com", To: []string{"alice@example.com"}, Attachments: []Attachment{{Filename: ""}}},
+		{From: "noreply@example.com", To: []string{"alice@example.com"}, Attachments: []Attachment{{Filename: "a", ContentType: "bad"}}},
+	} {
+		_, err := bad.Bytes()
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	}
+}
+
+func TestClientStartTLS(t *testing.T) {
+	t.Parallel()
+
+	server := mailtest.NewServer(t, mailtest.Options{StartTLS: true, Username: "user", Password: "secret"})
+	client := NewClient(Config{
+		Host:      server.Host,
+		Port:      server.Port,
+		Username:  "user",
+		Password:  "secret",
+		TLSConfig: server.ClientTLSConfig(),
+	})
+
+	msg := &Message{From: "Shop <noreply@example.com>", To: []string{"alice@example.com"}, Subject: "Hi", Text: "hello"}
+	id, err := client.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(id, "@example.com"), id)
+	assert.Empty(t, msg.MessageID)
+
+	received := server.Messages()
+	require.Len(t, received, 1)
+	assert.Equal(t, "noreply@example.com", received[0].From)
+	assert.Equal(t, []string{"alice@example.com"}, received[0].To)
+	assert.True(t, received[0].TLS)
+	assert.Equal(t, "user", received[0].Auth)
+	parsed, err := mail.ReadMessage(bytes.NewReader(received[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, "<"+id+">", parsed.Header.Get("Message-ID"))
+
+	// 密码错误
+	client = NewClient(Config{Host: server.Host, Port: server.Port, Username: "user", Password: "wrong", TLSConfig: server.ClientTLSConfig()})
+	_, err = client.Send(context.Background(), msg)
+	assert.Error(t, err)
+
+	// 不信任服务器证书
+	client = NewClient(Config{Host: server.Host, Port: server.Port})
+	_, err = client.Send(context.Background(), msg)
+	assert.Error(t, err)
+	assert.Len(t, server.Messages(), 1)
+}
+
+func TestClientModes(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{From: "noreply@example.com", To: []string{"alice@example.com", "bounce@example.com"}, Subject: "Hi", Text: "hello"}
+
+	// 服务器不支持 STARTTLS 时拒绝以明文发送
+	plain := mailtest.NewServer(t, mailtest.Options{})
+	_, err := NewClient(Config{Host: plain.Host, Port: plain.Port}).Send(context.Background(), msg)
+	assert.ErrorContains(t, err, "STARTTLS")
+	_, err = NewClient(Config{Host: plain.Host, Port: plain.Port, TLS: NoTLS}).Send(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, plain.Messages(), 1)
+	assert.False(t, plain.Messages()[0].TLS)
+
+	implicit := mailtest.NewServer(t, mailtest.Options{ImplicitTLS: true})
+	client := NewClient(Config{Host: implicit.Host, Port: implicit.Port, TLS: ImplicitTLS, TLSConfig: implicit.ClientTLSConfig()})
+	_, err = client.Send(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, implicit.Messages(), 1)
+	assert.True(t, implicit.Messages()[0].TLS)
+
+	// 任一收件人被拒绝时整封邮件不发送
+	rejecting := mailtest.NewServer(t, mailtest.Options{RejectRecipient: func(addr string) bool {
+		return strings.HasPrefix(addr, "bounce@")
+	}})
+	_, err = NewClient(Config{Host: rejecting.Host, Port: rejecting.Port, TLS: NoTLS}).Send(context.Background(), msg)
+	assert.ErrorContains(t, err, "550")
+	assert.Empty(t, rejecting.Messages())
+}
+
+func TestParseTLSMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseTLSMode("")
+	require.NoError(t, err)
+	assert.Equal(t, StartTLS, mode)
+	mode, err = ParseTLSMode("tls")
+	require.NoError(t, err)
+	assert.Equal(t, ImplicitTLS, mode)
+	_, err = ParseTLSMode("ssl3")
+	assert.Error(t, err)
+}
@@ -0,0 +1,332 @@
+// Package mailtest 提供测试用的进程内 SMTP 服务器
+//
+// 服务器支持 EHLO、STARTTLS、AUTH PLAIN、MAIL、RCPT、DATA、RSET、NOOP 和 QUIT，
+// 把收到的邮件保存在内存中，供测试检查。证书为启动时生成的自签名证书，
+// 客户端使用 ClientTLSConfig 信任该证书。
+package mailtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Options 服务器选项
+type Options struct {
+	StartTLS    bool   // 是否支持 STARTTLS
+	ImplicitTLS bool   // 是否直接使用 TLS 监听（SMTPS）
+	Username    string // 非空时要求 AUTH PLAIN 认证
+	Password    string
+
+	// RejectRecipient 返回 true 的收件人以 550 拒绝
+	RejectRecipient func(addr string) bool
+}
+
+// Message 服务器收到的一封邮件
+type Message struct {
+	From string
+	To   []string
+	Data []byte // 邮件原文，已去掉 DATA 的点填充，使用 CRLF 换行
+	TLS  bool   // 是否通过加密连接收到
+	Auth string // 认证的用户名，未认证时为空
+}
+
+// Server 进程内 SMTP 服务器
+type Server struct {
+	Host string
+	Port int
+
+	opts      Options
+	listener  net.Listener
+	tlsConfig *tls.Config
+	clientTLS *tls.Config
+
+	mu       sync.Mutex
+	messages []Message
+	wg       sync.WaitGroup
+}
+
+// NewServer 在 127.0.0.1 的随机端口启动服务器，测试结束时关闭
+func NewServer(t testing.TB, opts Options) *Server {
+	t.Helper()
+
+	cert, pool, err := selfSigned()
+	if err != nil {
+		t.Fatalf("mailtest: generate certificate: %v", err)
+	}
+	s := &Server{
+		opts:      opts,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		clientTLS: &tls.Config{RootCAs: pool},
+	}
+
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("mailtest: listen: %v", err)
+	}
+	if opts.ImplicitTLS {
+		s.listener = tls.NewListener(s.listener, s.tlsConfig)
+	}
+	addr := s.listener.Addr().(*net.TCPAddr)
+	s.Host, s.Port = addr.IP.String(), addr.Port
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ClientTLSConfig 返回信任服务器证书的客户端 TLS 配置
+func (s *Server) ClientTLSConfig() *tls.Config {
+	return s.clientTLS.Clone()
+}
+
+// Messages 返回已收到的邮件
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close 停止监听并等待所有连接结束
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(30 * time.Second))
+			s.handle(conn)
+		}()
+	}
+}
+
+// session 一个连接的会话状态
+type session struct {
+	text    *textproto.Conn
+	tls     bool
+	auth    string
+	from    string
+	to      []string
+	hasFrom bool
+}
+
+func (s *Server) handle(conn net.Conn) {
+	_, secure := conn.(*tls.Conn)
+	ss := &session{text: textproto.NewConn(conn), tls: secure}
+	ss.reply(220, "mailtest ESMTP ready")
+
+	for {
+		line, err := ss.text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			ss.reset()
+			ext := []string{"mailtest"}
+			if s.opts.StartTLS && !ss.tls {
+				ext = append(ext, "STARTTLS")
+			}
+			if s.opts.Username != "" {
+				ext = append(ext, "AUTH PLAIN")
+			}
+			ss.replyLines(250, ext)
+		case "STARTTLS":
+			if !s.opts.StartTLS || ss.tls {
+				ss.reply(502, "STARTTLS not available")
+				continue
+			}
+			ss.reply(220, "ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			ss.text = textproto.NewConn(conn)
+			ss.tls = true
+			ss.reset()
+		case "AUTH":
+			s.auth(ss, arg)
+		case "MAIL":
+			if s.opts.Username != "" && ss.auth == "" {
+				ss.reply(530, "authentication required")
+				continue
+			}
+			addr, ok := pathArg(arg, "FROM:")
+			if !ok {
+				ss.reply(501, "syntax: MAIL FROM:<address>")
+				continue
+			}
+			ss.from, ss.hasFrom = addr, true
+			ss.reply(250, "OK")
+		case "RCPT":
+			addr, ok := pathArg(arg, "TO:")
+			switch {
+			case !ss.hasFrom:
+				ss.reply(503, "need MAIL first")
+			case !ok:
+				ss.reply(501, "syntax: RCPT TO:<address>")
+			case s.opts.RejectRecipient != nil && s.opts.RejectRecipient(addr):
+				ss.reply(550, "mailbox unavailable")
+			default:
+				ss.to = append(ss.to, addr)
+				ss.reply(250, "OK")
+			}
+		case "DATA":
+			if len(ss.to) == 0 {
+				ss.reply(503, "need RCPT first")
+				continue
+			}
+			ss.reply(354, "end data with <CR><LF>.<CR><LF>")
+			data, err := ss.text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, Message{
+				From: ss.from,
+				To:   ss.to,
+				Data: []byte(strings.ReplaceAll(string(data), "\n", "\r\n")),
+				TLS:  ss.tls,
+				Auth: ss.auth,
+			})
+			s.mu.Unlock()
+			ss.reset()
+			ss.reply(250, "OK: queued")
+		case "RSET":
+			ss.reset()
+			ss.reply(250, "OK")
+		case "NOOP":
+			ss.reply(250, "OK")
+		case "QUIT":
+			ss.reply(221, "bye")
+			return
+		default:
+			ss.reply(502, "command not implemented")
+		}
+	}
+}
+
+// auth 处理 AUTH PLAIN，初始响应可以随命令发送，也可以在 334 之后发送
+func (s *Server) auth(ss *session, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	if s.opts.Username == "" || !strings.EqualFold(mechanism, "PLAIN") {
+		ss.reply(504, "unrecognized authentication type")
+		return
+	}
+	if !ss.tls {
+		ss.reply(538, "encryption required")
+		return
+	}
+	if initial == "" {
+		ss.reply(334, "")
+		line, err := ss.text.ReadLine()
+		if err != nil {
+			return
+		}
+		initial = line
+	}
+	decoded, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		ss.reply(501, "invalid base64")
+		return
+	}
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 || parts[1] != s.opts.Username || parts[2] != s.opts.Password {
+		ss.reply(535, "authentication failed")
+		return
+	}
+	ss.auth = parts[1]
+	ss.reply(235, "authenticated")
+}
+
+func (ss *session) reset() {
+	ss.from, ss.to, ss.hasFrom = "", nil, false
+}
+
+func (ss *session) reply(code int, msg string) {
+	ss.text.PrintfLine("%d %s", code, msg)
+}
+
+func (ss *session) replyLines(code int, lines []string) {
+	for i, line := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		ss.text.PrintfLine("%d%s%s", code, sep, line)
+	}
+}
+
+// pathArg 解析 "FROM:<addr>" 形式的参数，忽略其后的 ESMTP 参数
+func pathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if i := strings.IndexByte(path, ' '); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", false
+	}
+	return path[1 : len(path)-1], true
+}
+
+// selfSigned 生成 127.0.0.1 与 localhost 的自签名证书
+func selfSigned() (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "mailtest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool, nil
+}
@@ -0,0 +1,188 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// TLSMode 与 SMTP 服务器之间的加密方式
+type TLSMode string
+
+const (
+	// StartTLS 以明文连接后通过 STARTTLS 升级，服务器不支持时拒绝发送，默认端口 587
+	StartTLS TLSMode = "starttls"
+
+	// ImplicitTLS 直接建立 TLS 连接（SMTPS），默认端口 465
+	ImplicitTLS TLSMode = "tls"
+
+	// NoTLS 不加密，只用于本机或内网的中继
+	NoTLS TLSMode = "none"
+)
+
+// ParseTLSMode 解析 TLS 模式，空字符串为 StartTLS
+func ParseTLSMode(s string) (TLSMode, error) {
+	switch mode := TLSMode(s); mode {
+	case "":
+		return StartTLS, nil
+	case StartTLS, ImplicitTLS, NoTLS:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("mail: unknown TLS mode %q", s)
+	}
+}
+
+// Config SMTP 客户端配置
+type Config struct {
+	Host      string
+	Port      int         // 为 0 时按 TLS 模式使用 587 或 465
+	Username  string      // 为空时不认证
+	Password  string      // 使用 AUTH PLAIN，只能在加密连接或本机上使用
+	TLS       TLSMode     // 默认 StartTLS
+	TLSConfig *tls.Config // 为 nil 时按 Host 校验服务器证书
+	LocalName string      // EHLO 使用的主机名，默认 localhost
+	Timeout   time.Duration
+}
+
+// Client 通过 SMTP 发送邮件，每次发送使用一个新连接
+type Client struct {
+	config Config
+}
+
+// NewClient 创建 SMTP 客户端
+func NewClient(cfg Config) *Client {
+	if cfg.TLS == "" {
+		cfg.TLS = StartTLS
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLS == ImplicitTLS {
+			cfg.Port = 465
+		}
+	}
+	if cfg.LocalName == "" {
+		cfg.LocalName = "localhost"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Client{config: cfg}
+}
+
+// Send 发送邮件并返回其 Message-ID，msg 没有 Message-ID 时生成一个
+func (c *Client) Send(ctx context.Context, msg *Message) (string, error) {
+	m := *msg
+	if m.MessageID == "" {
+		m.MessageID = NewMessageID(m.From)
+	}
+	from, to, err := m.Envelope()
+	if err != nil {
+		return "", err
+	}
+	data, err := m.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.send(ctx, from, to, data); err != nil {
+		return "", fmt.Errorf("mail: smtp %s: %w", c.addr(), err)
+	}
+	return m.MessageID, nil
+}
+
+// send 建立连接并完成一次 SMTP 会话
+func (c *Client) send(ctx context.Context, from string, to []string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr())
+	if err != nil {
+		return err
+	}
+	// net/smtp 不支持 context，超时或取消时关闭连接使阻塞的读写返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if c.config.TLS == ImplicitTLS {
+		tlsConn := tls.Client(conn, c.tlsConfig())
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Hello(c.config.LocalName); err != nil {
+		return err
+	}
+	if c.config.TLS == StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(c.tlsConfig()); err != nil {
+			return err
+		}
+	}
+	if c.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("server does not support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// 邮件已被接收，QUIT 失败不影响结果
+	client.Quit()
+	return nil
+}
+
+// addr 返回服务器地址
+func (c *Client) addr() string {
+	return net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+}
+
+// tlsConfig 返回校验服务器证书使用的 TLS 配置
+func (c *Client) tlsConfig() *tls.Config {
+	if c.config.TLSConfig != nil {
+		cfg := c.config.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = c.config.Host
+		}
+		return cfg
+	}
+	return &tls.Config{ServerName: c.config.Host, MinVersion: tls.VersionTLS12}
+}
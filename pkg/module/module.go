@@ -0,0 +1,61 @@
+// Package module 定义资源模块的依赖与契约
+//
+// 每个资源包（server/user、server/message 等）提供一个构造函数
+//
+//	func New(deps module.Deps, cfg Config) *Handler
+//
+// 返回的 Handler 实现 Module，由 cmd/server/main.go 显式组装后挂载路由。
+// 模块内不保存任何包级状态，测试可以为每个用例创建独立的数据库并行运行。
+package module
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// Module 资源模块契约：把自身的路由挂载到 r 上
+type Module interface {
+	Routes(r chi.Router)
+}
+
+// Clock 提供当前时间，测试中可替换为固定时钟
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc 将普通函数适配为 Clock
+type ClockFunc func() time.Time
+
+// Now 返回当前时间
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock 使用系统时间的时钟
+var SystemClock Clock = ClockFunc(time.Now)
+
+// Deps 资源模块共享的依赖
+type Deps struct {
+	// DB 数据库连接，使用内存仓储的模块可以为 nil
+	DB *gorm.DB
+
+	// Logger 日志记录器，为 nil 时使用 slog.Default()
+	Logger *slog.Logger
+
+	// Clock 时钟，为 nil 时使用 SystemClock
+	Clock Clock
+}
+
+// WithDefaults 返回填充了默认 Logger 和 Clock 的副本
+func (d Deps) WithDefaults() Deps {
+	if d.Logger == nil {
+		d.Logger = slog.Default()
+	}
+	if d.Clock == nil {
+		d.Clock = SystemClock
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+// Package pubsub 进程内的发布/订阅
+//
+// 订阅者按主题（topic）登记，发布时把值非阻塞地写入每个订阅者的缓冲通道。
+// 订阅者处理不过来、缓冲区已满时会被移除并关闭通道，而不是拖慢发布方；
+// 订阅方看到通道关闭后应重新订阅，并从持久化存储补齐错过的数据。
+//
+// 主题按哈希分散到多个分片，各分片独立加锁，不同主题的发布和订阅互不阻塞。
+package pubsub
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+const (
+	// DefaultBuffer 每个订阅者的默认缓冲区大小
+	DefaultBuffer = 16
+
+	// shardCount 主题分片数
+	shardCount = 32
+)
+
+// Hub 发布/订阅中心，零值不可用，使用 New 创建
+type Hub[T any] struct {
+	buffer int
+	shards [shardCount]shard[T]
+}
+
+// shard 一组主题的订阅者
+type shard[T any] struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription[T]]struct{}
+}
+
+// New 创建发布/订阅中心，buffer 为每个订阅者的缓冲区大小，不大于 0 时使用 DefaultBuffer
+func New[T any](buffer int) *Hub[T] {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	h := &Hub[T]{buffer: buffer}
+	for i := range h.shards {
+		h.shards[i].topics = make(map[string]map[*Subscription[T]]struct{})
+	}
+	return h
+}
+
+func (h *Hub[T]) shard(topic string) *shard[T] {
+	f := fnv.New32a()
+	f.Write([]byte(topic))
+	return &h.shards[f.Sum32()%shardCount]
+}
+
+// Subscribe 订阅主题，使用完毕后必须调用 Subscription.Close
+func (h *Hub[T]) Subscribe(topic string) *Subscription[T] {
+	sub := &Subscription[T]{hub: h, topic: topic, ch: make(chan T, h.buffer)}
+
+	s := h.shard(topic)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topics[topic] == nil {
+		s.topics[topic] = make(map[*Subscription[T]]struct{})
+	}
+	s.topics[topic][sub] = struct{}{}
+	return sub
+}
+
+// Publish 向主题的所有订阅者发布 v，返回成功写入的订阅者数
+// 缓冲区已满的订阅者会被移除并关闭通道
+func (h *Hub[T]) Publish(topic string, v T) int {
+	s := h.shard(topic)
+
+	var delivered int
+	var lagging []*Subscription[T]
+	s.mu.RLock()
+	for sub := range s.topics[topic] {
+		if sub.send(v) {
+			delivered++
+		} else {
+			lagging = append(lagging, sub)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sub := range lagging {
+		sub.Close()
+	}
+	return delivered
+}
+
+// Subscribers 返回主题当前的订阅者数
+func (h *Hub[T]) Subscribers(topic string) int {
+	s := h.shard(topic)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.topics[topic])
+}
+
+// remove 移除订阅者，主题没有订阅者时一并删除
+func (h *Hub[T]) remove(sub *Subscription[T]) {
+	s := h.shard(sub.topic)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.topics[sub.topic], sub)
+	if len(s.topics[sub.topic]) == 0 {
+		delete(s.topics, sub.topic)
+	}
+}
+
+// Subscription 一个订阅者
+type Subscription[T any] struct {
+	hub   *Hub[T]
+	topic string
+	ch    chan T
+
+	mu     sync.Mutex // 保护 ch 的写入与关闭
+	closed bool
+}
+
+// C 返回接收发布值的通道，订阅被关闭（包括因缓冲区满被移除）后通道关闭
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// send 非阻塞写入，缓冲区已满或订阅已关闭时返回 false
+func (s *Subscription[T]) send(v T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.ch <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close 取消订阅并关闭通道，可重复调用
+func (s *Subscription[T]) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.ch)
+	s.mu.Unlock()
+
+	s.hub.remove(s)
+}
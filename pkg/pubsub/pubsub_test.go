@@ -0,0 +1,104 @@
+package pubsub
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	t.Parallel()
+
+	hub := New[int](4)
+	a := hub.Subscribe("user:1")
+	b := hub.Subscribe("user:1")
+	other := hub.Subscribe("user:2")
+	defer a.Close()
+	defer b.Close()
+	defer other.Close()
+
+	assert.Equal(t, 2, hub.Subscribers("user:1"))
+	assert.Equal(t, 2, hub.Publish("user:1", 42))
+	assert.Equal(t, 42, <-a.C())
+	assert.Equal(t, 42, <-b.C())
+	assert.Empty(t, other.C())
+
+	assert.Equal(t, 0, hub.Publish("user:3", 1))
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	t.Parallel()
+
+	hub := New[string](0)
+	sub := hub.Subscribe("topic")
+	sub.Close()
+	sub.Close()
+
+	_, ok := <-sub.C()
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Subscribers("topic"))
+	assert.Equal(t, 0, hub.Publish("topic", "x"))
+}
+
+func TestLaggingSubscriberIsDropped(t *testing.T) {
+	t.Parallel()
+
+	hub := New[int](2)
+	slow := hub.Subscribe("topic")
+	fast := hub.Subscribe("topic")
+	defer fast.Close()
+
+	for i := 1; i <= 2; i++ {
+		require.Equal(t, 2, hub.Publish("topic", i))
+		<-fast.C()
+	}
+
+	// slow 的缓冲区已满，第三次发布时被移除，fast 不受影响
+	assert.Equal(t, 1, hub.Publish("topic", 3))
+	assert.Equal(t, 3, <-fast.C())
+	assert.Equal(t, 1, hub.Subscribers("topic"))
+
+	var got []int
+	for v := range slow.C() {
+		got = append(got, v)
+	}
+	assert.Equal(t, []int{1, 2}, got)
+}
+
+func TestConcurrentPublish(t *testing.T) {
+	t.Parallel()
+
+	hub := New[int](1000)
+	const topics, perTopic = 8, 100
+
+	subs := make([]*Subscription[int], topics)
+	for i := range subs {
+		subs[i] = hub.Subscribe(fmt.Sprintf("topic:%d", i))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < topics; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < perTopic; n++ {
+				hub.Publish(fmt.Sprintf("topic:%d", i), n)
+			}
+		}(i)
+		// 发布的同时订阅、退订其他主题
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hub.Subscribe(fmt.Sprintf("churn:%d", i)).Close()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, sub := range subs {
+		assert.Len(t, sub.C(), perTopic)
+		sub.Close()
+	}
+}
@@ -0,0 +1,396 @@
+// Package queue 基于数据库的持久化任务队列
+//
+// 任务保存在 queue_jobs 表中，worker 轮询领取到期任务并调用对应的处理函数。
+// 处理失败按指数退避重试，超过最大次数后移入死信表 queue_dead_jobs，
+// 可以通过 Requeue 重新入队。
+//
+// 领取任务时先查询候选任务，再以 attempts 作为版本号做条件更新，
+// 因此在 SQLite 等不支持行锁的数据库上多个 worker 也不会重复领取；
+// Postgres 和 MySQL 上额外使用 FOR UPDATE SKIP LOCKED 减少冲突。
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// ErrNotFound 表示任务或死信不存在
+var ErrNotFound = errors.New("queue: job not found")
+
+// errClaimLost 表示候选任务已被其他 worker 领走，或租约过期后被重新领取
+var errClaimLost = errors.New("queue: job claimed by another worker")
+
+// Job 待执行的任务
+type Job struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Kind        string     `gorm:"size:64;not null;index" json:"kind"`
+	Payload     string     `gorm:"type:text;not null" json:"payload"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"` // 已领取（执行）的次数
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	RunAt       time.Time  `gorm:"not null;index" json:"run_at"` // 最早可执行时间
+	LockedUntil *time.Time `json:"locked_until,omitempty"`       // 领取后的租约到期时间，worker 崩溃后任务可被重新领取
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+}
+
+// TableName 指定任务表名
+func (Job) TableName() string {
+	return "queue_jobs"
+}
+
+// Decode 将任务负载解码到 v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// DeadJob 超过最大重试次数的任务
+type DeadJob struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	JobID     int64     `gorm:"not null" json:"job_id"` // 原任务 ID
+	Kind      string    `gorm:"size:64;not null;index" json:"kind"`
+	Payload   string    `gorm:"type:text;not null" json:"payload"`
+	Attempts  int       `gorm:"not null" json:"attempts"`
+	LastError string    `gorm:"type:text" json:"last_error"`
+	FailedAt  time.Time `gorm:"not null" json:"failed_at"`
+}
+
+// TableName 指定死信表名
+func (DeadJob) TableName() string {
+	return "queue_dead_jobs"
+}
+
+// Decode 将任务负载解码到 v
+func (d *DeadJob) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(d.Payload), v)
+}
+
+// Models 返回队列需要自动迁移的数据库模型
+func Models() []interface{} {
+	return []interface{}{&Job{}, &DeadJob{}}
+}
+
+// HandlerFunc 任务处理函数，返回错误时任务会重试
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Config 队列配置，零值字段使用默认值
+type Config struct {
+	Workers      int           // worker 数量，默认 2
+	MaxAttempts  int           // 每个任务的最大执行次数，默认 5
+	BaseBackoff  time.Duration // 第一次重试前的等待时间，默认 1 秒，之后每次翻倍
+	MaxBackoff   time.Duration // 重试等待时间上限，默认 10 分钟
+	Lease        time.Duration // 单次执行的租约，也是处理函数的超时时间，默认 1 分钟
+	PollInterval time.Duration // 没有到期任务时的轮询间隔，默认 1 秒
+}
+
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = 2
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 10 * time.Minute
+	}
+	if c.Lease <= 0 {
+		c.Lease = time.Minute
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	return c
+}
+
+// Queue 数据库任务队列
+type Queue struct {
+	db         *gorm.DB
+	cfg        Config
+	logger     *slog.Logger
+	clock      module.Clock
+	skipLocked bool
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// New 创建使用 deps.DB 的任务队列，表结构见 Models
+func New(deps module.Deps, cfg Config) *Queue {
+	deps = deps.WithDefaults()
+	dialect := deps.DB.Dialector.Name()
+	return &Queue{
+		db:         deps.DB,
+		cfg:        cfg.withDefaults(),
+		logger:     deps.Logger,
+		clock:      deps.Clock,
+		skipLocked: dialect == "postgres" || dialect == "mysql",
+		handlers:   make(map[string]HandlerFunc),
+	}
+}
+
+// Handle 登记任务类型的处理函数
+func (q *Queue) Handle(kind string, h HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+// Enqueue 添加一个立即可执行的任务，payload 以 JSON 保存
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}) (*Job, error) {
+	return q.EnqueueAt(ctx, kind, payload, q.now())
+}
+
+// EnqueueAt 添加一个在 runAt 之后执行的任务
+func (q *Queue) EnqueueAt(ctx context.Context, kind string, payload interface{}, runAt time.Time) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("queue: encode payload: %w", err)
+	}
+	job := &Job{
+		Kind:        kind,
+		Payload:     string(data),
+		MaxAttempts: q.cfg.MaxAttempts,
+		RunAt:       runAt.UTC(),
+	}
+	if err := q.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Backoff 返回第 attempt 次执行失败后的等待时间
+func (q *Queue) Backoff(attempt int) time.Duration {
+	d := q.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= q.cfg.MaxBackoff {
+			return q.cfg.MaxBackoff
+		}
+	}
+	return d
+}
+
+// Run 启动 worker 并阻塞到 ctx 取消且所有 worker 退出
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work 单个 worker 的循环：有任务就连续处理，没有就等待一个轮询间隔
+func (q *Queue) work(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		processed, err := q.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			q.logger.Error("处理队列任务失败", "error", err)
+		}
+		if processed {
+			timer.Reset(0)
+		} else {
+			timer.Reset(q.cfg.PollInterval)
+		}
+	}
+}
+
+// RunOnce 领取并执行一个到期任务，没有可执行的任务时返回 false
+func (q *Queue) RunOnce(ctx context.Context) (bool, error) {
+	job, err := q.claim(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errClaimLost) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+
+	var runErr error
+	if !ok {
+		runErr = fmt.Errorf("queue: no handler for kind %q", job.Kind)
+	} else {
+		runCtx, cancel := context.WithTimeout(ctx, q.cfg.Lease)
+		runErr = handler(runCtx, job)
+		cancel()
+	}
+
+	// 任务已经执行，worker 退出（ctx 取消）时也要记录结果，否则任务会在租约过期后重复执行
+	ctx = context.WithoutCancel(ctx)
+	if runErr == nil {
+		return true, q.db.WithContext(ctx).Delete(&Job{}, job.ID).Error
+	}
+	return true, q.fail(ctx, job, runErr)
+}
+
+// claim 领取最早到期的任务，并把执行次数加一、设置租约
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	now := q.now()
+	var job Job
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("run_at <= ? AND (locked_until IS NULL OR locked_until < ?)", now, now).
+			Order("run_at, id").
+			Limit(1)
+		if q.skipLocked {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		if err := query.Take(&job).Error; err != nil {
+			return err
+		}
+
+		lockedUntil := now.Add(q.cfg.Lease)
+		result := tx.Model(&Job{}).
+			Where("id = ? AND attempts = ?", job.ID, job.Attempts).
+			Updates(map[string]interface{}{
+				"attempts":     job.Attempts + 1,
+				"locked_until": lockedUntil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClaimLost
+		}
+		job.Attempts++
+		job.LockedUntil = &lockedUntil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// fail 记录失败：未达到最大次数时按退避时间重新排期，否则移入死信表
+// 以领取时的执行次数为条件更新，租约过期后任务已被其他 worker 重新领取时放弃记录，由新的执行者处理
+func (q *Queue) fail(ctx context.Context, job *Job, runErr error) error {
+	now := q.now()
+	owned := q.db.WithContext(ctx).Model(&Job{}).Where("id = ? AND attempts = ?", job.ID, job.Attempts)
+
+	if job.Attempts < job.MaxAttempts {
+		q.logger.Warn("队列任务失败，稍后重试", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", runErr)
+		result := owned.Updates(map[string]interface{}{
+			"run_at":       now.Add(q.Backoff(job.Attempts)),
+			"locked_until": nil,
+			"last_error":   runErr.Error(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			q.logger.Warn("队列任务的租约已失效，放弃记录失败", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts)
+		}
+		return nil
+	}
+
+	q.logger.Error("队列任务超过最大重试次数，移入死信表", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", runErr)
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND attempts = ?", job.ID, job.Attempts).Delete(&Job{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errClaimLost
+		}
+		return tx.Create(&DeadJob{
+			JobID:     job.ID,
+			Kind:      job.Kind,
+			Payload:   job.Payload,
+			Attempts:  job.Attempts,
+			LastError: runErr.Error(),
+			FailedAt:  now,
+		}).Error
+	})
+	if errors.Is(err, errClaimLost) {
+		q.logger.Warn("队列任务的租约已失效，放弃移入死信表", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts)
+		return nil
+	}
+	return err
+}
+
+// ListDead 按失败时间倒序列出死信，kind 为空时列出全部类型
+func (q *Queue) ListDead(ctx context.Context, kind string) ([]DeadJob, error) {
+	query := q.db.WithContext(ctx).Order("failed_at DESC, id DESC")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var dead []DeadJob
+	err := query.Find(&dead).Error
+	return dead, err
+}
+
+// FindDead 查询单条死信
+func (q *Queue) FindDead(ctx context.Context, id int64) (*DeadJob, error) {
+	var dead DeadJob
+	if err := q.db.WithContext(ctx).First(&dead, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &dead, nil
+}
+
+// Requeue 将死信重新入队，执行次数清零
+func (q *Queue) Requeue(ctx context.Context, deadID int64) (*Job, error) {
+	var job *Job
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var dead DeadJob
+		if err := tx.First(&dead, deadID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		job = &Job{
+			Kind:        dead.Kind,
+			Payload:     dead.Payload,
+			MaxAttempts: q.cfg.MaxAttempts,
+			RunAt:       q.now(),
+			LastError:   dead.LastError,
+		}
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&DeadJob{}, dead.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// now 返回 UTC 时间，SQLite 以字符串比较时间，统一时区才能正确排序
+func (q *Queue) now() time.Time {
+	return q.clock.Now().UTC()
+}
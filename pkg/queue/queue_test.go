@@ -0,0 +1,314 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// fakeClock 可手动拨动的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// setupTestDB 使用临时文件数据库，多个 worker 并发时由 busy_timeout 排队写入
+func setupTestDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "queue.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(Models()...))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func setupQueue(t *testing.T, cfg Config) (*Queue, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)}
+	return New(module.Deps{DB: setupTestDB(t), Clock: clock}, cfg), clock
+}
+
+type payload struct {
+	MessageID int64 `json:"message_id"`
+}
+
+func TestRunOnceCompletesJob(t *testing.T) {
+	t.Parallel()
+
+	q, _ := setupQueue(t, Config{})
+	ctx := context.Background()
+
+	var got payload
+	q.Handle("deliver", func(ctx context.Context, job *Job) error {
+		return job.Decode(&got)
+	})
+
+	_, err := q.Enqueue(ctx, "deliver", payload{MessageID: 42})
+	require.NoError(t, err)
+
+	processed, err := q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, int64(42), got.MessageID)
+
+	var count int64
+	require.NoError(t, q.db.Model(&Job{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	processed, err = q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestRetryBackoffAndDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	q, clock := setupQueue(t, Config{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute})
+	ctx := context.Background()
+
+	var calls int
+	q.Handle("flaky", func(ctx context.Context, job *Job) error {
+		calls++
+		return fmt.Errorf("gateway down #%d", calls)
+	})
+
+	job, err := q.Enqueue(ctx, "flaky", payload{MessageID: 1})
+	require.NoError(t, err)
+
+	// 第一次失败后 1 秒内不会再执行
+	processed, err := q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	processed, _ = q.RunOnce(ctx)
+	assert.False(t, processed)
+
+	var stored Job
+	require.NoError(t, q.db.First(&stored, job.ID).Error)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, "gateway down #1", stored.LastError)
+	assert.Nil(t, stored.LockedUntil)
+
+	clock.Advance(time.Second)
+	processed, _ = q.RunOnce(ctx)
+	assert.True(t, processed)
+
+	// 第二次失败后等待时间翻倍
+	clock.Advance(time.Second)
+	processed, _ = q.RunOnce(ctx)
+	assert.False(t, processed)
+	clock.Advance(time.Second)
+	processed, _ = q.RunOnce(ctx)
+	assert.True(t, processed)
+	assert.Equal(t, 3, calls)
+
+	// 第三次失败后进入死信表
+	dead, err := q.ListDead(ctx, "flaky")
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, job.ID, dead[0].JobID)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "gateway down #3", dead[0].LastError)
+	assert.ErrorIs(t, q.db.First(&Job{}, job.ID).Error, gorm.ErrRecordNotFound)
+
+	// 重新入队后立即可执行，成功后死信不再出现
+	q.Handle("flaky", func(ctx context.Context, job *Job) error { return nil })
+	requeued, err := q.Requeue(ctx, dead[0].ID)
+	require.NoError(t, err)
+	assert.Zero(t, requeued.Attempts)
+	processed, err = q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	dead, err = q.ListDead(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	_, err = q.Requeue(ctx, 9999)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestUnknownKindIsRetried(t *testing.T) {
+	t.Parallel()
+
+	q, _ := setupQueue(t, Config{MaxAttempts: 1})
+	ctx := context.Background()
+
+	_, err := q.Enqueue(ctx, "nobody-handles-this", payload{})
+	require.NoError(t, err)
+
+	processed, err := q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	dead, err := q.ListDead(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Contains(t, dead[0].LastError, "no handler")
+}
+
+func TestExpiredLeaseIsReclaimed(t *testing.T) {
+	t.Parallel()
+
+	q, clock := setupQueue(t, Config{Lease: 30 * time.Second})
+	ctx := context.Background()
+
+	_, err := q.Enqueue(ctx, "deliver", payload{})
+	require.NoError(t, err)
+
+	// 模拟 worker 领取后崩溃，租约期内其他 worker 领不到
+	job, err := q.claim(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Attempts)
+	_, err = q.claim(ctx)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	clock.Advance(31 * time.Second)
+	again, err := q.claim(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, again.ID)
+	assert.Equal(t, 2, again.Attempts)
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	q, _ := setupQueue(t, Config{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	assert.Equal(t, time.Second, q.Backoff(1))
+	assert.Equal(t, 2*time.Second, q.Backoff(2))
+	assert.Equal(t, 8*time.Second, q.Backoff(4))
+	assert.Equal(t, 10*time.Second, q.Backoff(5))
+	assert.Equal(t, 10*time.Second, q.Backoff(50))
+}
+
+func TestWorkersProcessEachJobOnce(t *testing.T) {
+	t.Parallel()
+
+	q, _ := setupQueue(t, Config{Workers: 4, PollInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const total = 30
+	var processed atomic.Int32
+	seen := make([]atomic.Int32, total)
+	q.Handle("count", func(ctx context.Context, job *Job) error {
+		var p payload
+		if err := job.Decode(&p); err != nil {
+			return err
+		}
+		seen[p.MessageID].Add(1)
+		if processed.Add(1) == total {
+			cancel()
+		}
+		return nil
+	})
+
+	for i := 0; i < total; i++ {
+		_, err := q.Enqueue(ctx, "count", payload{MessageID: int64(i)})
+		require.NoError(t, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("workers did not finish in time")
+	}
+
+	for i := range seen {
+		assert.Equal(t, int32(1), seen[i].Load(), "job %d", i)
+	}
+}
+
+func TestResultRecordedAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	q, _ := setupQueue(t, Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// worker 在任务执行期间退出，任务完成后仍然删除
+	q.Handle("deliver", func(context.Context, *Job) error {
+		cancel()
+		return nil
+	})
+	_, err := q.Enqueue(ctx, "deliver", payload{})
+	require.NoError(t, err)
+
+	processed, err := q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	var count int64
+	require.NoError(t, q.db.Model(&Job{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestFailAfterLeaseLost(t *testing.T) {
+	t.Parallel()
+
+	q, clock := setupQueue(t, Config{Lease: 30 * time.Second, MaxAttempts: 2})
+	ctx := context.Background()
+
+	_, err := q.Enqueue(ctx, "deliver", payload{})
+	require.NoError(t, err)
+
+	// 第一个 worker 执行超过租约，任务被第二个 worker 重新领取
+	stale, err := q.claim(ctx)
+	require.NoError(t, err)
+	clock.Advance(31 * time.Second)
+	current, err := q.claim(ctx)
+	require.NoError(t, err)
+	require.Equal(t, stale.ID, current.ID)
+
+	// 迟到的失败不会清除新的租约，也不会把任务移入死信表
+	require.NoError(t, q.fail(ctx, stale, fmt.Errorf("timeout")))
+	var job Job
+	require.NoError(t, q.db.First(&job, current.ID).Error)
+	require.NotNil(t, job.LockedUntil)
+	assert.True(t, job.LockedUntil.Equal(*current.LockedUntil))
+	assert.Empty(t, job.LastError)
+
+	stale.MaxAttempts = stale.Attempts
+	require.NoError(t, q.fail(ctx, stale, fmt.Errorf("timeout")))
+	dead, err := q.ListDead(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	// 当前持有者的失败正常移入死信表
+	require.NoError(t, q.fail(ctx, current, fmt.Errorf("boom")))
+	dead, err = q.ListDead(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "boom", dead[0].LastError)
+}
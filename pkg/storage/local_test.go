@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPutGetDelete(t *testing.T) {
+	s, err := NewLocal(t.TempDir(), "http://localhost/files", []byte("secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "avatars/u/original.png", strings.NewReader("data"), 4, "image/png"))
+
+	rc, err := s.Get(ctx, "avatars/u/original.png")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "data", string(body))
+
+	require.NoError(t, s.Delete(ctx, "avatars/u/original.png"))
+	_, err = s.Get(ctx, "avatars/u/original.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 重复删除不报错
+	assert.NoError(t, s.Delete(ctx, "avatars/u/original.png"))
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	s, err := NewLocal(t.TempDir(), "http://localhost/files", []byte("secret"))
+	require.NoError(t, err)
+
+	err = s.Put(context.Background(), "../escape.txt", strings.NewReader("x"), 1, "")
+	assert.Error(t, err)
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	s, err := NewLocal(t.TempDir(), "http://localhost/files", []byte("secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, s.Put(ctx, "a/b.png", strings.NewReader("img"), 3, "image/png"))
+
+	signed, err := s.SignedURL(ctx, "a/b.png", time.Minute)
+	require.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/files/a/b.png", u.Path)
+
+	h := http.StripPrefix("/files", s.Handler())
+
+	req := httptest.NewRequest(http.MethodGet, u.RequestURI(), nil)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "img", resp.Body.String())
+	assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+
+	// 篡改签名
+	q := u.Query()
+	q.Set("signature", "deadbeef")
+	req = httptest.NewRequest(http.MethodGet, u.Path+"?"+q.Encode(), nil)
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	// 已过期
+	s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	req = httptest.NewRequest(http.MethodGet, u.RequestURI(), nil)
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
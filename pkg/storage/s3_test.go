@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 是一个内存版的 S3 兼容服务，会校验每个请求的 SigV4 签名
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	signer  *S3
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verify 按请求中的参数重新计算签名并比较
+func (f *fakeS3) verify(r *http.Request) bool {
+	q := r.URL.Query()
+
+	if sig := q.Get("X-Amz-Signature"); sig != "" {
+		t, err := time.Parse(s3TimeFormat, q.Get("X-Amz-Date"))
+		if err != nil {
+			return false
+		}
+		canonical := canonicalRequest(r.Method, r.URL, r.Header, r.Host, strings.Split(q.Get("X-Amz-SignedHeaders"), ";"), s3UnsignedBody)
+		return sig == f.signer.signature(t, canonical)
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, s3Algorithm+" ") {
+		return false
+	}
+	fields := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, s3Algorithm+" "), ", ") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+	t, err := time.Parse(s3TimeFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	canonical := canonicalRequest(r.Method, r.URL, r.Header, r.Host, strings.Split(fields["SignedHeaders"], ";"), r.Header.Get("X-Amz-Content-Sha256"))
+	return fields["Signature"] == f.signer.signature(t, canonical)
+}
+
+func setupFakeS3(t *testing.T, secret string) (*S3, *fakeS3) {
+	signer, err := NewS3(S3Config{Endpoint: "http://unused", Region: "us-east-1", Bucket: "avatars", AccessKey: "AK", SecretKey: "SK"})
+	require.NoError(t, err)
+
+	fake := &fakeS3{objects: map[string][]byte{}, signer: signer}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3(S3Config{Endpoint: srv.URL, Region: "us-east-1", Bucket: "avatars", AccessKey: "AK", SecretKey: secret})
+	require.NoError(t, err)
+	return s, fake
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	s, fake := setupFakeS3(t, "SK")
+	ctx := context.Background()
+
+	err := s.Put(ctx, "avatars/john doe/original.png", strings.NewReader("png-data"), 8, "image/png")
+	require.NoError(t, err)
+	assert.Contains(t, fake.objects, "/avatars/avatars/john doe/original.png")
+
+	rc, err := s.Get(ctx, "avatars/john doe/original.png")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "png-data", string(body))
+
+	require.NoError(t, s.Delete(ctx, "avatars/john doe/original.png"))
+	_, err = s.Get(ctx, "avatars/john doe/original.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3RejectsWrongSecret(t *testing.T) {
+	s, _ := setupFakeS3(t, "wrong")
+
+	err := s.Put(context.Background(), "k", strings.NewReader("x"), 1, "")
+	assert.Error(t, err)
+}
+
+func TestS3SignedURL(t *testing.T) {
+	s, _ := setupFakeS3(t, "SK")
+	ctx := context.Background()
+	require.NoError(t, s.Put(ctx, "a/b.png", strings.NewReader("img"), 3, "image/png"))
+
+	signed, err := s.SignedURL(ctx, "a/b.png", time.Minute)
+	require.NoError(t, err)
+
+	u, _ := url.Parse(signed)
+	assert.Equal(t, "60", u.Query().Get("X-Amz-Expires"))
+
+	resp, err := http.Get(signed)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = s.SignedURL(ctx, "a/b.png", 8*24*time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/variables"
+)
+
+// ErrNotFound 表示对象不存在
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage 定义对象存储后端的通用接口
+// key 使用 "/" 分隔的相对路径，例如 avatars/johndoe/original.png
+type Storage interface {
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL 生成在 expires 时间内有效的下载地址
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Setup 根据环境变量初始化存储后端
+//
+//	STORAGE_TYPE=local 使用本地文件系统（默认），必须通过 STORAGE_SECRET 配置下载地址的签名密钥
+//	STORAGE_TYPE=s3    使用 S3 兼容的对象存储
+func Setup() (Storage, error) {
+	storageType := variables.GetEnv("STORAGE_TYPE", "local")
+
+	switch storageType {
+	case "local":
+		// 没有默认值：公开的默认密钥会让任何人都能伪造签名地址
+		secret := os.Getenv("STORAGE_SECRET")
+		if secret == "" {
+			return nil, errors.New("storage: STORAGE_SECRET is required for local storage")
+		}
+		return NewLocal(
+			variables.GetEnv("STORAGE_LOCAL_DIR", "uploads"),
+			variables.GetEnv("STORAGE_BASE_URL", "http://localhost:8080/files"),
+			[]byte(secret),
+		)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  variables.GetEnv("S3_ENDPOINT", "http://localhost:9000"),
+			Region:    variables.GetEnv("S3_REGION", "us-east-1"),
+			Bucket:    variables.GetEnv("S3_BUCKET", "blueprint"),
+			AccessKey: variables.GetEnv("S3_ACCESS_KEY", ""),
+			SecretKey: variables.GetEnv("S3_SECRET_KEY", ""),
+		})
+	default:
+		return nil, fmt.Errorf("storage: unsupported STORAGE_TYPE %q", storageType)
+	}
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupRequiresLocalSecret(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "local")
+	t.Setenv("STORAGE_LOCAL_DIR", t.TempDir())
+
+	t.Setenv("STORAGE_SECRET", "")
+	_, err := Setup()
+	assert.ErrorContains(t, err, "STORAGE_SECRET")
+
+	t.Setenv("STORAGE_SECRET", "secret")
+	s, err := Setup()
+	require.NoError(t, err)
+	assert.IsType(t, &Local{}, s)
+}
@@ -0,0 +1,5551 @@
+// Package message provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oapi-codegen/runtime"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	CallbackSignatureScopes = "CallbackSignature.Scopes"
+	MySecurityScopes        = "MySecurity.Scopes"
+)
+
+// Defines values for BroadcastMessageChannel.
+const (
+	Alert     BroadcastMessageChannel = "alert"
+	News      BroadcastMessageChannel = "news"
+	Promotion BroadcastMessageChannel = "promotion"
+)
+
+// Defines values for DeliveryReportStatus.
+const (
+	ReportDelivered DeliveryReportStatus = "delivered"
+	ReportFailed    DeliveryReportStatus = "failed"
+)
+
+// Defines values for FanoutStatus.
+const (
+	FanoutCompleted FanoutStatus = "completed"
+	FanoutRunning   FanoutStatus = "running"
+)
+
+// Defines values for MessageStatus.
+const (
+	Failed     MessageStatus = "failed"
+	Read       MessageStatus = "read"
+	Received   MessageStatus = "received"
+	Sending    MessageStatus = "sending"
+	Sent       MessageStatus = "sent"
+	Suppressed MessageStatus = "suppressed"
+)
+
+// Defines values for MessageType.
+const (
+	Abstract    MessageType = "abstract"
+	Broadcast   MessageType = "broadcast"
+	Email       MessageType = "email"
+	Sitemessage MessageType = "sitemessage"
+	Sms         MessageType = "sms"
+	Wechat      MessageType = "wechat"
+)
+
+// Defines values for ScheduleStatus.
+const (
+	ScheduleCancelled ScheduleStatus = "cancelled"
+	ScheduleCompleted ScheduleStatus = "completed"
+	SchedulePending   ScheduleStatus = "pending"
+)
+
+// Defines values for TemplateVariableType.
+const (
+	VariableBoolean TemplateVariableType = "boolean"
+	VariableInteger TemplateVariableType = "integer"
+	VariableNumber  TemplateVariableType = "number"
+	VariableString  TemplateVariableType = "string"
+)
+
+// BroadcastMessage defines model for BroadcastMessage.
+type BroadcastMessage struct {
+	// Category 消息类别，投递前按接收方的通知偏好判断是否送达；security 等强制类别不受偏好约束。
+	// 由广播扇出生成的站内信以广播频道为类别，验证码短信的类别为 security
+	Category *string                  `json:"category,omitempty"`
+	Channel  *BroadcastMessageChannel `json:"channel,omitempty"`
+
+	// Content 消息内容
+	Content string `json:"content"`
+	Id      int64  `json:"id"`
+
+	// Locale 模板语言，省略时按 Accept-Language 选择，都没有匹配时使用模板的默认语言
+	Locale *string `json:"locale,omitempty"`
+
+	// Params 模板变量的取值，与 template_id 一起使用
+	Params *map[string]interface{} `json:"params,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式（分 时 日 月 周，或 @daily 等简写），按 timezone 计算
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// SendAt 定时发送的时间，不能早于当前时间；与 recurrence 一起使用时为首次发送的最早时间
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Sender 发送方标识，如调用方的业务系统名称，按发送方限流时使用
+	Sender *string `json:"sender,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+
+	// TemplateId 使用的消息模板，创建时提供则由模板渲染 content，此时可以省略 content
+	TemplateId *string `json:"template_id,omitempty"`
+
+	// Timezone recurrence 使用的 IANA 时区，默认 UTC
+	Timezone *string     `json:"timezone,omitempty"`
+	Type     MessageType `json:"type"`
+}
+
+// BroadcastMessageChannel defines model for BroadcastMessage.Channel.
+type BroadcastMessageChannel string
+
+// CreateMessageRequest defines model for CreateMessageRequest.
+type CreateMessageRequest struct {
+	union json.RawMessage
+}
+
+// DeliveryJob defines model for DeliveryJob.
+type DeliveryJob struct {
+	// Id 队列任务ID
+	Id        int64 `json:"id"`
+	MessageId int64 `json:"message_id"`
+
+	// RunAt 最早执行时间
+	RunAt time.Time `json:"run_at"`
+}
+
+// DeliveryReport defines model for DeliveryReport.
+type DeliveryReport struct {
+	// Error 投递失败的原因，例如运营商返回的状态码
+	Error *string `json:"error,omitempty"`
+
+	// MessageId 通道侧的消息 ID，即通道接收消息时返回的 message_id
+	MessageId string `json:"message_id"`
+
+	// Status delivered 表示已送达接收方，failed 表示投递失败
+	Status DeliveryReportStatus `json:"status"`
+}
+
+// DeliveryReportStatus delivered 表示已送达接收方，failed 表示投递失败
+type DeliveryReportStatus string
+
+// EmailAttachment defines model for EmailAttachment.
+type EmailAttachment struct {
+	// Content base64 编码的附件内容
+	Content *[]byte `json:"content,omitempty"`
+
+	// ContentType 省略时按文件扩展名推断
+	ContentType *string `json:"content_type,omitempty"`
+	Filename    string  `json:"filename"`
+
+	// Size 附件的字节数
+	Size *int64 `json:"size,omitempty"`
+}
+
+// EmailMessage defines model for EmailMessage.
+type EmailMessage struct {
+	// Attachments 附件，总大小不超过 10 MiB
+	Attachments *[]EmailAttachment `json:"attachments,omitempty"`
+
+	// Category 消息类别，投递前按接收方的通知偏好判断是否送达；security 等强制类别不受偏好约束。
+	// 由广播扇出生成的站内信以广播频道为类别，验证码短信的类别为 security
+	Category *string `json:"category,omitempty"`
+
+	// Content 消息内容
+	Content string `json:"content"`
+
+	// Email 收件人邮箱
+	Email openapi_types.Email `json:"email"`
+
+	// Html HTML 正文，可选；提供时邮件同时包含纯文本和 HTML 两种正文
+	Html *string `json:"html,omitempty"`
+	Id   int64   `json:"id"`
+
+	// Locale 模板语言，省略时按 Accept-Language 选择，都没有匹配时使用模板的默认语言
+	Locale *string `json:"locale,omitempty"`
+
+	// Params 模板变量的取值，与 template_id 一起使用
+	Params *map[string]interface{} `json:"params,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式（分 时 日 月 周，或 @daily 等简写），按 timezone 计算
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// SendAt 定时发送的时间，不能早于当前时间；与 recurrence 一起使用时为首次发送的最早时间
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Sender 发送方标识，如调用方的业务系统名称，按发送方限流时使用
+	Sender *string `json:"sender,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+
+	// Subject 邮件主题
+	Subject string `json:"subject"`
+
+	// TemplateId 使用的消息模板，创建时提供则由模板渲染 content，此时可以省略 content
+	TemplateId *string `json:"template_id,omitempty"`
+
+	// Timezone recurrence 使用的 IANA 时区，默认 UTC
+	Timezone *string     `json:"timezone,omitempty"`
+	Type     MessageType `json:"type"`
+}
+
+// FailedDelivery defines model for FailedDelivery.
+type FailedDelivery struct {
+	// Attempts 已尝试投递的次数
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+
+	// Id 死信ID
+	Id int64 `json:"id"`
+
+	// LastError 最后一次投递的错误
+	LastError string   `json:"last_error"`
+	Message   *Message `json:"message,omitempty"`
+	MessageId int64    `json:"message_id"`
+}
+
+// FailedDeliveryList defines model for FailedDeliveryList.
+type FailedDeliveryList struct {
+	Failed *[]FailedDelivery `json:"failed,omitempty"`
+}
+
+// Fanout defines model for Fanout.
+type Fanout struct {
+	BroadcastId int64      `json:"broadcast_id"`
+	Channel     string     `json:"channel"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Delivered 已生成的站内信数
+	Delivered int64 `json:"delivered"`
+
+	// Status running 表示仍在分批生成站内信
+	Status FanoutStatus `json:"status"`
+
+	// Total 开始扇出时频道的订阅者数
+	Total int64 `json:"total"`
+}
+
+// FanoutStatus running 表示仍在分批生成站内信
+type FanoutStatus string
+
+// InboxSummary defines model for InboxSummary.
+type InboxSummary struct {
+	// Unread 未归档的未读站内信数
+	Unread int64 `json:"unread"`
+	UserId int64 `json:"user_id"`
+}
+
+// LatencyPercentiles defines model for LatencyPercentiles.
+type LatencyPercentiles struct {
+	// Count 参与统计的消息数
+	Count int64 `json:"count"`
+	MaxMs int64 `json:"max_ms"`
+	P50Ms int64 `json:"p50_ms"`
+	P90Ms int64 `json:"p90_ms"`
+	P99Ms int64 `json:"p99_ms"`
+}
+
+// MarkReadResult defines model for MarkReadResult.
+type MarkReadResult struct {
+	// Updated 本次标记为已读的消息数
+	Updated int64 `json:"updated"`
+}
+
+// Message defines model for Message.
+type Message struct {
+	// Category 消息类别，投递前按接收方的通知偏好判断是否送达；security 等强制类别不受偏好约束。
+	// 由广播扇出生成的站内信以广播频道为类别，验证码短信的类别为 security
+	Category *string `json:"category,omitempty"`
+
+	// Content 消息内容
+	Content string `json:"content"`
+	Id      int64  `json:"id"`
+
+	// Locale 模板语言，省略时按 Accept-Language 选择，都没有匹配时使用模板的默认语言
+	Locale *string `json:"locale,omitempty"`
+
+	// Params 模板变量的取值，与 template_id 一起使用
+	Params *map[string]interface{} `json:"params,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式（分 时 日 月 周，或 @daily 等简写），按 timezone 计算
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// SendAt 定时发送的时间，不能早于当前时间；与 recurrence 一起使用时为首次发送的最早时间
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Sender 发送方标识，如调用方的业务系统名称，按发送方限流时使用
+	Sender *string `json:"sender,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+
+	// TemplateId 使用的消息模板，创建时提供则由模板渲染 content，此时可以省略 content
+	TemplateId *string `json:"template_id,omitempty"`
+
+	// Timezone recurrence 使用的 IANA 时区，默认 UTC
+	Timezone *string     `json:"timezone,omitempty"`
+	Type     MessageType `json:"type"`
+}
+
+// MessageListResponse defines model for MessageListResponse.
+type MessageListResponse struct {
+	Messages *[]Message `json:"messages,omitempty"`
+}
+
+// MessageSearchResult defines model for MessageSearchResult.
+type MessageSearchResult struct {
+	Messages []MessageSummary `json:"messages"`
+
+	// NextBefore 还有更多消息时，作为下一页的 before 参数
+	NextBefore *int64 `json:"next_before,omitempty"`
+}
+
+// MessageStats defines model for MessageStats.
+type MessageStats struct {
+	// Buckets 按起始时间、类型、通道排序，没有消息的时间桶不列出
+	Buckets  []MessageStatsBucket `json:"buckets"`
+	From     time.Time            `json:"from"`
+	Interval string               `json:"interval"`
+	Timezone string               `json:"timezone"`
+	To       time.Time            `json:"to"`
+}
+
+// MessageStatsBucket defines model for MessageStatsBucket.
+type MessageStatsBucket struct {
+	DeliveryLatency *LatencyPercentiles `json:"delivery_latency,omitempty"`
+
+	// Provider 投递通道名称，未经通道投递的消息为空
+	Provider    string              `json:"provider"`
+	SendLatency *LatencyPercentiles `json:"send_latency,omitempty"`
+
+	// Start 时间桶的起始时间
+	Start time.Time `json:"start"`
+
+	// Statuses 消息状态 -> 当前处于该状态的消息数
+	Statuses map[string]int64 `json:"statuses"`
+	Total    int64            `json:"total"`
+	Type     MessageType      `json:"type"`
+}
+
+// MessageStatus suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+type MessageStatus string
+
+// MessageSummary defines model for MessageSummary.
+type MessageSummary struct {
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	Id        int64     `json:"id"`
+
+	// Provider 投递通道名称，尚未投递时为空
+	Provider *string `json:"provider,omitempty"`
+
+	// Recipient 接收方：手机号、用户ID、广播频道、openid 或邮箱
+	Recipient *string `json:"recipient,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status    MessageStatus `json:"status"`
+	Type      MessageType   `json:"type"`
+	UpdatedAt *time.Time    `json:"updated_at,omitempty"`
+}
+
+// MessageType defines model for MessageType.
+type MessageType string
+
+// OTPChallenge defines model for OTPChallenge.
+type OTPChallenge struct {
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// MessageId 发送验证码的短信ID
+	MessageId   int64  `json:"message_id"`
+	PhoneNumber string `json:"phone_number"`
+	Purpose     string `json:"purpose"`
+
+	// ResendAfter 此时间之后才能重新发送
+	ResendAfter time.Time `json:"resend_after"`
+}
+
+// OTPRequest defines model for OTPRequest.
+type OTPRequest struct {
+	// Locale 模板语言，省略时按 Accept-Language 选择
+	Locale      *string `json:"locale,omitempty"`
+	PhoneNumber string  `json:"phone_number"`
+
+	// Purpose 验证码用途，不同用途的验证码互不影响，默认 default
+	Purpose *string `json:"purpose,omitempty"`
+
+	// TemplateId 短信模板，省略时使用服务配置的默认模板
+	TemplateId *string `json:"template_id,omitempty"`
+}
+
+// OTPVerification defines model for OTPVerification.
+type OTPVerification struct {
+	PhoneNumber string `json:"phone_number"`
+	Purpose     string `json:"purpose"`
+	Verified    bool   `json:"verified"`
+}
+
+// OTPVerifyRequest defines model for OTPVerifyRequest.
+type OTPVerifyRequest struct {
+	Code        string  `json:"code"`
+	PhoneNumber string  `json:"phone_number"`
+	Purpose     *string `json:"purpose,omitempty"`
+}
+
+// SMSMessage defines model for SMSMessage.
+type SMSMessage struct {
+	// Category 消息类别，投递前按接收方的通知偏好判断是否送达；security 等强制类别不受偏好约束。
+	// 由广播扇出生成的站内信以广播频道为类别，验证码短信的类别为 security
+	Category *string `json:"category,omitempty"`
+
+	// Content 消息内容
+	Content string `json:"content"`
+	Id      int64  `json:"id"`
+
+	// Locale 模板语言，省略时按 Accept-Language 选择，都没有匹配时使用模板的默认语言
+	Locale *string `json:"locale,omitempty"`
+
+	// Params 模板变量的取值，与 template_id 一起使用
+	Params *map[string]interface{} `json:"params,omitempty"`
+
+	// PhoneNumber 接收手机号
+	PhoneNumber string `json:"phone_number"`
+
+	// RecipientTimezone 接收方所在的 IANA 时区，用于判断免打扰时段，省略时使用服务配置的默认时区
+	RecipientTimezone *string `json:"recipient_timezone,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式（分 时 日 月 周，或 @daily 等简写），按 timezone 计算
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// SendAt 定时发送的时间，不能早于当前时间；与 recurrence 一起使用时为首次发送的最早时间
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Sender 发送方标识，如调用方的业务系统名称，按发送方限流时使用
+	Sender *string `json:"sender,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+
+	// TemplateId 使用的消息模板，创建时提供则由模板渲染 content，此时可以省略 content
+	TemplateId *string `json:"template_id,omitempty"`
+
+	// Timezone recurrence 使用的 IANA 时区，默认 UTC
+	Timezone *string     `json:"timezone,omitempty"`
+	Type     MessageType `json:"type"`
+
+	// Urgent 紧急短信不受免打扰时段限制，立即发送
+	Urgent *bool `json:"urgent,omitempty"`
+}
+
+// Schedule defines model for Schedule.
+type Schedule struct {
+	// Category 生成的消息的类别
+	Category *string `json:"category,omitempty"`
+	Channel  *string `json:"channel,omitempty"`
+
+	// Content 每次发送的消息内容
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Email 邮件的收件人
+	Email *string `json:"email,omitempty"`
+	Id    int64   `json:"id"`
+
+	// LastMessageId 最近一次生成的消息ID
+	LastMessageId *int64     `json:"last_message_id,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+
+	// NextRunAt 下次发送时间，任务结束后为空
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+
+	// Openid 微信模板消息的接收方
+	Openid      *string `json:"openid,omitempty"`
+	PhoneNumber *string `json:"phone_number,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式，为空表示只发送一次
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// Runs 已发送的次数
+	Runs   int64   `json:"runs"`
+	Sender *string `json:"sender,omitempty"`
+
+	// Status pending 表示还有待发送的时间点
+	Status ScheduleStatus `json:"status"`
+
+	// Subject 邮件主题
+	Subject    *string     `json:"subject,omitempty"`
+	TemplateId *string     `json:"template_id,omitempty"`
+	Timezone   *string     `json:"timezone,omitempty"`
+	Type       MessageType `json:"type"`
+	UserId     *int64      `json:"user_id,omitempty"`
+}
+
+// ScheduleList defines model for ScheduleList.
+type ScheduleList struct {
+	Schedules []Schedule `json:"schedules"`
+}
+
+// ScheduleStatus pending 表示还有待发送的时间点
+type ScheduleStatus string
+
+// SiteMessage defines model for SiteMessage.
+type SiteMessage struct {
+	// ArchivedAt 归档的时间，未归档时为空
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// Category 消息类别，投递前按接收方的通知偏好判断是否送达；security 等强制类别不受偏好约束。
+	// 由广播扇出生成的站内信以广播频道为类别，验证码短信的类别为 security
+	Category *string `json:"category,omitempty"`
+
+	// Content 消息内容
+	Content string `json:"content"`
+	Id      int64  `json:"id"`
+
+	// Locale 模板语言，省略时按 Accept-Language 选择，都没有匹配时使用模板的默认语言
+	Locale *string `json:"locale,omitempty"`
+
+	// Params 模板变量的取值，与 template_id 一起使用
+	Params *map[string]interface{} `json:"params,omitempty"`
+
+	// ReadAt 标记为已读的时间
+	ReadAt *time.Time `json:"read_at,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式（分 时 日 月 周，或 @daily 等简写），按 timezone 计算
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// SendAt 定时发送的时间，不能早于当前时间；与 recurrence 一起使用时为首次发送的最早时间
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Sender 发送方标识，如调用方的业务系统名称，按发送方限流时使用
+	Sender *string `json:"sender,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+
+	// TemplateId 使用的消息模板，创建时提供则由模板渲染 content，此时可以省略 content
+	TemplateId *string `json:"template_id,omitempty"`
+
+	// Timezone recurrence 使用的 IANA 时区，默认 UTC
+	Timezone *string     `json:"timezone,omitempty"`
+	Type     MessageType `json:"type"`
+
+	// UserId 接收用户ID
+	UserId int64 `json:"user_id"`
+}
+
+// SiteMessageList defines model for SiteMessageList.
+type SiteMessageList struct {
+	Messages []SiteMessage `json:"messages"`
+
+	// NextAfter 还有更多消息时，作为下一页的 after 参数
+	NextAfter *int64 `json:"next_after,omitempty"`
+}
+
+// StatusTransition defines model for StatusTransition.
+type StatusTransition struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// From suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	From   *MessageStatus `json:"from,omitempty"`
+	Reason *string        `json:"reason,omitempty"`
+
+	// To suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	To MessageStatus `json:"to"`
+}
+
+// StatusTransitionList defines model for StatusTransitionList.
+type StatusTransitionList struct {
+	Transitions *[]StatusTransition `json:"transitions,omitempty"`
+}
+
+// StatusUpdate defines model for StatusUpdate.
+type StatusUpdate struct {
+	// Reason 变更原因，例如通道返回的回执说明
+	Reason *string `json:"reason,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+}
+
+// SubscriberList defines model for SubscriberList.
+type SubscriberList struct {
+	// NextAfter 还有更多订阅者时，作为下一页的 after 参数
+	NextAfter   *int64         `json:"next_after,omitempty"`
+	Subscribers []Subscription `json:"subscribers"`
+
+	// Total 频道中订阅中的用户总数
+	Total int64 `json:"total"`
+}
+
+// Subscription defines model for Subscription.
+type Subscription struct {
+	// Channel 广播频道（news、alert、promotion）
+	Channel string `json:"channel"`
+
+	// Subscribed false 表示用户已主动退订
+	Subscribed bool      `json:"subscribed"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	UserId     int64     `json:"user_id"`
+}
+
+// Template defines model for Template.
+type Template struct {
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// DefaultLocale 请求的语言没有对应内容时使用的语言，必须在 variants 中
+	DefaultLocale string  `json:"default_locale"`
+	Description   *string `json:"description,omitempty"`
+
+	// Id 模板ID，创建消息时作为 template_id
+	Id        string              `json:"id"`
+	Type      *MessageType        `json:"type,omitempty"`
+	UpdatedAt *time.Time          `json:"updated_at,omitempty"`
+	Variables *[]TemplateVariable `json:"variables,omitempty"`
+
+	// Variants 语言 -> 模板内容
+	Variants map[string]string `json:"variants"`
+}
+
+// TemplateList defines model for TemplateList.
+type TemplateList struct {
+	Templates []Template `json:"templates"`
+}
+
+// TemplatePreview defines model for TemplatePreview.
+type TemplatePreview struct {
+	Content string `json:"content"`
+
+	// Locale 实际使用的语言
+	Locale     string `json:"locale"`
+	TemplateId string `json:"template_id"`
+}
+
+// TemplatePreviewRequest defines model for TemplatePreviewRequest.
+type TemplatePreviewRequest struct {
+	Locale *string                 `json:"locale,omitempty"`
+	Params *map[string]interface{} `json:"params,omitempty"`
+}
+
+// TemplateVariable defines model for TemplateVariable.
+type TemplateVariable struct {
+	// Default 未提供时使用的默认值，类型需与 type 一致
+	Default     *interface{} `json:"default,omitempty"`
+	Description *string      `json:"description,omitempty"`
+	Name        string       `json:"name"`
+
+	// Required 是否必须提供，有默认值时不需要
+	Required *bool                `json:"required,omitempty"`
+	Type     TemplateVariableType `json:"type"`
+}
+
+// TemplateVariableType defines model for TemplateVariable.Type.
+type TemplateVariableType string
+
+// TypedMessage defines model for TypedMessage.
+type TypedMessage struct {
+	union json.RawMessage
+}
+
+// WechatMessage defines model for WechatMessage.
+type WechatMessage struct {
+	// Category 消息类别，投递前按接收方的通知偏好判断是否送达；security 等强制类别不受偏好约束。
+	// 由广播扇出生成的站内信以广播频道为类别，验证码短信的类别为 security
+	Category *string `json:"category,omitempty"`
+
+	// Content 消息内容
+	Content string `json:"content"`
+
+	// Data 模板各字段的取值，键为模板中的字段名
+	Data *map[string]string `json:"data,omitempty"`
+	Id   int64              `json:"id"`
+
+	// Locale 模板语言，省略时按 Accept-Language 选择，都没有匹配时使用模板的默认语言
+	Locale *string `json:"locale,omitempty"`
+
+	// Openid 接收用户在公众号下的 openid
+	Openid string `json:"openid"`
+
+	// Params 模板变量的取值，与 template_id 一起使用
+	Params *map[string]interface{} `json:"params,omitempty"`
+
+	// Recurrence 周期发送的 cron 表达式（分 时 日 月 周，或 @daily 等简写），按 timezone 计算
+	Recurrence *string `json:"recurrence,omitempty"`
+
+	// SendAt 定时发送的时间，不能早于当前时间；与 recurrence 一起使用时为首次发送的最早时间
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Sender 发送方标识，如调用方的业务系统名称，按发送方限流时使用
+	Sender *string `json:"sender,omitempty"`
+
+	// Status suppressed 表示接收方的通知偏好不接收该类消息，消息未投递
+	Status MessageStatus `json:"status"`
+
+	// TemplateId 使用的消息模板，创建时提供则由模板渲染 content，此时可以省略 content
+	TemplateId *string `json:"template_id,omitempty"`
+
+	// Timezone recurrence 使用的 IANA 时区，默认 UTC
+	Timezone *string     `json:"timezone,omitempty"`
+	Type     MessageType `json:"type"`
+
+	// Url 点击消息后跳转的链接
+	Url *string `json:"url,omitempty"`
+
+	// WechatTemplateId 公众号后台的模板消息ID，与本服务的消息模板 template_id 无关
+	WechatTemplateId string `json:"wechat_template_id"`
+}
+
+// LoginJSONBody defines parameters for Login.
+type LoginJSONBody struct {
+	Password *string `json:"password,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// CreateMessageParams defines parameters for CreateMessage.
+type CreateMessageParams struct {
+	// IdempotencyKey 幂等键，有效期（默认 24 小时）内带相同键的重试返回第一次成功（2xx）的响应，并带有 Idempotent-Replayed 响应头；失败的响应不保存，可以用同一个键重试
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+
+	// AcceptLanguage 语言偏好，请求体中未指定 locale 时用于选择模板的语言
+	AcceptLanguage *string `json:"Accept-Language,omitempty"`
+}
+
+// ReceiveDeliveryReportParams defines parameters for ReceiveDeliveryReport.
+type ReceiveDeliveryReportParams struct {
+	// XSignatureTimestamp 签名时间，Unix 秒
+	XSignatureTimestamp int64 `json:"X-Signature-Timestamp"`
+
+	// XSignature 请求签名
+	XSignature string `json:"X-Signature"`
+}
+
+// ListChannelSubscribersParams defines parameters for ListChannelSubscribers.
+type ListChannelSubscribersParams struct {
+	// After 上一页返回的 next_after，只返回用户ID更大的订阅者
+	After *int64 `form:"after,omitempty" json:"after,omitempty"`
+
+	// Limit 每页条数，默认 100，最大 1000
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// SendOTPParams defines parameters for SendOTP.
+type SendOTPParams struct {
+	// IdempotencyKey 幂等键，有效期（默认 24 小时）内带相同键的重试返回第一次成功（2xx）的响应，并带有 Idempotent-Replayed 响应头；失败的响应不保存，可以用同一个键重试
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+
+	// AcceptLanguage 语言偏好，请求体中未指定 locale 时用于选择模板的语言
+	AcceptLanguage *string `json:"Accept-Language,omitempty"`
+}
+
+// ListSchedulesParams defines parameters for ListSchedules.
+type ListSchedulesParams struct {
+	// Status 按状态过滤，省略时为 pending
+	Status *ScheduleStatus `form:"status,omitempty" json:"status,omitempty"`
+}
+
+// SearchMessagesParams defines parameters for SearchMessages.
+type SearchMessagesParams struct {
+	// Q 检索内容的关键词，以空格分隔
+	Q *string `form:"q,omitempty" json:"q,omitempty"`
+
+	// Type 消息类型：sms、sitemessage、broadcast、wechat、email
+	Type *string `form:"type,omitempty" json:"type,omitempty"`
+
+	// Status 消息状态：sending、sent、received、read、failed
+	Status *string `form:"status,omitempty" json:"status,omitempty"`
+
+	// Provider 投递通道名称
+	Provider *string `form:"provider,omitempty" json:"provider,omitempty"`
+
+	// From 创建时间不早于该时间（RFC 3339）
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To 创建时间早于该时间（RFC 3339）
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Before 上一页返回的 next_before，只返回ID更小的消息
+	Before *int64 `form:"before,omitempty" json:"before,omitempty"`
+
+	// Limit 每页条数，默认 50，最大 200
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// FindMessagesByUIDParams defines parameters for FindMessagesByUID.
+type FindMessagesByUIDParams struct {
+	// Status 按状态过滤：unread（未读）或 sending、sent、received、read、failed
+	Status *string `form:"status,omitempty" json:"status,omitempty"`
+
+	// Archived 为 true 时列出已归档的消息，默认列出未归档的消息
+	Archived *bool `form:"archived,omitempty" json:"archived,omitempty"`
+
+	// After 上一页返回的 next_after，只返回ID更大的消息
+	After *int64 `form:"after,omitempty" json:"after,omitempty"`
+
+	// Limit 每页条数，默认 50，最大 200
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// StreamSiteMessagesParams defines parameters for StreamSiteMessages.
+type StreamSiteMessagesParams struct {
+	// LastEventId 同 Last-Event-ID，用于无法设置请求头的客户端
+	LastEventId *int64 `form:"last_event_id,omitempty" json:"last_event_id,omitempty"`
+
+	// LastEventID 客户端最后收到的消息ID
+	LastEventID *int64 `json:"Last-Event-ID,omitempty"`
+}
+
+// WebSocketSiteMessagesParams defines parameters for WebSocketSiteMessages.
+type WebSocketSiteMessagesParams struct {
+	// LastEventId 客户端最后收到的消息ID
+	LastEventId *int64 `form:"last_event_id,omitempty" json:"last_event_id,omitempty"`
+}
+
+// GetMessageStatsParams defines parameters for GetMessageStats.
+type GetMessageStatsParams struct {
+	// From 统计的起始时间（RFC 3339），默认 to 之前 24 小时
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To 统计的结束时间（RFC 3339，不含），默认当前时间
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Interval 时间桶的长度：hour（默认）或 day
+	Interval *string `form:"interval,omitempty" json:"interval,omitempty"`
+
+	// Timezone 划分时间桶使用的 IANA 时区，默认 UTC
+	Timezone *string `form:"timezone,omitempty" json:"timezone,omitempty"`
+
+	// Type 只统计该类型的消息
+	Type *string `form:"type,omitempty" json:"type,omitempty"`
+
+	// Provider 只统计该通道投递的消息
+	Provider *string `form:"provider,omitempty" json:"provider,omitempty"`
+
+	// Status 不支持按状态过滤，统计结果已按状态分组，提供时返回 400
+	Status *string `form:"status,omitempty" json:"status,omitempty"`
+}
+
+// PreviewTemplateParams defines parameters for PreviewTemplate.
+type PreviewTemplateParams struct {
+	// AcceptLanguage 语言偏好，请求体中未指定 locale 时用于选择模板的语言
+	AcceptLanguage *string `json:"Accept-Language,omitempty"`
+}
+
+// LoginJSONRequestBody defines body for Login for application/json ContentType.
+type LoginJSONRequestBody LoginJSONBody
+
+// CreateMessageJSONRequestBody defines body for CreateMessage for application/json ContentType.
+type CreateMessageJSONRequestBody = CreateMessageRequest
+
+// ReceiveDeliveryReportJSONRequestBody defines body for ReceiveDeliveryReport for application/json ContentType.
+type ReceiveDeliveryReportJSONRequestBody = DeliveryReport
+
+// SendOTPJSONRequestBody defines body for SendOTP for application/json ContentType.
+type SendOTPJSONRequestBody = OTPRequest
+
+// VerifyOTPJSONRequestBody defines body for VerifyOTP for application/json ContentType.
+type VerifyOTPJSONRequestBody = OTPVerifyRequest
+
+// CreateTemplateJSONRequestBody defines body for CreateTemplate for application/json ContentType.
+type CreateTemplateJSONRequestBody = Template
+
+// UpdateTemplateJSONRequestBody defines body for UpdateTemplate for application/json ContentType.
+type UpdateTemplateJSONRequestBody = Template
+
+// PreviewTemplateJSONRequestBody defines body for PreviewTemplate for application/json ContentType.
+type PreviewTemplateJSONRequestBody = TemplatePreviewRequest
+
+// UpdateMessageStatusJSONRequestBody defines body for UpdateMessageStatus for application/json ContentType.
+type UpdateMessageStatusJSONRequestBody = StatusUpdate
+
+// AsSMSMessage returns the union data inside the CreateMessageRequest as a SMSMessage
+func (t CreateMessageRequest) AsSMSMessage() (SMSMessage, error) {
+	var body SMSMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromSMSMessage overwrites any union data inside the CreateMessageRequest as the provided SMSMessage
+func (t *CreateMessageRequest) FromSMSMessage(v SMSMessage) error {
+	v.Type = "sms"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeSMSMessage performs a merge with any union data inside the CreateMessageRequest, using the provided SMSMessage
+func (t *CreateMessageRequest) MergeSMSMessage(v SMSMessage) error {
+	v.Type = "sms"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsSiteMessage returns the union data inside the CreateMessageRequest as a SiteMessage
+func (t CreateMessageRequest) AsSiteMessage() (SiteMessage, error) {
+	var body SiteMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromSiteMessage overwrites any union data inside the CreateMessageRequest as the provided SiteMessage
+func (t *CreateMessageRequest) FromSiteMessage(v SiteMessage) error {
+	v.Type = "sitemessage"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeSiteMessage performs a merge with any union data inside the CreateMessageRequest, using the provided SiteMessage
+func (t *CreateMessageRequest) MergeSiteMessage(v SiteMessage) error {
+	v.Type = "sitemessage"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsBroadcastMessage returns the union data inside the CreateMessageRequest as a BroadcastMessage
+func (t CreateMessageRequest) AsBroadcastMessage() (BroadcastMessage, error) {
+	var body BroadcastMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromBroadcastMessage overwrites any union data inside the CreateMessageRequest as the provided BroadcastMessage
+func (t *CreateMessageRequest) FromBroadcastMessage(v BroadcastMessage) error {
+	v.Type = "broadcast"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeBroadcastMessage performs a merge with any union data inside the CreateMessageRequest, using the provided BroadcastMessage
+func (t *CreateMessageRequest) MergeBroadcastMessage(v BroadcastMessage) error {
+	v.Type = "broadcast"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsWechatMessage returns the union data inside the CreateMessageRequest as a WechatMessage
+func (t CreateMessageRequest) AsWechatMessage() (WechatMessage, error) {
+	var body WechatMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromWechatMessage overwrites any union data inside the CreateMessageRequest as the provided WechatMessage
+func (t *CreateMessageRequest) FromWechatMessage(v WechatMessage) error {
+	v.Type = "wechat"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeWechatMessage performs a merge with any union data inside the CreateMessageRequest, using the provided WechatMessage
+func (t *CreateMessageRequest) MergeWechatMessage(v WechatMessage) error {
+	v.Type = "wechat"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsEmailMessage returns the union data inside the CreateMessageRequest as a EmailMessage
+func (t CreateMessageRequest) AsEmailMessage() (EmailMessage, error) {
+	var body EmailMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromEmailMessage overwrites any union data inside the CreateMessageRequest as the provided EmailMessage
+func (t *CreateMessageRequest) FromEmailMessage(v EmailMessage) error {
+	v.Type = "email"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeEmailMessage performs a merge with any union data inside the CreateMessageRequest, using the provided EmailMessage
+func (t *CreateMessageRequest) MergeEmailMessage(v EmailMessage) error {
+	v.Type = "email"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+func (t CreateMessageRequest) Discriminator() (string, error) {
+	var discriminator struct {
+		Discriminator string `json:"type"`
+	}
+	err := json.Unmarshal(t.union, &discriminator)
+	return discriminator.Discriminator, err
+}
+
+func (t CreateMessageRequest) ValueByDiscriminator() (interface{}, error) {
+	discriminator, err := t.Discriminator()
+	if err != nil {
+		return nil, err
+	}
+	switch discriminator {
+	case "broadcast":
+		return t.AsBroadcastMessage()
+	case "email":
+		return t.AsEmailMessage()
+	case "sitemessage":
+		return t.AsSiteMessage()
+	case "sms":
+		return t.AsSMSMessage()
+	case "wechat":
+		return t.AsWechatMessage()
+	default:
+		return nil, errors.New("unknown discriminator value: " + discriminator)
+	}
+}
+
+func (t CreateMessageRequest) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *CreateMessageRequest) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
+
+// AsSMSMessage returns the union data inside the TypedMessage as a SMSMessage
+func (t TypedMessage) AsSMSMessage() (SMSMessage, error) {
+	var body SMSMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromSMSMessage overwrites any union data inside the TypedMessage as the provided SMSMessage
+func (t *TypedMessage) FromSMSMessage(v SMSMessage) error {
+	v.Type = "sms"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeSMSMessage performs a merge with any union data inside the TypedMessage, using the provided SMSMessage
+func (t *TypedMessage) MergeSMSMessage(v SMSMessage) error {
+	v.Type = "sms"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsSiteMessage returns the union data inside the TypedMessage as a SiteMessage
+func (t TypedMessage) AsSiteMessage() (SiteMessage, error) {
+	var body SiteMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromSiteMessage overwrites any union data inside the TypedMessage as the provided SiteMessage
+func (t *TypedMessage) FromSiteMessage(v SiteMessage) error {
+	v.Type = "sitemessage"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeSiteMessage performs a merge with any union data inside the TypedMessage, using the provided SiteMessage
+func (t *TypedMessage) MergeSiteMessage(v SiteMessage) error {
+	v.Type = "sitemessage"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsBroadcastMessage returns the union data inside the TypedMessage as a BroadcastMessage
+func (t TypedMessage) AsBroadcastMessage() (BroadcastMessage, error) {
+	var body BroadcastMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromBroadcastMessage overwrites any union data inside the TypedMessage as the provided BroadcastMessage
+func (t *TypedMessage) FromBroadcastMessage(v BroadcastMessage) error {
+	v.Type = "broadcast"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeBroadcastMessage performs a merge with any union data inside the TypedMessage, using the provided BroadcastMessage
+func (t *TypedMessage) MergeBroadcastMessage(v BroadcastMessage) error {
+	v.Type = "broadcast"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsWechatMessage returns the union data inside the TypedMessage as a WechatMessage
+func (t TypedMessage) AsWechatMessage() (WechatMessage, error) {
+	var body WechatMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromWechatMessage overwrites any union data inside the TypedMessage as the provided WechatMessage
+func (t *TypedMessage) FromWechatMessage(v WechatMessage) error {
+	v.Type = "wechat"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeWechatMessage performs a merge with any union data inside the TypedMessage, using the provided WechatMessage
+func (t *TypedMessage) MergeWechatMessage(v WechatMessage) error {
+	v.Type = "wechat"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsEmailMessage returns the union data inside the TypedMessage as a EmailMessage
+func (t TypedMessage) AsEmailMessage() (EmailMessage, error) {
+	var body EmailMessage
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromEmailMessage overwrites any union data inside the TypedMessage as the provided EmailMessage
+func (t *TypedMessage) FromEmailMessage(v EmailMessage) error {
+	v.Type = "email"
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeEmailMessage performs a merge with any union data inside the TypedMessage, using the provided EmailMessage
+func (t *TypedMessage) MergeEmailMessage(v EmailMessage) error {
+	v.Type = "email"
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+func (t TypedMessage) Discriminator() (string, error) {
+	var discriminator struct {
+		Discriminator string `json:"type"`
+	}
+	err := json.Unmarshal(t.union, &discriminator)
+	return discriminator.Discriminator, err
+}
+
+func (t TypedMessage) ValueByDiscriminator() (interface{}, error) {
+	discriminator, err := t.Discriminator()
+	if err != nil {
+		return nil, err
+	}
+	switch discriminator {
+	case "broadcast":
+		return t.AsBroadcastMessage()
+	case "email":
+		return t.AsEmailMessage()
+	case "sitemessage":
+		return t.AsSiteMessage()
+	case "sms":
+		return t.AsSMSMessage()
+	case "wechat":
+		return t.AsWechatMessage()
+	default:
+		return nil, errors.New("unknown discriminator value: " + discriminator)
+	}
+}
+
+func (t TypedMessage) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *TypedMessage) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// 用户登录
+	// (POST /auth/login)
+	Login(w http.ResponseWriter, r *http.Request)
+	// 发消息(短信/站内/广播)
+	// (POST /message)
+	CreateMessage(w http.ResponseWriter, r *http.Request, params CreateMessageParams)
+	// 接收投递回执
+	// (POST /message/callbacks/{provider})
+	ReceiveDeliveryReport(w http.ResponseWriter, r *http.Request, provider string, params ReceiveDeliveryReportParams)
+	// 查询频道的订阅者
+	// (GET /message/channels/{channel}/subscribers)
+	ListChannelSubscribers(w http.ResponseWriter, r *http.Request, channel string, params ListChannelSubscribersParams)
+	// 退订频道
+	// (DELETE /message/channels/{channel}/subscribers/{uid})
+	UnsubscribeChannel(w http.ResponseWriter, r *http.Request, channel string, uid int64)
+	// 查询用户对频道的订阅偏好
+	// (GET /message/channels/{channel}/subscribers/{uid})
+	GetChannelSubscription(w http.ResponseWriter, r *http.Request, channel string, uid int64)
+	// 订阅频道
+	// (PUT /message/channels/{channel}/subscribers/{uid})
+	SubscribeChannel(w http.ResponseWriter, r *http.Request, channel string, uid int64)
+	// 查询投递失败的消息
+	// (GET /message/failed)
+	ListFailedDeliveries(w http.ResponseWriter, r *http.Request)
+	// 重新投递失败的消息
+	// (POST /message/failed/{id}/requeue)
+	RequeueFailedDelivery(w http.ResponseWriter, r *http.Request, id int64)
+	// 发送短信验证码
+	// (POST /message/otp)
+	SendOTP(w http.ResponseWriter, r *http.Request, params SendOTPParams)
+	// 校验短信验证码
+	// (POST /message/otp/verify)
+	VerifyOTP(w http.ResponseWriter, r *http.Request)
+	// 查询定时发送任务
+	// (GET /message/schedules)
+	ListSchedules(w http.ResponseWriter, r *http.Request, params ListSchedulesParams)
+	// 取消定时发送任务
+	// (DELETE /message/schedules/{id})
+	CancelSchedule(w http.ResponseWriter, r *http.Request, id int64)
+	// 查询定时发送任务
+	// (GET /message/schedules/{id})
+	GetSchedule(w http.ResponseWriter, r *http.Request, id int64)
+	// 搜索消息
+	// (GET /message/search)
+	SearchMessages(w http.ResponseWriter, r *http.Request, params SearchMessagesParams)
+	// 根据UID查询站内消息（收件箱）
+	// (GET /message/sitemessage/{uid})
+	FindMessagesByUID(w http.ResponseWriter, r *http.Request, uid int64, params FindMessagesByUIDParams)
+	// 将用户的站内信全部标记为已读
+	// (POST /message/sitemessage/{uid}/read)
+	MarkAllSiteMessagesRead(w http.ResponseWriter, r *http.Request, uid int64)
+	// 实时推送站内消息（SSE）
+	// (GET /message/sitemessage/{uid}/stream)
+	StreamSiteMessages(w http.ResponseWriter, r *http.Request, uid int64, params StreamSiteMessagesParams)
+	// 查询用户的未读站内信数
+	// (GET /message/sitemessage/{uid}/unread)
+	CountUnreadSiteMessages(w http.ResponseWriter, r *http.Request, uid int64)
+	// 实时推送站内消息（WebSocket）
+	// (GET /message/sitemessage/{uid}/ws)
+	WebSocketSiteMessages(w http.ResponseWriter, r *http.Request, uid int64, params WebSocketSiteMessagesParams)
+	// 删除站内信
+	// (DELETE /message/sitemessage/{uid}/{id})
+	DeleteSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64)
+	// 取消归档，站内信回到收件箱
+	// (DELETE /message/sitemessage/{uid}/{id}/archive)
+	UnarchiveSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64)
+	// 归档站内信
+	// (PUT /message/sitemessage/{uid}/{id}/archive)
+	ArchiveSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64)
+	// 将站内信标记为已读
+	// (POST /message/sitemessage/{uid}/{id}/read)
+	MarkSiteMessageRead(w http.ResponseWriter, r *http.Request, uid int64, id int64)
+	// 根据手机号查询消息
+	// (GET /message/sms/{number})
+	FindMessagesByNumber(w http.ResponseWriter, r *http.Request, number int64)
+	// 消息统计
+	// (GET /message/stats)
+	GetMessageStats(w http.ResponseWriter, r *http.Request, params GetMessageStatsParams)
+	// 查询消息模板
+	// (GET /message/templates)
+	ListTemplates(w http.ResponseWriter, r *http.Request)
+	// 创建消息模板
+	// (POST /message/templates)
+	CreateTemplate(w http.ResponseWriter, r *http.Request)
+	// 删除消息模板
+	// (DELETE /message/templates/{id})
+	DeleteTemplate(w http.ResponseWriter, r *http.Request, id string)
+	// 查询消息模板
+	// (GET /message/templates/{id})
+	GetTemplate(w http.ResponseWriter, r *http.Request, id string)
+	// 更新消息模板
+	// (PUT /message/templates/{id})
+	UpdateTemplate(w http.ResponseWriter, r *http.Request, id string)
+	// 预览模板渲染结果
+	// (POST /message/templates/{id}/preview)
+	PreviewTemplate(w http.ResponseWriter, r *http.Request, id string, params PreviewTemplateParams)
+	// 查询广播的扇出进度
+	// (GET /message/{id}/fanout)
+	GetBroadcastFanout(w http.ResponseWriter, r *http.Request, id int64)
+	// 更新消息状态
+	// (POST /message/{id}/status)
+	UpdateMessageStatus(w http.ResponseWriter, r *http.Request, id int64)
+	// 查询消息的状态流转记录
+	// (GET /message/{id}/transitions)
+	ListMessageTransitions(w http.ResponseWriter, r *http.Request, id int64)
+}
+
+// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+
+type Unimplemented struct{}
+
+// 用户登录
+// (POST /auth/login)
+func (_ Unimplemented) Login(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 发消息(短信/站内/广播)
+// (POST /message)
+func (_ Unimplemented) CreateMessage(w http.ResponseWriter, r *http.Request, params CreateMessageParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 接收投递回执
+// (POST /message/callbacks/{provider})
+func (_ Unimplemented) ReceiveDeliveryReport(w http.ResponseWriter, r *http.Request, provider string, params ReceiveDeliveryReportParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询频道的订阅者
+// (GET /message/channels/{channel}/subscribers)
+func (_ Unimplemented) ListChannelSubscribers(w http.ResponseWriter, r *http.Request, channel string, params ListChannelSubscribersParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 退订频道
+// (DELETE /message/channels/{channel}/subscribers/{uid})
+func (_ Unimplemented) UnsubscribeChannel(w http.ResponseWriter, r *http.Request, channel string, uid int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询用户对频道的订阅偏好
+// (GET /message/channels/{channel}/subscribers/{uid})
+func (_ Unimplemented) GetChannelSubscription(w http.ResponseWriter, r *http.Request, channel string, uid int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 订阅频道
+// (PUT /message/channels/{channel}/subscribers/{uid})
+func (_ Unimplemented) SubscribeChannel(w http.ResponseWriter, r *http.Request, channel string, uid int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询投递失败的消息
+// (GET /message/failed)
+func (_ Unimplemented) ListFailedDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 重新投递失败的消息
+// (POST /message/failed/{id}/requeue)
+func (_ Unimplemented) RequeueFailedDelivery(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 发送短信验证码
+// (POST /message/otp)
+func (_ Unimplemented) SendOTP(w http.ResponseWriter, r *http.Request, params SendOTPParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 校验短信验证码
+// (POST /message/otp/verify)
+func (_ Unimplemented) VerifyOTP(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询定时发送任务
+// (GET /message/schedules)
+func (_ Unimplemented) ListSchedules(w http.ResponseWriter, r *http.Request, params ListSchedulesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 取消定时发送任务
+// (DELETE /message/schedules/{id})
+func (_ Unimplemented) CancelSchedule(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询定时发送任务
+// (GET /message/schedules/{id})
+func (_ Unimplemented) GetSchedule(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 搜索消息
+// (GET /message/search)
+func (_ Unimplemented) SearchMessages(w http.ResponseWriter, r *http.Request, params SearchMessagesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 根据UID查询站内消息（收件箱）
+// (GET /message/sitemessage/{uid})
+func (_ Unimplemented) FindMessagesByUID(w http.ResponseWriter, r *http.Request, uid int64, params FindMessagesByUIDParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 将用户的站内信全部标记为已读
+// (POST /message/sitemessage/{uid}/read)
+func (_ Unimplemented) MarkAllSiteMessagesRead(w http.ResponseWriter, r *http.Request, uid int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 实时推送站内消息（SSE）
+// (GET /message/sitemessage/{uid}/stream)
+func (_ Unimplemented) StreamSiteMessages(w http.ResponseWriter, r *http.Request, uid int64, params StreamSiteMessagesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询用户的未读站内信数
+// (GET /message/sitemessage/{uid}/unread)
+func (_ Unimplemented) CountUnreadSiteMessages(w http.ResponseWriter, r *http.Request, uid int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 实时推送站内消息（WebSocket）
+// (GET /message/sitemessage/{uid}/ws)
+func (_ Unimplemented) WebSocketSiteMessages(w http.ResponseWriter, r *http.Request, uid int64, params WebSocketSiteMessagesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 删除站内信
+// (DELETE /message/sitemessage/{uid}/{id})
+func (_ Unimplemented) DeleteSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 取消归档，站内信回到收件箱
+// (DELETE /message/sitemessage/{uid}/{id}/archive)
+func (_ Unimplemented) UnarchiveSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 归档站内信
+// (PUT /message/sitemessage/{uid}/{id}/archive)
+func (_ Unimplemented) ArchiveSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 将站内信标记为已读
+// (POST /message/sitemessage/{uid}/{id}/read)
+func (_ Unimplemented) MarkSiteMessageRead(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 根据手机号查询消息
+// (GET /message/sms/{number})
+func (_ Unimplemented) FindMessagesByNumber(w http.ResponseWriter, r *http.Request, number int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 消息统计
+// (GET /message/stats)
+func (_ Unimplemented) GetMessageStats(w http.ResponseWriter, r *http.Request, params GetMessageStatsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询消息模板
+// (GET /message/templates)
+func (_ Unimplemented) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 创建消息模板
+// (POST /message/templates)
+func (_ Unimplemented) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 删除消息模板
+// (DELETE /message/templates/{id})
+func (_ Unimplemented) DeleteTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询消息模板
+// (GET /message/templates/{id})
+func (_ Unimplemented) GetTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 更新消息模板
+// (PUT /message/templates/{id})
+func (_ Unimplemented) UpdateTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 预览模板渲染结果
+// (POST /message/templates/{id}/preview)
+func (_ Unimplemented) PreviewTemplate(w http.ResponseWriter, r *http.Request, id string, params PreviewTemplateParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询广播的扇出进度
+// (GET /message/{id}/fanout)
+func (_ Unimplemented) GetBroadcastFanout(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 更新消息状态
+// (POST /message/{id}/status)
+func (_ Unimplemented) UpdateMessageStatus(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// 查询消息的状态流转记录
+// (GET /message/{id}/transitions)
+func (_ Unimplemented) ListMessageTransitions(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// Login operation middleware
+func (siw *ServerInterfaceWrapper) Login(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Login(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateMessage operation middleware
+func (siw *ServerInterfaceWrapper) CreateMessage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CreateMessageParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	// ------------- Optional header parameter "Accept-Language" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Accept-Language")]; found {
+		var AcceptLanguage string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Accept-Language", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Accept-Language", valueList[0], &AcceptLanguage, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Accept-Language", Err: err})
+			return
+		}
+
+		params.AcceptLanguage = &AcceptLanguage
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateMessage(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ReceiveDeliveryReport operation middleware
+func (siw *ServerInterfaceWrapper) ReceiveDeliveryReport(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "provider" -------------
+	var provider string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "provider", chi.URLParam(r, "provider"), &provider, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "provider", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CallbackSignatureScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ReceiveDeliveryReportParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-Signature-Timestamp" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Signature-Timestamp")]; found {
+		var XSignatureTimestamp int64
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Signature-Timestamp", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Signature-Timestamp", valueList[0], &XSignatureTimestamp, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Signature-Timestamp", Err: err})
+			return
+		}
+
+		params.XSignatureTimestamp = XSignatureTimestamp
+
+	} else {
+		err := fmt.Errorf("Header parameter X-Signature-Timestamp is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-Signature-Timestamp", Err: err})
+		return
+	}
+
+	// ------------- Required header parameter "X-Signature" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Signature")]; found {
+		var XSignature string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Signature", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Signature", valueList[0], &XSignature, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Signature", Err: err})
+			return
+		}
+
+		params.XSignature = XSignature
+
+	} else {
+		err := fmt.Errorf("Header parameter X-Signature is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-Signature", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ReceiveDeliveryReport(w, r, provider, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListChannelSubscribers operation middleware
+func (siw *ServerInterfaceWrapper) ListChannelSubscribers(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "channel" -------------
+	var channel string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "channel", chi.URLParam(r, "channel"), &channel, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "channel", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListChannelSubscribersParams
+
+	// ------------- Optional query parameter "after" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "after", r.URL.Query(), &params.After)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "after", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListChannelSubscribers(w, r, channel, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UnsubscribeChannel operation middleware
+func (siw *ServerInterfaceWrapper) UnsubscribeChannel(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "channel" -------------
+	var channel string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "channel", chi.URLParam(r, "channel"), &channel, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "channel", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UnsubscribeChannel(w, r, channel, uid)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetChannelSubscription operation middleware
+func (siw *ServerInterfaceWrapper) GetChannelSubscription(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "channel" -------------
+	var channel string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "channel", chi.URLParam(r, "channel"), &channel, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "channel", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetChannelSubscription(w, r, channel, uid)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SubscribeChannel operation middleware
+func (siw *ServerInterfaceWrapper) SubscribeChannel(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "channel" -------------
+	var channel string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "channel", chi.URLParam(r, "channel"), &channel, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "channel", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SubscribeChannel(w, r, channel, uid)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListFailedDeliveries operation middleware
+func (siw *ServerInterfaceWrapper) ListFailedDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListFailedDeliveries(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RequeueFailedDelivery operation middleware
+func (siw *ServerInterfaceWrapper) RequeueFailedDelivery(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RequeueFailedDelivery(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SendOTP operation middleware
+func (siw *ServerInterfaceWrapper) SendOTP(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SendOTPParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	// ------------- Optional header parameter "Accept-Language" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Accept-Language")]; found {
+		var AcceptLanguage string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Accept-Language", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Accept-Language", valueList[0], &AcceptLanguage, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Accept-Language", Err: err})
+			return
+		}
+
+		params.AcceptLanguage = &AcceptLanguage
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SendOTP(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// VerifyOTP operation middleware
+func (siw *ServerInterfaceWrapper) VerifyOTP(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.VerifyOTP(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListSchedules operation middleware
+func (siw *ServerInterfaceWrapper) ListSchedules(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListSchedulesParams
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListSchedules(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CancelSchedule operation middleware
+func (siw *ServerInterfaceWrapper) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CancelSchedule(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetSchedule operation middleware
+func (siw *ServerInterfaceWrapper) GetSchedule(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSchedule(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SearchMessages operation middleware
+func (siw *ServerInterfaceWrapper) SearchMessages(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SearchMessagesParams
+
+	// ------------- Optional query parameter "q" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "q", r.URL.Query(), &params.Q)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "q", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", r.URL.Query(), &params.Type)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "type", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "provider" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "provider", r.URL.Query(), &params.Provider)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "provider", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "before" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "before", r.URL.Query(), &params.Before)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "before", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SearchMessages(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// FindMessagesByUID operation middleware
+func (siw *ServerInterfaceWrapper) FindMessagesByUID(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params FindMessagesByUIDParams
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "archived" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "archived", r.URL.Query(), &params.Archived)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "archived", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "after" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "after", r.URL.Query(), &params.After)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "after", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.FindMessagesByUID(w, r, uid, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// MarkAllSiteMessagesRead operation middleware
+func (siw *ServerInterfaceWrapper) MarkAllSiteMessagesRead(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.MarkAllSiteMessagesRead(w, r, uid)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// StreamSiteMessages operation middleware
+func (siw *ServerInterfaceWrapper) StreamSiteMessages(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StreamSiteMessagesParams
+
+	// ------------- Optional query parameter "last_event_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "last_event_id", r.URL.Query(), &params.LastEventId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "last_event_id", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID int64
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Last-Event-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Last-Event-ID", Err: err})
+			return
+		}
+
+		params.LastEventID = &LastEventID
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StreamSiteMessages(w, r, uid, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CountUnreadSiteMessages operation middleware
+func (siw *ServerInterfaceWrapper) CountUnreadSiteMessages(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CountUnreadSiteMessages(w, r, uid)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// WebSocketSiteMessages operation middleware
+func (siw *ServerInterfaceWrapper) WebSocketSiteMessages(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params WebSocketSiteMessagesParams
+
+	// ------------- Optional query parameter "last_event_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "last_event_id", r.URL.Query(), &params.LastEventId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "last_event_id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.WebSocketSiteMessages(w, r, uid, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteSiteMessage operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSiteMessage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteSiteMessage(w, r, uid, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UnarchiveSiteMessage operation middleware
+func (siw *ServerInterfaceWrapper) UnarchiveSiteMessage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UnarchiveSiteMessage(w, r, uid, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ArchiveSiteMessage operation middleware
+func (siw *ServerInterfaceWrapper) ArchiveSiteMessage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ArchiveSiteMessage(w, r, uid, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// MarkSiteMessageRead operation middleware
+func (siw *ServerInterfaceWrapper) MarkSiteMessageRead(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uid" -------------
+	var uid int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uid", chi.URLParam(r, "uid"), &uid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uid", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.MarkSiteMessageRead(w, r, uid, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// FindMessagesByNumber operation middleware
+func (siw *ServerInterfaceWrapper) FindMessagesByNumber(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "number" -------------
+	var number int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "number", chi.URLParam(r, "number"), &number, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "number", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.FindMessagesByNumber(w, r, number)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetMessageStats operation middleware
+func (siw *ServerInterfaceWrapper) GetMessageStats(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetMessageStatsParams
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "interval" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "interval", r.URL.Query(), &params.Interval)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "interval", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "timezone" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "timezone", r.URL.Query(), &params.Timezone)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "timezone", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", r.URL.Query(), &params.Type)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "type", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "provider" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "provider", r.URL.Query(), &params.Provider)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "provider", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMessageStats(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListTemplates operation middleware
+func (siw *ServerInterfaceWrapper) ListTemplates(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListTemplates(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateTemplate operation middleware
+func (siw *ServerInterfaceWrapper) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateTemplate(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteTemplate operation middleware
+func (siw *ServerInterfaceWrapper) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteTemplate(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetTemplate operation middleware
+func (siw *ServerInterfaceWrapper) GetTemplate(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetTemplate(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateTemplate operation middleware
+func (siw *ServerInterfaceWrapper) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateTemplate(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PreviewTemplate operation middleware
+func (siw *ServerInterfaceWrapper) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PreviewTemplateParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Accept-Language" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Accept-Language")]; found {
+		var AcceptLanguage string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Accept-Language", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Accept-Language", valueList[0], &AcceptLanguage, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Accept-Language", Err: err})
+			return
+		}
+
+		params.AcceptLanguage = &AcceptLanguage
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PreviewTemplate(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetBroadcastFanout operation middleware
+func (siw *ServerInterfaceWrapper) GetBroadcastFanout(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetBroadcastFanout(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateMessageStatus operation middleware
+func (siw *ServerInterfaceWrapper) UpdateMessageStatus(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, MySecurityScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateMessageStatus(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListMessageTransitions operation middleware
+func (siw *ServerInterfaceWrapper) ListMessageTransitions(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListMessageTransitions(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/auth/login", wrapper.Login)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message", wrapper.CreateMessage)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/callbacks/{provider}", wrapper.ReceiveDeliveryReport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/channels/{channel}/subscribers", wrapper.ListChannelSubscribers)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/message/channels/{channel}/subscribers/{uid}", wrapper.UnsubscribeChannel)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/channels/{channel}/subscribers/{uid}", wrapper.GetChannelSubscription)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/message/channels/{channel}/subscribers/{uid}", wrapper.SubscribeChannel)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/failed", wrapper.ListFailedDeliveries)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/failed/{id}/requeue", wrapper.RequeueFailedDelivery)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/otp", wrapper.SendOTP)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/otp/verify", wrapper.VerifyOTP)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/schedules", wrapper.ListSchedules)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/message/schedules/{id}", wrapper.CancelSchedule)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/schedules/{id}", wrapper.GetSchedule)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/search", wrapper.SearchMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/sitemessage/{uid}", wrapper.FindMessagesByUID)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/sitemessage/{uid}/read", wrapper.MarkAllSiteMessagesRead)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/sitemessage/{uid}/stream", wrapper.StreamSiteMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/sitemessage/{uid}/unread", wrapper.CountUnreadSiteMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/sitemessage/{uid}/ws", wrapper.WebSocketSiteMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/message/sitemessage/{uid}/{id}", wrapper.DeleteSiteMessage)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/message/sitemessage/{uid}/{id}/archive", wrapper.UnarchiveSiteMessage)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/message/sitemessage/{uid}/{id}/archive", wrapper.ArchiveSiteMessage)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/sitemessage/{uid}/{id}/read", wrapper.MarkSiteMessageRead)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/sms/{number}", wrapper.FindMessagesByNumber)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/stats", wrapper.GetMessageStats)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/templates", wrapper.ListTemplates)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/templates", wrapper.CreateTemplate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/message/templates/{id}", wrapper.DeleteTemplate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/templates/{id}", wrapper.GetTemplate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/message/templates/{id}", wrapper.UpdateTemplate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/templates/{id}/preview", wrapper.PreviewTemplate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/{id}/fanout", wrapper.GetBroadcastFanout)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/message/{id}/status", wrapper.UpdateMessageStatus)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/message/{id}/transitions", wrapper.ListMessageTransitions)
+	})
+
+	return r
+}
+
+type LoginRequestObject struct {
+	Body *LoginJSONRequestBody
+}
+
+type LoginResponseObject interface {
+	VisitLoginResponse(w http.ResponseWriter) error
+}
+
+type Login200JSONResponse struct {
+	Token *string `json:"token,omitempty"`
+}
+
+func (response Login200JSONResponse) VisitLoginResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type Login401JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response Login401JSONResponse) VisitLoginResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateMessageRequestObject struct {
+	Params CreateMessageParams
+	Body   *CreateMessageJSONRequestBody
+}
+
+type CreateMessageResponseObject interface {
+	VisitCreateMessageResponse(w http.ResponseWriter) error
+}
+
+type CreateMessage201JSONResponse TypedMessage
+
+func (response CreateMessage201JSONResponse) VisitCreateMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateMessage202JSONResponse Schedule
+
+func (response CreateMessage202JSONResponse) VisitCreateMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateMessage400JSONResponse struct {
+	Fields *[]struct {
+		// Field 字段路径，嵌套字段用 . 连接，数组元素带下标，如 attachments[0].filename
+		Field   *string `json:"field,omitempty"`
+		Message *string `json:"message,omitempty"`
+	} `json:"fields,omitempty"`
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CreateMessage400JSONResponse) VisitCreateMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateMessage409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CreateMessage409JSONResponse) VisitCreateMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateMessage429ResponseHeaders struct {
+	RetryAfter int
+}
+
+type CreateMessage429JSONResponse struct {
+	Body struct {
+		Message    *string `json:"message,omitempty"`
+		RetryAfter *int    `json:"retry_after,omitempty"`
+	}
+	Headers CreateMessage429ResponseHeaders
+}
+
+func (response CreateMessage429JSONResponse) VisitCreateMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type CreateMessage500JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CreateMessage500JSONResponse) VisitCreateMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReceiveDeliveryReportRequestObject struct {
+	Provider string `json:"provider"`
+	Params   ReceiveDeliveryReportParams
+	Body     *ReceiveDeliveryReportJSONRequestBody
+}
+
+type ReceiveDeliveryReportResponseObject interface {
+	VisitReceiveDeliveryReportResponse(w http.ResponseWriter) error
+}
+
+type ReceiveDeliveryReport200JSONResponse TypedMessage
+
+func (response ReceiveDeliveryReport200JSONResponse) VisitReceiveDeliveryReportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReceiveDeliveryReport400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ReceiveDeliveryReport400JSONResponse) VisitReceiveDeliveryReportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReceiveDeliveryReport401JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ReceiveDeliveryReport401JSONResponse) VisitReceiveDeliveryReportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReceiveDeliveryReport404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ReceiveDeliveryReport404JSONResponse) VisitReceiveDeliveryReportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListChannelSubscribersRequestObject struct {
+	Channel string `json:"channel"`
+	Params  ListChannelSubscribersParams
+}
+
+type ListChannelSubscribersResponseObject interface {
+	VisitListChannelSubscribersResponse(w http.ResponseWriter) error
+}
+
+type ListChannelSubscribers200JSONResponse SubscriberList
+
+func (response ListChannelSubscribers200JSONResponse) VisitListChannelSubscribersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListChannelSubscribers400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ListChannelSubscribers400JSONResponse) VisitListChannelSubscribersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UnsubscribeChannelRequestObject struct {
+	Channel string `json:"channel"`
+	Uid     int64  `json:"uid"`
+}
+
+type UnsubscribeChannelResponseObject interface {
+	VisitUnsubscribeChannelResponse(w http.ResponseWriter) error
+}
+
+type UnsubscribeChannel200JSONResponse Subscription
+
+func (response UnsubscribeChannel200JSONResponse) VisitUnsubscribeChannelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UnsubscribeChannel400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UnsubscribeChannel400JSONResponse) VisitUnsubscribeChannelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetChannelSubscriptionRequestObject struct {
+	Channel string `json:"channel"`
+	Uid     int64  `json:"uid"`
+}
+
+type GetChannelSubscriptionResponseObject interface {
+	VisitGetChannelSubscriptionResponse(w http.ResponseWriter) error
+}
+
+type GetChannelSubscription200JSONResponse Subscription
+
+func (response GetChannelSubscription200JSONResponse) VisitGetChannelSubscriptionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetChannelSubscription400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetChannelSubscription400JSONResponse) VisitGetChannelSubscriptionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetChannelSubscription404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetChannelSubscription404JSONResponse) VisitGetChannelSubscriptionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SubscribeChannelRequestObject struct {
+	Channel string `json:"channel"`
+	Uid     int64  `json:"uid"`
+}
+
+type SubscribeChannelResponseObject interface {
+	VisitSubscribeChannelResponse(w http.ResponseWriter) error
+}
+
+type SubscribeChannel200JSONResponse Subscription
+
+func (response SubscribeChannel200JSONResponse) VisitSubscribeChannelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SubscribeChannel400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response SubscribeChannel400JSONResponse) VisitSubscribeChannelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListFailedDeliveriesRequestObject struct {
+}
+
+type ListFailedDeliveriesResponseObject interface {
+	VisitListFailedDeliveriesResponse(w http.ResponseWriter) error
+}
+
+type ListFailedDeliveries200JSONResponse FailedDeliveryList
+
+func (response ListFailedDeliveries200JSONResponse) VisitListFailedDeliveriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListFailedDeliveries501JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ListFailedDeliveries501JSONResponse) VisitListFailedDeliveriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(501)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RequeueFailedDeliveryRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type RequeueFailedDeliveryResponseObject interface {
+	VisitRequeueFailedDeliveryResponse(w http.ResponseWriter) error
+}
+
+type RequeueFailedDelivery202JSONResponse DeliveryJob
+
+func (response RequeueFailedDelivery202JSONResponse) VisitRequeueFailedDeliveryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RequeueFailedDelivery404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response RequeueFailedDelivery404JSONResponse) VisitRequeueFailedDeliveryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SendOTPRequestObject struct {
+	Params SendOTPParams
+	Body   *SendOTPJSONRequestBody
+}
+
+type SendOTPResponseObject interface {
+	VisitSendOTPResponse(w http.ResponseWriter) error
+}
+
+type SendOTP202JSONResponse OTPChallenge
+
+func (response SendOTP202JSONResponse) VisitSendOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SendOTP400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response SendOTP400JSONResponse) VisitSendOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SendOTP409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response SendOTP409JSONResponse) VisitSendOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SendOTP429ResponseHeaders struct {
+	RetryAfter int
+}
+
+type SendOTP429JSONResponse struct {
+	Body struct {
+		Message *string `json:"message,omitempty"`
+	}
+	Headers SendOTP429ResponseHeaders
+}
+
+func (response SendOTP429JSONResponse) VisitSendOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type VerifyOTPRequestObject struct {
+	Body *VerifyOTPJSONRequestBody
+}
+
+type VerifyOTPResponseObject interface {
+	VisitVerifyOTPResponse(w http.ResponseWriter) error
+}
+
+type VerifyOTP200JSONResponse OTPVerification
+
+func (response VerifyOTP200JSONResponse) VisitVerifyOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type VerifyOTP400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response VerifyOTP400JSONResponse) VisitVerifyOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type VerifyOTP409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response VerifyOTP409JSONResponse) VisitVerifyOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type VerifyOTP429JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response VerifyOTP429JSONResponse) VisitVerifyOTPResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSchedulesRequestObject struct {
+	Params ListSchedulesParams
+}
+
+type ListSchedulesResponseObject interface {
+	VisitListSchedulesResponse(w http.ResponseWriter) error
+}
+
+type ListSchedules200JSONResponse ScheduleList
+
+func (response ListSchedules200JSONResponse) VisitListSchedulesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSchedules400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ListSchedules400JSONResponse) VisitListSchedulesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CancelScheduleRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type CancelScheduleResponseObject interface {
+	VisitCancelScheduleResponse(w http.ResponseWriter) error
+}
+
+type CancelSchedule200JSONResponse Schedule
+
+func (response CancelSchedule200JSONResponse) VisitCancelScheduleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CancelSchedule404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CancelSchedule404JSONResponse) VisitCancelScheduleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CancelSchedule409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CancelSchedule409JSONResponse) VisitCancelScheduleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetScheduleRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type GetScheduleResponseObject interface {
+	VisitGetScheduleResponse(w http.ResponseWriter) error
+}
+
+type GetSchedule200JSONResponse Schedule
+
+func (response GetSchedule200JSONResponse) VisitGetScheduleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSchedule404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetSchedule404JSONResponse) VisitGetScheduleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SearchMessagesRequestObject struct {
+	Params SearchMessagesParams
+}
+
+type SearchMessagesResponseObject interface {
+	VisitSearchMessagesResponse(w http.ResponseWriter) error
+}
+
+type SearchMessages200JSONResponse MessageSearchResult
+
+func (response SearchMessages200JSONResponse) VisitSearchMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SearchMessages400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response SearchMessages400JSONResponse) VisitSearchMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type FindMessagesByUIDRequestObject struct {
+	Uid    int64 `json:"uid"`
+	Params FindMessagesByUIDParams
+}
+
+type FindMessagesByUIDResponseObject interface {
+	VisitFindMessagesByUIDResponse(w http.ResponseWriter) error
+}
+
+type FindMessagesByUID200JSONResponse SiteMessageList
+
+func (response FindMessagesByUID200JSONResponse) VisitFindMessagesByUIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type FindMessagesByUID400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response FindMessagesByUID400JSONResponse) VisitFindMessagesByUIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type FindMessagesByUID500JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response FindMessagesByUID500JSONResponse) VisitFindMessagesByUIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type MarkAllSiteMessagesReadRequestObject struct {
+	Uid int64 `json:"uid"`
+}
+
+type MarkAllSiteMessagesReadResponseObject interface {
+	VisitMarkAllSiteMessagesReadResponse(w http.ResponseWriter) error
+}
+
+type MarkAllSiteMessagesRead200JSONResponse MarkReadResult
+
+func (response MarkAllSiteMessagesRead200JSONResponse) VisitMarkAllSiteMessagesReadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type MarkAllSiteMessagesRead400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response MarkAllSiteMessagesRead400JSONResponse) VisitMarkAllSiteMessagesReadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type StreamSiteMessagesRequestObject struct {
+	Uid    int64 `json:"uid"`
+	Params StreamSiteMessagesParams
+}
+
+type StreamSiteMessagesResponseObject interface {
+	VisitStreamSiteMessagesResponse(w http.ResponseWriter) error
+}
+
+type StreamSiteMessages200TexteventStreamResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response StreamSiteMessages200TexteventStreamResponse) VisitStreamSiteMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type StreamSiteMessages400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response StreamSiteMessages400JSONResponse) VisitStreamSiteMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CountUnreadSiteMessagesRequestObject struct {
+	Uid int64 `json:"uid"`
+}
+
+type CountUnreadSiteMessagesResponseObject interface {
+	VisitCountUnreadSiteMessagesResponse(w http.ResponseWriter) error
+}
+
+type CountUnreadSiteMessages200JSONResponse InboxSummary
+
+func (response CountUnreadSiteMessages200JSONResponse) VisitCountUnreadSiteMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CountUnreadSiteMessages400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CountUnreadSiteMessages400JSONResponse) VisitCountUnreadSiteMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type WebSocketSiteMessagesRequestObject struct {
+	Uid    int64 `json:"uid"`
+	Params WebSocketSiteMessagesParams
+}
+
+type WebSocketSiteMessagesResponseObject interface {
+	VisitWebSocketSiteMessagesResponse(w http.ResponseWriter) error
+}
+
+type WebSocketSiteMessages101Response struct {
+}
+
+func (response WebSocketSiteMessages101Response) VisitWebSocketSiteMessagesResponse(w http.ResponseWriter) error {
+	w.WriteHeader(101)
+	return nil
+}
+
+type WebSocketSiteMessages400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response WebSocketSiteMessages400JSONResponse) VisitWebSocketSiteMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteSiteMessageRequestObject struct {
+	Uid int64 `json:"uid"`
+	Id  int64 `json:"id"`
+}
+
+type DeleteSiteMessageResponseObject interface {
+	VisitDeleteSiteMessageResponse(w http.ResponseWriter) error
+}
+
+type DeleteSiteMessage204Response struct {
+}
+
+func (response DeleteSiteMessage204Response) VisitDeleteSiteMessageResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteSiteMessage400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response DeleteSiteMessage400JSONResponse) VisitDeleteSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteSiteMessage404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response DeleteSiteMessage404JSONResponse) VisitDeleteSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UnarchiveSiteMessageRequestObject struct {
+	Uid int64 `json:"uid"`
+	Id  int64 `json:"id"`
+}
+
+type UnarchiveSiteMessageResponseObject interface {
+	VisitUnarchiveSiteMessageResponse(w http.ResponseWriter) error
+}
+
+type UnarchiveSiteMessage200JSONResponse SiteMessage
+
+func (response UnarchiveSiteMessage200JSONResponse) VisitUnarchiveSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UnarchiveSiteMessage400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UnarchiveSiteMessage400JSONResponse) VisitUnarchiveSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UnarchiveSiteMessage404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UnarchiveSiteMessage404JSONResponse) VisitUnarchiveSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ArchiveSiteMessageRequestObject struct {
+	Uid int64 `json:"uid"`
+	Id  int64 `json:"id"`
+}
+
+type ArchiveSiteMessageResponseObject interface {
+	VisitArchiveSiteMessageResponse(w http.ResponseWriter) error
+}
+
+type ArchiveSiteMessage200JSONResponse SiteMessage
+
+func (response ArchiveSiteMessage200JSONResponse) VisitArchiveSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ArchiveSiteMessage400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ArchiveSiteMessage400JSONResponse) VisitArchiveSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ArchiveSiteMessage404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ArchiveSiteMessage404JSONResponse) VisitArchiveSiteMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type MarkSiteMessageReadRequestObject struct {
+	Uid int64 `json:"uid"`
+	Id  int64 `json:"id"`
+}
+
+type MarkSiteMessageReadResponseObject interface {
+	VisitMarkSiteMessageReadResponse(w http.ResponseWriter) error
+}
+
+type MarkSiteMessageRead200JSONResponse SiteMessage
+
+func (response MarkSiteMessageRead200JSONResponse) VisitMarkSiteMessageReadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type MarkSiteMessageRead400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response MarkSiteMessageRead400JSONResponse) VisitMarkSiteMessageReadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type MarkSiteMessageRead404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response MarkSiteMessageRead404JSONResponse) VisitMarkSiteMessageReadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type MarkSiteMessageRead409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response MarkSiteMessageRead409JSONResponse) VisitMarkSiteMessageReadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type FindMessagesByNumberRequestObject struct {
+	Number int64 `json:"number"`
+}
+
+type FindMessagesByNumberResponseObject interface {
+	VisitFindMessagesByNumberResponse(w http.ResponseWriter) error
+}
+
+type FindMessagesByNumber200JSONResponse MessageListResponse
+
+func (response FindMessagesByNumber200JSONResponse) VisitFindMessagesByNumberResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type FindMessagesByNumber400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response FindMessagesByNumber400JSONResponse) VisitFindMessagesByNumberResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type FindMessagesByNumber500JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response FindMessagesByNumber500JSONResponse) VisitFindMessagesByNumberResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetMessageStatsRequestObject struct {
+	Params GetMessageStatsParams
+}
+
+type GetMessageStatsResponseObject interface {
+	VisitGetMessageStatsResponse(w http.ResponseWriter) error
+}
+
+type GetMessageStats200JSONResponse MessageStats
+
+func (response GetMessageStats200JSONResponse) VisitGetMessageStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetMessageStats400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetMessageStats400JSONResponse) VisitGetMessageStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListTemplatesRequestObject struct {
+}
+
+type ListTemplatesResponseObject interface {
+	VisitListTemplatesResponse(w http.ResponseWriter) error
+}
+
+type ListTemplates200JSONResponse TemplateList
+
+func (response ListTemplates200JSONResponse) VisitListTemplatesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateTemplateRequestObject struct {
+	Body *CreateTemplateJSONRequestBody
+}
+
+type CreateTemplateResponseObject interface {
+	VisitCreateTemplateResponse(w http.ResponseWriter) error
+}
+
+type CreateTemplate201JSONResponse Template
+
+func (response CreateTemplate201JSONResponse) VisitCreateTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateTemplate400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CreateTemplate400JSONResponse) VisitCreateTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateTemplate409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response CreateTemplate409JSONResponse) VisitCreateTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteTemplateRequestObject struct {
+	Id string `json:"id"`
+}
+
+type DeleteTemplateResponseObject interface {
+	VisitDeleteTemplateResponse(w http.ResponseWriter) error
+}
+
+type DeleteTemplate204Response struct {
+}
+
+func (response DeleteTemplate204Response) VisitDeleteTemplateResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteTemplate404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response DeleteTemplate404JSONResponse) VisitDeleteTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetTemplateRequestObject struct {
+	Id string `json:"id"`
+}
+
+type GetTemplateResponseObject interface {
+	VisitGetTemplateResponse(w http.ResponseWriter) error
+}
+
+type GetTemplate200JSONResponse Template
+
+func (response GetTemplate200JSONResponse) VisitGetTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetTemplate404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetTemplate404JSONResponse) VisitGetTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateTemplateRequestObject struct {
+	Id   string `json:"id"`
+	Body *UpdateTemplateJSONRequestBody
+}
+
+type UpdateTemplateResponseObject interface {
+	VisitUpdateTemplateResponse(w http.ResponseWriter) error
+}
+
+type UpdateTemplate200JSONResponse Template
+
+func (response UpdateTemplate200JSONResponse) VisitUpdateTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateTemplate400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UpdateTemplate400JSONResponse) VisitUpdateTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateTemplate404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UpdateTemplate404JSONResponse) VisitUpdateTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PreviewTemplateRequestObject struct {
+	Id     string `json:"id"`
+	Params PreviewTemplateParams
+	Body   *PreviewTemplateJSONRequestBody
+}
+
+type PreviewTemplateResponseObject interface {
+	VisitPreviewTemplateResponse(w http.ResponseWriter) error
+}
+
+type PreviewTemplate200JSONResponse TemplatePreview
+
+func (response PreviewTemplate200JSONResponse) VisitPreviewTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PreviewTemplate400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response PreviewTemplate400JSONResponse) VisitPreviewTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PreviewTemplate404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response PreviewTemplate404JSONResponse) VisitPreviewTemplateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetBroadcastFanoutRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type GetBroadcastFanoutResponseObject interface {
+	VisitGetBroadcastFanoutResponse(w http.ResponseWriter) error
+}
+
+type GetBroadcastFanout200JSONResponse Fanout
+
+func (response GetBroadcastFanout200JSONResponse) VisitGetBroadcastFanoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetBroadcastFanout400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetBroadcastFanout400JSONResponse) VisitGetBroadcastFanoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetBroadcastFanout404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response GetBroadcastFanout404JSONResponse) VisitGetBroadcastFanoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateMessageStatusRequestObject struct {
+	Id   int64 `json:"id"`
+	Body *UpdateMessageStatusJSONRequestBody
+}
+
+type UpdateMessageStatusResponseObject interface {
+	VisitUpdateMessageStatusResponse(w http.ResponseWriter) error
+}
+
+type UpdateMessageStatus200JSONResponse TypedMessage
+
+func (response UpdateMessageStatus200JSONResponse) VisitUpdateMessageStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateMessageStatus400JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UpdateMessageStatus400JSONResponse) VisitUpdateMessageStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateMessageStatus404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UpdateMessageStatus404JSONResponse) VisitUpdateMessageStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateMessageStatus409JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response UpdateMessageStatus409JSONResponse) VisitUpdateMessageStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListMessageTransitionsRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type ListMessageTransitionsResponseObject interface {
+	VisitListMessageTransitionsResponse(w http.ResponseWriter) error
+}
+
+type ListMessageTransitions200JSONResponse StatusTransitionList
+
+func (response ListMessageTransitions200JSONResponse) VisitListMessageTransitionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListMessageTransitions404JSONResponse struct {
+	Message *string `json:"message,omitempty"`
+}
+
+func (response ListMessageTransitions404JSONResponse) VisitListMessageTransitionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+// StrictServerInterface represents all server handlers.
+type StrictServerInterface interface {
+	// 用户登录
+	// (POST /auth/login)
+	Login(ctx context.Context, request LoginRequestObject) (LoginResponseObject, error)
+	// 发消息(短信/站内/广播)
+	// (POST /message)
+	CreateMessage(ctx context.Context, request CreateMessageRequestObject) (CreateMessageResponseObject, error)
+	// 接收投递回执
+	// (POST /message/callbacks/{provider})
+	ReceiveDeliveryReport(ctx context.Context, request ReceiveDeliveryReportRequestObject) (ReceiveDeliveryReportResponseObject, error)
+	// 查询频道的订阅者
+	// (GET /message/channels/{channel}/subscribers)
+	ListChannelSubscribers(ctx context.Context, request ListChannelSubscribersRequestObject) (ListChannelSubscribersResponseObject, error)
+	// 退订频道
+	// (DELETE /message/channels/{channel}/subscribers/{uid})
+	UnsubscribeChannel(ctx context.Context, request UnsubscribeChannelRequestObject) (UnsubscribeChannelResponseObject, error)
+	// 查询用户对频道的订阅偏好
+	// (GET /message/channels/{channel}/subscribers/{uid})
+	GetChannelSubscription(ctx context.Context, request GetChannelSubscriptionRequestObject) (GetChannelSubscriptionResponseObject, error)
+	// 订阅频道
+	// (PUT /message/channels/{channel}/subscribers/{uid})
+	SubscribeChannel(ctx context.Context, request SubscribeChannelRequestObject) (SubscribeChannelResponseObject, error)
+	// 查询投递失败的消息
+	// (GET /message/failed)
+	ListFailedDeliveries(ctx context.Context, request ListFailedDeliveriesRequestObject) (ListFailedDeliveriesResponseObject, error)
+	// 重新投递失败的消息
+	// (POST /message/failed/{id}/requeue)
+	RequeueFailedDelivery(ctx context.Context, request RequeueFailedDeliveryRequestObject) (RequeueFailedDeliveryResponseObject, error)
+	// 发送短信验证码
+	// (POST /message/otp)
+	SendOTP(ctx context.Context, request SendOTPRequestObject) (SendOTPResponseObject, error)
+	// 校验短信验证码
+	// (POST /message/otp/verify)
+	VerifyOTP(ctx context.Context, request VerifyOTPRequestObject) (VerifyOTPResponseObject, error)
+	// 查询定时发送任务
+	// (GET /message/schedules)
+	ListSchedules(ctx context.Context, request ListSchedulesRequestObject) (ListSchedulesResponseObject, error)
+	// 取消定时发送任务
+	// (DELETE /message/schedules/{id})
+	CancelSchedule(ctx context.Context, request CancelScheduleRequestObject) (CancelScheduleResponseObject, error)
+	// 查询定时发送任务
+	// (GET /message/schedules/{id})
+	GetSchedule(ctx context.Context, request GetScheduleRequestObject) (GetScheduleResponseObject, error)
+	// 搜索消息
+	// (GET /message/search)
+	SearchMessages(ctx context.Context, request SearchMessagesRequestObject) (SearchMessagesResponseObject, error)
+	// 根据UID查询站内消息（收件箱）
+	// (GET /message/sitemessage/{uid})
+	FindMessagesByUID(ctx context.Context, request FindMessagesByUIDRequestObject) (FindMessagesByUIDResponseObject, error)
+	// 将用户的站内信全部标记为已读
+	// (POST /message/sitemessage/{uid}/read)
+	MarkAllSiteMessagesRead(ctx context.Context, request MarkAllSiteMessagesReadRequestObject) (MarkAllSiteMessagesReadResponseObject, error)
+	// 实时推送站内消息（SSE）
+	// (GET /message/sitemessage/{uid}/stream)
+	StreamSiteMessages(ctx context.Context, request StreamSiteMessagesRequestObject) (StreamSiteMessagesResponseObject, error)
+	// 查询用户的未读站内信数
+	// (GET /message/sitemessage/{uid}/unread)
+	CountUnreadSiteMessages(ctx context.Context, request CountUnreadSiteMessagesRequestObject) (CountUnreadSiteMessagesResponseObject, error)
+	// 实时推送站内消息（WebSocket）
+	// (GET /message/sitemessage/{uid}/ws)
+	WebSocketSiteMessages(ctx context.Context, request WebSocketSiteMessagesRequestObject) (WebSocketSiteMessagesResponseObject, error)
+	// 删除站内信
+	// (DELETE /message/sitemessage/{uid}/{id})
+	DeleteSiteMessage(ctx context.Context, request DeleteSiteMessageRequestObject) (DeleteSiteMessageResponseObject, error)
+	// 取消归档，站内信回到收件箱
+	// (DELETE /message/sitemessage/{uid}/{id}/archive)
+	UnarchiveSiteMessage(ctx context.Context, request UnarchiveSiteMessageRequestObject) (UnarchiveSiteMessageResponseObject, error)
+	// 归档站内信
+	// (PUT /message/sitemessage/{uid}/{id}/archive)
+	ArchiveSiteMessage(ctx context.Context, request ArchiveSiteMessageRequestObject) (ArchiveSiteMessageResponseObject, error)
+	// 将站内信标记为已读
+	// (POST /message/sitemessage/{uid}/{id}/read)
+	MarkSiteMessageRead(ctx context.Context, request MarkSiteMessageReadRequestObject) (MarkSiteMessageReadResponseObject, error)
+	// 根据手机号查询消息
+	// (GET /message/sms/{number})
+	FindMessagesByNumber(ctx context.Context, request FindMessagesByNumberRequestObject) (FindMessagesByNumberResponseObject, error)
+	// 消息统计
+	// (GET /message/stats)
+	GetMessageStats(ctx context.Context, request GetMessageStatsRequestObject) (GetMessageStatsResponseObject, error)
+	// 查询消息模板
+	// (GET /message/templates)
+	ListTemplates(ctx context.Context, request ListTemplatesRequestObject) (ListTemplatesResponseObject, error)
+	// 创建消息模板
+	// (POST /message/templates)
+	CreateTemplate(ctx context.Context, request CreateTemplateRequestObject) (CreateTemplateResponseObject, error)
+	// 删除消息模板
+	// (DELETE /message/templates/{id})
+	DeleteTemplate(ctx context.Context, request DeleteTemplateRequestObject) (DeleteTemplateResponseObject, error)
+	// 查询消息模板
+	// (GET /message/templates/{id})
+	GetTemplate(ctx context.Context, request GetTemplateRequestObject) (GetTemplateResponseObject, error)
+	// 更新消息模板
+	// (PUT /message/templates/{id})
+	UpdateTemplate(ctx context.Context, request UpdateTemplateRequestObject) (UpdateTemplateResponseObject, error)
+	// 预览模板渲染结果
+	// (POST /message/templates/{id}/preview)
+	PreviewTemplate(ctx context.Context, request PreviewTemplateRequestObject) (PreviewTemplateResponseObject, error)
+	// 查询广播的扇出进度
+	// (GET /message/{id}/fanout)
+	GetBroadcastFanout(ctx context.Context, request GetBroadcastFanoutRequestObject) (GetBroadcastFanoutResponseObject, error)
+	// 更新消息状态
+	// (POST /message/{id}/status)
+	UpdateMessageStatus(ctx context.Context, request UpdateMessageStatusRequestObject) (UpdateMessageStatusResponseObject, error)
+	// 查询消息的状态流转记录
+	// (GET /message/{id}/transitions)
+	ListMessageTransitions(ctx context.Context, request ListMessageTransitionsRequestObject) (ListMessageTransitionsResponseObject, error)
+}
+
+type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
+type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+
+type StrictHTTPServerOptions struct {
+	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
+	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		},
+	}}
+}
+
+func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
+}
+
+type strictHandler struct {
+	ssi         StrictServerInterface
+	middlewares []StrictMiddlewareFunc
+	options     StrictHTTPServerOptions
+}
+
+// Login operation middleware
+func (sh *strictHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var request LoginRequestObject
+
+	var body LoginJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.Login(ctx, request.(LoginRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "Login")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(LoginResponseObject); ok {
+		if err := validResponse.VisitLoginResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateMessage operation middleware
+func (sh *strictHandler) CreateMessage(w http.ResponseWriter, r *http.Request, params CreateMessageParams) {
+	var request CreateMessageRequestObject
+
+	request.Params = params
+
+	var body CreateMessageJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateMessage(ctx, request.(CreateMessageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateMessage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateMessageResponseObject); ok {
+		if err := validResponse.VisitCreateMessageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ReceiveDeliveryReport operation middleware
+func (sh *strictHandler) ReceiveDeliveryReport(w http.ResponseWriter, r *http.Request, provider string, params ReceiveDeliveryReportParams) {
+	var request ReceiveDeliveryReportRequestObject
+
+	request.Provider = provider
+	request.Params = params
+
+	var body ReceiveDeliveryReportJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ReceiveDeliveryReport(ctx, request.(ReceiveDeliveryReportRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ReceiveDeliveryReport")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ReceiveDeliveryReportResponseObject); ok {
+		if err := validResponse.VisitReceiveDeliveryReportResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListChannelSubscribers operation middleware
+func (sh *strictHandler) ListChannelSubscribers(w http.ResponseWriter, r *http.Request, channel string, params ListChannelSubscribersParams) {
+	var request ListChannelSubscribersRequestObject
+
+	request.Channel = channel
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListChannelSubscribers(ctx, request.(ListChannelSubscribersRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListChannelSubscribers")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListChannelSubscribersResponseObject); ok {
+		if err := validResponse.VisitListChannelSubscribersResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UnsubscribeChannel operation middleware
+func (sh *strictHandler) UnsubscribeChannel(w http.ResponseWriter, r *http.Request, channel string, uid int64) {
+	var request UnsubscribeChannelRequestObject
+
+	request.Channel = channel
+	request.Uid = uid
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UnsubscribeChannel(ctx, request.(UnsubscribeChannelRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UnsubscribeChannel")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UnsubscribeChannelResponseObject); ok {
+		if err := validResponse.VisitUnsubscribeChannelResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetChannelSubscription operation middleware
+func (sh *strictHandler) GetChannelSubscription(w http.ResponseWriter, r *http.Request, channel string, uid int64) {
+	var request GetChannelSubscriptionRequestObject
+
+	request.Channel = channel
+	request.Uid = uid
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetChannelSubscription(ctx, request.(GetChannelSubscriptionRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetChannelSubscription")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetChannelSubscriptionResponseObject); ok {
+		if err := validResponse.VisitGetChannelSubscriptionResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// SubscribeChannel operation middleware
+func (sh *strictHandler) SubscribeChannel(w http.ResponseWriter, r *http.Request, channel string, uid int64) {
+	var request SubscribeChannelRequestObject
+
+	request.Channel = channel
+	request.Uid = uid
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.SubscribeChannel(ctx, request.(SubscribeChannelRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "SubscribeChannel")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(SubscribeChannelResponseObject); ok {
+		if err := validResponse.VisitSubscribeChannelResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListFailedDeliveries operation middleware
+func (sh *strictHandler) ListFailedDeliveries(w http.ResponseWriter, r *http.Request) {
+	var request ListFailedDeliveriesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListFailedDeliveries(ctx, request.(ListFailedDeliveriesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListFailedDeliveries")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListFailedDeliveriesResponseObject); ok {
+		if err := validResponse.VisitListFailedDeliveriesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// RequeueFailedDelivery operation middleware
+func (sh *strictHandler) RequeueFailedDelivery(w http.ResponseWriter, r *http.Request, id int64) {
+	var request RequeueFailedDeliveryRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RequeueFailedDelivery(ctx, request.(RequeueFailedDeliveryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RequeueFailedDelivery")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RequeueFailedDeliveryResponseObject); ok {
+		if err := validResponse.VisitRequeueFailedDeliveryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// SendOTP operation middleware
+func (sh *strictHandler) SendOTP(w http.ResponseWriter, r *http.Request, params SendOTPParams) {
+	var request SendOTPRequestObject
+
+	request.Params = params
+
+	var body SendOTPJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.SendOTP(ctx, request.(SendOTPRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "SendOTP")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(SendOTPResponseObject); ok {
+		if err := validResponse.VisitSendOTPResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// VerifyOTP operation middleware
+func (sh *strictHandler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
+	var request VerifyOTPRequestObject
+
+	var body VerifyOTPJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.VerifyOTP(ctx, request.(VerifyOTPRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "VerifyOTP")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(VerifyOTPResponseObject); ok {
+		if err := validResponse.VisitVerifyOTPResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListSchedules operation middleware
+func (sh *strictHandler) ListSchedules(w http.ResponseWriter, r *http.Request, params ListSchedulesParams) {
+	var request ListSchedulesRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListSchedules(ctx, request.(ListSchedulesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListSchedules")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListSchedulesResponseObject); ok {
+		if err := validResponse.VisitListSchedulesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CancelSchedule operation middleware
+func (sh *strictHandler) CancelSchedule(w http.ResponseWriter, r *http.Request, id int64) {
+	var request CancelScheduleRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CancelSchedule(ctx, request.(CancelScheduleRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CancelSchedule")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CancelScheduleResponseObject); ok {
+		if err := validResponse.VisitCancelScheduleResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetSchedule operation middleware
+func (sh *strictHandler) GetSchedule(w http.ResponseWriter, r *http.Request, id int64) {
+	var request GetScheduleRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetSchedule(ctx, request.(GetScheduleRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetSchedule")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetScheduleResponseObject); ok {
+		if err := validResponse.VisitGetScheduleResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// SearchMessages operation middleware
+func (sh *strictHandler) SearchMessages(w http.ResponseWriter, r *http.Request, params SearchMessagesParams) {
+	var request SearchMessagesRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.SearchMessages(ctx, request.(SearchMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "SearchMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(SearchMessagesResponseObject); ok {
+		if err := validResponse.VisitSearchMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// FindMessagesByUID operation middleware
+func (sh *strictHandler) FindMessagesByUID(w http.ResponseWriter, r *http.Request, uid int64, params FindMessagesByUIDParams) {
+	var request FindMessagesByUIDRequestObject
+
+	request.Uid = uid
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.FindMessagesByUID(ctx, request.(FindMessagesByUIDRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "FindMessagesByUID")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(FindMessagesByUIDResponseObject); ok {
+		if err := validResponse.VisitFindMessagesByUIDResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// MarkAllSiteMessagesRead operation middleware
+func (sh *strictHandler) MarkAllSiteMessagesRead(w http.ResponseWriter, r *http.Request, uid int64) {
+	var request MarkAllSiteMessagesReadRequestObject
+
+	request.Uid = uid
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.MarkAllSiteMessagesRead(ctx, request.(MarkAllSiteMessagesReadRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "MarkAllSiteMessagesRead")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(MarkAllSiteMessagesReadResponseObject); ok {
+		if err := validResponse.VisitMarkAllSiteMessagesReadResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// StreamSiteMessages operation middleware
+func (sh *strictHandler) StreamSiteMessages(w http.ResponseWriter, r *http.Request, uid int64, params StreamSiteMessagesParams) {
+	var request StreamSiteMessagesRequestObject
+
+	request.Uid = uid
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.StreamSiteMessages(ctx, request.(StreamSiteMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "StreamSiteMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(StreamSiteMessagesResponseObject); ok {
+		if err := validResponse.VisitStreamSiteMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CountUnreadSiteMessages operation middleware
+func (sh *strictHandler) CountUnreadSiteMessages(w http.ResponseWriter, r *http.Request, uid int64) {
+	var request CountUnreadSiteMessagesRequestObject
+
+	request.Uid = uid
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CountUnreadSiteMessages(ctx, request.(CountUnreadSiteMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CountUnreadSiteMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CountUnreadSiteMessagesResponseObject); ok {
+		if err := validResponse.VisitCountUnreadSiteMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// WebSocketSiteMessages operation middleware
+func (sh *strictHandler) WebSocketSiteMessages(w http.ResponseWriter, r *http.Request, uid int64, params WebSocketSiteMessagesParams) {
+	var request WebSocketSiteMessagesRequestObject
+
+	request.Uid = uid
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.WebSocketSiteMessages(ctx, request.(WebSocketSiteMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "WebSocketSiteMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(WebSocketSiteMessagesResponseObject); ok {
+		if err := validResponse.VisitWebSocketSiteMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteSiteMessage operation middleware
+func (sh *strictHandler) DeleteSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	var request DeleteSiteMessageRequestObject
+
+	request.Uid = uid
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteSiteMessage(ctx, request.(DeleteSiteMessageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteSiteMessage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteSiteMessageResponseObject); ok {
+		if err := validResponse.VisitDeleteSiteMessageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UnarchiveSiteMessage operation middleware
+func (sh *strictHandler) UnarchiveSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	var request UnarchiveSiteMessageRequestObject
+
+	request.Uid = uid
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UnarchiveSiteMessage(ctx, request.(UnarchiveSiteMessageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UnarchiveSiteMessage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UnarchiveSiteMessageResponseObject); ok {
+		if err := validResponse.VisitUnarchiveSiteMessageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ArchiveSiteMessage operation middleware
+func (sh *strictHandler) ArchiveSiteMessage(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	var request ArchiveSiteMessageRequestObject
+
+	request.Uid = uid
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ArchiveSiteMessage(ctx, request.(ArchiveSiteMessageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ArchiveSiteMessage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ArchiveSiteMessageResponseObject); ok {
+		if err := validResponse.VisitArchiveSiteMessageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// MarkSiteMessageRead operation middleware
+func (sh *strictHandler) MarkSiteMessageRead(w http.ResponseWriter, r *http.Request, uid int64, id int64) {
+	var request MarkSiteMessageReadRequestObject
+
+	request.Uid = uid
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.MarkSiteMessageRead(ctx, request.(MarkSiteMessageReadRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "MarkSiteMessageRead")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(MarkSiteMessageReadResponseObject); ok {
+		if err := validResponse.VisitMarkSiteMessageReadResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// FindMessagesByNumber operation middleware
+func (sh *strictHandler) FindMessagesByNumber(w http.ResponseWriter, r *http.Request, number int64) {
+	var request FindMessagesByNumberRequestObject
+
+	request.Number = number
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.FindMessagesByNumber(ctx, request.(FindMessagesByNumberRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "FindMessagesByNumber")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(FindMessagesByNumberResponseObject); ok {
+		if err := validResponse.VisitFindMessagesByNumberResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetMessageStats operation middleware
+func (sh *strictHandler) GetMessageStats(w http.ResponseWriter, r *http.Request, params GetMessageStatsParams) {
+	var request GetMessageStatsRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetMessageStats(ctx, request.(GetMessageStatsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetMessageStats")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetMessageStatsResponseObject); ok {
+		if err := validResponse.VisitGetMessageStatsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListTemplates operation middleware
+func (sh *strictHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	var request ListTemplatesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListTemplates(ctx, request.(ListTemplatesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListTemplates")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListTemplatesResponseObject); ok {
+		if err := validResponse.VisitListTemplatesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateTemplate operation middleware
+func (sh *strictHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var request CreateTemplateRequestObject
+
+	var body CreateTemplateJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateTemplate(ctx, request.(CreateTemplateRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateTemplate")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateTemplateResponseObject); ok {
+		if err := validResponse.VisitCreateTemplateResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteTemplate operation middleware
+func (sh *strictHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	var request DeleteTemplateRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteTemplate(ctx, request.(DeleteTemplateRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteTemplate")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteTemplateResponseObject); ok {
+		if err := validResponse.VisitDeleteTemplateResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetTemplate operation middleware
+func (sh *strictHandler) GetTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	var request GetTemplateRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetTemplate(ctx, request.(GetTemplateRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetTemplate")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetTemplateResponseObject); ok {
+		if err := validResponse.VisitGetTemplateResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpdateTemplate operation middleware
+func (sh *strictHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	var request UpdateTemplateRequestObject
+
+	request.Id = id
+
+	var body UpdateTemplateJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpdateTemplate(ctx, request.(UpdateTemplateRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpdateTemplate")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpdateTemplateResponseObject); ok {
+		if err := validResponse.VisitUpdateTemplateResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PreviewTemplate operation middleware
+func (sh *strictHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request, id string, params PreviewTemplateParams) {
+	var request PreviewTemplateRequestObject
+
+	request.Id = id
+	request.Params = params
+
+	var body PreviewTemplateJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PreviewTemplate(ctx, request.(PreviewTemplateRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PreviewTemplate")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PreviewTemplateResponseObject); ok {
+		if err := validResponse.VisitPreviewTemplateResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetBroadcastFanout operation middleware
+func (sh *strictHandler) GetBroadcastFanout(w http.ResponseWriter, r *http.Request, id int64) {
+	var request GetBroadcastFanoutRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetBroadcastFanout(ctx, request.(GetBroadcastFanoutRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetBroadcastFanout")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetBroadcastFanoutResponseObject); ok {
+		if err := validResponse.VisitGetBroadcastFanoutResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpdateMessageStatus operation middleware
+func (sh *strictHandler) UpdateMessageStatus(w http.ResponseWriter, r *http.Request, id int64) {
+	var request UpdateMessageStatusRequestObject
+
+	request.Id = id
+
+	var body UpdateMessageStatusJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpdateMessageStatus(ctx, request.(UpdateMessageStatusRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpdateMessageStatus")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpdateMessageStatusResponseObject); ok {
+		if err := validResponse.VisitUpdateMessageStatusResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListMessageTransitions operation middleware
+func (sh *strictHandler) ListMessageTransitions(w http.ResponseWriter, r *http.Request, id int64) {
+	var request ListMessageTransitionsRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListMessageTransitions(ctx, request.(ListMessageTransitionsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListMessageTransitions")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListMessageTransitionsResponseObject); ok {
+		if err := validResponse.VisitListMessageTransitionsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
@@ -0,0 +1,164 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// CallbackTolerance 回执签名时间与服务器时间允许的最大偏差，超出视为重放
+	CallbackTolerance = 5 * time.Minute
+
+	// maxCallbackBody 回执请求体的大小上限
+	maxCallbackBody = 64 << 10
+)
+
+// SignCallback 计算投递回执的签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制
+func SignCallback(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseCallbackSecrets 解析 "provider:secret,provider:secret" 格式的回执密钥配置
+func ParseCallbackSecrets(s string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		provider, secret, ok := strings.Cut(pair, ":")
+		if !ok || provider == "" || secret == "" {
+			return nil, fmt.Errorf("message: invalid callback secret %q, want provider:secret", pair)
+		}
+		secrets[provider] = secret
+	}
+	return secrets, nil
+}
+
+// verifySignature 校验回执的签名和时间戳
+func (h *Server) verifySignature(secret string, r *http.Request, body []byte) bool {
+	timestamp, err := strconv.ParseInt(r.Header.Get("X-Signature-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := h.clock.Now().Sub(time.Unix(timestamp, 0))
+	if skew > CallbackTolerance || skew < -CallbackTolerance {
+		return false
+	}
+
+	signature := strings.TrimPrefix(r.Header.Get("X-Signature"), "sha256=")
+	expected := SignCallback(secret, timestamp, body)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// verifyCallback 校验投递回执签名的中间件，签名覆盖原始请求体，
+// 因此在生成的代码解码请求体之前读取并校验，再把请求体交还给后续处理
+func (h *Server) verifyCallback(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := h.callbackSecrets[chi.URLParam(r, "provider")]
+		if !ok {
+			errors.WriteJSON(w, errors.NotFound("未知的投递通道"))
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBody))
+		if err != nil {
+			errors.WriteJSON(w, errors.BadRequest("读取请求体失败"))
+			return
+		}
+		if !h.verifySignature(secret, r, body) {
+			errors.WriteJSON(w, errors.New(http.StatusUnauthorized, "签名无效或已过期"))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReceiveDeliveryReport 接收投递通道的投递回执，把消息推进到 received 或 failed
+// 签名已由 verifyCallback 校验；网关会在没有收到 2xx 时重试，重复的回执和过时的回执都直接返回消息的当前状态
+func (h *Server) ReceiveDeliveryReport(ctx context.Context, request ReceiveDeliveryReportRequestObject) (ReceiveDeliveryReportResponseObject, error) {
+	provider, report := request.Provider, request.Body
+	if report.MessageId == "" {
+		return nil, errors.BadRequest("回执必须包含消息ID")
+	}
+
+	var to MessageStatus
+	reason := "delivery report"
+	switch report.Status {
+	case ReportDelivered:
+		to = Received
+	case ReportFailed:
+		to = Failed
+		if report.Error != nil && *report.Error != "" {
+			reason = *report.Error
+		}
+	default:
+		return nil, errors.BadRequest(fmt.Sprintf("无效的回执状态: %s", report.Status))
+	}
+
+	model, err := h.messages.FindByReceipt(ctx, provider, report.MessageId)
+	if err != nil {
+		if stderrors.Is(err, ErrMessageNotFound) {
+			// 回执可能早于投递结果落库到达，返回 404 让网关稍后重试
+			return nil, errors.NotFound("消息不存在")
+		}
+		return nil, errors.InternalServer("查询消息失败")
+	}
+
+	id := model.ID
+	model, err = h.applyReport(ctx, model, to, reason)
+	if err != nil {
+		h.logger.Error("处理投递回执失败", "id", id, "provider", provider, "status", to, "error", err)
+		return nil, errors.InternalServer("更新消息状态失败")
+	}
+
+	typed, err := model.ToTypedMessage()
+	if err != nil {
+		return nil, err
+	}
+	return ReceiveDeliveryReport200JSONResponse(typed), nil
+}
+
+// applyReport 按回执流转消息状态，返回流转后的消息
+// 回执先于 sent 落库到达时先补上 sent；状态机不允许的流转说明回执已经过时，保持原状态
+func (h *Server) applyReport(ctx context.Context, model *MessageModel, to MessageStatus, reason string) (*MessageModel, error) {
+	if model.Status == Sending && to == Received {
+		updated, err := h.messages.Transition(ctx, model.ID, Sent, "delivered")
+		if err != nil && !stderrors.Is(err, ErrInvalidTransition) {
+			return model, err
+		}
+		if err == nil {
+			model = updated
+		}
+	}
+
+	updated, err := h.messages.Transition(ctx, model.ID, to, reason)
+	if err != nil {
+		if stderrors.Is(err, ErrInvalidTransition) {
+			h.logger.Info("忽略过时的投递回执", "id", model.ID, "error", err)
+			return h.messages.FindByID(ctx, model.ID)
+		}
+		return model, err
+	}
+	return updated, nil
+}
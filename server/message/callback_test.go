@@ -0,0 +1,182 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+const testCallbackSecret = "callback-secret"
+
+var testCallbackTime = time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// setupCallbackRouter 创建接收 acme 通道回执的路由，短信经由 acme 网关投递
+func setupCallbackRouter(t *testing.T) (*chi.Mux, *Server) {
+	gateway := newFakeGateway(t, http.StatusOK)
+	registry := NewRegistry()
+	registry.Register(Sms, NewHTTPProvider(HTTPProviderConfig{Name: "acme", URL: gateway.URL}))
+
+	deps := module.Deps{
+		DB:    setupTestDB(t),
+		Clock: module.ClockFunc(func() time.Time { return testCallbackTime }),
+	}
+	h := New(deps, Config{
+		Providers:       registry,
+		CallbackSecrets: map[string]string{"acme": testCallbackSecret},
+	})
+
+	r := chi.NewRouter()
+	h.Routes(r)
+	return r, h
+}
+
+// postReport 发送使用 secret 签名的投递回执
+func postReport(r http.Handler, provider, secret string, at time.Time, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/message/callbacks/"+provider, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set("X-Signature", "sha256="+SignCallback(secret, at.Unix(), []byte(body)))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReceiveDeliveryReport(t *testing.T) {
+	t.Parallel()
+
+	r, h := setupCallbackRouter(t)
+	ctx := context.Background()
+
+	resp := postMessage(r, `{"type":"sms","content":"验证码：929253","phone_number":"8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	require.Equal(t, Sent, sms.Status)
+
+	model, err := h.messages.FindByID(ctx, sms.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", model.Provider)
+	assert.Equal(t, "gw-"+strconv.FormatInt(sms.Id, 10), model.ProviderMessageID)
+
+	report := `{"message_id":"` + model.ProviderMessageID + `","status":"delivered"}`
+	resp = postReport(r, "acme", testCallbackSecret, testCallbackTime, report)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var msg Message
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	assert.Equal(t, Received, msg.Status)
+
+	// 网关重试同一回执时不重复记录状态流转
+	resp = postReport(r, "acme", testCallbackSecret, testCallbackTime, report)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// 晚到的失败回执不会把已送达的消息改回 failed
+	resp = postReport(r, "acme", testCallbackSecret, testCallbackTime,
+		`{"message_id":"`+model.ProviderMessageID+`","status":"failed","error":"UNDELIV"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	assert.Equal(t, Received, msg.Status)
+
+	records, err := h.messages.ListTransitions(ctx, sms.Id)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, Received, records[2].To)
+	assert.Equal(t, "delivery report", records[2].Reason)
+}
+
+func TestReceiveDeliveryReportFailed(t *testing.T) {
+	t.Parallel()
+
+	r, h := setupCallbackRouter(t)
+	ctx := context.Background()
+
+	// 回执早于 sent 落库到达：消息仍为 sending
+	model := FromSMS(SMSMessage{Content: "hello", PhoneNumber: "8613800138000"})
+	model.Status = Sending
+	require.NoError(t, h.messages.Create(ctx, model))
+	require.NoError(t, h.messages.SaveReceipt(ctx, model.ID, Receipt{Provider: "acme", MessageID: "gw-x"}))
+
+	resp := postReport(r, "acme", testCallbackSecret, testCallbackTime,
+		`{"message_id":"gw-x","status":"failed","error":"UNDELIV"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	records, err := h.messages.ListTransitions(ctx, model.ID)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, Failed, records[1].To)
+	assert.Equal(t, "UNDELIV", records[1].Reason)
+
+	// 送达回执先于 sent 到达时补上 sent
+	other := FromSMS(SMSMessage{Content: "hello", PhoneNumber: "8613800138000"})
+	other.Status = Sending
+	require.NoError(t, h.messages.Create(ctx, other))
+	require.NoError(t, h.messages.SaveReceipt(ctx, other.ID, Receipt{Provider: "acme", MessageID: "gw-y"}))
+
+	resp = postReport(r, "acme", testCallbackSecret, testCallbackTime, `{"message_id":"gw-y","status":"delivered"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var msg Message
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	assert.Equal(t, Received, msg.Status)
+}
+
+func TestReceiveDeliveryReportRejected(t *testing.T) {
+	t.Parallel()
+
+	r, _ := setupCallbackRouter(t)
+	report := `{"message_id":"gw-1","status":"delivered"}`
+
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		at       time.Time
+		body     string
+		want     int
+	}{
+		{"未配置密钥的通道", "other", testCallbackSecret, testCallbackTime, report, http.StatusNotFound},
+		{"签名错误", "acme", "wrong-secret", testCallbackTime, report, http.StatusUnauthorized},
+		{"时间戳过期", "acme", testCallbackSecret, testCallbackTime.Add(-CallbackTolerance - time.Second), report, http.StatusUnauthorized},
+		{"无效的状态", "acme", testCallbackSecret, testCallbackTime, `{"message_id":"gw-1","status":"lost"}`, http.StatusBadRequest},
+		{"缺少消息ID", "acme", testCallbackSecret, testCallbackTime, `{"status":"delivered"}`, http.StatusBadRequest},
+		{"未知的消息", "acme", testCallbackSecret, testCallbackTime, report, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := postReport(r, tt.provider, tt.secret, tt.at, tt.body)
+			assert.Equal(t, tt.want, resp.Code, resp.Body.String())
+		})
+	}
+
+	// 签名覆盖请求体，篡改后校验失败
+	req := httptest.NewRequest(http.MethodPost, "/message/callbacks/acme", strings.NewReader(`{"message_id":"gw-2","status":"delivered"}`))
+	req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(testCallbackTime.Unix(), 10))
+	req.Header.Set("X-Signature", SignCallback(testCallbackSecret, testCallbackTime.Unix(), []byte(report)))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestParseCallbackSecrets(t *testing.T) {
+	t.Parallel()
+
+	secrets, err := ParseCallbackSecrets(" acme:s1, other:s2:with-colon ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"acme": "s1", "other": "s2:with-colon"}, secrets)
+
+	secrets, err = ParseCallbackSecrets("")
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	_, err = ParseCallbackSecrets("acme")
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// defaultSubscriberPageSize 订阅者列表的默认每页条数
+	defaultSubscriberPageSize = 100
+
+	// maxSubscriberPageSize 订阅者列表的最大每页条数
+	maxSubscriberPageSize = 1000
+)
+
+var (
+	// ErrSubscriptionNotFound 表示用户从未设置过该频道的订阅偏好
+	ErrSubscriptionNotFound = stderrors.New("subscription not found")
+
+	// ErrFanoutNotFound 表示广播没有扇出任务
+	ErrFanoutNotFound = stderrors.New("fanout not found")
+
+	// ErrFanoutConflict 表示同一扇出任务的批次被并发推进，调用方可以重试
+	ErrFanoutConflict = stderrors.New("fanout advanced concurrently")
+)
+
+// Valid 判断是否为已定义的广播频道
+func (c BroadcastMessageChannel) Valid() bool {
+	switch c {
+	case News, Alert, Promotion:
+		return true
+	}
+	return false
+}
+
+// SubscriptionModel 用户对广播频道的订阅偏好
+// 退订时保留记录并把 Subscribed 置为 false，以区分“主动退订”和“从未订阅”
+type SubscriptionModel struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Channel    string    `gorm:"size:20;not null;uniqueIndex:idx_subscription_channel_user" json:"channel"`
+	UserID     int64     `gorm:"not null;uniqueIndex:idx_subscription_channel_user" json:"user_id"`
+	Subscribed bool      `gorm:"not null" json:"subscribed"`
+}
+
+// TableName 指定订阅表名
+func (SubscriptionModel) TableName() string {
+	return "message_channel_subscriptions"
+}
+
+// ToAPI 将订阅记录转换为API模型
+func (s *SubscriptionModel) ToAPI() Subscription {
+	return Subscription{
+		Channel:    s.Channel,
+		UserId:     s.UserID,
+		Subscribed: s.Subscribed,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+// FanoutModel 广播扇出任务：把一条广播按批次生成为各订阅者的站内信
+// Cursor 是已处理的最大订阅者用户 ID，批次在事务中生成站内信并推进 Cursor，重复执行不会重复生成
+type FanoutModel struct {
+	ID          int64        `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	BroadcastID int64        `gorm:"not null;uniqueIndex" json:"broadcast_id"`
+	Channel     string       `gorm:"size:20;not null" json:"channel"`
+	Status      FanoutStatus `gorm:"size:20;not null" json:"status"`
+	Cursor      int64        `gorm:"not null;default:0" json:"cursor"`
+	Total       int64        `gorm:"not null" json:"total"`     // 创建时的订阅者数，扇出期间订阅变化会使其与 Delivered 不一致
+	Delivered   int64        `gorm:"not null" json:"delivered"` // 已生成的站内信数
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+}
+
+// TableName 指定扇出任务表名
+func (FanoutModel) TableName() string {
+	return "message_fanouts"
+}
+
+// ToAPI 将扇出任务转换为API模型
+func (f *FanoutModel) ToAPI() Fanout {
+	return Fanout{
+		BroadcastId: f.BroadcastID,
+		Channel:     f.Channel,
+		Status:      f.Status,
+		Total:       f.Total,
+		Delivered:   f.Delivered,
+		CreatedAt:   f.CreatedAt,
+		CompletedAt: f.CompletedAt,
+	}
+}
+
+// ChannelRepository 广播频道订阅与扇出的数据访问接口
+type ChannelRepository interface {
+	// SetSubscription 设置用户对频道的订阅偏好，subscribed 为 false 表示退订
+	SetSubscription(ctx context.Context, channel string, userID int64, subscribed bool) (*SubscriptionModel, error)
+
+	// FindSubscription 查询用户对频道的订阅偏好，从未设置时返回 ErrSubscriptionNotFound
+	FindSubscription(ctx context.Context, channel string, userID int64) (*SubscriptionModel, error)
+
+	// ListSubscribers 按用户 ID 升序列出频道中订阅中的用户，只返回用户 ID 大于 afterUserID 的最多 limit 条
+	ListSubscribers(ctx context.Context, channel string, afterUserID int64, limit int) ([]SubscriptionModel, error)
+
+	// CountSubscribers 统计频道中订阅中的用户数
+	CountSubscribers(ctx context.Context, channel string) (int64, error)
+
+	// CreateFanout 为广播创建扇出任务，已存在时返回已有的任务
+	CreateFanout(ctx context.Context, broadcast *MessageModel) (*FanoutModel, error)
+
+	// FindFanout 按广播 ID 查询扇出任务，不存在时返回 ErrFanoutNotFound
+	FindFanout(ctx context.Context, broadcastID int64) (*FanoutModel, error)
+
+	// FanoutBatch 为 Cursor 之后的至多 size 个订阅者生成 sending 状态的站内信、写入发件箱并推进进度，
+	// 三者在同一事务中完成，站内信由发件箱中继投递；不足 size 个时任务完成。返回本批生成的站内信和更新后的任务，
+	// 任务已完成时返回空批次，批次被并发推进时返回 ErrFanoutConflict
+	FanoutBatch(ctx context.Context, broadcastID int64, size int) ([]MessageModel, *FanoutModel, error)
+}
+
+// validChannel 校验路径中的频道
+func validChannel(channel string) error {
+	if !BroadcastMessageChannel(channel).Valid() {
+		return errors.BadRequest("无效的广播渠道")
+	}
+	return nil
+}
+
+// ListChannelSubscribers 按用户ID分页列出频道的订阅者
+func (h *Server) ListChannelSubscribers(ctx context.Context, request ListChannelSubscribersRequestObject) (ListChannelSubscribersResponseObject, error) {
+	channel := request.Channel
+	if err := validChannel(channel); err != nil {
+		return nil, err
+	}
+
+	var after int64
+	if request.Params.After != nil {
+		after = *request.Params.After
+	}
+	limit := defaultSubscriberPageSize
+	if request.Params.Limit != nil {
+		if limit = *request.Params.Limit; limit <= 0 || limit > maxSubscriberPageSize {
+			return nil, errors.BadRequest("无效的 limit 参数")
+		}
+	}
+
+	// 多取一条判断是否还有下一页
+	subs, err := h.messages.ListSubscribers(ctx, channel, after, limit+1)
+	if err != nil {
+		return nil, errors.InternalServer("查询订阅者失败")
+	}
+	total, err := h.messages.CountSubscribers(ctx, channel)
+	if err != nil {
+		return nil, errors.InternalServer("查询订阅者失败")
+	}
+
+	list := ListChannelSubscribers200JSONResponse{Subscribers: make([]Subscription, 0, len(subs)), Total: total}
+	if len(subs) > limit {
+		subs = subs[:limit]
+		next := subs[len(subs)-1].UserID
+		list.NextAfter = &next
+	}
+	for i := range subs {
+		list.Subscribers = append(list.Subscribers, subs[i].ToAPI())
+	}
+	return list, nil
+}
+
+// GetChannelSubscription 查询用户对频道的订阅偏好
+func (h *Server) GetChannelSubscription(ctx context.Context, request GetChannelSubscriptionRequestObject) (GetChannelSubscriptionResponseObject, error) {
+	if err := validChannel(request.Channel); err != nil {
+		return nil, err
+	}
+
+	sub, err := h.messages.FindSubscription(ctx, request.Channel, request.Uid)
+	if err != nil {
+		if stderrors.Is(err, ErrSubscriptionNotFound) {
+			return nil, errors.NotFound("未设置订阅偏好")
+		}
+		return nil, errors.InternalServer("查询订阅偏好失败")
+	}
+	return GetChannelSubscription200JSONResponse(sub.ToAPI()), nil
+}
+
+// SubscribeChannel 用户订阅频道
+func (h *Server) SubscribeChannel(ctx context.Context, request SubscribeChannelRequestObject) (SubscribeChannelResponseObject, error) {
+	sub, err := h.setSubscription(ctx, request.Channel, request.Uid, true)
+	if err != nil {
+		return nil, err
+	}
+	return SubscribeChannel200JSONResponse(sub.ToAPI()), nil
+}
+
+// UnsubscribeChannel 用户退订频道，保留退订偏好
+func (h *Server) UnsubscribeChannel(ctx context.Context, request UnsubscribeChannelRequestObject) (UnsubscribeChannelResponseObject, error) {
+	sub, err := h.setSubscription(ctx, request.Channel, request.Uid, false)
+	if err != nil {
+		return nil, err
+	}
+	return UnsubscribeChannel200JSONResponse(sub.ToAPI()), nil
+}
+
+// setSubscription 设置订阅偏好，重复设置相同的偏好不报错
+func (h *Server) setSubscription(ctx context.Context, channel string, uid int64, subscribed bool) (*SubscriptionModel, error) {
+	if err := validChannel(channel); err != nil {
+		return nil, err
+	}
+
+	sub, err := h.messages.SetSubscription(ctx, channel, uid, subscribed)
+	if err != nil {
+		h.logger.Error("更新订阅偏好失败", "channel", channel, "user_id", uid, "error", err)
+		return nil, errors.InternalServer("更新订阅偏好失败")
+	}
+	return sub, nil
+}
@@ -0,0 +1,246 @@
+package message
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+// messageVariant CreateMessageRequest 中按 type 区分的一种消息，即 oneOf 的一个分支
+type messageVariant interface {
+	// validate 校验该类型特有的字段，返回全部字段错误
+	validate() []errors.FieldError
+
+	// toModel 转换为数据库模型，只在校验通过后调用
+	toModel() *MessageModel
+}
+
+// messageVariants 按 type 创建对应的请求模型，与 api.yaml 中 CreateMessageRequest 的 discriminator 映射一致
+var messageVariants = map[MessageType]func() messageVariant{
+	Sms:         func() messageVariant { return &SMSMessage{} },
+	Sitemessage: func() messageVariant { return &SiteMessage{} },
+	Broadcast:   func() messageVariant { return &BroadcastMessage{} },
+	Wechat:      func() messageVariant { return &WechatMessage{} },
+	Email:       func() messageVariant { return &EmailMessage{} },
+}
+
+// createRequest 解码并校验后的创建消息请求
+type createRequest struct {
+	Message                // 各类型共有的字段：内容、模板、定时发送和发送方
+	variant messageVariant // 按 type 解码的消息
+}
+
+// decodeCreateMessage 按 type 将请求体解码为对应的消息类型并校验字段。
+// 请求体不是 JSON 对象时返回 error；字段有误时返回全部字段错误，类型不符的字段在前
+func decodeCreateMessage(data []byte) (*createRequest, []errors.FieldError, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	if raw == nil {
+		return nil, nil, stderrors.New("请求体必须是 JSON 对象")
+	}
+
+	var body CreateMessageRequest
+	if err := body.UnmarshalJSON(data); err != nil {
+		return nil, nil, err
+	}
+	discriminator, err := body.Discriminator()
+	if err != nil {
+		return nil, []errors.FieldError{{Field: "type", Message: "消息类型必须是字符串"}}, nil
+	}
+	newVariant, ok := messageVariants[MessageType(discriminator)]
+	switch {
+	case discriminator == "":
+		return nil, []errors.FieldError{{Field: "type", Message: "缺少消息类型"}}, nil
+	case !ok:
+		return nil, []errors.FieldError{{Field: "type", Message: fmt.Sprintf("不支持的消息类型: %s", discriminator)}}, nil
+	}
+
+	req := &createRequest{variant: newVariant()}
+	fields := decodeFields(data, raw, req.variant)
+	// 共有字段在各类型中的定义相同，出错时已在上面报告
+	decodeFields(data, raw, &req.Message)
+
+	// 解码出错的字段取值不可信，不再报告其校验错误
+	invalid := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		invalid[topLevelField(f.Field)] = true
+	}
+	for _, f := range append(req.validate(), req.variant.validate()...) {
+		if !invalid[topLevelField(f.Field)] {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) > 0 {
+		return nil, fields, nil
+	}
+	return req, nil, nil
+}
+
+// maxCategoryLength 消息类别的最大长度，与 category 列一致
+const maxCategoryLength = 50
+
+// validate 校验各类型共有的字段：content 与 template_id 必须且只能提供一个，类别不能过长
+func (req *createRequest) validate() []errors.FieldError {
+	var fields []errors.FieldError
+	templateID := stringValue(req.TemplateId)
+	switch {
+	case req.TemplateId != nil && templateID == "":
+		fields = append(fields, errors.FieldError{Field: "template_id", Message: "无效的模板ID"})
+	case templateID != "" && req.Content != "":
+		fields = append(fields, errors.FieldError{Field: "content", Message: "content 与 template_id 不能同时提供"})
+	case templateID == "" && req.Content == "":
+		fields = append(fields, errors.FieldError{Field: "content", Message: "消息内容不能为空"})
+	}
+	if category := stringValue(req.Category); utf8.RuneCountInString(category) > maxCategoryLength {
+		fields = append(fields, errors.FieldError{Field: "category", Message: fmt.Sprintf("消息类别不能超过 %d 个字符", maxCategoryLength)})
+	}
+	return fields
+}
+
+// decodeFields 将 JSON 对象解码到 target：先整体解码，失败时逐个字段解码以找出所有类型不符的字段
+func decodeFields(data []byte, raw map[string]json.RawMessage, target any) []errors.FieldError {
+	if err := json.Unmarshal(data, target); err == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var fields []errors.FieldError
+	for _, key := range keys {
+		single, err := json.Marshal(map[string]json.RawMessage{key: raw[key]})
+		if err != nil {
+			fields = append(fields, errors.FieldError{Field: key, Message: "格式无效"})
+			continue
+		}
+		if err := json.Unmarshal(single, target); err != nil {
+			fields = append(fields, fieldError(key, err))
+		}
+	}
+	return fields
+}
+
+// fieldError 将字段 key 的解码错误转换为字段错误，嵌套字段使用解码器给出的路径
+func fieldError(key string, err error) errors.FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) {
+		field := key
+		if typeErr.Field != "" {
+			field = fieldPath(typeErr.Field)
+		}
+		return errors.FieldError{Field: field, Message: "必须是" + jsonTypeName(typeErr.Type)}
+	}
+	var parseErr *time.ParseError
+	if stderrors.As(err, &parseErr) {
+		return errors.FieldError{Field: key, Message: "必须是 RFC 3339 格式的时间"}
+	}
+	return errors.FieldError{Field: key, Message: "格式无效"}
+}
+
+// fieldPath 将解码器给出的路径 attachments.0.filename 转换为 attachments[0].filename
+func fieldPath(path string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// topLevelField 返回字段路径中的顶层字段名
+func topLevelField(field string) string {
+	if i := strings.IndexAny(field, ".["); i >= 0 {
+		return field[:i]
+	}
+	return field
+}
+
+// jsonTypeName 返回 Go 类型对应的 JSON 类型说明
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return " RFC 3339 格式的时间"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "字符串"
+	case reflect.Bool:
+		return "布尔值"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "整数"
+	case reflect.Float32, reflect.Float64:
+		return "数字"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return " base64 编码的字符串"
+		}
+		return "数组"
+	case reflect.Map, reflect.Struct:
+		return "对象"
+	default:
+		return "有效的值"
+	}
+}
+
+func (m *SMSMessage) validate() []errors.FieldError {
+	var fields []errors.FieldError
+	if m.PhoneNumber == "" {
+		fields = append(fields, errors.FieldError{Field: "phone_number", Message: "短信消息必须包含手机号"})
+	}
+	if tz := stringValue(m.RecipientTimezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			fields = append(fields, errors.FieldError{Field: "recipient_timezone", Message: fmt.Sprintf("无效的接收方时区: %s", tz)})
+		}
+	}
+	return fields
+}
+
+func (m *SMSMessage) toModel() *MessageModel { return FromSMS(*m) }
+
+func (m *SiteMessage) validate() []errors.FieldError {
+	if m.UserId <= 0 {
+		return []errors.FieldError{{Field: "user_id", Message: "站内消息必须包含用户ID"}}
+	}
+	return nil
+}
+
+func (m *SiteMessage) toModel() *MessageModel { return FromSiteMessage(*m) }
+
+func (m *BroadcastMessage) validate() []errors.FieldError {
+	if m.Channel != nil && !m.Channel.Valid() {
+		return []errors.FieldError{{Field: "channel", Message: fmt.Sprintf("无效的广播渠道: %s", *m.Channel)}}
+	}
+	return nil
+}
+
+func (m *BroadcastMessage) toModel() *MessageModel { return FromBroadcast(*m) }
+
+func (m *WechatMessage) validate() []errors.FieldError { return validateWechat(*m) }
+
+func (m *WechatMessage) toModel() *MessageModel { return FromWechat(*m) }
+
+func (m *EmailMessage) validate() []errors.FieldError { return validateEmail(m) }
+
+func (m *EmailMessage) toModel() *MessageModel { return FromEmail(*m) }
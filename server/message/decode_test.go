@@ -0,0 +1,123 @@
+package message
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+func TestDecodeCreateMessage(t *testing.T) {
+	t.Parallel()
+
+	req, fields, err := decodeCreateMessage([]byte(`{"type":"sms","content":"验证码","phone_number":"+86 138-0013-8000","urgent":true}`))
+	require.NoError(t, err)
+	require.Empty(t, fields)
+	sms, ok := req.variant.(*SMSMessage)
+	require.True(t, ok)
+	assert.True(t, *sms.Urgent)
+	assert.Equal(t, "验证码", req.Content)
+	model := req.variant.toModel()
+	assert.Equal(t, Sms, model.Type)
+	assert.Equal(t, "8613800138000", model.PhoneNumber)
+
+	req, fields, err = decodeCreateMessage([]byte(`{"type":"sitemessage","user_id":1,"template_id":"welcome","params":{"name":"Ann"},"send_at":"2099-01-01T00:00:00Z"}`))
+	require.NoError(t, err)
+	require.Empty(t, fields)
+	assert.IsType(t, &SiteMessage{}, req.variant)
+	assert.Equal(t, "Ann", (*req.Params)["name"])
+	require.NotNil(t, req.SendAt)
+
+	for _, body := range []string{``, `[]`, `null`, `{"type":`} {
+		_, _, err := decodeCreateMessage([]byte(body))
+		assert.Error(t, err, body)
+	}
+}
+
+func TestDecodeCreateMessageFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		body   string
+		fields []errors.FieldError
+	}{
+		{"缺少类型", `{"content":"x"}`, []errors.FieldError{{Field: "type", Message: "缺少消息类型"}}},
+		{"类型不是字符串", `{"type":1}`, []errors.FieldError{{Field: "type", Message: "消息类型必须是字符串"}}},
+		{"未知类型", `{"type":"fax","content":"x"}`, []errors.FieldError{{Field: "type", Message: "不支持的消息类型: fax"}}},
+		{"短信", `{"type":"sms","content":"x","recipient_timezone":"Mars/Base"}`, []errors.FieldError{
+			{Field: "phone_number", Message: "短信消息必须包含手机号"},
+			{Field: "recipient_timezone", Message: "无效的接收方时区: Mars/Base"},
+		}},
+		{"字段类型不符时不重复报告", `{"type":"sitemessage","content":"x","user_id":"7","urgent":1}`, []errors.FieldError{
+			{Field: "user_id", Message: "必须是整数"},
+		}},
+		{"多个字段类型不符", `{"type":"sms","phone_number":123,"send_at":"tomorrow","params":[]}`, []errors.FieldError{
+			{Field: "params", Message: "必须是对象"},
+			{Field: "phone_number", Message: "必须是字符串"},
+			{Field: "send_at", Message: "必须是 RFC 3339 格式的时间"},
+			{Field: "content", Message: "消息内容不能为空"},
+		}},
+		{"content 与模板", `{"type":"sitemessage","user_id":1,"content":"x","template_id":"welcome"}`, []errors.FieldError{
+			{Field: "content", Message: "content 与 template_id 不能同时提供"},
+		}},
+		{"广播渠道", `{"type":"broadcast","content":"x","channel":"sports"}`, []errors.FieldError{
+			{Field: "channel", Message: "无效的广播渠道: sports"},
+		}},
+		{"微信", `{"type":"wechat","content":"x","url":"ftp://example.com"}`, []errors.FieldError{
+			{Field: "openid", Message: "微信消息必须包含 openid"},
+			{Field: "wechat_template_id", Message: "微信消息必须包含 wechat_template_id"},
+			{Field: "url", Message: "无效的跳转链接"},
+		}},
+		{"邮件附件", `{"type":"email","content":"x","email":"a@example.com","subject":"hi",
+			"attachments":[{"filename":"ok.txt","content":"aGk="},{"filename":"../x","content_type":"bad"}]}`, []errors.FieldError{
+			{Field: "attachments[1].filename", Message: `无效的附件文件名: "../x"`},
+			{Field: "attachments[1].content", Message: "附件缺少内容"},
+			{Field: "attachments[1].content_type", Message: "无效的附件类型: bad"},
+		}},
+		{"嵌套字段类型不符", `{"type":"email","content":"x","email":"a@example.com","subject":"hi","attachments":[{"filename":1}]}`, []errors.FieldError{
+			{Field: "attachments[0].filename", Message: "必须是字符串"},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, fields, err := decodeCreateMessage([]byte(tc.body))
+			require.NoError(t, err)
+			assert.Nil(t, req)
+			assert.Equal(t, tc.fields, fields)
+		})
+	}
+}
+
+func TestCreateMessageFieldErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	resp := postMessage(r, `{"type":"email","content":"x","subject":"a\nb"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	var body errors.APIError
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, []errors.FieldError{
+		{Field: "email", Message: "邮件必须包含收件人邮箱"},
+		{Field: "subject", Message: "邮件主题不能包含换行"},
+	}, body.Fields)
+
+	// 模板不存在时指向 template_id
+	resp = postMessage(r, `{"type":"sitemessage","user_id":1,"template_id":"missing"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	body = errors.APIError{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	require.Len(t, body.Fields, 1)
+	assert.Equal(t, "template_id", body.Fields[0].Field)
+
+	resp = postMessage(r, `not json`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	body = errors.APIError{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Empty(t, body.Fields)
+}
@@ -0,0 +1,209 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/queue"
+)
+
+// DeliverJobKind 投递任务在队列中的类型
+const DeliverJobKind = "message.deliver"
+
+// deliverPayload 投递任务的负载，只保存消息 ID，执行时读取最新的消息
+type deliverPayload struct {
+	MessageID int64 `json:"message_id"`
+}
+
+// dispatch 投递新建的消息：启用队列时入队异步发送，请求不等待通道响应；否则同步发送
+// 只有入队失败会返回错误
+func (h *Server) dispatch(ctx context.Context, model *MessageModel) error {
+	if h.queue != nil {
+		_, err := h.queue.Enqueue(ctx, DeliverJobKind, deliverPayload{MessageID: model.ID})
+		return err
+	}
+	h.deliver(ctx, model)
+	return nil
+}
+
+// launch 投递新建的消息并推送给在线用户，带频道的广播同时为频道的订阅者生成站内信
+// 接收方的通知偏好不接收该类消息时不投递也不推送；消息已不是 sending（如重复中继）时同样跳过
+func (h *Server) launch(ctx context.Context, model *MessageModel) error {
+	if model.Status == Sending && h.admit(ctx, model) {
+		if err := h.dispatch(ctx, model); err != nil {
+			return fmt.Errorf("enqueue message: %w", err)
+		}
+		h.publish(model)
+	}
+
+	if model.Type == Broadcast && model.Channel != "" {
+		if err := h.startFanout(ctx, model); err != nil {
+			return fmt.Errorf("start fanout: %w", err)
+		}
+	}
+	return nil
+}
+
+// deliver 在请求中同步投递消息，成功后标记为 sent，失败标记为 failed
+// 投递失败不影响消息的创建
+func (h *Server) deliver(ctx context.Context, model *MessageModel) {
+	if h.providers == nil {
+		return
+	}
+
+	// 与投递任务一样以最新状态为准，重复投递（如中继在投递后、删除发件箱记录前崩溃）时不再发送
+	current, err := h.messages.FindByID(ctx, model.ID)
+	if err != nil {
+		h.logger.Error("查询消息失败", "id", model.ID, "error", err)
+		return
+	}
+	if current.Status != Sending {
+		*model = *current
+		return
+	}
+
+	to, reason := Sent, "delivered"
+	receipt, err := h.providers.Send(ctx, model)
+	if err != nil {
+		h.logger.Warn("投递消息失败", "id", model.ID, "type", model.Type, "error", err)
+		to, reason = Failed, err.Error()
+	}
+	h.saveReceipt(ctx, model.ID, receipt)
+
+	updated, err := h.messages.Transition(ctx, model.ID, to, reason)
+	if err != nil {
+		h.logger.Error("更新消息状态失败", "id", model.ID, "status", to, "error", err)
+		return
+	}
+	*model = *updated
+}
+
+// deliverJob 队列中投递任务的处理函数，返回错误时由队列按退避时间重试
+func (h *Server) deliverJob(ctx context.Context, job *queue.Job) error {
+	var payload deliverPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	model, err := h.messages.FindByID(ctx, payload.MessageID)
+	if stderrors.Is(err, ErrMessageNotFound) {
+		// 投递前已被用户删除
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// 重复执行（例如租约过期后被重新领取）时不再发送
+	if model.Status != Sending {
+		return nil
+	}
+	if h.providers == nil {
+		return nil
+	}
+
+	receipt, err := h.providers.Send(ctx, model)
+	if err != nil {
+		// 最后一次尝试也失败时标记为 failed，任务随后进入死信表
+		if job.Attempts >= job.MaxAttempts {
+			h.saveReceipt(ctx, model.ID, receipt)
+			if _, terr := h.messages.Transition(ctx, model.ID, Failed, err.Error()); terr != nil {
+				h.logger.Error("更新消息状态失败", "id", model.ID, "status", Failed, "error", terr)
+			}
+		}
+		return err
+	}
+	h.saveReceipt(ctx, model.ID, receipt)
+	_, err = h.messages.Transition(ctx, model.ID, Sent, "delivered")
+	return err
+}
+
+// saveReceipt 记录投递回执，没有选出通道时跳过；投递失败时只记录通道名称，用于按通道统计
+// 消息已经发出，保存失败只记录日志，不能因此重新发送
+func (h *Server) saveReceipt(ctx context.Context, id int64, receipt Receipt) {
+	if receipt.Provider == "" {
+		return
+	}
+	if err := h.messages.SaveReceipt(ctx, id, receipt); err != nil {
+		h.logger.Error("保存投递回执失败", "id", id, "provider", receipt.Provider, "error", err)
+	}
+}
+
+// ListFailedDeliveries 列出超过最大重试次数的投递任务
+func (h *Server) ListFailedDeliveries(ctx context.Context, request ListFailedDeliveriesRequestObject) (ListFailedDeliveriesResponseObject, error) {
+	if h.queue == nil {
+		return nil, errors.New(http.StatusNotImplemented, "未启用投递队列")
+	}
+
+	dead, err := h.queue.ListDead(ctx, DeliverJobKind)
+	if err != nil {
+		return nil, errors.InternalServer("查询投递失败的消息失败")
+	}
+
+	failed := make([]FailedDelivery, 0, len(dead))
+	for i := range dead {
+		var payload deliverPayload
+		if err := dead[i].Decode(&payload); err != nil {
+			h.logger.Warn("无法解析死信负载", "id", dead[i].ID, "error", err)
+		}
+
+		item := FailedDelivery{
+			Id:        dead[i].ID,
+			MessageId: payload.MessageID,
+			Attempts:  dead[i].Attempts,
+			LastError: dead[i].LastError,
+			FailedAt:  dead[i].FailedAt,
+		}
+		if model, err := h.messages.FindByID(ctx, payload.MessageID); err == nil {
+			msg := model.ToAPI()
+			item.Message = &msg
+		}
+		failed = append(failed, item)
+	}
+
+	return ListFailedDeliveries200JSONResponse{Failed: &failed}, nil
+}
+
+// RequeueFailedDelivery 将投递失败的消息重新放回队列
+func (h *Server) RequeueFailedDelivery(ctx context.Context, request RequeueFailedDeliveryRequestObject) (RequeueFailedDeliveryResponseObject, error) {
+	if h.queue == nil {
+		return nil, errors.New(http.StatusNotImplemented, "未启用投递队列")
+	}
+
+	// 只允许重新投递消息任务，其他模块的死信不经由这里处理
+	dead, err := h.queue.FindDead(ctx, request.Id)
+	if err == nil && dead.Kind != DeliverJobKind {
+		err = queue.ErrNotFound
+	}
+	if err != nil {
+		if stderrors.Is(err, queue.ErrNotFound) {
+			return nil, errors.NotFound("死信不存在")
+		}
+		return nil, errors.InternalServer("查询死信失败")
+	}
+
+	// 重新投递前把消息恢复为 sending，否则投递任务会跳过它
+	var payload deliverPayload
+	if err := dead.Decode(&payload); err != nil {
+		return nil, errors.InternalServer("无法解析死信")
+	}
+	if _, err := h.messages.Transition(ctx, payload.MessageID, Sending, "requeued"); err != nil {
+		return nil, transitionError(err)
+	}
+
+	job, err := h.queue.Requeue(ctx, request.Id)
+	if err != nil {
+		if stderrors.Is(err, queue.ErrNotFound) {
+			return nil, errors.NotFound("死信不存在")
+		}
+		return nil, errors.InternalServer("重新入队失败")
+	}
+
+	return RequeueFailedDelivery202JSONResponse{
+		Id:        job.ID,
+		MessageId: payload.MessageID,
+		RunAt:     job.RunAt,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+	"github.com/twotwo/go-blueprint/pkg/queue"
+)
+
+func setupQueueRouter(t *testing.T, registry *Registry) (*chi.Mux, *Server, *queue.Queue) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(queue.Models()...))
+
+	deps := module.Deps{DB: db}
+	q := queue.New(deps, queue.Config{MaxAttempts: 2, BaseBackoff: time.Nanosecond})
+	h := New(deps, Config{Providers: registry, Queue: q})
+
+	r := chi.NewRouter()
+	h.Routes(r)
+	return r, h, q
+}
+
+func authed(req *http.Request) *http.Request {
+	req.Header.Set("Authorization", testToken)
+	return req
+}
+
+func TestCreateMessageEnqueuesDelivery(t *testing.T) {
+	t.Parallel()
+
+	loopback := NewLoopback(nil)
+	registry := NewRegistry()
+	registry.Register(Sitemessage, loopback)
+	r, h, q := setupQueueRouter(t, registry)
+	ctx := context.Background()
+
+	resp := postMessage(r, `{"type":"sitemessage","content":"异步","user_id":5}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var site SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &site))
+	assert.Equal(t, Sending, site.Status)
+	assert.Empty(t, loopback.Sent(), "请求中不应同步投递")
+
+	processed, err := q.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	require.Len(t, loopback.Sent(), 1)
+
+	model, err := h.messages.FindByID(ctx, site.Id)
+	require.NoError(t, err)
+	assert.Equal(t, Sent, model.Status)
+}
+
+func TestFailedDeliveryRequeue(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Register(Sms, NewHTTPProvider(HTTPProviderConfig{URL: newFakeGateway(t, http.StatusServiceUnavailable).URL}))
+	r, h, q := setupQueueRouter(t, registry)
+	ctx := context.Background()
+
+	resp := postMessage(r, `{"type":"sms","content":"重试","phone_number":"8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+
+	// MaxAttempts=2，退避时间极短，两次执行后进入死信表
+	processed, err := q.RunOnce(ctx)
+	require.NoError(t, err)
+	require.True(t, processed)
+	time.Sleep(time.Millisecond)
+	processed, err = q.RunOnce(ctx)
+	require.NoError(t, err)
+	require.True(t, processed)
+
+	// 列出死信需要认证
+	list := httptest.NewRecorder()
+	r.ServeHTTP(list, httptest.NewRequest(http.MethodGet, "/message/failed", nil))
+	assert.Equal(t, http.StatusUnauthorized, list.Code)
+
+	list = httptest.NewRecorder()
+	r.ServeHTTP(list, authed(httptest.NewRequest(http.MethodGet, "/message/failed", nil)))
+	require.Equal(t, http.StatusOK, list.Code)
+
+	var failed FailedDeliveryList
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &failed))
+	require.Len(t, *failed.Failed, 1)
+	item := (*failed.Failed)[0]
+	assert.Equal(t, sms.Id, item.MessageId)
+	assert.Equal(t, 2, item.Attempts)
+	assert.Contains(t, item.LastError, "503")
+	require.NotNil(t, item.Message)
+	assert.Equal(t, Failed, item.Message.Status)
+
+	// 网关恢复后重新投递
+	loopback := NewLoopback(nil)
+	registry.Register(Sms, loopback)
+
+	requeue := httptest.NewRecorder()
+	r.ServeHTTP(requeue, authed(httptest.NewRequest(http.MethodPost, fmt.Sprintf("/message/failed/%d/requeue", item.Id), nil)))
+	require.Equal(t, http.StatusAccepted, requeue.Code)
+	var job DeliveryJob
+	require.NoError(t, json.Unmarshal(requeue.Body.Bytes(), &job))
+	assert.Equal(t, sms.Id, job.MessageId)
+
+	processed, err = q.RunOnce(ctx)
+	require.NoError(t, err)
+	require.True(t, processed)
+	require.Len(t, loopback.Sent(), 1)
+
+	model, err := h.messages.FindByID(ctx, sms.Id)
+	require.NoError(t, err)
+	assert.Equal(t, Sent, model.Status)
+
+	requeue = httptest.NewRecorder()
+	r.ServeHTTP(requeue, authed(httptest.NewRequest(http.MethodPost, fmt.Sprintf("/message/failed/%d/requeue", item.Id), nil)))
+	assert.Equal(t, http.StatusNotFound, requeue.Code)
+}
+
+func TestFailedDeliveriesWithoutQueue(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, authed(httptest.NewRequest(http.MethodGet, "/message/failed", nil)))
+	assert.Equal(t, http.StatusNotImplemented, resp.Code)
+}
@@ -0,0 +1,90 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/queue"
+)
+
+// FanoutJobKind 广播扇出任务在队列中的类型
+const FanoutJobKind = "message.fanout"
+
+// DefaultFanoutBatchSize 广播扇出每批生成的默认站内信数
+const DefaultFanoutBatchSize = 500
+
+// fanoutPayload 扇出任务的负载，进度保存在扇出任务表中
+type fanoutPayload struct {
+	BroadcastID int64 `json:"broadcast_id"`
+}
+
+// startFanout 为带频道的广播创建扇出任务
+// 启用队列时交给 worker 分批执行，否则在请求中执行完所有批次；生成的站内信都由发件箱中继投递
+func (h *Server) startFanout(ctx context.Context, broadcast *MessageModel) error {
+	if _, err := h.messages.CreateFanout(ctx, broadcast); err != nil {
+		return err
+	}
+	if h.queue != nil {
+		_, err := h.queue.Enqueue(ctx, FanoutJobKind, fanoutPayload{BroadcastID: broadcast.ID})
+		return err
+	}
+	for {
+		fanout, err := h.fanoutBatch(ctx, broadcast.ID)
+		if err != nil {
+			return err
+		}
+		if fanout.Status == FanoutCompleted {
+			return nil
+		}
+	}
+}
+
+// fanoutCategory 扇出的站内信的类别：沿用广播的类别，未指定时以频道为类别，
+// 用户可以在通知偏好中按频道选择是否接收
+func (m *MessageModel) fanoutCategory() string {
+	if m.Category != "" {
+		return m.Category
+	}
+	return m.Channel
+}
+
+// fanoutBatch 执行一批扇出，返回更新后的任务
+// 本批站内信与进度在同一事务中写入发件箱，由 RunOutboxRelay 投递和推送，推进进度后崩溃也不会漏发
+func (h *Server) fanoutBatch(ctx context.Context, broadcastID int64) (*FanoutModel, error) {
+	_, fanout, err := h.messages.FanoutBatch(ctx, broadcastID, h.batchSize)
+	return fanout, err
+}
+
+// fanoutJob 队列中扇出任务的处理函数：每个任务执行一批，未完成时为下一批入队新任务
+// 进度保存在扇出任务表中，任务重试或重复执行都从上次的位置继续
+func (h *Server) fanoutJob(ctx context.Context, job *queue.Job) error {
+	var payload fanoutPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	fanout, err := h.fanoutBatch(ctx, payload.BroadcastID)
+	if err != nil {
+		return err
+	}
+	if fanout.Status == FanoutCompleted {
+		h.logger.Info("广播扇出完成", "broadcast", fanout.BroadcastID, "delivered", fanout.Delivered)
+		return nil
+	}
+	_, err = h.queue.Enqueue(ctx, FanoutJobKind, payload)
+	return err
+}
+
+// GetBroadcastFanout 查询广播的扇出进度
+func (h *Server) GetBroadcastFanout(ctx context.Context, request GetBroadcastFanoutRequestObject) (GetBroadcastFanoutResponseObject, error) {
+	fanout, err := h.messages.FindFanout(ctx, request.Id)
+	if err != nil {
+		if stderrors.Is(err, ErrFanoutNotFound) {
+			return nil, errors.NotFound("广播没有扇出任务")
+		}
+		return nil, errors.InternalServer("查询扇出进度失败")
+	}
+
+	return GetBroadcastFanout200JSONResponse(fanout.ToAPI()), nil
+}
@@ -0,0 +1,195 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// subscribe 通过接口设置订阅偏好
+func subscribe(t *testing.T, r http.Handler, method, channel string, uid int64) Subscription {
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, authed(httptest.NewRequest(method, fmt.Sprintf("/message/channels/%s/subscribers/%d", channel, uid), nil)))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var sub Subscription
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sub))
+	return sub
+}
+
+// getFanout 查询广播的扇出进度
+func getFanout(t *testing.T, r http.Handler, id int64) Fanout {
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/message/%d/fanout", id), nil))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var fanout Fanout
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &fanout))
+	return fanout
+}
+
+func TestChannelSubscriptionHandlers(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	for uid := int64(1); uid <= 3; uid++ {
+		sub := subscribe(t, r, http.MethodPut, "news", uid)
+		assert.True(t, sub.Subscribed)
+		assert.Equal(t, "news", sub.Channel)
+	}
+	sub := subscribe(t, r, http.MethodDelete, "news", 2)
+	assert.False(t, sub.Subscribed)
+
+	// 分页列出订阅中的用户
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/message/channels/news/subscribers?limit=1", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	var list SubscriberList
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Equal(t, int64(2), list.Total)
+	require.Len(t, list.Subscribers, 1)
+	assert.Equal(t, int64(1), list.Subscribers[0].UserId)
+	require.NotNil(t, list.NextAfter)
+
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/message/channels/news/subscribers?after=%d", *list.NextAfter), nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	list = SubscriberList{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	require.Len(t, list.Subscribers, 1)
+	assert.Equal(t, int64(3), list.Subscribers[0].UserId)
+	assert.Nil(t, list.NextAfter)
+
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/message/channels/news/subscribers/2", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/message/channels/alert/subscribers/2", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   bool
+		want   int
+	}{
+		{"未知频道", http.MethodGet, "/message/channels/sports/subscribers", false, http.StatusBadRequest},
+		{"无效的 limit", http.MethodGet, "/message/channels/news/subscribers?limit=0", false, http.StatusBadRequest},
+		{"无效的用户ID", http.MethodPut, "/message/channels/news/subscribers/abc", true, http.StatusBadRequest},
+		{"订阅需要认证", http.MethodPut, "/message/channels/news/subscribers/9", false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth {
+				authed(req)
+			}
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
+
+func TestBroadcastFanout(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t)}, Config{FanoutBatchSize: 2}).Routes(r)
+	for uid := int64(1); uid <= 5; uid++ {
+		subscribe(t, r, http.MethodPut, "alert", uid)
+	}
+	subscribe(t, r, http.MethodDelete, "alert", 4)
+
+	resp := postMessage(r, `{"type":"broadcast","content":"系统维护通知","channel":"alert"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var broadcast BroadcastMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &broadcast))
+
+	// 未启用队列时在请求中完成扇出，退订的用户收不到
+	fanout := getFanout(t, r, broadcast.Id)
+	assert.Equal(t, FanoutCompleted, fanout.Status)
+	assert.Equal(t, int64(4), fanout.Total)
+	assert.Equal(t, int64(4), fanout.Delivered)
+	assert.NotNil(t, fanout.CompletedAt)
+
+	for uid, want := range map[int64]int{1: 1, 4: 0, 5: 1} {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/message/sitemessage/%d", uid), nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		var list MessageListResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+		require.Len(t, *list.Messages, want, "user %d", uid)
+		if want > 0 {
+			assert.Equal(t, "系统维护通知", (*list.Messages)[0].Content)
+		}
+	}
+
+	// 不带频道的广播没有扇出任务
+	resp = postMessage(r, `{"type":"broadcast","content":"无频道"}`)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &broadcast))
+	missing := httptest.NewRecorder()
+	r.ServeHTTP(missing, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/message/%d/fanout", broadcast.Id), nil))
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestBroadcastFanoutQueued(t *testing.T) {
+	t.Parallel()
+
+	loopback := NewLoopback(nil)
+	registry := NewRegistry()
+	registry.Register(Broadcast, loopback)
+	registry.Register(Sitemessage, loopback)
+	r, h, q := setupQueueRouter(t, registry)
+	h.batchSize = 2
+	ctx := context.Background()
+
+	for uid := int64(1); uid <= 5; uid++ {
+		subscribe(t, r, http.MethodPut, "news", uid)
+	}
+	resp := postMessage(r, `{"type":"broadcast","content":"周报","channel":"news"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var broadcast BroadcastMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &broadcast))
+
+	fanout := getFanout(t, r, broadcast.Id)
+	assert.Equal(t, FanoutRunning, fanout.Status)
+	assert.Equal(t, int64(0), fanout.Delivered)
+
+	runQueue := func() {
+		for {
+			processed, err := q.RunOnce(ctx)
+			require.NoError(t, err)
+			if !processed {
+				break
+			}
+		}
+	}
+
+	// 依次执行广播投递和三批扇出，站内信写入发件箱，由中继为五条站内信创建投递任务
+	runQueue()
+	assert.Len(t, loopback.Sent(), 1)
+	relayed, err := h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, relayed)
+	runQueue()
+
+	fanout = getFanout(t, r, broadcast.Id)
+	assert.Equal(t, FanoutCompleted, fanout.Status)
+	assert.Equal(t, int64(5), fanout.Delivered)
+	assert.Len(t, loopback.Sent(), 6)
+
+	found, err := h.messages.FindByUserID(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, Sent, found[0].Status)
+	assert.Equal(t, broadcast.Id, found[0].BroadcastID)
+}
@@ -1,211 +1,274 @@
 package message
 
 import (
-	"encoding/json"
+	"context"
+	stderrors "errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
-
-	"github.com/go-chi/chi/v5"
+	"time"
 
 	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/idempotency"
+	"github.com/twotwo/go-blueprint/pkg/lease"
+	"github.com/twotwo/go-blueprint/pkg/module"
+	"github.com/twotwo/go-blueprint/pkg/pubsub"
+	"github.com/twotwo/go-blueprint/pkg/queue"
 )
 
-// CreateMessage 处理创建消息的请求
-func CreateMessage(w http.ResponseWriter, r *http.Request) {
-	var messageData map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&messageData); err != nil {
-		apiErr := errors.BadRequest("无效的请求体")
-		errors.WriteJSON(w, apiErr)
-		return
-	}
+// Server 消息资源的 HTTP 处理器
+type Server struct {
+	messages  MessageRepository
+	providers *Registry
+	queue     *queue.Queue
+	logger    *slog.Logger
+	clock     module.Clock
+	hub       *pubsub.Hub[MessageModel]
+	heartbeat time.Duration
+	batchSize int // 广播扇出每批生成的站内信数
 
-	// 根据类型确定消息模型
-	messageType, ok := messageData["type"].(string)
-	if !ok {
-		apiErr := errors.BadRequest("缺少消息类型或类型无效")
-		errors.WriteJSON(w, apiErr)
-		return
-	}
+	// leases 调度器和发件箱中继选主使用的租约，为 nil 时不选主
+	leases            *lease.Manager
+	schedulerInterval time.Duration
+	outboxInterval    time.Duration
 
-	var responseMsg interface{}
+	idempotency *idempotency.Store
 
-	switch MessageType(messageType) {
-	case Sms:
-		var msg SMSMessage
-		jsonData, err := json.Marshal(messageData)
-		if err != nil {
-			apiErr := errors.InternalServer("序列化请求数据失败")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+	otp OTPConfig
 
-		if err := json.Unmarshal(jsonData, &msg); err != nil {
-			apiErr := errors.BadRequest("无效的短信消息格式")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+	rateLimits []RateLimit
+	quietHours QuietHours
 
-		if msg.PhoneNumber == "" {
-			apiErr := errors.BadRequest("短信消息必须包含手机号")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+	preferences PreferenceFunc
+	login       LoginFunc
 
-		// 这里可以添加创建短信消息的业务逻辑
-		msg.Id = getNextID() // 模拟ID生成
-		msg.Status = Sending // 设置初始状态
-		responseMsg = msg
+	// callbackSecrets 各通道投递回执的签名密钥
+	callbackSecrets map[string]string
+}
 
-	case Sitemessage:
-		var msg SiteMessage
-		jsonData, err := json.Marshal(messageData)
-		if err != nil {
-			apiErr := errors.InternalServer("序列化请求数据失败")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+// NewServer 使用指定的仓储创建消息处理器
+func NewServer(messages MessageRepository, deps module.Deps, cfg Config) *Server {
+	deps = deps.WithDefaults()
+	cfg = cfg.withDefaults()
+	h := &Server{
+		messages:  messages,
+		providers: cfg.Providers,
+		queue:     cfg.Queue,
+		logger:    deps.Logger,
+		clock:     deps.Clock,
+		hub:       cfg.Hub,
+		heartbeat: cfg.StreamHeartbeat,
+		batchSize: cfg.FanoutBatchSize,
 
-		if err := json.Unmarshal(jsonData, &msg); err != nil {
-			apiErr := errors.BadRequest("无效的站内消息格式")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+		leases:            cfg.Leases,
+		schedulerInterval: cfg.SchedulerInterval,
+		outboxInterval:    cfg.OutboxInterval,
 
-		if msg.UserId == 0 {
-			apiErr := errors.BadRequest("站内消息必须包含用户ID")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+		idempotency: cfg.Idempotency,
+
+		otp: cfg.OTP,
+
+		rateLimits: cfg.RateLimits,
+		quietHours: cfg.QuietHours,
+
+		preferences: cfg.Preferences,
+		login:       cfg.Login,
+
+		callbackSecrets: cfg.CallbackSecrets,
+	}
+	if h.queue != nil {
+		h.queue.Handle(DeliverJobKind, h.deliverJob)
+		h.queue.Handle(FanoutJobKind, h.fanoutJob)
+	}
+	return h
+}
 
-		// 这里可以添加创建站内消息的业务逻辑
-		msg.Id = getNextID() // 模拟ID生成
-		msg.Status = Sending // 设置初始状态
-		responseMsg = msg
+// CreateMessage 处理创建消息的请求
+func (h *Server) CreateMessage(ctx context.Context, request CreateMessageRequestObject) (CreateMessageResponseObject, error) {
+	data, err := request.Body.MarshalJSON()
+	if err != nil {
+		return nil, errors.BadRequest("无效的请求体")
+	}
+	req, fields, err := decodeCreateMessage(data)
+	if err != nil {
+		return nil, errors.BadRequest("无效的请求体")
+	}
+	if len(fields) > 0 {
+		return nil, errors.Validation(fields...)
+	}
 
-	case Broadcast:
-		var msg BroadcastMessage
-		jsonData, err := json.Marshal(messageData)
+	// 设置初始状态并保存，ID 由存储层生成
+	model := req.variant.toModel()
+	model.Status = Sending
+
+	// 使用模板时由模板渲染 content
+	if req.TemplateId != nil {
+		content, err := h.applyTemplate(ctx, req, model.Type, stringValue(request.Params.AcceptLanguage))
 		if err != nil {
-			apiErr := errors.InternalServer("序列化请求数据失败")
-			errors.WriteJSON(w, apiErr)
-			return
+			return nil, err
 		}
+		model.Content = content
+		model.TemplateID = *req.TemplateId
+	}
 
-		if err := json.Unmarshal(jsonData, &msg); err != nil {
-			apiErr := errors.BadRequest("无效的广播消息格式")
-			errors.WriteJSON(w, apiErr)
-			return
+	// quietLoc 需要检查免打扰时段的短信接收方时区，为 nil 时立即发送
+	var quietLoc *time.Location
+	if sms, ok := req.variant.(*SMSMessage); ok && h.quietHours.Enabled() && (sms.Urgent == nil || !*sms.Urgent) {
+		if quietLoc, err = h.quietHours.location(stringValue(sms.RecipientTimezone)); err != nil {
+			h.logger.Error("加载免打扰时区失败", "error", err)
+			return nil, errors.InternalServer("加载免打扰时区失败")
 		}
+	}
+
+	// 定时发送：保存任务，到期时由调度器生成消息
+	schedule, err := h.scheduleRequest(req.Message, model)
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求校验通过后才计入发送频率，无效的请求不占用额度
+	if err := h.consumeRateLimits(ctx, model); err != nil {
+		return nil, err
+	}
 
-		// 验证 Channel 是否有效
-		if msg.Channel != nil {
-			channel := *msg.Channel
-			if channel != News && channel != Alert && channel != Promotion {
-				apiErr := errors.BadRequest("无效的广播渠道")
-				errors.WriteJSON(w, apiErr)
-				return
+	// 免打扰时段内的非紧急短信不丢弃，转为在时段结束时发送的定时任务
+	if schedule == nil && quietLoc != nil {
+		now := h.clock.Now()
+		if until, quiet := h.quietHours.DeferUntil(now, quietLoc); quiet {
+			if schedule, err = NewSchedule(model, &until, "", "", now); err != nil {
+				h.logger.Error("推迟短信失败", "error", err)
+				return nil, errors.InternalServer("推迟短信失败")
 			}
 		}
+	}
+	if schedule != nil {
+		if err := h.messages.CreateSchedule(ctx, schedule); err != nil {
+			h.logger.Error("保存定时任务失败", "type", schedule.Type, "error", err)
+			return nil, errors.InternalServer("保存定时任务失败")
+		}
+		return CreateMessage202JSONResponse(schedule.ToAPI()), nil
+	}
 
-		// 这里可以添加创建广播消息的业务逻辑
-		msg.Id = getNextID() // 模拟ID生成
-		msg.Status = Sending // 设置初始状态
-		responseMsg = msg
+	if err := h.messages.Create(ctx, model); err != nil {
+		h.logger.Error("保存消息失败", "type", model.Type, "error", err)
+		return nil, errors.InternalServer("保存消息失败")
+	}
 
-	default:
-		apiErr := errors.BadRequest(fmt.Sprintf("不支持的消息类型: %s", messageType))
-		errors.WriteJSON(w, apiErr)
-		return
+	if err := h.launch(ctx, model); err != nil {
+		h.logger.Error("投递消息失败", "id", model.ID, "error", err)
+		return nil, errors.InternalServer("投递消息失败")
 	}
 
-	// 返回成功响应
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(responseMsg)
+	typed, err := model.ToTypedMessage()
+	if err != nil {
+		return nil, err
+	}
+	return CreateMessage201JSONResponse(typed), nil
 }
 
 // FindMessagesByNumber 根据手机号查询短信消息
-func FindMessagesByNumber(w http.ResponseWriter, r *http.Request) {
-	numberParam := chi.URLParam(r, "number")
-
-	// 验证手机号
-	_, err := strconv.ParseInt(numberParam, 10, 64)
+func (h *Server) FindMessagesByNumber(ctx context.Context, request FindMessagesByNumberRequestObject) (FindMessagesByNumberResponseObject, error) {
+	models, err := h.messages.FindByPhoneNumber(ctx, strconv.FormatInt(request.Number, 10))
 	if err != nil {
-		apiErr := errors.BadRequest("无效的手机号格式")
-		errors.WriteJSON(w, apiErr)
-		return
-	}
-
-	// 这里应该添加根据手机号查询数据的逻辑
-	// 模拟数据
-	messages := []Message{
-		{
-			Id:      1,
-			Content: "验证码：929253，有效期10分钟。如非本人操作，请忽略。",
-			Type:    Sms,
-			Status:  Sent,
-		},
-		{
-			Id:      2,
-			Content: "您的订单已发货，物流单号：SF12345678",
-			Type:    Sms,
-			Status:  Sent,
-		},
-	}
-
-	response := MessageListResponse{
-		Messages: &messages,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+		return nil, errors.InternalServer("查询短信失败")
+	}
+	messages := toAPIList(models)
+
+	return FindMessagesByNumber200JSONResponse{Messages: &messages}, nil
 }
 
-// FindMessagesByUID 根据用户ID查询站内消息
-func FindMessagesByUID(w http.ResponseWriter, r *http.Request) {
-	uidParam := chi.URLParam(r, "uid")
+// FindMessagesByUID 根据用户ID查询站内消息，支持按状态、归档过滤和分页
+func (h *Server) FindMessagesByUID(ctx context.Context, request FindMessagesByUIDRequestObject) (FindMessagesByUIDResponseObject, error) {
+	filter, err := inboxFilter(request.Params)
+	if err != nil {
+		return nil, err
+	}
 
-	// 验证用户ID
-	uid, err := strconv.ParseInt(uidParam, 10, 64)
+	// 多取一条判断是否还有下一页
+	limit := filter.Limit
+	filter.Limit++
+	models, err := h.messages.ListInbox(ctx, request.Uid, filter)
 	if err != nil {
-		apiErr := errors.BadRequest("无效的用户ID格式")
-		errors.WriteJSON(w, apiErr)
-		return
+		return nil, errors.InternalServer("查询站内消息失败")
 	}
 
-	// 这里应该添加根据用户ID查询数据的逻辑
-	// 模拟数据
-	messages := []Message{
-		{
-			Id:      3,
-			Content: "您的账户已成功升级为VIP会员",
-			Type:    Sitemessage,
-			Status:  Read,
-		},
-		{
-			Id:      4,
-			Content: "系统将于明日凌晨2点进行维护，预计持续2小时",
-			Type:    Sitemessage,
-			Status:  Received,
-		},
+	response := FindMessagesByUID200JSONResponse{Messages: make([]SiteMessage, 0, len(models))}
+	if len(models) > limit {
+		models = models[:limit]
+		next := models[len(models)-1].ID
+		response.NextAfter = &next
+	}
+	for i := range models {
+		response.Messages = append(response.Messages, models[i].ToSiteMessage())
 	}
+	return response, nil
+}
 
-	// 打印用户ID，确保被正确解析（实际生产环境可删除）
-	fmt.Printf("查询用户ID: %d\n", uid)
+// toAPIList 将数据库模型列表转换为API模型列表，空结果返回空数组而不是 null
+func toAPIList(models []MessageModel) []Message {
+	messages := make([]Message, 0, len(models))
+	for i := range models {
+		messages = append(messages, models[i].ToAPI())
+	}
+	return messages
+}
 
-	response := MessageListResponse{
-		Messages: &messages,
+// UpdateMessageStatus 按状态机更新消息状态，供投递通道回调使用
+func (h *Server) UpdateMessageStatus(ctx context.Context, request UpdateMessageStatusRequestObject) (UpdateMessageStatusResponseObject, error) {
+	update := request.Body
+	if !update.Status.Valid() {
+		return nil, errors.BadRequest(fmt.Sprintf("无效的消息状态: %s", update.Status))
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	reason := "callback"
+	if update.Reason != nil && *update.Reason != "" {
+		reason = *update.Reason
+	}
+
+	model, err := h.messages.Transition(ctx, request.Id, update.Status, reason)
+	if err != nil {
+		return nil, transitionError(err)
+	}
+
+	typed, err := model.ToTypedMessage()
+	if err != nil {
+		return nil, err
+	}
+	return UpdateMessageStatus200JSONResponse(typed), nil
 }
 
-// 生成下一个消息ID的辅助函数（实际应用中应该由数据库生成）
-var lastID int64 = 100
+// ListMessageTransitions 查询消息的状态流转记录
+func (h *Server) ListMessageTransitions(ctx context.Context, request ListMessageTransitionsRequestObject) (ListMessageTransitionsResponseObject, error) {
+	if _, err := h.messages.FindByID(ctx, request.Id); err != nil {
+		if stderrors.Is(err, ErrMessageNotFound) {
+			return nil, errors.NotFound("消息不存在")
+		}
+		return nil, errors.InternalServer("查询消息失败")
+	}
+
+	records, err := h.messages.ListTransitions(ctx, request.Id)
+	if err != nil {
+		return nil, errors.InternalServer("查询状态流转记录失败")
+	}
+
+	transitions := make([]StatusTransition, 0, len(records))
+	for i := range records {
+		transitions = append(transitions, records[i].ToAPI())
+	}
+	return ListMessageTransitions200JSONResponse{Transitions: &transitions}, nil
+}
 
-func getNextID() int64 {
-	lastID++
-	return lastID
+// transitionError 将状态流转的错误映射为 API 错误
+func transitionError(err error) error {
+	var transitionErr *TransitionError
+	switch {
+	case stderrors.Is(err, ErrMessageNotFound):
+		return errors.NotFound("消息不存在")
+	case stderrors.As(err, &transitionErr):
+		return errors.New(http.StatusConflict,
+			fmt.Sprintf("不允许的状态流转: %s -> %s", transitionErr.From, transitionErr.To))
+	default:
+		return errors.InternalServer("更新消息状态失败")
+	}
 }
@@ -0,0 +1,152 @@
+package message
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/idempotency"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+const testToken = "Bearer test-token-123456"
+
+func setupRouter(t *testing.T) *chi.Mux {
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t)}, Config{}).Routes(r)
+	return r
+}
+
+func postMessage(r http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", testToken)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestCreateMessageHandler(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+
+	resp := postMessage(r, `{"type":"sms","content":"验证码：929253","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.NotZero(t, sms.Id)
+	assert.Equal(t, Sending, sms.Status)
+
+	resp = postMessage(r, `{"type":"sms","content":"x"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = postMessage(r, `{"type":"broadcast","content":"x","channel":"weather"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = postMessage(r, `{"type":"unknown","content":"x"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestFindMessagesHandlers(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+
+	require.Equal(t, http.StatusCreated, postMessage(r, `{"type":"sms","content":"a","phone_number":"+8613800138000"}`).Code)
+	require.Equal(t, http.StatusCreated, postMessage(r, `{"type":"sitemessage","content":"b","user_id":1001}`).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/message/sms/8613800138000", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusUnauthorized, resp.Code, "查询短信需要认证")
+
+	req.Header.Set("Authorization", testToken)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var list MessageListResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	require.Len(t, *list.Messages, 1)
+	assert.Equal(t, "a", (*list.Messages)[0].Content)
+
+	req = httptest.NewRequest(http.MethodGet, "/message/sitemessage/1001", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	require.Len(t, *list.Messages, 1)
+	assert.Equal(t, "b", (*list.Messages)[0].Content)
+
+	req = httptest.NewRequest(http.MethodGet, "/message/sitemessage/2002", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.JSONEq(t, `{"messages":[]}`, resp.Body.String())
+}
+
+// 消息写入数据库，重新创建模块（相当于服务重启）后仍可查询
+func TestMessagesPersistAcrossHandlers(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestDB(t)
+
+	first := chi.NewRouter()
+	New(module.Deps{DB: db}, Config{}).Routes(first)
+	require.Equal(t, http.StatusCreated, postMessage(first, `{"type":"sitemessage","content":"持久化","user_id":7}`).Code)
+
+	second := chi.NewRouter()
+	New(module.Deps{DB: db}, Config{}).Routes(second)
+
+	req := httptest.NewRequest(http.MethodGet, "/message/sitemessage/7", nil)
+	resp := httptest.NewRecorder()
+	second.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var list MessageListResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	require.Len(t, *list.Messages, 1)
+	assert.Equal(t, "持久化", (*list.Messages)[0].Content)
+	assert.Equal(t, Sending, (*list.Messages)[0].Status)
+}
+
+// 带 Idempotency-Key 的重试不会重复创建消息
+func TestCreateMessageIdempotency(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))
+		req.Header.Set("Authorization", testToken)
+		req.Header.Set(idempotency.Header, key)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	const body = `{"type":"sms","content":"您好","phone_number":"+8613800138000"}`
+
+	first := post("order-1001", body)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	retry := post("order-1001", body)
+	require.Equal(t, http.StatusCreated, retry.Code, retry.Body.String())
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+
+	resp := post("order-1001", `{"type":"sms","content":"另一条","phone_number":"+8613800138000"}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/message/sms/8613800138000", nil)
+	req.Header.Set("Authorization", testToken)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	var list MessageListResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Len(t, *list.Messages, 1)
+}
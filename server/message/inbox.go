@@ -0,0 +1,163 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// defaultInboxPageSize 收件箱每页的默认条数
+	defaultInboxPageSize = 50
+
+	// maxInboxPageSize 收件箱每页的最大条数
+	maxInboxPageSize = 200
+
+	// statusUnread 收件箱按状态过滤时表示所有未读状态
+	statusUnread = "unread"
+)
+
+// InboxFilter 收件箱的查询条件
+type InboxFilter struct {
+	Status   MessageStatus // 只列出该状态的消息，为空时不限
+	Unread   bool          // 只列出未读的消息
+	Archived bool          // 列出已归档的消息，否则列出未归档的消息
+	AfterID  int64         // 只列出 ID 更大的消息
+	Limit    int           // 至多返回的条数
+}
+
+// InboxRepository 站内信收件箱的数据访问接口
+// 所有操作都限定在 userID 的站内信中，消息不存在、不是站内信或不属于该用户时返回 ErrMessageNotFound
+type InboxRepository interface {
+	// ListInbox 按 ID 升序列出用户的站内信
+	ListInbox(ctx context.Context, userID int64, filter InboxFilter) ([]MessageModel, error)
+
+	// CountUnread 统计用户未归档的未读站内信
+	CountUnread(ctx context.Context, userID int64) (int64, error)
+
+	// MarkRead 按状态机将站内信标记为已读并记录每一步状态流转，已读时不做修改
+	// failed 等不能标记为已读的状态返回 *TransitionError
+	MarkRead(ctx context.Context, userID, id int64, at time.Time) (*MessageModel, error)
+
+	// MarkAllRead 将用户所有未读（见 unreadStatuses）的站内信标记为已读，返回标记的条数
+	MarkAllRead(ctx context.Context, userID int64, at time.Time) (int64, error)
+
+	// SetArchived 归档（at 不为 nil）或取消归档站内信
+	SetArchived(ctx context.Context, userID, id int64, at *time.Time) (*MessageModel, error)
+
+	// DeleteSiteMessage 软删除站内信
+	DeleteSiteMessage(ctx context.Context, userID, id int64) error
+}
+
+// unreadStatuses 未读的站内信状态，即可以标记为已读的状态，见 readPath
+var unreadStatuses = []MessageStatus{Sending, Sent, Received}
+
+// isUnread 判断站内信是否未读
+func isUnread(status MessageStatus) bool {
+	_, ok := readSteps[status]
+	return ok
+}
+
+// inboxFilter 校验收件箱的查询参数并转换为查询条件
+func inboxFilter(params FindMessagesByUIDParams) (InboxFilter, error) {
+	filter := InboxFilter{Limit: defaultInboxPageSize}
+
+	switch status := stringValue(params.Status); {
+	case status == "":
+	case status == statusUnread:
+		filter.Unread = true
+	case MessageStatus(status).Valid():
+		filter.Status = MessageStatus(status)
+	default:
+		return filter, errors.BadRequest("无效的 status 参数")
+	}
+	if params.Archived != nil {
+		filter.Archived = *params.Archived
+	}
+	if params.After != nil {
+		filter.AfterID = *params.After
+	}
+	if params.Limit != nil {
+		if limit := *params.Limit; limit <= 0 || limit > maxInboxPageSize {
+			return filter, errors.BadRequest("无效的 limit 参数")
+		}
+		filter.Limit = *params.Limit
+	}
+	return filter, nil
+}
+
+// CountUnreadSiteMessages 查询用户的未读站内信数
+func (h *Server) CountUnreadSiteMessages(ctx context.Context, request CountUnreadSiteMessagesRequestObject) (CountUnreadSiteMessagesResponseObject, error) {
+	unread, err := h.messages.CountUnread(ctx, request.Uid)
+	if err != nil {
+		return nil, errors.InternalServer("查询未读数失败")
+	}
+	return CountUnreadSiteMessages200JSONResponse{UserId: request.Uid, Unread: unread}, nil
+}
+
+// MarkAllSiteMessagesRead 将用户的站内信全部标记为已读
+func (h *Server) MarkAllSiteMessagesRead(ctx context.Context, request MarkAllSiteMessagesReadRequestObject) (MarkAllSiteMessagesReadResponseObject, error) {
+	updated, err := h.messages.MarkAllRead(ctx, request.Uid, h.clock.Now().UTC())
+	if err != nil {
+		h.logger.Error("标记已读失败", "uid", request.Uid, "error", err)
+		return nil, errors.InternalServer("标记已读失败")
+	}
+	return MarkAllSiteMessagesRead200JSONResponse{Updated: updated}, nil
+}
+
+// MarkSiteMessageRead 将一条站内信标记为已读
+func (h *Server) MarkSiteMessageRead(ctx context.Context, request MarkSiteMessageReadRequestObject) (MarkSiteMessageReadResponseObject, error) {
+	model, err := h.messages.MarkRead(ctx, request.Uid, request.Id, h.clock.Now().UTC())
+	if err != nil {
+		return nil, h.inboxError(err, "标记已读失败")
+	}
+	return MarkSiteMessageRead200JSONResponse(model.ToSiteMessage()), nil
+}
+
+// ArchiveSiteMessage 归档站内信
+func (h *Server) ArchiveSiteMessage(ctx context.Context, request ArchiveSiteMessageRequestObject) (ArchiveSiteMessageResponseObject, error) {
+	now := h.clock.Now().UTC()
+	model, err := h.messages.SetArchived(ctx, request.Uid, request.Id, &now)
+	if err != nil {
+		return nil, h.inboxError(err, "归档失败")
+	}
+	return ArchiveSiteMessage200JSONResponse(model.ToSiteMessage()), nil
+}
+
+// UnarchiveSiteMessage 取消归档，站内信回到收件箱
+func (h *Server) UnarchiveSiteMessage(ctx context.Context, request UnarchiveSiteMessageRequestObject) (UnarchiveSiteMessageResponseObject, error) {
+	model, err := h.messages.SetArchived(ctx, request.Uid, request.Id, nil)
+	if err != nil {
+		return nil, h.inboxError(err, "取消归档失败")
+	}
+	return UnarchiveSiteMessage200JSONResponse(model.ToSiteMessage()), nil
+}
+
+// DeleteSiteMessage 删除站内信
+func (h *Server) DeleteSiteMessage(ctx context.Context, request DeleteSiteMessageRequestObject) (DeleteSiteMessageResponseObject, error) {
+	if err := h.messages.DeleteSiteMessage(ctx, request.Uid, request.Id); err != nil {
+		if stderrors.Is(err, ErrMessageNotFound) {
+			return nil, errors.NotFound("消息不存在")
+		}
+		return nil, errors.InternalServer("删除消息失败")
+	}
+	return DeleteSiteMessage204Response{}, nil
+}
+
+// inboxError 将收件箱操作的错误映射为 API 错误
+func (h *Server) inboxError(err error, failure string) error {
+	if stderrors.Is(err, ErrMessageNotFound) {
+		return errors.NotFound("消息不存在")
+	}
+	var transitionErr *TransitionError
+	if stderrors.As(err, &transitionErr) {
+		return errors.New(http.StatusConflict,
+			fmt.Sprintf("不允许的状态流转: %s -> %s", transitionErr.From, transitionErr.To))
+	}
+	h.logger.Error(failure, "error", err)
+	return errors.InternalServer(failure)
+}
@@ -0,0 +1,183 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inboxRequest 请求收件箱接口，修改操作带上认证头
+func inboxRequest(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if method != http.MethodGet {
+		req.Header.Set("Authorization", testToken)
+	}
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+// listInbox 查询收件箱
+func listInbox(t *testing.T, r http.Handler, path string) SiteMessageList {
+	resp := inboxRequest(r, http.MethodGet, path)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var list SiteMessageList
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	return list
+}
+
+// unreadCount 查询未读数
+func unreadCount(t *testing.T, r http.Handler, uid int64) int64 {
+	resp := inboxRequest(r, http.MethodGet, fmt.Sprintf("/message/sitemessage/%d/unread", uid))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var summary InboxSummary
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &summary))
+	assert.Equal(t, uid, summary.UserId)
+	return summary.Unread
+}
+
+// setupInbox 为用户 1001 创建 count 条站内信，返回消息ID
+func setupInbox(t *testing.T, r *chi.Mux, count int) []int64 {
+	ids := make([]int64, 0, count)
+	for i := 0; i < count; i++ {
+		resp := postMessage(r, fmt.Sprintf(`{"type":"sitemessage","content":"消息%d","user_id":1001}`, i+1))
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+		var msg SiteMessage
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+		ids = append(ids, msg.Id)
+	}
+	require.Equal(t, http.StatusCreated, postMessage(r, `{"type":"sitemessage","content":"别人的","user_id":2002}`).Code)
+	return ids
+}
+
+func TestInboxReadState(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	ids := setupInbox(t, r, 3)
+	assert.EqualValues(t, 3, unreadCount(t, r, 1001))
+
+	resp := inboxRequest(r, http.MethodPost, fmt.Sprintf("/message/sitemessage/1001/%d/read", ids[0]))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var msg SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	assert.Equal(t, Read, msg.Status)
+	require.NotNil(t, msg.ReadAt)
+	assert.EqualValues(t, 2, unreadCount(t, r, 1001))
+
+	// 按状态机经过 sent、received 进入 read，重复标记不再记录状态流转
+	resp = inboxRequest(r, http.MethodPost, fmt.Sprintf("/message/sitemessage/1001/%d/read", ids[0]))
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = inboxRequest(r, http.MethodGet, fmt.Sprintf("/message/%d/transitions", ids[0]))
+	var transitions StatusTransitionList
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &transitions))
+	require.Len(t, *transitions.Transitions, 4)
+	assert.Equal(t, Read, (*transitions.Transitions)[3].To)
+
+	// 只能操作自己的站内信
+	resp = inboxRequest(r, http.MethodPost, fmt.Sprintf("/message/sitemessage/2002/%d/read", ids[1]))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	resp = inboxRequest(r, http.MethodPost, "/message/sitemessage/1001/abc/read")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	list := listInbox(t, r, "/message/sitemessage/1001?status=unread")
+	require.Len(t, list.Messages, 2)
+	assert.Equal(t, ids[1], list.Messages[0].Id)
+	list = listInbox(t, r, "/message/sitemessage/1001?status=read")
+	require.Len(t, list.Messages, 1)
+	assert.Equal(t, ids[0], list.Messages[0].Id)
+
+	resp = inboxRequest(r, http.MethodPost, "/message/sitemessage/1001/read")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"updated":2}`, resp.Body.String())
+	assert.EqualValues(t, 0, unreadCount(t, r, 1001))
+	assert.EqualValues(t, 1, unreadCount(t, r, 2002))
+
+	resp = inboxRequest(r, http.MethodGet, "/message/sitemessage/1001?status=unknown")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// 投递失败的站内信不能标记为已读
+	resp = postStatus(r, ids[2]+1, `{"status":"failed"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	resp = inboxRequest(r, http.MethodPost, fmt.Sprintf("/message/sitemessage/2002/%d/read", ids[2]+1))
+	assert.Equal(t, http.StatusConflict, resp.Code, resp.Body.String())
+	assert.EqualValues(t, 0, unreadCount(t, r, 2002))
+}
+
+func TestInboxArchiveAndDelete(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	ids := setupInbox(t, r, 3)
+
+	resp := inboxRequest(r, http.MethodPut, fmt.Sprintf("/message/sitemessage/1001/%d/archive", ids[1]))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var msg SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	require.NotNil(t, msg.ArchivedAt)
+
+	// 归档的消息不在收件箱中，也不计入未读数
+	list := listInbox(t, r, "/message/sitemessage/1001")
+	require.Len(t, list.Messages, 2)
+	assert.Equal(t, ids[0], list.Messages[0].Id)
+	assert.Equal(t, ids[2], list.Messages[1].Id)
+	assert.EqualValues(t, 2, unreadCount(t, r, 1001))
+	list = listInbox(t, r, "/message/sitemessage/1001?archived=true")
+	require.Len(t, list.Messages, 1)
+	assert.Equal(t, ids[1], list.Messages[0].Id)
+
+	resp = inboxRequest(r, http.MethodDelete, fmt.Sprintf("/message/sitemessage/1001/%d/archive", ids[1]))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var unarchived SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &unarchived))
+	assert.Nil(t, unarchived.ArchivedAt)
+	assert.Len(t, listInbox(t, r, "/message/sitemessage/1001").Messages, 3)
+
+	resp = inboxRequest(r, http.MethodDelete, fmt.Sprintf("/message/sitemessage/1001/%d", ids[0]))
+	require.Equal(t, http.StatusNoContent, resp.Code, resp.Body.String())
+	resp = inboxRequest(r, http.MethodDelete, fmt.Sprintf("/message/sitemessage/1001/%d", ids[0]))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	resp = inboxRequest(r, http.MethodPost, fmt.Sprintf("/message/sitemessage/1001/%d/read", ids[0]))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Len(t, listInbox(t, r, "/message/sitemessage/1001").Messages, 2)
+	assert.EqualValues(t, 2, unreadCount(t, r, 1001))
+
+	// 修改需要认证
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/message/sitemessage/1001/%d", ids[1]), nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestInboxPagination(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	ids := setupInbox(t, r, 5)
+
+	list := listInbox(t, r, "/message/sitemessage/1001?limit=2")
+	require.Len(t, list.Messages, 2)
+	require.NotNil(t, list.NextAfter)
+	assert.Equal(t, ids[1], *list.NextAfter)
+
+	list = listInbox(t, r, fmt.Sprintf("/message/sitemessage/1001?limit=2&after=%d", *list.NextAfter))
+	require.Len(t, list.Messages, 2)
+	assert.Equal(t, ids[2], list.Messages[0].Id)
+	require.NotNil(t, list.NextAfter)
+
+	list = listInbox(t, r, fmt.Sprintf("/message/sitemessage/1001?limit=2&after=%d", *list.NextAfter))
+	require.Len(t, list.Messages, 1)
+	assert.Equal(t, ids[4], list.Messages[0].Id)
+	assert.Nil(t, list.NextAfter)
+
+	for _, query := range []string{"limit=0", "limit=201", "after=x", "archived=maybe"} {
+		resp := inboxRequest(r, http.MethodGet, "/message/sitemessage/1001?"+query)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+	}
+}
@@ -0,0 +1,38 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+// ErrInvalidCredentials 用户名或密码错误，LoginFunc 返回该错误时登录接口返回 401
+var ErrInvalidCredentials = stderrors.New("message: invalid credentials")
+
+// LoginFunc 校验用户名和密码并返回访问令牌，例如 user.Server.Login
+type LoginFunc func(ctx context.Context, username, password string) (string, error)
+
+// Login 处理 /auth/login，由 Config.Login 校验用户名和密码，未配置时返回 501
+func (h *Server) Login(ctx context.Context, request LoginRequestObject) (LoginResponseObject, error) {
+	if h.login == nil {
+		return nil, errors.New(http.StatusNotImplemented, "未配置登录")
+	}
+
+	username, password := stringValue(request.Body.Username), stringValue(request.Body.Password)
+	if username == "" || password == "" {
+		return nil, errors.BadRequest("必须提供用户名和密码")
+	}
+
+	token, err := h.login(ctx, username, password)
+	if stderrors.Is(err, ErrInvalidCredentials) {
+		message := "用户名或密码错误"
+		return Login401JSONResponse{Message: &message}, nil
+	}
+	if err != nil {
+		h.logger.Error("登录失败", "username", username, "error", err)
+		return nil, errors.InternalServer("登录失败")
+	}
+	return Login200JSONResponse{Token: &token}, nil
+}
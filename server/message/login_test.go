@@ -0,0 +1,62 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+func postLogin(r http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t)}, Config{
+		Login: func(ctx context.Context, username, password string) (string, error) {
+			if username != "twotwo" || password != "123456" {
+				return "", ErrInvalidCredentials
+			}
+			return "token-" + username, nil
+		},
+	}).Routes(r)
+
+	resp := postLogin(r, `{"username":"twotwo","password":"123456"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var body struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, "token-twotwo", body.Token)
+
+	resp = postLogin(r, `{"username":"twotwo","password":"wrong"}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = postLogin(r, `{"username":"twotwo"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = postLogin(r, `not json`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestLoginNotConfigured(t *testing.T) {
+	t.Parallel()
+
+	resp := postLogin(setupRouter(t), `{"username":"twotwo","password":"123456"}`)
+	assert.Equal(t, http.StatusNotImplemented, resp.Code)
+}
@@ -0,0 +1,353 @@
+package message
+
+import (
+	"fmt"
+	"time"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/domain"
+)
+
+// MessageModel 定义消息在数据库中的表示
+// 短信、站内信、广播、微信模板消息、邮件共用一张表，按 Type 区分，类型专属字段在其他类型中为空
+type MessageModel struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Type    MessageType   `gorm:"size:20;not null;index" json:"type"`
+	Status  MessageStatus `gorm:"size:20;not null" json:"status"`
+	Content string        `gorm:"type:text;not null" json:"content"`
+
+	PhoneNumber string `gorm:"size:20;index" json:"phone_number,omitempty"` // 短信：接收手机号
+	UserID      int64  `gorm:"index" json:"user_id,omitempty"`              // 站内信：接收用户ID
+	Channel     string `gorm:"size:20" json:"channel,omitempty"`            // 广播：频道
+	BroadcastID int64  `gorm:"index" json:"broadcast_id,omitempty"`         // 站内信：由哪条广播扇出生成
+	TemplateID  string `gorm:"size:64" json:"template_id,omitempty"`        // 由哪个模板渲染生成
+	ScheduleID  int64  `gorm:"index" json:"schedule_id,omitempty"`          // 由哪个定时任务生成
+	Sender      string `gorm:"size:64;index" json:"sender,omitempty"`       // 发送方标识，用于按发送方限流
+	Category    string `gorm:"size:50" json:"category,omitempty"`           // 消息类别，投递前按接收方的通知偏好判断是否送达
+
+	// 微信模板消息：接收方 openid、公众号模板ID、跳转链接及模板字段取值
+	OpenID           string            `gorm:"column:openid;size:64;index" json:"openid,omitempty"`
+	WechatTemplateID string            `gorm:"size:64" json:"wechat_template_id,omitempty"`
+	WechatURL        string            `gorm:"size:512" json:"wechat_url,omitempty"`
+	WechatData       map[string]string `gorm:"serializer:json;type:text" json:"wechat_data,omitempty"`
+
+	// 邮件：收件人、主题、HTML 正文与附件，Content 为纯文本正文
+	// HTML 正文与附件不指定列类型，由各数据库选择能容纳大文本的类型（如 MySQL 的 longtext）
+	Email       string              `gorm:"size:254;index" json:"email,omitempty"`
+	Subject     string              `gorm:"size:255" json:"subject,omitempty"`
+	HTMLBody    string              `json:"html_body,omitempty"`
+	Attachments []MessageAttachment `gorm:"serializer:json" json:"attachments,omitempty"`
+
+	// 站内信的收件箱状态：已读时间、归档时间；用户删除的站内信软删除
+	ReadAt     *time.Time     `json:"read_at,omitempty"`
+	ArchivedAt *time.Time     `json:"archived_at,omitempty"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 投递回执：接收消息的通道及通道侧的消息 ID，用于把投递回执对应回消息
+	Provider          string `gorm:"size:50;index:idx_messages_receipt" json:"provider,omitempty"`
+	ProviderMessageID string `gorm:"size:100;index:idx_messages_receipt" json:"provider_message_id,omitempty"`
+}
+
+// MessageAttachment 邮件附件，随消息以 JSON 保存
+type MessageAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Models 返回消息模块需要自动迁移的数据库模型，由 pkg/database.Setup 迁移
+func Models() []interface{} {
+	return []interface{}{&MessageModel{}, &StatusTransitionModel{}, &SubscriptionModel{}, &FanoutModel{}, &TemplateModel{}, &ScheduleModel{}, &OTPModel{}, &RateLimitModel{}, &OutboxModel{}}
+}
+
+// TableName 指定消息表名
+func (MessageModel) TableName() string {
+	return "messages"
+}
+
+// redactedContent 安全类消息在接口中返回的内容，验证码等敏感内容只发给接收方
+const redactedContent = "******"
+
+// ToAPI 将数据库模型转换为API模型
+func (m *MessageModel) ToAPI() Message {
+	return Message{
+		Id:         m.ID,
+		Content:    m.apiContent(),
+		Type:       m.Type,
+		Status:     m.Status,
+		TemplateId: m.templateID(),
+		Sender:     m.sender(),
+		Category:   m.category(),
+	}
+}
+
+// ToSMS 转换为短信API模型
+func (m *MessageModel) ToSMS() SMSMessage {
+	return SMSMessage{
+		Id:          m.ID,
+		Content:     m.apiContent(),
+		Type:        m.Type,
+		Status:      m.Status,
+		PhoneNumber: m.PhoneNumber,
+		TemplateId:  m.templateID(),
+		Sender:      m.sender(),
+		Category:    m.category(),
+	}
+}
+
+// ToSiteMessage 转换为站内信API模型
+func (m *MessageModel) ToSiteMessage() SiteMessage {
+	return SiteMessage{
+		Id:         m.ID,
+		Content:    m.apiContent(),
+		Type:       m.Type,
+		Status:     m.Status,
+		UserId:     m.UserID,
+		TemplateId: m.templateID(),
+		Sender:     m.sender(),
+		Category:   m.category(),
+		ReadAt:     m.ReadAt,
+		ArchivedAt: m.ArchivedAt,
+	}
+}
+
+// ToBroadcast 转换为广播API模型
+func (m *MessageModel) ToBroadcast() BroadcastMessage {
+	msg := BroadcastMessage{
+		Id:         m.ID,
+		Content:    m.apiContent(),
+		Type:       m.Type,
+		Status:     m.Status,
+		TemplateId: m.templateID(),
+		Sender:     m.sender(),
+		Category:   m.category(),
+	}
+	if m.Channel != "" {
+		channel := BroadcastMessageChannel(m.Channel)
+		msg.Channel = &channel
+	}
+	return msg
+}
+
+// ToWechat 转换为微信模板消息API模型
+func (m *MessageModel) ToWechat() WechatMessage {
+	msg := WechatMessage{
+		Id:               m.ID,
+		Content:          m.apiContent(),
+		Type:             m.Type,
+		Status:           m.Status,
+		Openid:           m.OpenID,
+		WechatTemplateId: m.WechatTemplateID,
+		TemplateId:       m.templateID(),
+		Sender:           m.sender(),
+		Category:         m.category(),
+	}
+	if m.WechatURL != "" {
+		url := m.WechatURL
+		msg.Url = &url
+	}
+	if len(m.WechatData) > 0 {
+		data := make(map[string]string, len(m.WechatData))
+		for k, v := range m.WechatData {
+			data[k] = v
+		}
+		msg.Data = &data
+	}
+	return msg
+}
+
+// ToEmail 转换为邮件API模型，附件只返回文件名、类型和大小
+func (m *MessageModel) ToEmail() EmailMessage {
+	msg := EmailMessage{
+		Id:         m.ID,
+		Content:    m.apiContent(),
+		Type:       m.Type,
+		Status:     m.Status,
+		Email:      openapi_types.Email(m.Email),
+		Subject:    m.Subject,
+		TemplateId: m.templateID(),
+		Sender:     m.sender(),
+		Category:   m.category(),
+	}
+	if m.HTMLBody != "" {
+		html := m.HTMLBody
+		msg.Html = &html
+	}
+	if len(m.Attachments) > 0 {
+		attachments := make([]EmailAttachment, 0, len(m.Attachments))
+		for _, a := range m.Attachments {
+			contentType := a.ContentType
+			size := int64(len(a.Data))
+			attachments = append(attachments, EmailAttachment{Filename: a.Filename, ContentType: &contentType, Size: &size})
+		}
+		msg.Attachments = &attachments
+	}
+	return msg
+}
+
+// apiContent 返回 API 模型中的消息内容，安全类消息的内容被隐藏
+func (m *MessageModel) apiContent() string {
+	if m.Category == CategorySecurity {
+		return redactedContent
+	}
+	return m.Content
+}
+
+// templateID 返回 API 模型中的模板ID，未使用模板时为 nil
+func (m *MessageModel) templateID() *string {
+	if m.TemplateID == "" {
+		return nil
+	}
+	id := m.TemplateID
+	return &id
+}
+
+// sender 返回 API 模型中的发送方，未提供时为 nil
+func (m *MessageModel) sender() *string {
+	if m.Sender == "" {
+		return nil
+	}
+	sender := m.Sender
+	return &sender
+}
+
+// category 返回 API 模型中的消息类别，未指定时为 nil
+func (m *MessageModel) category() *string {
+	if m.Category == "" {
+		return nil
+	}
+	category := m.Category
+	return &category
+}
+
+// ToTypedMessage 按消息类型转换为对应的API模型
+func (m *MessageModel) ToTypedMessage() (TypedMessage, error) {
+	var (
+		typed TypedMessage
+		err   error
+	)
+	switch m.Type {
+	case Sms:
+		err = typed.FromSMSMessage(m.ToSMS())
+	case Sitemessage:
+		err = typed.FromSiteMessage(m.ToSiteMessage())
+	case Broadcast:
+		err = typed.FromBroadcastMessage(m.ToBroadcast())
+	case Wechat:
+		err = typed.FromWechatMessage(m.ToWechat())
+	case Email:
+		err = typed.FromEmailMessage(m.ToEmail())
+	default:
+		err = fmt.Errorf("message: unknown message type %q", m.Type)
+	}
+	return typed, err
+}
+
+// 生成的响应类型是 TypedMessage 的新类型，不会继承它的 MarshalJSON，需要显式转发
+
+func (r CreateMessage201JSONResponse) MarshalJSON() ([]byte, error) {
+	return TypedMessage(r).MarshalJSON()
+}
+
+func (r UpdateMessageStatus200JSONResponse) MarshalJSON() ([]byte, error) {
+	return TypedMessage(r).MarshalJSON()
+}
+
+func (r ReceiveDeliveryReport200JSONResponse) MarshalJSON() ([]byte, error) {
+	return TypedMessage(r).MarshalJSON()
+}
+
+// FromSMS 从短信API模型创建数据库模型
+func FromSMS(msg SMSMessage) *MessageModel {
+	return &MessageModel{
+		Type:        Sms,
+		Content:     msg.Content,
+		PhoneNumber: NormalizePhoneNumber(msg.PhoneNumber),
+		Sender:      stringValue(msg.Sender),
+		Category:    stringValue(msg.Category),
+	}
+}
+
+// FromSiteMessage 从站内信API模型创建数据库模型
+func FromSiteMessage(msg SiteMessage) *MessageModel {
+	return &MessageModel{
+		Type:     Sitemessage,
+		Content:  msg.Content,
+		UserID:   msg.UserId,
+		Sender:   stringValue(msg.Sender),
+		Category: stringValue(msg.Category),
+	}
+}
+
+// FromBroadcast 从广播API模型创建数据库模型
+func FromBroadcast(msg BroadcastMessage) *MessageModel {
+	m := &MessageModel{
+		Type:     Broadcast,
+		Content:  msg.Content,
+		Sender:   stringValue(msg.Sender),
+		Category: stringValue(msg.Category),
+	}
+	if msg.Channel != nil {
+		m.Channel = string(*msg.Channel)
+	}
+	return m
+}
+
+// FromWechat 从微信模板消息API模型创建数据库模型
+func FromWechat(msg WechatMessage) *MessageModel {
+	m := &MessageModel{
+		Type:             Wechat,
+		Content:          msg.Content,
+		OpenID:           msg.Openid,
+		WechatTemplateID: msg.WechatTemplateId,
+		WechatURL:        stringValue(msg.Url),
+		Sender:           stringValue(msg.Sender),
+		Category:         stringValue(msg.Category),
+	}
+	if msg.Data != nil {
+		m.WechatData = *msg.Data
+	}
+	return m
+}
+
+// FromEmail 从邮件API模型创建数据库模型，附件已由 validateEmail 校验并补全类型
+func FromEmail(msg EmailMessage) *MessageModel {
+	m := &MessageModel{
+		Type:     Email,
+		Content:  msg.Content,
+		Email:    string(msg.Email),
+		Subject:  msg.Subject,
+		HTMLBody: stringValue(msg.Html),
+		Sender:   stringValue(msg.Sender),
+		Category: stringValue(msg.Category),
+	}
+	if msg.Attachments != nil {
+		for _, a := range *msg.Attachments {
+			attachment := MessageAttachment{Filename: a.Filename, ContentType: stringValue(a.ContentType)}
+			if a.Content != nil {
+				attachment.Data = *a.Content
+			}
+			m.Attachments = append(m.Attachments, attachment)
+		}
+	}
+	return m
+}
+
+// NormalizePhoneNumber 去掉手机号中的 "+"、空格和连字符，
+// 使 "+86 138-0013-8000" 与路径参数 8613800138000 能匹配到同一条记录；与用户表中手机号的规范化规则相同
+func NormalizePhoneNumber(number string) string {
+	return domain.NormalizePhone(number)
+}
+
+// stringValue 返回可选字符串的值，nil 时为空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
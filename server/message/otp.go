@@ -0,0 +1,317 @@
+package message
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// DefaultOTPTemplate 发送验证码默认使用的短信模板
+	DefaultOTPTemplate = "verification-code"
+
+	// DefaultOTPPurpose 未指定用途时的验证码用途
+	DefaultOTPPurpose = "default"
+)
+
+var (
+	// ErrOTPNotFound 表示手机号没有可校验的验证码
+	ErrOTPNotFound = stderrors.New("otp not found")
+
+	// ErrOTPCooldown 表示冷却时间内已发送过验证码
+	ErrOTPCooldown = stderrors.New("otp resend cooldown")
+
+	// ErrOTPConflict 表示同一验证码被并发校验
+	ErrOTPConflict = stderrors.New("otp attempted concurrently")
+)
+
+// OTPConfig 短信验证码配置，零值字段使用默认值
+type OTPConfig struct {
+	// Secret 计算验证码哈希的密钥，多副本需使用相同的值
+	// 为空时每次启动随机生成，重启前发送的验证码将无法校验
+	Secret string
+
+	TemplateID     string        // 默认短信模板，默认 DefaultOTPTemplate
+	Length         int           // 验证码位数，默认 6
+	TTL            time.Duration // 有效期，默认 5 分钟
+	MaxAttempts    int           // 每个验证码允许的校验次数，默认 5
+	ResendCooldown time.Duration // 同一手机号和用途两次发送的最小间隔，默认 1 分钟
+}
+
+func (c OTPConfig) withDefaults() OTPConfig {
+	if c.Secret == "" {
+		secret := make([]byte, 32)
+		rand.Read(secret)
+		c.Secret = hex.EncodeToString(secret)
+	}
+	if c.TemplateID == "" {
+		c.TemplateID = DefaultOTPTemplate
+	}
+	if c.Length <= 0 {
+		c.Length = 6
+	}
+	if c.TTL <= 0 {
+		c.TTL = 5 * time.Minute
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.ResendCooldown <= 0 {
+		c.ResendCooldown = time.Minute
+	}
+	return c
+}
+
+// OTPModel 一次发送的短信验证码，只保存带随机盐的 HMAC，不保存明文
+// 手机号按 NormalizePhoneNumber 规范化后保存
+type OTPModel struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt   time.Time  `gorm:"index:idx_otps_phone_purpose,priority:3" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PhoneNumber string     `gorm:"size:20;not null;index:idx_otps_phone_purpose,priority:1" json:"phone_number"`
+	Purpose     string     `gorm:"size:50;not null;index:idx_otps_phone_purpose,priority:2" json:"purpose"`
+	Salt        string     `gorm:"size:32;not null" json:"-"`
+	CodeHash    string     `gorm:"size:64;not null" json:"-"`
+	MessageID   int64      `gorm:"not null" json:"message_id"` // 发送验证码的短信
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"` // 已校验次数，兼作并发校验的版本号
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+}
+
+// TableName 指定验证码表名
+func (OTPModel) TableName() string {
+	return "message_otps"
+}
+
+// OTPRepository 短信验证码的数据访问接口
+type OTPRepository interface {
+	// CreateOTP 在同一事务中保存验证码短信和验证码记录，并回填 otp.MessageID
+	// 同一手机号和用途在 cooldownSince 之后已有验证码时返回 ErrOTPCooldown
+	CreateOTP(ctx context.Context, otp *OTPModel, msg *MessageModel, cooldownSince time.Time) error
+
+	// FindLatestOTP 查询手机号和用途最近一次发送的验证码，不存在时返回 ErrOTPNotFound
+	FindLatestOTP(ctx context.Context, phoneNumber, purpose string) (*OTPModel, error)
+
+	// AttemptOTP 记录一次校验：次数加一，verifiedAt 不为 nil 时标记为已校验
+	// 以读到的 Attempts 为条件，并发校验时返回 ErrOTPConflict
+	AttemptOTP(ctx context.Context, id int64, attempts int, verifiedAt *time.Time) error
+}
+
+// generateOTP 生成指定位数的随机数字验证码
+func generateOTP(length int) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(math.Pow10(length))))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n.Int64()), nil
+}
+
+// hashOTP 计算验证码的 HMAC-SHA256，盐和手机号、用途一起参与计算
+func hashOTP(secret, salt, phoneNumber, purpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", salt, phoneNumber, purpose, code)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matches 判断验证码是否正确，使用常量时间比较
+func (o *OTPModel) matches(secret, code string) bool {
+	expected := hashOTP(secret, o.Salt, o.PhoneNumber, o.Purpose, code)
+	return hmac.Equal([]byte(expected), []byte(o.CodeHash))
+}
+
+// otpParams 按模板声明的变量组装参数：code 必须声明，minutes 声明时填入有效分钟数
+func otpParams(tpl *TemplateModel, code string, ttl time.Duration) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, 2)
+	for _, v := range tpl.Variables {
+		switch v.Name {
+		case "code":
+			params["code"] = code
+		case "minutes":
+			params["minutes"] = int(math.Ceil(ttl.Minutes()))
+		}
+	}
+	if _, ok := params["code"]; !ok {
+		return nil, fmt.Errorf("%w: template %s does not declare variable \"code\"", ErrInvalidTemplate, tpl.ID)
+	}
+	return params, nil
+}
+
+// SendOTP 生成验证码并按模板发送短信
+func (h *Server) SendOTP(ctx context.Context, request SendOTPRequestObject) (SendOTPResponseObject, error) {
+	req := request.Body
+	phoneNumber := NormalizePhoneNumber(req.PhoneNumber)
+	if phoneNumber == "" {
+		return nil, errors.BadRequest("必须提供手机号")
+	}
+	purpose := DefaultOTPPurpose
+	if req.Purpose != nil && *req.Purpose != "" {
+		purpose = *req.Purpose
+	}
+	templateID := h.otp.TemplateID
+	if req.TemplateId != nil && *req.TemplateId != "" {
+		templateID = *req.TemplateId
+	}
+
+	tpl, err := h.messages.FindTemplate(ctx, templateID)
+	if err != nil {
+		if stderrors.Is(err, ErrTemplateNotFound) {
+			return nil, errors.BadRequest(fmt.Sprintf("模板不存在: %s", templateID))
+		}
+		return nil, errors.InternalServer("查询模板失败")
+	}
+	if tpl.Type != "" && tpl.Type != Sms {
+		return nil, errors.BadRequest(fmt.Sprintf("模板 %s 只能用于 %s 消息", tpl.ID, tpl.Type))
+	}
+
+	code, err := generateOTP(h.otp.Length)
+	if err != nil {
+		return nil, errors.InternalServer("生成验证码失败")
+	}
+	params, err := otpParams(tpl, code, h.otp.TTL)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("验证码模板无效: %s", err))
+	}
+	locales := localePreferences(stringValue(request.Params.AcceptLanguage), stringValue(req.Locale))
+	_, content, err := tpl.Render(locales, params)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("验证码模板无效: %s", err))
+	}
+	// 保存的消息内容中隐藏验证码，明文只出现在发给通道的短信中
+	params["code"] = redactedContent
+	_, masked, err := tpl.Render(locales, params)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("验证码模板无效: %s", err))
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.InternalServer("生成验证码失败")
+	}
+	now := h.clock.Now().UTC()
+	otp := &OTPModel{
+		CreatedAt:   now,
+		PhoneNumber: phoneNumber,
+		Purpose:     purpose,
+		Salt:        hex.EncodeToString(salt),
+		ExpiresAt:   now.Add(h.otp.TTL),
+		MaxAttempts: h.otp.MaxAttempts,
+	}
+	otp.CodeHash = hashOTP(h.otp.Secret, otp.Salt, otp.PhoneNumber, otp.Purpose, code)
+	msg := &MessageModel{
+		Type:        Sms,
+		Status:      Sending,
+		Content:     masked,
+		PhoneNumber: phoneNumber,
+		TemplateID:  tpl.ID,
+		Category:    CategorySecurity,
+	}
+
+	// 先检查冷却时间，冷却中被拒绝的请求不消耗发送配额；CreateOTP 在事务中再次检查
+	cooldownSince := now.Add(-h.otp.ResendCooldown)
+	if latest, err := h.messages.FindLatestOTP(ctx, phoneNumber, purpose); err == nil && latest.CreatedAt.After(cooldownSince) {
+		return nil, errors.TooManyRequests("发送过于频繁，请稍后再试", latest.CreatedAt.Add(h.otp.ResendCooldown).Sub(now))
+	} else if err != nil && !stderrors.Is(err, ErrOTPNotFound) {
+		h.logger.Error("查询验证码失败", "error", err)
+		return nil, errors.InternalServer("查询验证码失败")
+	}
+
+	// 验证码短信与普通短信共用按手机号的发送频率限制
+	if err := h.consumeRateLimits(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	if err := h.messages.CreateOTP(ctx, otp, msg, cooldownSince); err != nil {
+		if stderrors.Is(err, ErrOTPCooldown) {
+			retryAfter := h.retryAfter(ctx, otp.PhoneNumber, purpose, now)
+			return nil, errors.TooManyRequests("发送过于频繁，请稍后再试", retryAfter)
+		}
+		h.logger.Error("保存验证码失败", "error", err)
+		return nil, errors.InternalServer("保存验证码失败")
+	}
+
+	// 数据库中没有明文验证码，投递任务无法重新生成短信，因此不经过队列，在请求中同步发送；
+	// 投递失败时验证码仍然有效，冷却后可重新发送
+	if h.admit(ctx, msg) {
+		sending := *msg
+		sending.Content = content
+		h.deliver(ctx, &sending)
+	}
+
+	return SendOTP202JSONResponse{
+		PhoneNumber: otp.PhoneNumber,
+		Purpose:     otp.Purpose,
+		MessageId:   msg.ID,
+		ExpiresAt:   otp.ExpiresAt,
+		ResendAfter: now.Add(h.otp.ResendCooldown),
+	}, nil
+}
+
+// retryAfter 返回距离可以重新发送的时间
+func (h *Server) retryAfter(ctx context.Context, phoneNumber, purpose string, now time.Time) time.Duration {
+	if latest, err := h.messages.FindLatestOTP(ctx, phoneNumber, purpose); err == nil {
+		return latest.CreatedAt.Add(h.otp.ResendCooldown).Sub(now)
+	}
+	return h.otp.ResendCooldown
+}
+
+// VerifyOTP 校验最近一次发送的验证码，成功后验证码失效
+// 过期、已使用和不存在的验证码返回相同的错误，不透露具体原因
+func (h *Server) VerifyOTP(ctx context.Context, request VerifyOTPRequestObject) (VerifyOTPResponseObject, error) {
+	req := request.Body
+	phoneNumber := NormalizePhoneNumber(req.PhoneNumber)
+	if phoneNumber == "" || req.Code == "" {
+		return nil, errors.BadRequest("必须提供手机号和验证码")
+	}
+	purpose := DefaultOTPPurpose
+	if req.Purpose != nil && *req.Purpose != "" {
+		purpose = *req.Purpose
+	}
+
+	otp, err := h.messages.FindLatestOTP(ctx, phoneNumber, purpose)
+	if err != nil {
+		if stderrors.Is(err, ErrOTPNotFound) {
+			return nil, errors.BadRequest("验证码错误或已过期")
+		}
+		return nil, errors.InternalServer("查询验证码失败")
+	}
+
+	now := h.clock.Now().UTC()
+	if otp.VerifiedAt != nil || !now.Before(otp.ExpiresAt) {
+		return nil, errors.BadRequest("验证码错误或已过期")
+	}
+	if otp.Attempts >= otp.MaxAttempts {
+		return nil, errors.New(http.StatusTooManyRequests, "错误次数过多，请重新获取验证码")
+	}
+
+	// 先计入次数再返回结果，错误的尝试同样消耗次数
+	var verifiedAt *time.Time
+	ok := otp.matches(h.otp.Secret, req.Code)
+	if ok {
+		verifiedAt = &now
+	}
+	if err := h.messages.AttemptOTP(ctx, otp.ID, otp.Attempts, verifiedAt); err != nil {
+		if stderrors.Is(err, ErrOTPConflict) {
+			return nil, errors.New(http.StatusConflict, "验证码正在校验，请稍后重试")
+		}
+		return nil, errors.InternalServer("记录校验结果失败")
+	}
+	if !ok {
+		if remaining := otp.MaxAttempts - otp.Attempts - 1; remaining > 0 {
+			return nil, errors.BadRequest(fmt.Sprintf("验证码错误，还可以尝试 %d 次", remaining))
+		}
+		return nil, errors.New(http.StatusTooManyRequests, "错误次数过多，请重新获取验证码")
+	}
+
+	return VerifyOTP200JSONResponse{PhoneNumber: otp.PhoneNumber, Purpose: otp.Purpose, Verified: true}, nil
+}
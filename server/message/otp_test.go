@@ -0,0 +1,225 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// setupOTPRouter 创建带默认验证码模板的消息模块，返回可拨动的时钟
+func setupOTPRouter(t *testing.T, cfg OTPConfig) (*chi.Mux, *Server, *testClock) {
+	clock := &testClock{now: time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)}
+	registry := NewRegistry()
+	registry.Register(Sms, NewLoopback(nil))
+	h := NewServer(NewGormRepository(setupTestDB(t)), module.Deps{Clock: clock}, Config{OTP: cfg, Providers: registry})
+	r := chi.NewRouter()
+	h.Routes(r)
+
+	resp := doTemplateRequest(r, http.MethodPost, "/message/templates", verificationTemplateJSON)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	return r, h, clock
+}
+
+// sendOTP 请求发送验证码
+func sendOTP(r http.Handler, body string) *httptest.ResponseRecorder {
+	return doTemplateRequest(r, http.MethodPost, "/message/otp", body)
+}
+
+// verifyOTP 请求校验验证码
+func verifyOTP(r http.Handler, phone, code string) *httptest.ResponseRecorder {
+	return doTemplateRequest(r, http.MethodPost, "/message/otp/verify", fmt.Sprintf(`{"phone_number":%q,"code":%q}`, phone, code))
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+// sentCode 从通道收到的短信中取出验证码
+func sentCode(t *testing.T, h *Server, messageID int64) string {
+	provider, err := h.providers.Provider(Sms)
+	require.NoError(t, err)
+	for _, msg := range provider.(*Loopback).Sent() {
+		if msg.ID == messageID {
+			code := codePattern.FindString(msg.Content)
+			require.NotEmpty(t, code, msg.Content)
+			return code
+		}
+	}
+	t.Fatalf("message %d was not sent", messageID)
+	return ""
+}
+
+func TestSendAndVerifyOTP(t *testing.T) {
+	t.Parallel()
+
+	r, h, _ := setupOTPRouter(t, OTPConfig{})
+	const phone = "+8613800138000"
+
+	resp := sendOTP(r, fmt.Sprintf(`{"phone_number":%q,"locale":"zh-CN"}`, phone))
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var challenge OTPChallenge
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &challenge))
+	assert.Equal(t, DefaultOTPPurpose, challenge.Purpose)
+	assert.Equal(t, time.Date(2025, 6, 2, 8, 5, 0, 0, time.UTC), challenge.ExpiresAt.UTC())
+	assert.Equal(t, time.Date(2025, 6, 2, 8, 1, 0, 0, time.UTC), challenge.ResendAfter.UTC())
+	assert.NotContains(t, resp.Body.String(), "code")
+
+	msg, err := h.messages.FindByID(context.Background(), challenge.MessageId)
+	require.NoError(t, err)
+	assert.Equal(t, Sms, msg.Type)
+	assert.Equal(t, NormalizePhoneNumber(phone), msg.PhoneNumber)
+	assert.Equal(t, "verification-code", msg.TemplateID)
+	assert.Equal(t, Sent, msg.Status)
+	code := sentCode(t, h, challenge.MessageId)
+
+	// 数据库中的短信内容隐藏了验证码
+	var content string
+	require.NoError(t, h.messages.(*GormRepository).db.Model(&MessageModel{}).
+		Where("id = ?", challenge.MessageId).Pluck("content", &content).Error)
+	assert.Equal(t, "验证码：******，5分钟内有效", content)
+	assert.NotContains(t, content, code)
+
+	// 只保存哈希
+	otp, err := h.messages.FindLatestOTP(context.Background(), NormalizePhoneNumber(phone), DefaultOTPPurpose)
+	require.NoError(t, err)
+	assert.NotContains(t, otp.CodeHash, code)
+	assert.Len(t, otp.CodeHash, 64)
+
+	// 短信查询接口不返回验证码
+	req := httptest.NewRequest(http.MethodGet, "/message/sms/8613800138000", nil)
+	req.Header.Set("Authorization", testToken)
+	list := httptest.NewRecorder()
+	r.ServeHTTP(list, req)
+	require.Equal(t, http.StatusOK, list.Code)
+	assert.NotContains(t, list.Body.String(), code)
+	assert.Contains(t, list.Body.String(), redactedContent)
+
+	// 手机号写法不同也能匹配
+	resp = verifyOTP(r, "+86 138-0013-8000", code)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var result OTPVerification
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.True(t, result.Verified)
+
+	// 校验成功后失效
+	assert.Equal(t, http.StatusBadRequest, verifyOTP(r, phone, code).Code)
+	assert.Equal(t, http.StatusBadRequest, verifyOTP(r, "+8613900139000", code).Code)
+}
+
+func TestOTPAttemptLimit(t *testing.T) {
+	t.Parallel()
+
+	r, h, _ := setupOTPRouter(t, OTPConfig{MaxAttempts: 3})
+	const phone = "+8613800138000"
+
+	resp := sendOTP(r, fmt.Sprintf(`{"phone_number":%q}`, phone))
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var challenge OTPChallenge
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &challenge))
+	code := sentCode(t, h, challenge.MessageId)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	resp = verifyOTP(r, phone, wrong)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "还可以尝试 2 次")
+	assert.Equal(t, http.StatusBadRequest, verifyOTP(r, phone, wrong).Code)
+	assert.Equal(t, http.StatusTooManyRequests, verifyOTP(r, phone, wrong).Code)
+
+	// 次数用完后正确的验证码也不再通过
+	assert.Equal(t, http.StatusTooManyRequests, verifyOTP(r, phone, code).Code)
+}
+
+func TestOTPExpiryAndCooldown(t *testing.T) {
+	t.Parallel()
+
+	r, h, clock := setupOTPRouter(t, OTPConfig{TTL: 2 * time.Minute, ResendCooldown: time.Minute})
+	const phone = "+8613800138000"
+	body := fmt.Sprintf(`{"phone_number":%q,"purpose":"login"}`, phone)
+
+	resp := sendOTP(r, body)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var first OTPChallenge
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &first))
+
+	// 冷却时间内不能重发，其他用途不受影响
+	clock.Advance(20 * time.Second)
+	resp = sendOTP(r, body)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "40", resp.Header().Get("Retry-After"))
+	resp = sendOTP(r, fmt.Sprintf(`{"phone_number":%q,"purpose":"register"}`, phone))
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	// 重发后旧验证码失效
+	clock.Advance(time.Minute)
+	oldCode := sentCode(t, h, first.MessageId)
+	resp = sendOTP(r, body)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var second OTPChallenge
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &second))
+	newCode := sentCode(t, h, second.MessageId)
+	if oldCode != newCode {
+		resp = doTemplateRequest(r, http.MethodPost, "/message/otp/verify", fmt.Sprintf(`{"phone_number":%q,"purpose":"login","code":%q}`, phone, oldCode))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	}
+
+	// 过期后不能校验
+	clock.Advance(2 * time.Minute)
+	resp = doTemplateRequest(r, http.MethodPost, "/message/otp/verify", fmt.Sprintf(`{"phone_number":%q,"purpose":"login","code":%q}`, phone, newCode))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestSendOTPValidation(t *testing.T) {
+	t.Parallel()
+
+	r, _, _ := setupOTPRouter(t, OTPConfig{})
+
+	// 未声明 code 变量的模板不能用于验证码
+	resp := doTemplateRequest(r, http.MethodPost, "/message/templates", `{"id":"no-code","type":"sms","default_locale":"en","variants":{"en":"hello"}}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates", `{"id":"site","type":"sitemessage","default_locale":"en","variables":[{"name":"code","type":"string"}],"variants":{"en":"{{code}}"}}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	for _, body := range []string{
+		`{}`,
+		`{"phone_number":"+8613800138000","template_id":"missing"}`,
+		`{"phone_number":"+8613800138000","template_id":"no-code"}`,
+		`{"phone_number":"+8613800138000","template_id":"site"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, sendOTP(r, body).Code, body)
+	}
+	assert.Equal(t, http.StatusBadRequest, doTemplateRequest(r, http.MethodPost, "/message/otp/verify", `{"phone_number":"+8613800138000"}`).Code)
+
+	// 需要认证
+	req := httptest.NewRequest(http.MethodPost, "/message/otp", strings.NewReader(`{"phone_number":"+8613800138000"}`))
+	unauth := httptest.NewRecorder()
+	r.ServeHTTP(unauth, req)
+	assert.Equal(t, http.StatusUnauthorized, unauth.Code)
+}
+
+func TestGenerateOTP(t *testing.T) {
+	t.Parallel()
+
+	for _, length := range []int{4, 6, 8} {
+		code, err := generateOTP(length)
+		require.NoError(t, err)
+		assert.Regexp(t, fmt.Sprintf(`^\d{%d}$`, length), code)
+	}
+
+	a := hashOTP("secret", "salt", "+86138", "login", "123456")
+	assert.Equal(t, a, hashOTP("secret", "salt", "+86138", "login", "123456"))
+	assert.NotEqual(t, a, hashOTP("other", "salt", "+86138", "login", "123456"))
+	assert.NotEqual(t, a, hashOTP("secret", "salt", "+86138", "register", "123456"))
+}
@@ -0,0 +1,98 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// OutboxLease 发件箱中继在租约表中的名称，多个副本中只有持有者中继发件箱
+	OutboxLease = "message.outbox"
+
+	// DefaultOutboxInterval 中继扫描发件箱的默认间隔
+	DefaultOutboxInterval = time.Second
+
+	// outboxBatchSize 中继每次读取的发件箱记录数
+	outboxBatchSize = 100
+)
+
+// ErrOutboxNotFound 表示发件箱记录不存在，通常是已被其他中继处理
+var ErrOutboxNotFound = stderrors.New("outbox entry not found")
+
+// OutboxModel 事务发件箱：在业务事务中创建的消息先记录在这里，事务提交后才由中继投递
+// 事务回滚时消息和记录一起消失，不会发出；中继投递后删除记录
+type OutboxModel struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `gorm:"not null"`
+	MessageID int64     `gorm:"not null;uniqueIndex"`
+}
+
+// TableName 指定发件箱表名
+func (OutboxModel) TableName() string {
+	return "message_outbox"
+}
+
+// OutboxRepository 事务发件箱的数据访问接口
+type OutboxRepository interface {
+	// CreateOutboxed 保存新消息并写入发件箱，两者在同一事务中完成，消息由中继投递
+	CreateOutboxed(ctx context.Context, msg *MessageModel) error
+
+	// PendingOutbox 按 ID 升序列出至多 limit 条待中继的发件箱记录
+	PendingOutbox(ctx context.Context, limit int) ([]OutboxModel, error)
+
+	// DeleteOutbox 删除已中继的发件箱记录，记录不存在时返回 ErrOutboxNotFound
+	DeleteOutbox(ctx context.Context, id int64) error
+}
+
+// CreateInTx 在调用方的 gorm 事务中创建消息并写入发件箱，事务提交后由中继投递，回滚时不会发出
+// 用于在业务事务（如用户注册）中原子地发送通知；消息按原样保存，
+// 不经过模板渲染、频率限制和免打扰时段，Status 为空时为 sending
+func CreateInTx(tx *gorm.DB, msg *MessageModel) error {
+	if msg.Status == "" {
+		msg.Status = Sending
+	}
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return NewGormRepository(tx).CreateOutboxed(ctx, msg)
+}
+
+// RunOutboxRelay 启动发件箱中继循环，阻塞到 ctx 取消
+// 配置了租约时只有租约持有者中继，持有者退出或崩溃后由其他副本接手
+func (h *Server) RunOutboxRelay(ctx context.Context) {
+	h.runElected(ctx, OutboxLease, h.outboxInterval, "中继发件箱失败", outboxBatchSize, h.RelayOutboxOnce)
+}
+
+// RelayOutboxOnce 投递至多一批已提交的发件箱消息，返回处理的记录数
+// 投递后才删除记录，中继在两者之间崩溃时记录会被再次处理：
+// 每次都重新读取消息，已不是 sending 的消息不再投递，同步投递和投递任务发送前也都检查状态
+func (h *Server) RelayOutboxOnce(ctx context.Context) (int, error) {
+	pending, err := h.messages.PendingOutbox(ctx, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, entry := range pending {
+		msg, err := h.messages.FindByID(ctx, entry.MessageID)
+		switch {
+		case stderrors.Is(err, ErrMessageNotFound):
+			// 中继前已被删除，无需投递
+		case err != nil:
+			return i, err
+		default:
+			if err := h.launch(ctx, msg); err != nil {
+				return i, fmt.Errorf("relay outbox message %d: %w", msg.ID, err)
+			}
+		}
+
+		if err := h.messages.DeleteOutbox(ctx, entry.ID); err != nil && !stderrors.Is(err, ErrOutboxNotFound) {
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
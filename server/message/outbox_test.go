@@ -0,0 +1,135 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+func TestCreateInTx(t *testing.T) {
+	t.Parallel()
+
+	loopback := NewLoopback(nil)
+	registry := NewRegistry()
+	registry.Register(Sitemessage, loopback)
+	db := setupTestDB(t)
+	h := New(module.Deps{DB: db}, Config{Providers: registry})
+	ctx := context.Background()
+
+	// 事务回滚时消息和发件箱记录都不会留下
+	errSignup := stderrors.New("signup failed")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, CreateInTx(tx, &MessageModel{Type: Sitemessage, UserID: 1, Content: "欢迎"}))
+		return errSignup
+	})
+	require.ErrorIs(t, err, errSignup)
+	var count int64
+	require.NoError(t, db.Model(&MessageModel{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&OutboxModel{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// 事务提交后才由中继投递
+	msg := &MessageModel{Type: Sitemessage, UserID: 2, Content: "欢迎"}
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return CreateInTx(tx, msg)
+	}))
+	assert.Equal(t, Sending, msg.Status)
+	assert.Empty(t, loopback.Sent(), "事务提交前不应投递")
+
+	sub := h.hub.Subscribe(siteMessageTopic(2))
+	defer sub.Close()
+	relayed, err := h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	require.Len(t, loopback.Sent(), 1)
+	assert.Equal(t, msg.ID, loopback.Sent()[0].ID)
+	stored, err := h.messages.FindByID(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Sent, stored.Status)
+	select {
+	case pushed := <-sub.C():
+		assert.Equal(t, msg.ID, pushed.ID)
+	default:
+		t.Fatal("中继的站内信应推送给在线用户")
+	}
+
+	// 已中继的记录被删除，不会重复投递
+	relayed, err = h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, relayed)
+	assert.Len(t, loopback.Sent(), 1)
+}
+
+func TestOutboxRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first := &MessageModel{Type: Sitemessage, UserID: 1, Content: "a", Status: Sending}
+			second := &MessageModel{Type: Sitemessage, UserID: 2, Content: "b", Status: Sending}
+			require.NoError(t, repo.CreateOutboxed(ctx, first))
+			require.NoError(t, repo.CreateOutboxed(ctx, second))
+			assert.NotZero(t, first.ID)
+
+			transitions, err := repo.ListTransitions(ctx, first.ID)
+			require.NoError(t, err)
+			require.Len(t, transitions, 1)
+			assert.Equal(t, Sending, transitions[0].To)
+
+			pending, err := repo.PendingOutbox(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, first.ID, pending[0].MessageID)
+
+			require.NoError(t, repo.DeleteOutbox(ctx, pending[0].ID))
+			assert.ErrorIs(t, repo.DeleteOutbox(ctx, pending[0].ID), ErrOutboxNotFound)
+
+			pending, err = repo.PendingOutbox(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, second.ID, pending[0].MessageID)
+		})
+	}
+}
+
+// 中继投递后、删除记录前崩溃时，记录被再次处理也不会重复发送
+func TestRelayOutboxAfterCrash(t *testing.T) {
+	t.Parallel()
+
+	loopback := NewLoopback(nil)
+	registry := NewRegistry()
+	registry.Register(Sitemessage, loopback)
+	h := New(module.Deps{DB: setupTestDB(t)}, Config{Providers: registry})
+	ctx := context.Background()
+
+	msg := &MessageModel{Type: Sitemessage, Status: Sending, UserID: 1, Content: "欢迎"}
+	require.NoError(t, h.messages.CreateOutboxed(ctx, msg))
+
+	// 模拟上一次中继：已经投递，但没来得及删除记录
+	stale := *msg
+	require.NoError(t, h.launch(ctx, msg))
+	require.Len(t, loopback.Sent(), 1)
+
+	relayed, err := h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Len(t, loopback.Sent(), 1)
+
+	// 读到旧状态的同步投递同样不会重复发送
+	h.deliver(ctx, &stale)
+	assert.Len(t, loopback.Sent(), 1)
+	assert.Equal(t, Sent, stale.Status)
+
+	pending, err := h.messages.PendingOutbox(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,35 @@
+package message
+
+import "context"
+
+// CategorySecurity 安全类消息，例如验证码；接收方通常不能通过通知偏好关闭
+const CategorySecurity = "security"
+
+// PreferenceFunc 判断消息的接收方是否接收该类消息，返回 false 时消息不投递，标记为 suppressed
+// 由调用方按消息类型和接收方查询通知偏好，例如 user.Server.AllowsNotification
+type PreferenceFunc func(ctx context.Context, msg *MessageModel) (bool, error)
+
+// admit 投递前按接收方的通知偏好判断是否送达，不接收时将消息标记为 suppressed 并返回 false
+// 广播没有单个接收方，由扇出生成的站内信各自判断；查询偏好失败时照常投递
+func (h *Server) admit(ctx context.Context, model *MessageModel) bool {
+	if h.preferences == nil || model.Type == Broadcast {
+		return true
+	}
+
+	allowed, err := h.preferences(ctx, model)
+	if err != nil {
+		h.logger.Warn("查询通知偏好失败", "id", model.ID, "type", model.Type, "error", err)
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	updated, err := h.messages.Transition(ctx, model.ID, Suppressed, "接收方的通知偏好不接收该类消息")
+	if err != nil {
+		h.logger.Error("更新消息状态失败", "id", model.ID, "status", Suppressed, "error", err)
+		return false
+	}
+	*model = *updated
+	return false
+}
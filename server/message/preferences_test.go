@@ -0,0 +1,133 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// setupPreferenceRouter 创建按通知偏好过滤的消息模块：用户 7 和手机号 8613800138000 不接收 promotion 类消息
+func setupPreferenceRouter(t *testing.T) (*chi.Mux, *Server, *Loopback) {
+	loopback := NewLoopback(nil)
+	registry := NewRegistry()
+	registry.Register(Sms, loopback)
+	registry.Register(Sitemessage, loopback)
+	registry.Register(Broadcast, loopback)
+	h := NewServer(NewGormRepository(setupTestDB(t)), module.Deps{}, Config{
+		Providers: registry,
+		Preferences: func(ctx context.Context, msg *MessageModel) (bool, error) {
+			optedOut := msg.UserID == 7 || msg.PhoneNumber == "8613800138000"
+			return !optedOut || msg.Category != "promotion", nil
+		},
+	})
+	r := chi.NewRouter()
+	h.Routes(r)
+	return r, h, loopback
+}
+
+func TestCreateMessageSuppressedByPreferences(t *testing.T) {
+	t.Parallel()
+
+	r, h, loopback := setupPreferenceRouter(t)
+
+	resp := postMessage(r, `{"type":"sms","content":"限时优惠","phone_number":"+86 138-0013-8000","category":"promotion"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.Equal(t, Suppressed, sms.Status)
+	assert.Equal(t, "promotion", *sms.Category)
+	assert.Empty(t, loopback.Sent())
+
+	transitions, err := h.messages.ListTransitions(context.Background(), sms.Id)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, Suppressed, transitions[1].To)
+
+	// 其他类别和其他接收方照常投递
+	resp = postMessage(r, `{"type":"sms","content":"账单","phone_number":"+8613800138000","category":"billing"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.Equal(t, Sent, sms.Status)
+	resp = postMessage(r, `{"type":"sms","content":"限时优惠","phone_number":"13900139000","category":"promotion"}`)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.Equal(t, Sent, sms.Status)
+	assert.Len(t, loopback.Sent(), 2)
+
+	// 未送达的站内信不在收件箱中
+	resp = postMessage(r, `{"type":"sitemessage","content":"新品上架","user_id":7,"category":"promotion"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var suppressed SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &suppressed))
+	require.Equal(t, http.StatusCreated, postMessage(r, `{"type":"sitemessage","content":"周报","user_id":7,"category":"news"}`).Code)
+	list := listInbox(t, r, "/message/sitemessage/7")
+	require.Len(t, list.Messages, 1)
+	assert.Equal(t, "周报", (list.Messages)[0].Content)
+	assert.Equal(t, int64(1), unreadCount(t, r, 7))
+	assert.Equal(t, http.StatusNotFound, inboxRequest(r, http.MethodPost, fmt.Sprintf("/message/sitemessage/7/%d/read", suppressed.Id)).Code)
+
+	resp = postMessage(r, fmt.Sprintf(`{"type":"sitemessage","content":"x","user_id":7,"category":%q}`, strings.Repeat("类", 51)))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestFanoutSuppressedByPreferences(t *testing.T) {
+	t.Parallel()
+
+	r, h, loopback := setupPreferenceRouter(t)
+	ctx := context.Background()
+	for _, uid := range []int64{6, 7, 8} {
+		subscribe(t, r, http.MethodPut, "news", uid)
+	}
+
+	resp := postMessage(r, `{"type":"broadcast","content":"限时优惠","channel":"news","category":"promotion"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var broadcast BroadcastMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &broadcast))
+	assert.Equal(t, Sent, broadcast.Status, "广播本身不受单个接收方的偏好约束")
+
+	// 扇出的站内信沿用广播的类别，由发件箱中继投递，退订了该类消息的用户 7 不接收
+	assert.Equal(t, int64(3), getFanout(t, r, broadcast.Id).Delivered)
+	relayed, err := h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, relayed)
+	assert.Len(t, loopback.Sent(), 3)
+	found, err := h.messages.FindByUserID(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, Suppressed, found[0].Status)
+	assert.Equal(t, "promotion", found[0].Category)
+	assert.Empty(t, listInbox(t, r, "/message/sitemessage/7").Messages)
+	assert.Len(t, listInbox(t, r, "/message/sitemessage/8").Messages, 1)
+
+	// 未指定类别的广播以频道为类别
+	for _, uid := range []int64{7, 8} {
+		subscribe(t, r, http.MethodPut, "promotion", uid)
+	}
+	resp = postMessage(r, `{"type":"broadcast","content":"新品上架","channel":"promotion"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	_, err = h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, listInbox(t, r, "/message/sitemessage/7").Messages)
+	assert.Len(t, listInbox(t, r, "/message/sitemessage/8").Messages, 2)
+}
+
+func TestOTPSecurityCategory(t *testing.T) {
+	t.Parallel()
+
+	r, h, _ := setupOTPRouter(t, OTPConfig{})
+	resp := sendOTP(r, `{"phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+
+	msgs, err := h.messages.FindByPhoneNumber(context.Background(), "+8613800138000")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, CategorySecurity, msgs[0].Category)
+}
@@ -0,0 +1,193 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/twotwo/go-blueprint/pkg/mail"
+	"github.com/twotwo/go-blueprint/pkg/variables"
+)
+
+// ErrNoProvider 表示没有为消息类型配置投递通道
+var ErrNoProvider = errors.New("message: no provider for message type")
+
+// Provider 消息投递通道，例如短信网关、站内信推送、微信模板消息
+type Provider interface {
+	// Name 通道名称，用于配置和日志
+	Name() string
+
+	// Send 投递一条消息，返回 nil 表示通道已接收
+	// 返回通道侧的消息 ID，用于把投递回执对应回消息，通道不提供时为空
+	Send(ctx context.Context, msg *MessageModel) (string, error)
+}
+
+// Receipt 通道接收消息后的回执
+type Receipt struct {
+	Provider  string // 通道名称，与回执回调地址中的 {provider} 对应
+	MessageID string // 通道侧的消息 ID
+}
+
+// Registry 按消息类型登记投递通道
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[MessageType]Provider
+}
+
+// NewRegistry 创建空的通道注册表
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[MessageType]Provider)}
+}
+
+// Register 为消息类型登记通道，已登记的会被替换
+func (r *Registry) Register(t MessageType, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[t] = p
+}
+
+// Provider 返回消息类型对应的通道，未登记时返回 ErrNoProvider
+func (r *Registry) Provider(t MessageType) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.providers[t]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoProvider, t)
+	}
+	return p, nil
+}
+
+// Send 使用消息类型对应的通道投递消息
+// 通道投递失败时回执中只有通道名称，用于按通道统计失败的消息
+func (r *Registry) Send(ctx context.Context, msg *MessageModel) (Receipt, error) {
+	p, err := r.Provider(msg.Type)
+	if err != nil {
+		return Receipt{}, err
+	}
+	id, err := p.Send(ctx, msg)
+	if err != nil {
+		return Receipt{Provider: p.Name()}, err
+	}
+	return Receipt{Provider: p.Name(), MessageID: id}, nil
+}
+
+// deliverableTypes 需要配置投递通道的消息类型
+var deliverableTypes = []MessageType{Sms, Sitemessage, Broadcast, Wechat, Email}
+
+// SetupProviders 根据环境变量为每种消息类型选择投递通道
+//
+//	MESSAGE_PROVIDER=loopback          所有类型的默认通道：loopback（默认）、file、http、none，
+//	                                   微信还可以选择 wechat，邮件还可以选择 smtp
+//	MESSAGE_PROVIDER_SMS=http          按类型覆盖，类型名大写，例如 MESSAGE_PROVIDER_WECHAT
+//	MESSAGE_PROVIDER_FILE=messages.log file 通道写入的文件
+//	MESSAGE_HTTP_URL=...               http 通道的网关地址，可按类型覆盖，例如 MESSAGE_HTTP_URL_SMS
+//	MESSAGE_HTTP_TOKEN=...             http 通道的 Bearer 令牌，可按类型覆盖
+//	MESSAGE_HTTP_NAME=acme             http 通道的名称（默认 http），即回执回调地址中的 {provider}，可按类型覆盖
+//	MESSAGE_WECHAT_APPID=...           wechat 通道的公众号 AppID
+//	MESSAGE_WECHAT_SECRET=...          wechat 通道的公众号 AppSecret
+//	MESSAGE_WECHAT_URL=...             wechat 通道的接口地址，默认 DefaultWechatURL
+//	MESSAGE_SMTP_HOST=smtp.example.com smtp 通道的服务器，MESSAGE_SMTP_PORT 默认按 TLS 模式为 587 或 465
+//	MESSAGE_SMTP_TLS=starttls          smtp 通道的加密方式：starttls（默认）、tls、none
+//	MESSAGE_SMTP_USERNAME=...          smtp 通道的认证用户名和密码（MESSAGE_SMTP_PASSWORD），为空时不认证
+//	MESSAGE_SMTP_FROM=...              smtp 通道的发件人，可带显示名，例如 "通知 <noreply@example.com>"
+func SetupProviders() (*Registry, error) {
+	registry := NewRegistry()
+
+	// 多个类型选择同一个 loopback/file 通道时共用一个实例
+	var loopback *Loopback
+	var file *Loopback
+
+	for _, t := range deliverableTypes {
+		suffix := "_" + strings.ToUpper(string(t))
+		name := variables.GetEnv("MESSAGE_PROVIDER"+suffix, variables.GetEnv("MESSAGE_PROVIDER", "loopback"))
+
+		switch name {
+		case "none":
+			continue
+		case "loopback":
+			if loopback == nil {
+				loopback = NewLoopback(nil)
+			}
+			registry.Register(t, loopback)
+		case "file":
+			if file == nil {
+				var err error
+				file, err = NewFileProvider(variables.GetEnv("MESSAGE_PROVIDER_FILE", "messages.log"))
+				if err != nil {
+					return nil, err
+				}
+			}
+			registry.Register(t, file)
+		case "http":
+			url := variables.GetEnv("MESSAGE_HTTP_URL"+suffix, variables.GetEnv("MESSAGE_HTTP_URL", ""))
+			if url == "" {
+				return nil, fmt.Errorf("message: MESSAGE_HTTP_URL%s is required for http provider", suffix)
+			}
+			registry.Register(t, NewHTTPProvider(HTTPProviderConfig{
+				Name:  variables.GetEnv("MESSAGE_HTTP_NAME"+suffix, variables.GetEnv("MESSAGE_HTTP_NAME", "")),
+				URL:   url,
+				Token: variables.GetEnv("MESSAGE_HTTP_TOKEN"+suffix, variables.GetEnv("MESSAGE_HTTP_TOKEN", "")),
+			}))
+		case "wechat":
+			if t != Wechat {
+				return nil, fmt.Errorf("message: wechat provider cannot deliver %s", t)
+			}
+			appID := variables.GetEnv("MESSAGE_WECHAT_APPID", "")
+			secret := variables.GetEnv("MESSAGE_WECHAT_SECRET", "")
+			if appID == "" || secret == "" {
+				return nil, fmt.Errorf("message: MESSAGE_WECHAT_APPID and MESSAGE_WECHAT_SECRET are required for wechat provider")
+			}
+			registry.Register(t, NewWechatProvider(WechatProviderConfig{
+				AppID:     appID,
+				AppSecret: secret,
+				BaseURL:   variables.GetEnv("MESSAGE_WECHAT_URL", ""),
+			}))
+		case "smtp":
+			if t != Email {
+				return nil, fmt.Errorf("message: smtp provider cannot deliver %s", t)
+			}
+			provider, err := setupEmailProvider()
+			if err != nil {
+				return nil, err
+			}
+			registry.Register(t, provider)
+		default:
+			return nil, fmt.Errorf("message: unknown provider %q for %s", name, t)
+		}
+	}
+
+	return registry, nil
+}
+
+// setupEmailProvider 根据 MESSAGE_SMTP_* 环境变量创建 SMTP 邮件通道
+func setupEmailProvider() (*EmailProvider, error) {
+	host := variables.GetEnv("MESSAGE_SMTP_HOST", "")
+	from := variables.GetEnv("MESSAGE_SMTP_FROM", "")
+	if host == "" || from == "" {
+		return nil, fmt.Errorf("message: MESSAGE_SMTP_HOST and MESSAGE_SMTP_FROM are required for smtp provider")
+	}
+	if _, err := netmail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("message: invalid MESSAGE_SMTP_FROM %q: %w", from, err)
+	}
+	mode, err := mail.ParseTLSMode(variables.GetEnv("MESSAGE_SMTP_TLS", ""))
+	if err != nil {
+		return nil, err
+	}
+	var port int
+	if v := variables.GetEnv("MESSAGE_SMTP_PORT", ""); v != "" {
+		if port, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("message: invalid MESSAGE_SMTP_PORT %q", v)
+		}
+	}
+	return NewEmailProvider(mail.NewClient(mail.Config{
+		Host:     host,
+		Port:     port,
+		Username: variables.GetEnv("MESSAGE_SMTP_USERNAME", ""),
+		Password: variables.GetEnv("MESSAGE_SMTP_PASSWORD", ""),
+		TLS:      mode,
+	}), from), nil
+}
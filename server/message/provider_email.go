@@ -0,0 +1,118 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/mail"
+)
+
+const (
+	// maxEmailAttachments 每封邮件的最大附件数
+	maxEmailAttachments = 10
+
+	// maxEmailAttachmentBytes 每封邮件附件的总大小上限
+	maxEmailAttachmentBytes = 10 << 20
+
+	// maxEmailSubjectLength 主题的最大字符数，与数据库字段一致
+	maxEmailSubjectLength = 255
+)
+
+// validateEmail 校验邮件的收件人、主题和附件，并为未指定类型的附件按扩展名补全类型
+func validateEmail(msg *EmailMessage) []errors.FieldError {
+	var fields []errors.FieldError
+	if msg.Email == "" {
+		fields = append(fields, errors.FieldError{Field: "email", Message: "邮件必须包含收件人邮箱"})
+	}
+	switch {
+	case msg.Subject == "":
+		fields = append(fields, errors.FieldError{Field: "subject", Message: "邮件必须包含主题"})
+	case strings.ContainsAny(msg.Subject, "\r\n"):
+		fields = append(fields, errors.FieldError{Field: "subject", Message: "邮件主题不能包含换行"})
+	case utf8.RuneCountInString(msg.Subject) > maxEmailSubjectLength:
+		fields = append(fields, errors.FieldError{Field: "subject", Message: "邮件主题过长"})
+	}
+	if msg.Attachments == nil {
+		return fields
+	}
+
+	attachments := *msg.Attachments
+	if len(attachments) > maxEmailAttachments {
+		return append(fields, errors.FieldError{Field: "attachments", Message: fmt.Sprintf("附件不能超过 %d 个", maxEmailAttachments)})
+	}
+	var total int
+	for i := range attachments {
+		a := &attachments[i]
+		field := fmt.Sprintf("attachments[%d]", i)
+		if a.Filename == "" || len(a.Filename) > 255 || strings.ContainsAny(a.Filename, "/\\\r\n") {
+			fields = append(fields, errors.FieldError{Field: field + ".filename", Message: fmt.Sprintf("无效的附件文件名: %q", a.Filename)})
+		}
+		if a.Content == nil {
+			fields = append(fields, errors.FieldError{Field: field + ".content", Message: "附件缺少内容"})
+		} else {
+			total += len(*a.Content)
+		}
+
+		contentType := stringValue(a.ContentType)
+		if contentType == "" {
+			contentType = mime.TypeByExtension(path.Ext(a.Filename))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			fields = append(fields, errors.FieldError{Field: field + ".content_type", Message: fmt.Sprintf("无效的附件类型: %s", contentType)})
+			continue
+		}
+		contentType = mime.FormatMediaType(mediaType, params)
+		a.ContentType = &contentType
+	}
+	if total > maxEmailAttachmentBytes {
+		fields = append(fields, errors.FieldError{Field: "attachments", Message: fmt.Sprintf("附件总大小不能超过 %d MiB", maxEmailAttachmentBytes>>20)})
+	}
+	return fields
+}
+
+// EmailProvider 通过 SMTP 投递邮件，以邮件的 Message-ID 作为通道侧的消息 ID
+type EmailProvider struct {
+	client *mail.Client
+	from   string
+}
+
+// ensure that we've conformed to the `Provider` with a compile-time check
+var _ Provider = (*EmailProvider)(nil)
+
+// NewEmailProvider 创建 SMTP 邮件通道，from 为发件人，可带显示名
+func NewEmailProvider(client *mail.Client, from string) *EmailProvider {
+	return &EmailProvider{client: client, from: from}
+}
+
+// Name 返回通道名称
+func (p *EmailProvider) Name() string {
+	return "smtp"
+}
+
+// Send 组装并发送邮件
+func (p *EmailProvider) Send(ctx context.Context, msg *MessageModel) (string, error) {
+	m := &mail.Message{
+		From:    p.from,
+		To:      []string{msg.Email},
+		Subject: msg.Subject,
+		Text:    msg.Content,
+		HTML:    msg.HTMLBody,
+	}
+	for _, a := range msg.Attachments {
+		m.Attachments = append(m.Attachments, mail.Attachment{Filename: a.Filename, ContentType: a.ContentType, Data: a.Data})
+	}
+	id, err := p.client.Send(ctx, m)
+	if err != nil {
+		return "", fmt.Errorf("message: smtp provider: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,181 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	netmail "net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/mail"
+	"github.com/twotwo/go-blueprint/pkg/mail/mailtest"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+func TestCreateEmailMessage(t *testing.T) {
+	t.Parallel()
+
+	server := mailtest.NewServer(t, mailtest.Options{StartTLS: true, Username: "mailer", Password: "secret"})
+	client := mail.NewClient(mail.Config{
+		Host:      server.Host,
+		Port:      server.Port,
+		Username:  "mailer",
+		Password:  "secret",
+		TLSConfig: server.ClientTLSConfig(),
+	})
+	registry := NewRegistry()
+	registry.Register(Email, NewEmailProvider(client, "通知 <noreply@example.com>"))
+	db := setupTestDB(t)
+	r := chi.NewRouter()
+	New(module.Deps{DB: db}, Config{Providers: registry}).Routes(r)
+
+	pdf := []byte("%PDF-1.4 fake invoice")
+	resp := postMessage(r, fmt.Sprintf(`{"type":"email","content":"您的订单已发货","email":"alice@example.com",
+		"subject":"订单已发货","html":"<p>您的订单已发货</p>",
+		"attachments":[{"filename":"invoice.pdf","content":%q}]}`, base64.StdEncoding.EncodeToString(pdf)))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var msg EmailMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	assert.Equal(t, Email, msg.Type)
+	assert.Equal(t, Sent, msg.Status)
+	assert.EqualValues(t, "alice@example.com", msg.Email)
+	assert.Equal(t, "订单已发货", msg.Subject)
+	require.NotNil(t, msg.Attachments)
+	require.Len(t, *msg.Attachments, 1)
+	attachment := (*msg.Attachments)[0]
+	assert.Equal(t, "invoice.pdf", attachment.Filename)
+	require.NotNil(t, attachment.ContentType)
+	assert.Equal(t, "application/pdf", *attachment.ContentType)
+	require.NotNil(t, attachment.Size)
+	assert.EqualValues(t, len(pdf), *attachment.Size)
+	// 响应中不回显附件内容
+	assert.Nil(t, attachment.Content)
+	assert.NotContains(t, resp.Body.String(), base64.StdEncoding.EncodeToString(pdf))
+
+	received := server.Messages()
+	require.Len(t, received, 1)
+	assert.Equal(t, []string{"alice@example.com"}, received[0].To)
+	assert.Equal(t, "noreply@example.com", received[0].From)
+	assert.True(t, received[0].TLS)
+	parsed, err := netmail.ReadMessage(bytes.NewReader(received[0].Data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "订单已发货", subject)
+	mediaType, _, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	body, err := io.ReadAll(parsed.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `filename=invoice.pdf`)
+
+	// 附件随消息保存，Message-ID 作为投递回执
+	stored, err := NewGormRepository(db).FindByID(context.Background(), msg.Id)
+	require.NoError(t, err)
+	require.Len(t, stored.Attachments, 1)
+	assert.Equal(t, pdf, stored.Attachments[0].Data)
+	assert.Equal(t, "<p>您的订单已发货</p>", stored.HTMLBody)
+	assert.Equal(t, "smtp", stored.Provider)
+	assert.Equal(t, "<"+stored.ProviderMessageID+">", parsed.Header.Get("Message-ID"))
+}
+
+func TestCreateEmailMessageValidation(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	tooMany := make([]string, maxEmailAttachments+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"filename":"a%d.txt","content":"YQ=="}`, i)
+	}
+	oversized := base64.StdEncoding.EncodeToString(make([]byte, maxEmailAttachmentBytes+1))
+
+	for _, body := range []string{
+		`{"type":"email","content":"x","subject":"s"}`,
+		`{"type":"email","content":"x","email":"not-an-address","subject":"s"}`,
+		`{"type":"email","content":"x","email":"alice@example.com"}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"s\r\nBcc: eve@example.com"}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"` + strings.Repeat("长", maxEmailSubjectLength+1) + `"}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"s","attachments":[{"filename":"../etc/passwd","content":"YQ=="}]}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"s","attachments":[{"filename":"a.txt"}]}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"s","attachments":[{"filename":"a.txt","content":"YQ==","content_type":"bad;"}]}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"s","attachments":[` + strings.Join(tooMany, ",") + `]}`,
+		`{"type":"email","content":"x","email":"alice@example.com","subject":"s","attachments":[{"filename":"big.bin","content":"` + oversized + `"}]}`,
+	} {
+		resp := postMessage(r, body)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+	}
+
+	resp := postMessage(r, `{"type":"email","content":"x","email":"alice@example.com","subject":"s","attachments":[{"filename":"notes","content":"YQ=="}]}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var msg EmailMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	require.NotNil(t, msg.Attachments)
+	assert.Equal(t, "application/octet-stream", *(*msg.Attachments)[0].ContentType)
+}
+
+func TestScheduleCarriesEmailFields(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	at := now.Add(time.Hour)
+	msg := &MessageModel{
+		Type:        Email,
+		Content:     "提醒",
+		Email:       "alice@example.com",
+		Subject:     "提醒",
+		HTMLBody:    "<p>提醒</p>",
+		Attachments: []MessageAttachment{{Filename: "a.txt", ContentType: "text/plain", Data: []byte("a")}},
+	}
+	s, err := NewSchedule(msg, &at, "", "", now)
+	require.NoError(t, err)
+
+	generated := s.Message()
+	assert.Equal(t, msg.Email, generated.Email)
+	assert.Equal(t, msg.Subject, generated.Subject)
+	assert.Equal(t, msg.HTMLBody, generated.HTMLBody)
+	assert.Equal(t, msg.Attachments, generated.Attachments)
+	require.NotNil(t, s.ToAPI().Email)
+	assert.Equal(t, "alice@example.com", *s.ToAPI().Email)
+}
+
+func TestSetupEmailProvider(t *testing.T) {
+	t.Setenv("MESSAGE_PROVIDER", "loopback")
+	t.Setenv("MESSAGE_PROVIDER_EMAIL", "smtp")
+	t.Setenv("MESSAGE_SMTP_HOST", "smtp.example.com")
+	t.Setenv("MESSAGE_SMTP_FROM", "通知 <noreply@example.com>")
+
+	registry, err := SetupProviders()
+	require.NoError(t, err)
+	p, err := registry.Provider(Email)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp", p.Name())
+
+	for key, value := range map[string]string{
+		"MESSAGE_SMTP_TLS":  "ssl3",
+		"MESSAGE_SMTP_PORT": "smtp",
+		"MESSAGE_SMTP_FROM": "not an address",
+		"MESSAGE_SMTP_HOST": "",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := SetupProviders()
+			assert.Error(t, err)
+		})
+	}
+
+	// smtp 通道只能投递邮件
+	t.Setenv("MESSAGE_PROVIDER_SMS", "smtp")
+	_, err = SetupProviders()
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPProviderConfig HTTP 网关通道的配置
+type HTTPProviderConfig struct {
+	Name   string       // 通道名称，默认 http，用于匹配回执回调
+	URL    string       // 网关地址，消息以 JSON POST 到该地址
+	Token  string       // 可选，作为 Bearer 令牌放在 Authorization 头中
+	Client *http.Client // 为 nil 时使用 10 秒超时的默认客户端
+}
+
+// HTTPProvider 通过 HTTP 网关投递消息，网关返回 2xx 即视为接收成功
+// 网关可以在响应体中返回 {"message_id": "..."} 作为通道侧的消息 ID
+type HTTPProvider struct {
+	name   string
+	url    string
+	token  string
+	client *http.Client
+}
+
+// ensure that we've conformed to the `Provider` with a compile-time check
+var _ Provider = (*HTTPProvider)(nil)
+
+// NewHTTPProvider 创建 HTTP 网关通道
+func NewHTTPProvider(cfg HTTPProviderConfig) *HTTPProvider {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	name := cfg.Name
+	if name == "" {
+		name = "http"
+	}
+	return &HTTPProvider{name: name, url: cfg.URL, token: cfg.Token, client: client}
+}
+
+// gatewayRequest 发给网关的请求体，只包含投递需要的字段
+type gatewayRequest struct {
+	ID          int64       `json:"id"`
+	Type        MessageType `json:"type"`
+	Content     string      `json:"content"`
+	PhoneNumber string      `json:"phone_number,omitempty"`
+	UserID      int64       `json:"user_id,omitempty"`
+	Channel     string      `json:"channel,omitempty"`
+
+	OpenID           string            `json:"openid,omitempty"`
+	WechatTemplateID string            `json:"wechat_template_id,omitempty"`
+	WechatURL        string            `json:"wechat_url,omitempty"`
+	WechatData       map[string]string `json:"wechat_data,omitempty"`
+
+	Email       string              `json:"email,omitempty"`
+	Subject     string              `json:"subject,omitempty"`
+	HTMLBody    string              `json:"html_body,omitempty"`
+	Attachments []MessageAttachment `json:"attachments,omitempty"`
+}
+
+// gatewayResponse 网关的响应体，字段均可省略
+type gatewayResponse struct {
+	MessageID string `json:"message_id"`
+}
+
+// Name 返回通道名称
+func (p *HTTPProvider) Name() string {
+	return p.name
+}
+
+// Send 把消息 POST 到网关
+func (p *HTTPProvider) Send(ctx context.Context, msg *MessageModel) (string, error) {
+	body, err := json.Marshal(gatewayRequest{
+		ID:          msg.ID,
+		Type:        msg.Type,
+		Content:     msg.Content,
+		PhoneNumber: msg.PhoneNumber,
+		UserID:      msg.UserID,
+		Channel:     msg.Channel,
+
+		OpenID:           msg.OpenID,
+		WechatTemplateID: msg.WechatTemplateID,
+		WechatURL:        msg.WechatURL,
+		WechatData:       msg.WechatData,
+
+		Email:       msg.Email,
+		Subject:     msg.Subject,
+		HTMLBody:    msg.HTMLBody,
+		Attachments: msg.Attachments,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("message: http provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(detail) > 512 {
+			detail = detail[:512]
+		}
+		return "", fmt.Errorf("message: http provider: gateway returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+
+	// 网关已接收，响应体无法解析时只是拿不到消息 ID
+	var accepted gatewayResponse
+	_ = json.Unmarshal(detail, &accepted)
+	return accepted.MessageID, nil
+}
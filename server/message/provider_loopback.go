@@ -0,0 +1,68 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Loopback 本地开发用的投递通道：不真正发送，只把消息记录在内存中，
+// 可选地以 JSON Lines 格式写入 w，便于查看“发出”的内容
+type Loopback struct {
+	mu   sync.Mutex
+	w    io.Writer
+	sent []MessageModel
+}
+
+// ensure that we've conformed to the `Provider` with a compile-time check
+var _ Provider = (*Loopback)(nil)
+
+// NewLoopback 创建 loopback 通道，w 为 nil 时只记录在内存中
+func NewLoopback(w io.Writer) *Loopback {
+	return &Loopback{w: w}
+}
+
+// NewFileProvider 创建追加写入 path 的 loopback 通道
+func NewFileProvider(path string) (*Loopback, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoopback(f), nil
+}
+
+// Name 返回通道名称
+func (l *Loopback) Name() string {
+	if l.w != nil {
+		return "file"
+	}
+	return "loopback"
+}
+
+// Send 记录消息，以记录序号作为通道侧的消息 ID
+func (l *Loopback) Send(ctx context.Context, msg *MessageModel) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w != nil {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return "", err
+		}
+		if _, err := l.w.Write(append(line, '\n')); err != nil {
+			return "", err
+		}
+	}
+	l.sent = append(l.sent, *msg)
+	return fmt.Sprintf("%s-%d", l.Name(), len(l.sent)), nil
+}
+
+// Sent 返回已记录消息的副本，按发送顺序排列
+func (l *Loopback) Sent() []MessageModel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]MessageModel(nil), l.sent...)
+}
@@ -0,0 +1,189 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// fakeGateway 模拟短信网关，记录收到的请求
+type fakeGateway struct {
+	*httptest.Server
+	status   int
+	auth     []string
+	received []gatewayRequest
+}
+
+func newFakeGateway(t *testing.T, status int) *fakeGateway {
+	g := &fakeGateway{status: status}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gatewayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		g.auth = append(g.auth, r.Header.Get("Authorization"))
+		g.received = append(g.received, req)
+		if g.status != http.StatusOK {
+			http.Error(w, "gateway unavailable", g.status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"message_id":"gw-%d"}`, req.ID)
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	loopback := NewLoopback(nil)
+	registry.Register(Sms, loopback)
+
+	p, err := registry.Provider(Sms)
+	require.NoError(t, err)
+	assert.Equal(t, "loopback", p.Name())
+
+	_, err = registry.Provider(Wechat)
+	assert.ErrorIs(t, err, ErrNoProvider)
+
+	receipt, err := registry.Send(context.Background(), &MessageModel{ID: 1, Type: Sms, Content: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, Receipt{Provider: "loopback", MessageID: "loopback-1"}, receipt)
+	_, err = registry.Send(context.Background(), &MessageModel{Type: Broadcast})
+	assert.ErrorIs(t, err, ErrNoProvider)
+	require.Len(t, loopback.Sent(), 1)
+	assert.Equal(t, "hi", loopback.Sent()[0].Content)
+}
+
+func TestLoopbackWritesJSONLines(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	loopback := NewLoopback(&buf)
+	assert.Equal(t, "file", loopback.Name())
+
+	_, err := loopback.Send(context.Background(), &MessageModel{ID: 1, Type: Sms, Content: "a"})
+	require.NoError(t, err)
+	_, err = loopback.Send(context.Background(), &MessageModel{ID: 2, Type: Sms, Content: "b"})
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var msg MessageModel
+	require.NoError(t, json.Unmarshal(lines[1], &msg))
+	assert.Equal(t, int64(2), msg.ID)
+}
+
+func TestHTTPProvider(t *testing.T) {
+	t.Parallel()
+
+	gateway := newFakeGateway(t, http.StatusOK)
+	p := NewHTTPProvider(HTTPProviderConfig{URL: gateway.URL, Token: "secret"})
+	assert.Equal(t, "http", p.Name())
+
+	msg := &MessageModel{ID: 7, Type: Sms, Content: "验证码：123456", PhoneNumber: "8613800138000"}
+	id, err := p.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, "gw-7", id)
+
+	require.Len(t, gateway.received, 1)
+	assert.Equal(t, "Bearer secret", gateway.auth[0])
+	assert.Equal(t, int64(7), gateway.received[0].ID)
+	assert.Equal(t, "8613800138000", gateway.received[0].PhoneNumber)
+}
+
+func TestHTTPProviderGatewayError(t *testing.T) {
+	t.Parallel()
+
+	gateway := newFakeGateway(t, http.StatusServiceUnavailable)
+	p := NewHTTPProvider(HTTPProviderConfig{URL: gateway.URL})
+
+	_, err := p.Send(context.Background(), &MessageModel{ID: 1, Type: Sms})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.Contains(t, err.Error(), "gateway unavailable")
+	assert.Equal(t, "", gateway.auth[0])
+}
+
+func TestSetupProviders(t *testing.T) {
+	gateway := newFakeGateway(t, http.StatusOK)
+	file := filepath.Join(t.TempDir(), "messages.log")
+
+	t.Setenv("MESSAGE_PROVIDER", "file")
+	t.Setenv("MESSAGE_PROVIDER_FILE", file)
+	t.Setenv("MESSAGE_PROVIDER_SMS", "http")
+	t.Setenv("MESSAGE_HTTP_URL_SMS", gateway.URL)
+	t.Setenv("MESSAGE_HTTP_NAME_SMS", "acme")
+	t.Setenv("MESSAGE_PROVIDER_WECHAT", "none")
+
+	registry, err := SetupProviders()
+	require.NoError(t, err)
+
+	sms, err := registry.Provider(Sms)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", sms.Name())
+
+	site, err := registry.Provider(Sitemessage)
+	require.NoError(t, err)
+	assert.Equal(t, "file", site.Name())
+	_, err = site.Send(context.Background(), &MessageModel{ID: 1, Type: Sitemessage})
+	require.NoError(t, err)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"sitemessage"`)
+
+	_, err = registry.Provider(Wechat)
+	assert.ErrorIs(t, err, ErrNoProvider)
+
+	t.Setenv("MESSAGE_PROVIDER", "carrier-pigeon")
+	_, err = SetupProviders()
+	assert.Error(t, err)
+
+	t.Setenv("MESSAGE_PROVIDER", "http")
+	t.Setenv("MESSAGE_HTTP_URL_SMS", "")
+	_, err = SetupProviders()
+	assert.Error(t, err)
+}
+
+func TestCreateMessageDelivers(t *testing.T) {
+	t.Parallel()
+
+	loopback := NewLoopback(nil)
+	failing := NewHTTPProvider(HTTPProviderConfig{URL: newFakeGateway(t, http.StatusBadGateway).URL})
+	registry := NewRegistry()
+	registry.Register(Sitemessage, loopback)
+	registry.Register(Sms, failing)
+
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t)}, Config{Providers: registry}).Routes(r)
+
+	resp := postMessage(r, `{"type":"sitemessage","content":"hello","user_id":1}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var site SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &site))
+	assert.Equal(t, Sent, site.Status)
+	require.Len(t, loopback.Sent(), 1)
+	assert.Equal(t, site.Id, loopback.Sent()[0].ID)
+
+	// 网关失败时消息仍然创建成功，状态为 failed
+	resp = postMessage(r, `{"type":"sms","content":"hello","phone_number":"8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.Equal(t, Failed, sms.Status)
+}
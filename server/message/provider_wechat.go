@@ -0,0 +1,271 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// DefaultWechatURL 微信公众平台接口地址
+const DefaultWechatURL = "https://api.weixin.qq.com"
+
+const (
+	// maxWechatIDLength openid 与模板ID的最大长度，与数据库字段一致
+	maxWechatIDLength = 64
+
+	// maxWechatURLLength 跳转链接的最大长度
+	maxWechatURLLength = 512
+
+	// wechatTokenMargin 提前刷新 access_token 的时间，避免发送途中过期
+	wechatTokenMargin = 5 * time.Minute
+)
+
+// 微信接口中表示 access_token 无效或过期的错误码，遇到时刷新令牌后重试一次
+const (
+	wechatInvalidCredential = 40001
+	wechatInvalidToken      = 40014
+	wechatTokenExpired      = 42001
+)
+
+// validateWechat 校验微信模板消息的接收方、模板ID和跳转链接
+func validateWechat(msg WechatMessage) []errors.FieldError {
+	var fields []errors.FieldError
+	switch {
+	case msg.Openid == "":
+		fields = append(fields, errors.FieldError{Field: "openid", Message: "微信消息必须包含 openid"})
+	case len(msg.Openid) > maxWechatIDLength:
+		fields = append(fields, errors.FieldError{Field: "openid", Message: "openid 过长"})
+	}
+	switch {
+	case msg.WechatTemplateId == "":
+		fields = append(fields, errors.FieldError{Field: "wechat_template_id", Message: "微信消息必须包含 wechat_template_id"})
+	case len(msg.WechatTemplateId) > maxWechatIDLength:
+		fields = append(fields, errors.FieldError{Field: "wechat_template_id", Message: "wechat_template_id 过长"})
+	}
+	if msg.Url != nil && *msg.Url != "" {
+		u, err := url.Parse(*msg.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(*msg.Url) > maxWechatURLLength {
+			fields = append(fields, errors.FieldError{Field: "url", Message: "无效的跳转链接"})
+		}
+	}
+	if msg.Data != nil {
+		if _, ok := (*msg.Data)[""]; ok {
+			fields = append(fields, errors.FieldError{Field: "data", Message: "模板字段名不能为空"})
+		}
+	}
+	return fields
+}
+
+// WechatProviderConfig 微信模板消息通道的配置
+type WechatProviderConfig struct {
+	AppID     string       // 公众号的 AppID
+	AppSecret string       // 公众号的 AppSecret，用于获取 access_token
+	BaseURL   string       // 接口地址，默认 DefaultWechatURL，测试时指向本地的模拟服务
+	Client    *http.Client // 为 nil 时使用 10 秒超时的默认客户端
+	Clock     module.Clock // 判断 access_token 是否过期，为 nil 时使用系统时间
+}
+
+// WechatProvider 调用微信公众平台的模板消息接口投递消息
+// access_token 在进程内缓存，过期前自动刷新；接口报告令牌失效时刷新后重试一次
+type WechatProvider struct {
+	appID   string
+	secret  string
+	baseURL string
+	client  *http.Client
+	clock   module.Clock
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// ensure that we've conformed to the `Provider` with a compile-time check
+var _ Provider = (*WechatProvider)(nil)
+
+// NewWechatProvider 创建微信模板消息通道
+func NewWechatProvider(cfg WechatProviderConfig) *WechatProvider {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultWechatURL
+	}
+	clock := cfg.Clock
+	if clock == nil {
+		clock = module.SystemClock
+	}
+	return &WechatProvider{
+		appID:   cfg.AppID,
+		secret:  cfg.AppSecret,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+		clock:   clock,
+	}
+}
+
+// WechatError 微信接口返回的错误
+type WechatError struct {
+	Code    int
+	Message string
+}
+
+func (e *WechatError) Error() string {
+	return fmt.Sprintf("message: wechat provider: errcode %d: %s", e.Code, e.Message)
+}
+
+// tokenInvalid 判断错误是否表示 access_token 无效或过期
+func (e *WechatError) tokenInvalid() bool {
+	switch e.Code {
+	case wechatInvalidCredential, wechatInvalidToken, wechatTokenExpired:
+		return true
+	}
+	return false
+}
+
+// wechatValue 模板字段的取值
+type wechatValue struct {
+	Value string `json:"value"`
+}
+
+// wechatTemplateRequest 模板消息接口的请求体
+type wechatTemplateRequest struct {
+	ToUser     string                 `json:"touser"`
+	TemplateID string                 `json:"template_id"`
+	URL        string                 `json:"url,omitempty"`
+	Data       map[string]wechatValue `json:"data"`
+}
+
+// wechatResponse 微信接口的响应体，成功时 errcode 为 0 或省略
+type wechatResponse struct {
+	ErrCode     int    `json:"errcode"`
+	ErrMsg      string `json:"errmsg"`
+	MsgID       int64  `json:"msgid"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Name 返回通道名称
+func (p *WechatProvider) Name() string {
+	return "wechat"
+}
+
+// Send 发送模板消息，返回微信的 msgid 作为通道侧的消息 ID
+func (p *WechatProvider) Send(ctx context.Context, msg *MessageModel) (string, error) {
+	data := make(map[string]wechatValue, len(msg.WechatData))
+	for k, v := range msg.WechatData {
+		data[k] = wechatValue{Value: v}
+	}
+	body, err := json.Marshal(wechatTemplateRequest{
+		ToUser:     msg.OpenID,
+		TemplateID: msg.WechatTemplateID,
+		URL:        msg.WechatURL,
+		Data:       data,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.sendTemplate(ctx, body, false)
+	var wechatErr *WechatError
+	if stderrors.As(err, &wechatErr) && wechatErr.tokenInvalid() {
+		// 令牌可能被其他进程刷新而失效，强制刷新后重试
+		resp, err = p.sendTemplate(ctx, body, true)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(resp.MsgID, 10), nil
+}
+
+// sendTemplate 调用模板消息接口，refresh 为 true 时先丢弃缓存的 access_token
+func (p *WechatProvider) sendTemplate(ctx context.Context, body []byte, refresh bool) (*wechatResponse, error) {
+	token, err := p.accessToken(ctx, refresh)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := p.baseURL + "/cgi-bin/message/template/send?access_token=" + url.QueryEscape(token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return p.do(req)
+}
+
+// accessToken 返回缓存的 access_token，过期或 refresh 时重新获取
+// 获取期间持有锁，并发发送时只请求一次令牌
+func (p *WechatProvider) accessToken(ctx context.Context, refresh bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	if !refresh && p.token != "" && now.Before(p.expiresAt) {
+		return p.token, nil
+	}
+
+	query := url.Values{}
+	query.Set("grant_type", "client_credential")
+	query.Set("appid", p.appID)
+	query.Set("secret", p.secret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/cgi-bin/token?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+	if resp.AccessToken == "" {
+		return "", fmt.Errorf("message: wechat provider: empty access_token")
+	}
+
+	p.token = resp.AccessToken
+	p.expiresAt = now.Add(time.Duration(resp.ExpiresIn)*time.Second - wechatTokenMargin)
+	return p.token, nil
+}
+
+// do 发出请求并解析响应，HTTP 错误或 errcode 不为 0 时返回错误
+func (p *WechatProvider) do(req *http.Request) (*wechatResponse, error) {
+	// 错误信息中不能带上含 secret 或 access_token 的地址
+	path := req.URL.Path
+	resp, err := p.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if stderrors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("message: wechat provider: %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(detail) > 512 {
+			detail = detail[:512]
+		}
+		return nil, fmt.Errorf("message: wechat provider: %s returned %s: %s", path, resp.Status, bytes.TrimSpace(detail))
+	}
+
+	var result wechatResponse
+	if err := json.Unmarshal(detail, &result); err != nil {
+		return nil, fmt.Errorf("message: wechat provider: %s: invalid response: %w", path, err)
+	}
+	if result.ErrCode != 0 {
+		return nil, &WechatError{Code: result.ErrCode, Message: result.ErrMsg}
+	}
+	return &result, nil
+}
@@ -0,0 +1,259 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// fakeWechat 模拟微信公众平台的令牌和模板消息接口
+type fakeWechat struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	tokens   int                     // 已签发的令牌数
+	valid    string                  // 当前有效的令牌，签发新令牌后旧令牌失效
+	errcode  int                     // 模板消息接口固定返回的错误码，0 表示成功
+	received []wechatTemplateRequest // 成功接收的模板消息
+}
+
+func newFakeWechat(t *testing.T) *fakeWechat {
+	f := &fakeWechat{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cgi-bin/token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		query := r.URL.Query()
+		if query.Get("grant_type") != "client_credential" || query.Get("appid") != "wx-app" || query.Get("secret") != "wx-secret" {
+			fmt.Fprint(w, `{"errcode":40013,"errmsg":"invalid appid"}`)
+			return
+		}
+		f.tokens++
+		f.valid = fmt.Sprintf("token-%d", f.tokens)
+		fmt.Fprintf(w, `{"access_token":%q,"expires_in":7200}`, f.valid)
+	})
+	mux.HandleFunc("POST /cgi-bin/message/template/send", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.URL.Query().Get("access_token") != f.valid {
+			fmt.Fprint(w, `{"errcode":40001,"errmsg":"invalid credential"}`)
+			return
+		}
+		if f.errcode != 0 {
+			fmt.Fprintf(w, `{"errcode":%d,"errmsg":"require subscribe"}`, f.errcode)
+			return
+		}
+		var req wechatTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		f.received = append(f.received, req)
+		fmt.Fprintf(w, `{"errcode":0,"errmsg":"ok","msgid":%d}`, 200228330+len(f.received))
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// expire 让当前令牌失效，模拟其他进程刷新了令牌
+func (f *fakeWechat) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.valid = ""
+}
+
+func (f *fakeWechat) issued() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokens
+}
+
+func (f *fakeWechat) sent() []wechatTemplateRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]wechatTemplateRequest(nil), f.received...)
+}
+
+func newTestWechatProvider(f *fakeWechat, clock module.Clock) *WechatProvider {
+	return NewWechatProvider(WechatProviderConfig{AppID: "wx-app", AppSecret: "wx-secret", BaseURL: f.URL, Clock: clock})
+}
+
+func TestWechatProviderSend(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeWechat(t)
+	clock := &testClock{now: time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)}
+	p := newTestWechatProvider(f, clock)
+	assert.Equal(t, "wechat", p.Name())
+
+	msg := &MessageModel{
+		ID:               1,
+		Type:             Wechat,
+		OpenID:           "o-user",
+		WechatTemplateID: "tpl-shipped",
+		WechatURL:        "https://example.com/orders/1",
+		WechatData:       map[string]string{"first": "您的订单已发货", "keyword1": "1001"},
+	}
+	id, err := p.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, "200228331", id)
+	_, err = p.Send(context.Background(), msg)
+	require.NoError(t, err)
+
+	// 令牌在有效期内复用
+	assert.Equal(t, 1, f.issued())
+	sent := f.sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, wechatTemplateRequest{
+		ToUser:     "o-user",
+		TemplateID: "tpl-shipped",
+		URL:        "https://example.com/orders/1",
+		Data:       map[string]wechatValue{"first": {Value: "您的订单已发货"}, "keyword1": {Value: "1001"}},
+	}, sent[0])
+
+	// 临近过期时提前刷新
+	clock.Advance(2*time.Hour - wechatTokenMargin)
+	_, err = p.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.issued())
+
+	// 令牌被其他进程刷新而失效时，刷新后重试一次
+	f.expire()
+	_, err = p.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.issued())
+	assert.Len(t, f.sent(), 4)
+}
+
+func TestWechatProviderErrors(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeWechat(t)
+	msg := &MessageModel{ID: 1, Type: Wechat, OpenID: "o-user", WechatTemplateID: "tpl"}
+
+	// 其他错误码不重试
+	f.errcode = 43004
+	_, err := newTestWechatProvider(f, nil).Send(context.Background(), msg)
+	var wechatErr *WechatError
+	require.ErrorAs(t, err, &wechatErr)
+	assert.Equal(t, 43004, wechatErr.Code)
+	assert.Equal(t, 1, f.issued())
+
+	// 错误信息中不包含 AppSecret
+	p := NewWechatProvider(WechatProviderConfig{AppID: "wx-app", AppSecret: "wrong-secret", BaseURL: f.URL})
+	_, err = p.Send(context.Background(), msg)
+	require.ErrorAs(t, err, &wechatErr)
+	assert.Equal(t, 40013, wechatErr.Code)
+	assert.NotContains(t, err.Error(), "wrong-secret")
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	p = NewWechatProvider(WechatProviderConfig{AppID: "wx-app", AppSecret: "wx-secret", BaseURL: down.URL})
+	_, err = p.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "wx-secret")
+}
+
+func TestCreateWechatMessage(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeWechat(t)
+	registry := NewRegistry()
+	registry.Register(Wechat, newTestWechatProvider(f, nil))
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t)}, Config{Providers: registry}).Routes(r)
+
+	resp := postMessage(r, `{"type":"wechat","content":"订单已发货","openid":"o-user","wechat_template_id":"tpl-shipped",
+		"url":"https://example.com/orders/1","data":{"first":"您的订单已发货","keyword1":"1001"}}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var msg WechatMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	assert.Equal(t, Wechat, msg.Type)
+	assert.Equal(t, Sent, msg.Status)
+	assert.Equal(t, "o-user", msg.Openid)
+	assert.Equal(t, "tpl-shipped", msg.WechatTemplateId)
+	require.NotNil(t, msg.Url)
+	assert.Equal(t, "https://example.com/orders/1", *msg.Url)
+	require.NotNil(t, msg.Data)
+	assert.Equal(t, map[string]string{"first": "您的订单已发货", "keyword1": "1001"}, *msg.Data)
+
+	sent := f.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "o-user", sent[0].ToUser)
+
+	// 定时发送时保存微信专属字段，到期生成的消息与直接发送的一致
+	resp = postMessage(r, `{"type":"wechat","content":"提醒","openid":"o-user","wechat_template_id":"tpl-remind","send_at":"2099-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var schedule Schedule
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &schedule))
+	require.NotNil(t, schedule.Openid)
+	assert.Equal(t, "o-user", *schedule.Openid)
+
+	for _, body := range []string{
+		`{"type":"wechat","content":"x","wechat_template_id":"tpl"}`,
+		`{"type":"wechat","content":"x","openid":"o-user"}`,
+		`{"type":"wechat","content":"x","openid":"o-user","wechat_template_id":"tpl","url":"javascript:alert(1)"}`,
+		`{"type":"wechat","content":"x","openid":"o-user","wechat_template_id":"tpl","data":{"first":1}}`,
+	} {
+		resp := postMessage(r, body)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, body)
+	}
+}
+
+func TestScheduleCarriesWechatFields(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	at := now.Add(time.Hour)
+	msg := &MessageModel{
+		Type:             Wechat,
+		Content:          "提醒",
+		OpenID:           "o-user",
+		WechatTemplateID: "tpl",
+		WechatURL:        "https://example.com",
+		WechatData:       map[string]string{"first": "x"},
+	}
+	s, err := NewSchedule(msg, &at, "", "", now)
+	require.NoError(t, err)
+
+	generated := s.Message()
+	assert.Equal(t, msg.OpenID, generated.OpenID)
+	assert.Equal(t, msg.WechatTemplateID, generated.WechatTemplateID)
+	assert.Equal(t, msg.WechatURL, generated.WechatURL)
+	assert.Equal(t, msg.WechatData, generated.WechatData)
+}
+
+func TestSetupWechatProvider(t *testing.T) {
+	t.Setenv("MESSAGE_PROVIDER", "loopback")
+	t.Setenv("MESSAGE_PROVIDER_WECHAT", "wechat")
+	t.Setenv("MESSAGE_WECHAT_APPID", "wx-app")
+	t.Setenv("MESSAGE_WECHAT_SECRET", "wx-secret")
+
+	registry, err := SetupProviders()
+	require.NoError(t, err)
+	p, err := registry.Provider(Wechat)
+	require.NoError(t, err)
+	assert.Equal(t, "wechat", p.Name())
+
+	t.Setenv("MESSAGE_WECHAT_SECRET", "")
+	_, err = SetupProviders()
+	assert.Error(t, err)
+
+	// wechat 通道只能投递微信消息
+	t.Setenv("MESSAGE_WECHAT_SECRET", "wx-secret")
+	t.Setenv("MESSAGE_PROVIDER_SMS", "wechat")
+	_, err = SetupProviders()
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours 短信免打扰时段，按接收方所在时区的当地时间计算
+// Start 与 End 为当天零点起的时长，Start 大于 End 表示跨越午夜，如 22:00-08:00；
+// 两者相等时不启用
+type QuietHours struct {
+	Start time.Duration
+	End   time.Duration
+
+	// Timezone 请求未提供 recipient_timezone 时使用的 IANA 时区，默认 UTC
+	Timezone string
+}
+
+// ParseQuietHours 解析 "HH:MM-HH:MM" 格式的免打扰时段，空字符串表示不启用
+func ParseQuietHours(s, timezone string) (QuietHours, error) {
+	q := QuietHours{Timezone: timezone}
+	if strings.TrimSpace(s) == "" {
+		return q, nil
+	}
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return q, fmt.Errorf("message: invalid quiet hours %q, want HH:MM-HH:MM", s)
+	}
+	var err error
+	if q.Start, err = parseClock(from); err != nil {
+		return q, fmt.Errorf("message: invalid quiet hours %q: %w", s, err)
+	}
+	if q.End, err = parseClock(to); err != nil {
+		return q, fmt.Errorf("message: invalid quiet hours %q: %w", s, err)
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return q, fmt.Errorf("message: invalid quiet hours timezone %q", timezone)
+		}
+	}
+	return q, nil
+}
+
+// parseClock 解析 HH:MM 格式的当天时刻
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Enabled 是否配置了免打扰时段
+func (q QuietHours) Enabled() bool {
+	return q.Start != q.End
+}
+
+// location 返回接收方的时区，timezone 为空时使用默认时区
+func (q QuietHours) location(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		timezone = q.Timezone
+	}
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(timezone)
+}
+
+// DeferUntil 判断 now 是否处于 loc 时区的免打扰时段内，是则返回时段结束的时间（UTC）
+func (q QuietHours) DeferUntil(now time.Time, loc *time.Location) (time.Time, bool) {
+	if !q.Enabled() {
+		return time.Time{}, false
+	}
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	elapsed := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+
+	// 按日期和时刻构造结束时间，而不是在零点上加时长，跨越夏令时切换时也落在当地的 End
+	endOn := func(days int) time.Time {
+		return time.Date(midnight.Year(), midnight.Month(), midnight.Day()+days,
+			int(q.End/time.Hour), int(q.End%time.Hour/time.Minute), 0, 0, loc).UTC()
+	}
+	switch {
+	case q.Start < q.End && elapsed >= q.Start && elapsed < q.End:
+		return endOn(0), true
+	case q.Start > q.End && elapsed >= q.Start:
+		return endOn(1), true
+	case q.Start > q.End && elapsed < q.End:
+		return endOn(0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,106 @@
+package message
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	t.Parallel()
+
+	q, err := ParseQuietHours("22:00-08:30", "Asia/Shanghai")
+	require.NoError(t, err)
+	assert.Equal(t, QuietHours{Start: 22 * time.Hour, End: 8*time.Hour + 30*time.Minute, Timezone: "Asia/Shanghai"}, q)
+	assert.True(t, q.Enabled())
+
+	q, err = ParseQuietHours("", "")
+	require.NoError(t, err)
+	assert.False(t, q.Enabled())
+
+	for _, spec := range []string{"22:00", "22-08", "25:00-08:00", "22:00-8am"} {
+		_, err := ParseQuietHours(spec, "")
+		assert.Error(t, err, spec)
+	}
+	_, err = ParseQuietHours("22:00-08:00", "Mars/Olympus")
+	assert.Error(t, err)
+}
+
+func TestQuietHoursDeferUntil(t *testing.T) {
+	t.Parallel()
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	overnight := QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour}
+	daytime := QuietHours{Start: 12 * time.Hour, End: 14 * time.Hour}
+	tests := []struct {
+		name  string
+		q     QuietHours
+		now   time.Time
+		loc   *time.Location
+		until time.Time // 零值表示不推迟
+	}{
+		{"before start", overnight, time.Date(2025, 6, 2, 21, 59, 0, 0, shanghai), shanghai, time.Time{}},
+		{"after start", overnight, time.Date(2025, 6, 2, 23, 0, 0, 0, shanghai), shanghai, time.Date(2025, 6, 3, 8, 0, 0, 0, shanghai)},
+		{"after midnight", overnight, time.Date(2025, 6, 3, 2, 0, 0, 0, shanghai), shanghai, time.Date(2025, 6, 3, 8, 0, 0, 0, shanghai)},
+		{"at end", overnight, time.Date(2025, 6, 3, 8, 0, 0, 0, shanghai), shanghai, time.Time{}},
+		{"same day window", daytime, time.Date(2025, 6, 2, 13, 0, 0, 0, shanghai), shanghai, time.Date(2025, 6, 2, 14, 0, 0, 0, shanghai)},
+		{"outside same day window", daytime, time.Date(2025, 6, 2, 23, 0, 0, 0, shanghai), shanghai, time.Time{}},
+		// 同一时刻在上海是白天，在纽约是深夜
+		{"recipient timezone", overnight, time.Date(2025, 6, 2, 12, 0, 0, 0, shanghai), newYork, time.Date(2025, 6, 2, 8, 0, 0, 0, newYork)},
+		// 纽约 2025-03-09 凌晨切换夏令时，结束时间仍是当地 8 点
+		{"dst", overnight, time.Date(2025, 3, 8, 23, 0, 0, 0, newYork), newYork, time.Date(2025, 3, 9, 8, 0, 0, 0, newYork)},
+		{"disabled", QuietHours{}, time.Date(2025, 6, 2, 23, 0, 0, 0, shanghai), shanghai, time.Time{}},
+	}
+	for _, tt := range tests {
+		until, quiet := tt.q.DeferUntil(tt.now, tt.loc)
+		assert.Equal(t, !tt.until.IsZero(), quiet, tt.name)
+		if quiet {
+			assert.True(t, tt.until.Equal(until), "%s: got %s, want %s", tt.name, until, tt.until)
+			assert.Equal(t, time.UTC, until.Location(), tt.name)
+		}
+	}
+}
+
+func TestCreateMessageQuietHours(t *testing.T) {
+	t.Parallel()
+
+	// 当前时间 2025-06-02 08:00 UTC，即上海 16:00、纽约 04:00
+	r, clock := setupLimitedRouter(t, Config{QuietHours: QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour, Timezone: "Asia/Shanghai"}})
+
+	// 默认时区不在免打扰时段内，立即发送
+	resp := postMessage(r, `{"type":"sms","content":"您好","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 接收方在纽约时推迟到当地 8 点
+	resp = postMessage(r, `{"type":"sms","content":"您好","phone_number":"+12025550100","recipient_timezone":"America/New_York","sender":"marketing"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var schedule Schedule
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &schedule))
+	require.NotNil(t, schedule.NextRunAt)
+	assert.Equal(t, time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC), schedule.NextRunAt.UTC())
+	assert.Equal(t, SchedulePending, schedule.Status)
+	require.NotNil(t, schedule.Sender)
+	assert.Equal(t, "marketing", *schedule.Sender)
+
+	// 紧急短信不推迟
+	resp = postMessage(r, `{"type":"sms","content":"告警","phone_number":"+12025550100","recipient_timezone":"America/New_York","urgent":true}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 站内信不受免打扰时段限制
+	clock.Advance(7 * time.Hour)
+	resp = postMessage(r, `{"type":"sitemessage","content":"欢迎","user_id":1001}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = postMessage(r, `{"type":"sms","content":"您好","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+
+	resp = postMessage(r, `{"type":"sms","content":"您好","phone_number":"+8613800138000","recipient_timezone":"Mars/Olympus"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
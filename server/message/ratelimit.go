@@ -0,0 +1,186 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+// ErrRateLimited 表示超过发送频率限制
+var ErrRateLimited = stderrors.New("rate limit exceeded")
+
+// RateLimitScope 限流的维度
+type RateLimitScope string
+
+const (
+	// ScopePhoneNumber 按接收手机号限流，适用于短信
+	ScopePhoneNumber RateLimitScope = "phone_number"
+
+	// ScopeEmail 按收件人邮箱限流，适用于邮件
+	ScopeEmail RateLimitScope = "email"
+
+	// ScopeUser 按接收用户限流，适用于站内信
+	ScopeUser RateLimitScope = "user"
+
+	// ScopeSender 按消息的 sender 限流，适用于所有类型
+	ScopeSender RateLimitScope = "sender"
+)
+
+// scopeLabels 限流维度在错误信息中的名称
+var scopeLabels = map[RateLimitScope]string{
+	ScopePhoneNumber: "该手机号",
+	ScopeEmail:       "该邮箱",
+	ScopeUser:        "该用户",
+	ScopeSender:      "该发送方",
+}
+
+// RateLimit 一条限流规则：同一维度取值在每个 Window 内最多发送 Limit 条
+// 窗口按 UTC 对齐，例如 24 小时的窗口从 UTC 零点开始计算
+type RateLimit struct {
+	Scope  RateLimitScope
+	Limit  int
+	Window time.Duration
+}
+
+// windowNames 限流配置中可以使用的窗口名称
+var windowNames = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// ParseRateLimits 解析 "scope:limit/window,scope:limit/window" 格式的限流配置，
+// window 可以是 second、minute、hour、day 或 time.ParseDuration 支持的时长，
+// 例如 "phone_number:1/minute,phone_number:10/day,sender:1000/1h"
+func ParseRateLimits(s string) ([]RateLimit, error) {
+	var limits []RateLimit
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		scope, spec, ok := strings.Cut(rule, ":")
+		limit, window, ok2 := strings.Cut(spec, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("message: invalid rate limit %q, want scope:limit/window", rule)
+		}
+		if _, known := scopeLabels[RateLimitScope(scope)]; !known {
+			return nil, fmt.Errorf("message: invalid rate limit %q: unknown scope %q", rule, scope)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("message: invalid rate limit %q: limit must be a positive integer", rule)
+		}
+		d, ok := windowNames[window]
+		if !ok {
+			if d, err = time.ParseDuration(window); err != nil || d < time.Second {
+				return nil, fmt.Errorf("message: invalid rate limit %q: window must be at least 1s", rule)
+			}
+		}
+		limits = append(limits, RateLimit{Scope: RateLimitScope(scope), Limit: n, Window: d})
+	}
+	return limits, nil
+}
+
+// RateLimitCounter 一次发送需要计入的计数器
+type RateLimitCounter struct {
+	RateLimit
+	Value string // 维度的取值，如手机号
+}
+
+// key 计数器在存储中的标识，同一维度取值的不同窗口分开计数
+func (c RateLimitCounter) key() string {
+	return fmt.Sprintf("%s:%s:%s", c.Scope, c.Window, c.Value)
+}
+
+// windowStart 返回 now 所在窗口的开始时间
+func (c RateLimitCounter) windowStart(now time.Time) time.Time {
+	return now.UTC().Truncate(c.Window)
+}
+
+// RateLimitModel 一个计数器在一个窗口内的发送次数，窗口结束后可以删除
+type RateLimitModel struct {
+	Counter     string    `gorm:"primaryKey;size:160" json:"counter"`
+	WindowStart time.Time `gorm:"primaryKey" json:"window_start"`
+	Hits        int       `gorm:"not null" json:"hits"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// TableName 指定限流计数表名
+func (RateLimitModel) TableName() string {
+	return "message_rate_limits"
+}
+
+// RateLimitError 描述被拒绝的发送：触发的规则以及距离窗口结束的时间
+type RateLimitError struct {
+	RateLimitCounter
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded: %s %s allows %d per %s", e.Scope, e.Value, e.Limit, e.Window)
+}
+
+// Is 使 errors.Is(err, ErrRateLimited) 成立
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimitRepository 限流计数的数据访问接口
+type RateLimitRepository interface {
+	// ConsumeRateLimits 在同一事务中为每个计数器计入一次发送，同时清理已结束的窗口
+	// 任一计数器超出上限时都不计入，并返回 *RateLimitError
+	ConsumeRateLimits(ctx context.Context, counters []RateLimitCounter, now time.Time) error
+}
+
+// rateLimitCounters 返回消息需要计入的计数器，消息没有对应维度的取值时跳过该规则
+func (h *Server) rateLimitCounters(msg *MessageModel) []RateLimitCounter {
+	var counters []RateLimitCounter
+	for _, limit := range h.rateLimits {
+		var value string
+		switch limit.Scope {
+		case ScopePhoneNumber:
+			value = msg.PhoneNumber
+		case ScopeEmail:
+			value = strings.ToLower(msg.Email)
+		case ScopeUser:
+			if msg.UserID != 0 {
+				value = strconv.FormatInt(msg.UserID, 10)
+			}
+		case ScopeSender:
+			value = msg.Sender
+		}
+		if value != "" {
+			counters = append(counters, RateLimitCounter{RateLimit: limit, Value: value})
+		}
+	}
+	return counters
+}
+
+// consumeRateLimits 为消息计入发送次数，超过限制时返回 429 错误
+func (h *Server) consumeRateLimits(ctx context.Context, msg *MessageModel) error {
+	counters := h.rateLimitCounters(msg)
+	if len(counters) == 0 {
+		return nil
+	}
+	err := h.messages.ConsumeRateLimits(ctx, counters, h.clock.Now().UTC())
+	if err == nil {
+		return nil
+	}
+
+	var limitErr *RateLimitError
+	if stderrors.As(err, &limitErr) {
+		h.logger.Info("超过发送频率限制", "scope", limitErr.Scope, "value", limitErr.Value,
+			"limit", limitErr.Limit, "window", limitErr.Window)
+		message := fmt.Sprintf("%s发送过于频繁，请稍后再试", scopeLabels[limitErr.Scope])
+		return errors.TooManyRequests(message, limitErr.RetryAfter)
+	}
+	h.logger.Error("记录发送频率失败", "error", err)
+	return errors.InternalServer("记录发送频率失败")
+}
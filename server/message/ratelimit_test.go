@@ -0,0 +1,173 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// setupLimitedRouter 创建带发送限制的消息模块，当前时间为 2025-06-02 08:00 UTC
+func setupLimitedRouter(t *testing.T, cfg Config) (*chi.Mux, *testClock) {
+	clock := &testClock{now: time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)}
+	h := NewServer(NewGormRepository(setupTestDB(t)), module.Deps{Clock: clock}, cfg)
+	r := chi.NewRouter()
+	h.Routes(r)
+	return r, clock
+}
+
+func TestParseRateLimits(t *testing.T) {
+	t.Parallel()
+
+	limits, err := ParseRateLimits(" phone_number:1/minute, phone_number:10/day,sender:1000/30m ")
+	require.NoError(t, err)
+	assert.Equal(t, []RateLimit{
+		{Scope: ScopePhoneNumber, Limit: 1, Window: time.Minute},
+		{Scope: ScopePhoneNumber, Limit: 10, Window: 24 * time.Hour},
+		{Scope: ScopeSender, Limit: 1000, Window: 30 * time.Minute},
+	}, limits)
+
+	limits, err = ParseRateLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+
+	for _, spec := range []string{"phone_number", "phone_number:1", "ip:1/minute", "user:0/minute", "user:1/fortnight", "user:1/10ms"} {
+		_, err := ParseRateLimits(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCreateMessageRateLimits(t *testing.T) {
+	t.Parallel()
+
+	r, clock := setupLimitedRouter(t, Config{RateLimits: []RateLimit{
+		{Scope: ScopePhoneNumber, Limit: 1, Window: time.Minute},
+		{Scope: ScopePhoneNumber, Limit: 3, Window: 24 * time.Hour},
+	}})
+	sms := func(phone string) string {
+		return fmt.Sprintf(`{"type":"sms","content":"您好","phone_number":%q}`, phone)
+	}
+
+	clock.Advance(20 * time.Second)
+	resp := postMessage(r, sms("+86 138-0013-8000"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 同一号码的不同写法计入同一个计数器
+	resp = postMessage(r, sms("8613800138000"))
+	require.Equal(t, http.StatusTooManyRequests, resp.Code, resp.Body.String())
+	assert.Equal(t, "40", resp.Header().Get("Retry-After"))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, float64(40), body["retry_after"])
+	assert.Contains(t, body["message"], "手机号")
+
+	// 其他号码不受影响
+	resp = postMessage(r, sms("+8613900139000"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 下一个窗口恢复，被拒绝的请求不占用当天的额度
+	clock.Advance(time.Minute)
+	resp = postMessage(r, sms("+8613800138000"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	clock.Advance(time.Minute)
+	resp = postMessage(r, sms("+8613800138000"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 当天额度用完，等到 UTC 零点
+	clock.Advance(time.Minute)
+	resp = postMessage(r, sms("+8613800138000"))
+	require.Equal(t, http.StatusTooManyRequests, resp.Code, resp.Body.String())
+	assert.Equal(t, "57400", resp.Header().Get("Retry-After"))
+
+	// 无效的请求不计数
+	resp = postMessage(r, `{"type":"sms","content":"x","phone_number":"+8613700137000","send_at":"2020-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = postMessage(r, sms("+8613700137000"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+}
+
+func TestCreateMessageRateLimitsByUserAndSender(t *testing.T) {
+	t.Parallel()
+
+	r, _ := setupLimitedRouter(t, Config{RateLimits: []RateLimit{
+		{Scope: ScopeUser, Limit: 1, Window: time.Hour},
+		{Scope: ScopeSender, Limit: 2, Window: time.Hour},
+	}})
+
+	resp := postMessage(r, `{"type":"sitemessage","content":"欢迎","user_id":1001}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = postMessage(r, `{"type":"sitemessage","content":"欢迎","user_id":1001}`)
+	require.Equal(t, http.StatusTooManyRequests, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), "该用户")
+
+	resp = postMessage(r, `{"type":"sms","content":"a","phone_number":"+8613800138000","sender":"marketing"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	require.NotNil(t, sms.Sender)
+	assert.Equal(t, "marketing", *sms.Sender)
+
+	resp = postMessage(r, `{"type":"broadcast","content":"b","channel":"news","sender":"marketing"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = postMessage(r, `{"type":"sitemessage","content":"c","user_id":1002,"sender":"marketing"}`)
+	require.Equal(t, http.StatusTooManyRequests, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), "该发送方")
+
+	// 被发送方限制拒绝时用户维度也不计数
+	resp = postMessage(r, `{"type":"sitemessage","content":"c","user_id":1002,"sender":"billing"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 没有 sender 的消息不受发送方限制
+	resp = postMessage(r, `{"type":"sms","content":"d","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+}
+
+func TestSendOTPRateLimits(t *testing.T) {
+	t.Parallel()
+
+	r, clock := setupLimitedRouter(t, Config{RateLimits: []RateLimit{
+		{Scope: ScopePhoneNumber, Limit: 2, Window: 24 * time.Hour},
+	}})
+	resp := doTemplateRequest(r, http.MethodPost, "/message/templates", verificationTemplateJSON)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	// 验证码短信和普通短信共用手机号的额度
+	resp = sendOTP(r, `{"phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	resp = postMessage(r, `{"type":"sms","content":"您好","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	clock.Advance(2 * time.Minute)
+	resp = sendOTP(r, `{"phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusTooManyRequests, resp.Code, resp.Body.String())
+	assert.Equal(t, "57480", resp.Header().Get("Retry-After"))
+}
+
+func TestSendOTPCooldownDoesNotConsumeRateLimit(t *testing.T) {
+	t.Parallel()
+
+	r, _ := setupLimitedRouter(t, Config{RateLimits: []RateLimit{
+		{Scope: ScopePhoneNumber, Limit: 2, Window: 24 * time.Hour},
+	}})
+	resp := doTemplateRequest(r, http.MethodPost, "/message/templates", verificationTemplateJSON)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	resp = sendOTP(r, `{"phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+
+	// 冷却中被拒绝的请求不占用额度
+	for i := 0; i < 3; i++ {
+		resp = sendOTP(r, `{"phone_number":"+8613800138000"}`)
+		require.Equal(t, http.StatusTooManyRequests, resp.Code, resp.Body.String())
+		assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	}
+	resp = postMessage(r, `{"type":"sms","content":"您好","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+}
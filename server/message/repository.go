@@ -0,0 +1,51 @@
+package message
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMessageNotFound 表示消息不存在
+var ErrMessageNotFound = errors.New("message not found")
+
+// MessageRepository 消息数据访问接口
+// 处理器只依赖该接口，可以替换为不同的存储实现
+type MessageRepository interface {
+	ChannelRepository
+	TemplateRepository
+	ScheduleRepository
+	OTPRepository
+	RateLimitRepository
+	InboxRepository
+	OutboxRepository
+	SearchRepository
+	StatsRepository
+
+	// Create 保存新消息并回填 ID 和时间戳，同时记录初始状态
+	Create(ctx context.Context, msg *MessageModel) error
+
+	// FindByID 按 ID 查询消息，不存在时返回 ErrMessageNotFound
+	FindByID(ctx context.Context, id int64) (*MessageModel, error)
+
+	// Transition 按状态机把消息流转到 to 并记录流转日志，返回更新后的消息
+	// 不允许的流转返回 *TransitionError，状态未变化时不做任何修改
+	Transition(ctx context.Context, id int64, to MessageStatus, reason string) (*MessageModel, error)
+
+	// SaveReceipt 记录通道接收消息后返回的回执
+	SaveReceipt(ctx context.Context, id int64, receipt Receipt) error
+
+	// FindByReceipt 按通道名称和通道侧的消息 ID 查询消息，不存在时返回 ErrMessageNotFound
+	FindByReceipt(ctx context.Context, provider, providerMessageID string) (*MessageModel, error)
+
+	// ListTransitions 按时间先后列出消息的状态流转记录
+	ListTransitions(ctx context.Context, id int64) ([]StatusTransitionModel, error)
+
+	// FindByPhoneNumber 查询发往指定手机号的短信，按 ID 升序
+	FindByPhoneNumber(ctx context.Context, number string) ([]MessageModel, error)
+
+	// FindByUserID 查询发给指定用户的站内信，按 ID 升序
+	FindByUserID(ctx context.Context, userID int64) ([]MessageModel, error)
+
+	// FindByUserIDAfter 查询发给指定用户、ID 大于 afterID 的站内信，按 ID 升序，用于断线续传
+	FindByUserIDAfter(ctx context.Context, userID, afterID int64) ([]MessageModel, error)
+}
@@ -0,0 +1,950 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormRepository 基于 gorm 的 MessageRepository 实现，支持 SQLite/MySQL/Postgres
+type GormRepository struct {
+	db *gorm.DB
+}
+
+// ensure that we've conformed to the `MessageRepository` with a compile-time check
+var _ MessageRepository = (*GormRepository)(nil)
+
+// NewGormRepository 创建基于 gorm 的消息仓储
+func NewGormRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db: db}
+}
+
+// Create 保存新消息，ID 由数据库生成
+func (r *GormRepository) Create(ctx context.Context, msg *MessageModel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		return tx.Create(&StatusTransitionModel{MessageID: msg.ID, To: msg.Status, Reason: "created"}).Error
+	})
+}
+
+// FindByID 按 ID 查询消息
+func (r *GormRepository) FindByID(ctx context.Context, id int64) (*MessageModel, error) {
+	var msg MessageModel
+	if err := r.db.WithContext(ctx).First(&msg, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Transition 按状态机流转消息状态
+func (r *GormRepository) Transition(ctx context.Context, id int64, to MessageStatus, reason string) (*MessageModel, error) {
+	var msg MessageModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&msg, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMessageNotFound
+			}
+			return err
+		}
+
+		from := msg.Status
+		if from == to {
+			return nil
+		}
+		if err := checkTransition(from, to); err != nil {
+			return err
+		}
+
+		// 以读到的状态为条件更新，并发流转时只有一个能成功
+		result := tx.Model(&msg).Where("status = ?", from).Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var current MessageModel
+			if err := tx.Select("status").First(&current, id).Error; err != nil {
+				return err
+			}
+			return &TransitionError{From: current.Status, To: to}
+		}
+
+		return tx.Create(&StatusTransitionModel{MessageID: id, From: from, To: to, Reason: reason}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// SaveReceipt 记录投递回执
+func (r *GormRepository) SaveReceipt(ctx context.Context, id int64, receipt Receipt) error {
+	result := r.db.WithContext(ctx).Model(&MessageModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"provider":            receipt.Provider,
+		"provider_message_id": receipt.MessageID,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+// FindByReceipt 按投递回执查询消息
+func (r *GormRepository) FindByReceipt(ctx context.Context, provider, providerMessageID string) (*MessageModel, error) {
+	var msg MessageModel
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND provider_message_id = ?", provider, providerMessageID).
+		First(&msg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ListTransitions 列出消息的状态流转记录
+func (r *GormRepository) ListTransitions(ctx context.Context, id int64) ([]StatusTransitionModel, error) {
+	var records []StatusTransitionModel
+	err := r.db.WithContext(ctx).Where("message_id = ?", id).Order("id").Find(&records).Error
+	return records, err
+}
+
+// FindByPhoneNumber 查询发往指定手机号的短信
+func (r *GormRepository) FindByPhoneNumber(ctx context.Context, number string) ([]MessageModel, error) {
+	var messages []MessageModel
+	err := r.db.WithContext(ctx).
+		Where("type = ? AND phone_number = ?", Sms, NormalizePhoneNumber(number)).
+		Order("id").
+		Find(&messages).Error
+	return messages, err
+}
+
+// FindByUserID 查询发给指定用户的站内信
+func (r *GormRepository) FindByUserID(ctx context.Context, userID int64) ([]MessageModel, error) {
+	var messages []MessageModel
+	err := r.db.WithContext(ctx).
+		Where("type = ? AND user_id = ?", Sitemessage, userID).
+		Order("id").
+		Find(&messages).Error
+	return messages, err
+}
+
+// FindByUserIDAfter 查询发给指定用户、ID 大于 afterID 的站内信，不含因通知偏好未送达的消息
+func (r *GormRepository) FindByUserIDAfter(ctx context.Context, userID, afterID int64) ([]MessageModel, error) {
+	var messages []MessageModel
+	err := r.db.WithContext(ctx).
+		Where("type = ? AND user_id = ? AND id > ? AND status <> ?", Sitemessage, userID, afterID, Suppressed).
+		Order("id").
+		Find(&messages).Error
+	return messages, err
+}
+
+// SetSubscription 设置订阅偏好，已有记录时更新
+func (r *GormRepository) SetSubscription(ctx context.Context, channel string, userID int64, subscribed bool) (*SubscriptionModel, error) {
+	sub := SubscriptionModel{Channel: channel, UserID: userID, Subscribed: subscribed}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subscribed", "updated_at"}),
+	}).Create(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	// 冲突更新时 sub 中的 ID 和创建时间不可靠，重新读取
+	return r.FindSubscription(ctx, channel, userID)
+}
+
+// FindSubscription 查询订阅偏好
+func (r *GormRepository) FindSubscription(ctx context.Context, channel string, userID int64) (*SubscriptionModel, error) {
+	var sub SubscriptionModel
+	err := r.db.WithContext(ctx).Where("channel = ? AND user_id = ?", channel, userID).First(&sub).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscribers 分页列出订阅中的用户
+func (r *GormRepository) ListSubscribers(ctx context.Context, channel string, afterUserID int64, limit int) ([]SubscriptionModel, error) {
+	var subs []SubscriptionModel
+	err := r.db.WithContext(ctx).
+		Where("channel = ? AND subscribed = ? AND user_id > ?", channel, true, afterUserID).
+		Order("user_id").
+		Limit(limit).
+		Find(&subs).Error
+	return subs, err
+}
+
+// CountSubscribers 统计订阅中的用户数
+func (r *GormRepository) CountSubscribers(ctx context.Context, channel string) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&SubscriptionModel{}).
+		Where("channel = ? AND subscribed = ?", channel, true).
+		Count(&total).Error
+	return total, err
+}
+
+// CreateFanout 创建扇出任务，Total 为当前的订阅者数
+func (r *GormRepository) CreateFanout(ctx context.Context, broadcast *MessageModel) (*FanoutModel, error) {
+	var fanout FanoutModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("broadcast_id = ?", broadcast.ID).First(&fanout).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var total int64
+		if err := tx.Model(&SubscriptionModel{}).
+			Where("channel = ? AND subscribed = ?", broadcast.Channel, true).
+			Count(&total).Error; err != nil {
+			return err
+		}
+		fanout = FanoutModel{
+			BroadcastID: broadcast.ID,
+			Channel:     broadcast.Channel,
+			Status:      FanoutRunning,
+			Total:       total,
+		}
+		return tx.Create(&fanout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &fanout, nil
+}
+
+// FindFanout 按广播 ID 查询扇出任务
+func (r *GormRepository) FindFanout(ctx context.Context, broadcastID int64) (*FanoutModel, error) {
+	var fanout FanoutModel
+	err := r.db.WithContext(ctx).Where("broadcast_id = ?", broadcastID).First(&fanout).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFanoutNotFound
+		}
+		return nil, err
+	}
+	return &fanout, nil
+}
+
+// FanoutBatch 在同一事务中生成下一批站内信、写入发件箱并推进扇出进度
+func (r *GormRepository) FanoutBatch(ctx context.Context, broadcastID int64, size int) ([]MessageModel, *FanoutModel, error) {
+	var fanout FanoutModel
+	var batch []MessageModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("broadcast_id = ?", broadcastID).First(&fanout).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFanoutNotFound
+			}
+			return err
+		}
+		if fanout.Status == FanoutCompleted {
+			return nil
+		}
+
+		var broadcast MessageModel
+		if err := tx.First(&broadcast, broadcastID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMessageNotFound
+			}
+			return err
+		}
+
+		var subs []SubscriptionModel
+		if err := tx.Where("channel = ? AND subscribed = ? AND user_id > ?", fanout.Channel, true, fanout.Cursor).
+			Order("user_id").
+			Limit(size).
+			Find(&subs).Error; err != nil {
+			return err
+		}
+
+		cursor := fanout.Cursor
+		if len(subs) > 0 {
+			batch = make([]MessageModel, 0, len(subs))
+			for _, sub := range subs {
+				batch = append(batch, MessageModel{
+					Type:        Sitemessage,
+					Status:      Sending,
+					Content:     broadcast.Content,
+					UserID:      sub.UserID,
+					BroadcastID: broadcast.ID,
+					Category:    broadcast.fanoutCategory(),
+				})
+			}
+			if err := tx.Create(&batch).Error; err != nil {
+				return err
+			}
+			records := make([]StatusTransitionModel, 0, len(batch))
+			for i := range batch {
+				records = append(records, StatusTransitionModel{MessageID: batch[i].ID, To: Sending, Reason: "fanout"})
+			}
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+			outbox := make([]OutboxModel, 0, len(batch))
+			for i := range batch {
+				outbox = append(outbox, OutboxModel{MessageID: batch[i].ID})
+			}
+			if err := tx.Create(&outbox).Error; err != nil {
+				return err
+			}
+			cursor = subs[len(subs)-1].UserID
+		}
+
+		updates := map[string]interface{}{
+			"cursor":    cursor,
+			"delivered": gorm.Expr("delivered + ?", len(batch)),
+		}
+		if len(subs) < size {
+			updates["status"] = FanoutCompleted
+			updates["completed_at"] = time.Now()
+		}
+		// 以读到的 Cursor 为条件更新，并发执行同一批次时只有一个能成功，另一个回滚
+		result := tx.Model(&FanoutModel{}).
+			Where("id = ? AND cursor = ? AND status = ?", fanout.ID, fanout.Cursor, FanoutRunning).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFanoutConflict
+		}
+		return tx.First(&fanout, fanout.ID).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return batch, &fanout, nil
+}
+
+// CreateTemplate 保存新模板
+func (r *GormRepository) CreateTemplate(ctx context.Context, tpl *TemplateModel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&TemplateModel{}).Where("id = ?", tpl.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTemplateExists
+		}
+		return tx.Create(tpl).Error
+	})
+}
+
+// FindTemplate 按 ID 查询模板
+func (r *GormRepository) FindTemplate(ctx context.Context, id string) (*TemplateModel, error) {
+	var tpl TemplateModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&tpl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// ListTemplates 列出所有模板
+func (r *GormRepository) ListTemplates(ctx context.Context) ([]TemplateModel, error) {
+	var templates []TemplateModel
+	err := r.db.WithContext(ctx).Order("id").Find(&templates).Error
+	return templates, err
+}
+
+// UpdateTemplate 整体替换模板定义，保留创建时间
+func (r *GormRepository) UpdateTemplate(ctx context.Context, tpl *TemplateModel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&TemplateModel{ID: tpl.ID}).Select("*").Omit("id", "created_at").Updates(tpl)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTemplateNotFound
+		}
+		return tx.Where("id = ?", tpl.ID).First(tpl).Error
+	})
+}
+
+// DeleteTemplate 删除模板
+func (r *GormRepository) DeleteTemplate(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&TemplateModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// CreateSchedule 保存新的定时任务
+func (r *GormRepository) CreateSchedule(ctx context.Context, s *ScheduleModel) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+// FindSchedule 按 ID 查询定时任务
+func (r *GormRepository) FindSchedule(ctx context.Context, id int64) (*ScheduleModel, error) {
+	var s ScheduleModel
+	if err := r.db.WithContext(ctx).First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListSchedules 按下次发送时间升序列出指定状态的定时任务
+func (r *GormRepository) ListSchedules(ctx context.Context, status ScheduleStatus) ([]ScheduleModel, error) {
+	var schedules []ScheduleModel
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("next_run_at, id").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// CancelSchedule 取消待发送的定时任务
+func (r *GormRepository) CancelSchedule(ctx context.Context, id int64) (*ScheduleModel, error) {
+	var s ScheduleModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&s, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrScheduleNotFound
+			}
+			return err
+		}
+		if s.Status != SchedulePending {
+			return ErrScheduleNotPending
+		}
+
+		// 与调度器并发时以状态为条件，调度器刚好完成任务时取消失败
+		result := tx.Model(&s).Where("status = ?", SchedulePending).
+			Updates(map[string]interface{}{"status": ScheduleCancelled, "next_run_at": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrScheduleNotPending
+		}
+		return tx.First(&s, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DueSchedules 列出到期的待发送任务
+func (r *GormRepository) DueSchedules(ctx context.Context, now time.Time, limit int) ([]ScheduleModel, error) {
+	var schedules []ScheduleModel
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", SchedulePending, now.UTC()).
+		Order("next_run_at, id").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// FireSchedule 在同一事务中生成消息、写入发件箱并以 Runs 为条件推进任务
+func (r *GormRepository) FireSchedule(ctx context.Context, s *ScheduleModel, next *time.Time, at time.Time) (*MessageModel, error) {
+	msg := s.Message()
+	updated := *s
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&StatusTransitionModel{MessageID: msg.ID, To: msg.Status, Reason: "scheduled"}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&OutboxModel{MessageID: msg.ID}).Error; err != nil {
+			return err
+		}
+
+		lastRunAt := at.UTC()
+		updated.Runs++
+		updated.LastRunAt = &lastRunAt
+		updated.LastMessageID = &msg.ID
+		updated.NextRunAt = next
+		if next == nil {
+			updated.Status = ScheduleCompleted
+		}
+		result := tx.Model(&ScheduleModel{}).
+			Where("id = ? AND status = ? AND runs = ?", s.ID, SchedulePending, s.Runs).
+			Updates(map[string]interface{}{
+				"runs":            updated.Runs,
+				"last_run_at":     updated.LastRunAt,
+				"last_message_id": msg.ID,
+				"next_run_at":     updated.NextRunAt,
+				"status":          updated.Status,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrScheduleConflict
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	*s = updated
+	return msg, nil
+}
+
+// CreateOTP 在同一事务中检查冷却时间、保存验证码短信和验证码记录
+func (r *GormRepository) CreateOTP(ctx context.Context, otp *OTPModel, msg *MessageModel, cooldownSince time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var recent int64
+		if err := tx.Model(&OTPModel{}).
+			Where("phone_number = ? AND purpose = ? AND created_at > ?", otp.PhoneNumber, otp.Purpose, cooldownSince.UTC()).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return ErrOTPCooldown
+		}
+
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&StatusTransitionModel{MessageID: msg.ID, To: msg.Status, Reason: "created"}).Error; err != nil {
+			return err
+		}
+		otp.MessageID = msg.ID
+		return tx.Create(otp).Error
+	})
+}
+
+// FindLatestOTP 查询最近一次发送的验证码
+func (r *GormRepository) FindLatestOTP(ctx context.Context, phoneNumber, purpose string) (*OTPModel, error) {
+	var otp OTPModel
+	err := r.db.WithContext(ctx).
+		Where("phone_number = ? AND purpose = ?", phoneNumber, purpose).
+		Order("created_at DESC, id DESC").
+		Take(&otp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOTPNotFound
+		}
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// AttemptOTP 以校验次数为条件记录一次校验
+func (r *GormRepository) AttemptOTP(ctx context.Context, id int64, attempts int, verifiedAt *time.Time) error {
+	updates := map[string]interface{}{"attempts": attempts + 1}
+	if verifiedAt != nil {
+		updates["verified_at"] = verifiedAt.UTC()
+	}
+	result := r.db.WithContext(ctx).Model(&OTPModel{}).
+		Where("id = ? AND attempts = ? AND verified_at IS NULL", id, attempts).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOTPConflict
+	}
+	return nil
+}
+
+// ConsumeRateLimits 在同一事务中为每个计数器计入一次发送，超出上限时回滚全部计数
+// 计数通过 upsert 自增完成，多个副本并发计数时也不会超出上限
+func (r *GormRepository) ConsumeRateLimits(ctx context.Context, counters []RateLimitCounter, now time.Time) error {
+	now = now.UTC()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at <= ?", now).Delete(&RateLimitModel{}).Error; err != nil {
+			return err
+		}
+
+		for _, c := range counters {
+			row := RateLimitModel{Counter: c.key(), WindowStart: c.windowStart(now), Hits: 1}
+			row.ExpiresAt = row.WindowStart.Add(c.Window)
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "counter"}, {Name: "window_start"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"hits": gorm.Expr("hits + 1")}),
+			}).Create(&row).Error; err != nil {
+				return err
+			}
+
+			var hits int
+			if err := tx.Model(&RateLimitModel{}).
+				Where("counter = ? AND window_start = ?", row.Counter, row.WindowStart).
+				Pluck("hits", &hits).Error; err != nil {
+				return err
+			}
+			if hits > c.Limit {
+				return &RateLimitError{RateLimitCounter: c, RetryAfter: row.ExpiresAt.Sub(now)}
+			}
+		}
+		return nil
+	})
+}
+
+// inbox 限定在用户站内信中的查询，因通知偏好未送达的站内信不在收件箱中
+func (r *GormRepository) inbox(tx *gorm.DB, userID int64) *gorm.DB {
+	return tx.Model(&MessageModel{}).Where("type = ? AND user_id = ? AND status <> ?", Sitemessage, userID, Suppressed)
+}
+
+// findInbox 查询用户的一条站内信
+func (r *GormRepository) findInbox(tx *gorm.DB, userID, id int64) (*MessageModel, error) {
+	var msg MessageModel
+	if err := r.inbox(tx, userID).Where("id = ?", id).Take(&msg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ListInbox 按 ID 升序列出用户的站内信
+func (r *GormRepository) ListInbox(ctx context.Context, userID int64, filter InboxFilter) ([]MessageModel, error) {
+	query := r.inbox(r.db.WithContext(ctx), userID).Where("id > ?", filter.AfterID)
+	if filter.Archived {
+		query = query.Where("archived_at IS NOT NULL")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Unread {
+		query = query.Where("status IN ?", unreadStatuses)
+	}
+
+	var messages []MessageModel
+	err := query.Order("id").Limit(filter.Limit).Find(&messages).Error
+	return messages, err
+}
+
+// CountUnread 统计用户未归档的未读站内信
+func (r *GormRepository) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.inbox(r.db.WithContext(ctx), userID).
+		Where("status IN ? AND archived_at IS NULL", unreadStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将站内信标记为已读，以读到的状态为条件更新，并发标记时只记录一次流转
+func (r *GormRepository) MarkRead(ctx context.Context, userID, id int64, at time.Time) (*MessageModel, error) {
+	var msg *MessageModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if msg, err = r.findInbox(tx, userID, id); err != nil {
+			return err
+		}
+		path, err := readPath(msg.Status)
+		if err != nil || len(path) == 0 {
+			return err
+		}
+
+		from := msg.Status
+		result := tx.Model(msg).Where("status = ?", from).Updates(map[string]interface{}{"status": Read, "read_at": at.UTC()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 已被并发标记，返回最新状态
+			msg, err = r.findInbox(tx, userID, id)
+			return err
+		}
+		if err := tx.Create(readTransitions(id, from, path)).Error; err != nil {
+			return err
+		}
+		msg, err = r.findInbox(tx, userID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// MarkAllRead 将用户所有未读的站内信标记为已读，每条消息记录一次状态流转
+func (r *GormRepository) MarkAllRead(ctx context.Context, userID int64, at time.Time) (int64, error) {
+	var updated int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var unread []MessageModel
+		if err := r.inbox(tx, userID).Select("id", "status").Where("status IN ?", unreadStatuses).Find(&unread).Error; err != nil {
+			return err
+		}
+
+		for _, msg := range unread {
+			path, err := readPath(msg.Status)
+			if err != nil {
+				return err
+			}
+			result := tx.Model(&MessageModel{}).
+				Where("id = ? AND status = ?", msg.ID, msg.Status).
+				Updates(map[string]interface{}{"status": Read, "read_at": at.UTC()})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := tx.Create(readTransitions(msg.ID, msg.Status, path)).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// readTransitions 标记已读时按 readPath 逐步记录的状态流转
+func readTransitions(id int64, from MessageStatus, path []MessageStatus) []StatusTransitionModel {
+	records := make([]StatusTransitionModel, 0, len(path))
+	for _, to := range path {
+		records = append(records, StatusTransitionModel{MessageID: id, From: from, To: to, Reason: "read"})
+		from = to
+	}
+	return records
+}
+
+// SetArchived 归档或取消归档站内信
+func (r *GormRepository) SetArchived(ctx context.Context, userID, id int64, at *time.Time) (*MessageModel, error) {
+	var msg *MessageModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if msg, err = r.findInbox(tx, userID, id); err != nil {
+			return err
+		}
+		var archivedAt interface{}
+		if at != nil {
+			archivedAt = at.UTC()
+		}
+		if err := tx.Model(msg).Update("archived_at", archivedAt).Error; err != nil {
+			return err
+		}
+		msg, err = r.findInbox(tx, userID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// DeleteSiteMessage 软删除站内信
+func (r *GormRepository) DeleteSiteMessage(ctx context.Context, userID, id int64) error {
+	result := r.db.WithContext(ctx).
+		Where("type = ? AND user_id = ? AND id = ?", Sitemessage, userID, id).
+		Delete(&MessageModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+// CreateOutboxed 在同一事务中保存新消息、初始状态和发件箱记录
+// r 基于调用方的事务创建时，嵌套的事务以保存点的方式加入调用方的事务
+func (r *GormRepository) CreateOutboxed(ctx context.Context, msg *MessageModel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&StatusTransitionModel{MessageID: msg.ID, To: msg.Status, Reason: "created"}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&OutboxModel{MessageID: msg.ID}).Error
+	})
+}
+
+// PendingOutbox 按 ID 升序列出待中继的发件箱记录
+func (r *GormRepository) PendingOutbox(ctx context.Context, limit int) ([]OutboxModel, error) {
+	var entries []OutboxModel
+	err := r.db.WithContext(ctx).Order("id").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// DeleteOutbox 删除已中继的发件箱记录
+func (r *GormRepository) DeleteOutbox(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).Delete(&OutboxModel{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutboxNotFound
+	}
+	return nil
+}
+
+// SearchMessages 按 ID 降序搜索消息
+func (r *GormRepository) SearchMessages(ctx context.Context, filter SearchFilter) ([]MessageModel, error) {
+	query := matchContent(r.db.WithContext(ctx).Model(&MessageModel{}), filter.Terms)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To.UTC())
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	var messages []MessageModel
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&messages).Error
+	return messages, err
+}
+
+// statusCount 统计查询中一个分组的消息数
+type statusCount struct {
+	Bucket   int64
+	Type     MessageType
+	Provider string
+	Status   MessageStatus
+	Count    int64
+}
+
+// latencyRank 统计查询中一个分组在某个分位数上的耗时样本
+type latencyRank struct {
+	Bucket   int64
+	Type     MessageType
+	Provider string
+	Status   MessageStatus // sent 为发送耗时，received 为送达耗时
+	N        int64         // 分组的样本数
+	Rn       int64         // 样本按耗时升序的序号
+	Ms       int64
+}
+
+// MessageStats 在数据库中按时间桶、类型、通道和状态汇总消息数；
+// 耗时取首次进入 sent、received 的状态流转记录，用窗口函数按最近秩法只取出分位数上的样本
+func (r *GormRepository) MessageStats(ctx context.Context, filter StatsFilter) ([]MessageStatsBucket, error) {
+	dialect, ok := statsDialects[r.db.Dialector.Name()]
+	if !ok {
+		return nil, fmt.Errorf("message stats are not supported on %s", r.db.Dialector.Name())
+	}
+	bucket, bucketArgs := dialect.bucketExpr("messages.created_at", filter)
+	where := "messages.created_at >= ? AND messages.created_at < ?"
+	whereArgs := []interface{}{filter.From.UTC(), filter.To.UTC()}
+	if filter.Type != "" {
+		where += " AND messages.type = ?"
+		whereArgs = append(whereArgs, filter.Type)
+	}
+	if filter.Provider != "" {
+		where += " AND messages.provider = ?"
+		whereArgs = append(whereArgs, filter.Provider)
+	}
+	db := r.db.WithContext(ctx)
+
+	var counts []statusCount
+	err := db.Raw(fmt.Sprintf(`SELECT %s AS bucket, type, provider, status, COUNT(*) AS count
+		FROM messages WHERE %s
+		GROUP BY bucket, type, provider, status`, bucket, where),
+		append(bucketArgs, whereArgs...)...).Scan(&counts).Error
+	if err != nil || len(counts) == 0 {
+		return nil, err
+	}
+
+	// 第 p 分位数是第 ceil(p% * n) 个样本，即满足 rn*100 >= p*n > (rn-1)*100 的 rn
+	ranks := []string{"rn = n"}
+	for _, p := range latencyPercentiles {
+		ranks = append(ranks, fmt.Sprintf("(rn * 100 >= n * %d AND (rn - 1) * 100 < n * %d)", p, p))
+	}
+	var samples []latencyRank
+	err = db.Raw(fmt.Sprintf(`WITH samples AS (
+			SELECT %s AS bucket, messages.type AS type, messages.provider AS provider, t.to_status AS status,
+				MIN(%s) - %s AS ms
+			FROM messages JOIN message_status_transitions t ON t.message_id = messages.id
+			WHERE t.to_status IN ? AND %s
+			GROUP BY messages.id, messages.type, messages.provider, messages.created_at, t.to_status
+		), ranked AS (
+			SELECT bucket, type, provider, status, ms,
+				ROW_NUMBER() OVER (PARTITION BY bucket, type, provider, status ORDER BY ms) AS rn,
+				COUNT(*) OVER (PARTITION BY bucket, type, provider, status) AS n
+			FROM samples
+		)
+		SELECT bucket, type, provider, status, n, rn, ms FROM ranked WHERE %s`,
+		bucket, fmt.Sprintf(dialect.millis, "t.created_at"), fmt.Sprintf(dialect.millis, "messages.created_at"),
+		where, strings.Join(ranks, " OR ")),
+		append(append(bucketArgs, []MessageStatus{Sent, Received}), whereArgs...)...).Scan(&samples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type groupKey struct {
+		bucket   int64
+		msgType  MessageType
+		provider string
+	}
+	groups := make(map[groupKey]*MessageStatsBucket)
+	for _, c := range counts {
+		key := groupKey{c.Bucket, c.Type, c.Provider}
+		g, ok := groups[key]
+		if !ok {
+			g = &MessageStatsBucket{
+				Start:    bucketTime(c.Bucket, filter),
+				Type:     c.Type,
+				Provider: c.Provider,
+				Statuses: make(map[string]int64),
+			}
+			groups[key] = g
+		}
+		g.Total += c.Count
+		g.Statuses[string(c.Status)] += c.Count
+	}
+	for _, s := range samples {
+		g, ok := groups[groupKey{s.Bucket, s.Type, s.Provider}]
+		if !ok {
+			continue
+		}
+		latency := &g.SendLatency
+		if s.Status == Received {
+			latency = &g.DeliveryLatency
+		}
+		if *latency == nil {
+			*latency = &LatencyPercentiles{Count: s.N}
+		}
+		if s.Rn == s.N {
+			(*latency).MaxMs = s.Ms
+		}
+		for _, p := range latencyPercentiles {
+			if s.Rn == percentileRank(p, s.N) {
+				(*latency).setPercentile(p, s.Ms)
+			}
+		}
+	}
+
+	buckets := make([]MessageStatsBucket, 0, len(groups))
+	for _, g := range groups {
+		buckets = append(buckets, *g)
+	}
+	sortStatsBuckets(buckets)
+	return buckets, nil
+}
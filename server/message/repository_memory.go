@@ -0,0 +1,884 @@
+package message
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryRepository 内存版的 MessageRepository，用于测试和本地开发
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	messages    []MessageModel // 按 ID 升序追加
+	transitions []StatusTransitionModel
+	nextID      int64
+
+	subscriptions []SubscriptionModel
+	fanouts       []FanoutModel
+	templates     map[string]TemplateModel
+	schedules     []ScheduleModel           // 按 ID 升序追加，ID 即下标加一
+	otps          []OTPModel                // 同上
+	rateLimits    map[string]RateLimitModel // 计数器标识 -> 当前窗口的计数
+	outbox        []OutboxModel             // 按 ID 升序追加，中继后删除
+	nextOutboxID  int64
+}
+
+// ensure that we've conformed to the `MessageRepository` with a compile-time check
+var _ MessageRepository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository 创建内存版消息仓储
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		nextID:       1,
+		nextOutboxID: 1,
+		templates:    make(map[string]TemplateModel),
+		rateLimits:   make(map[string]RateLimitModel),
+	}
+}
+
+// Create 保存新消息并分配自增 ID
+func (r *MemoryRepository) Create(ctx context.Context, msg *MessageModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	msg.ID = r.nextID
+	r.nextID++
+	// 与 gorm 一致，保留调用方指定的创建时间
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = now
+	}
+	msg.UpdatedAt = now
+	r.messages = append(r.messages, *msg)
+	r.record(msg.ID, "", msg.Status, "created", now)
+	return nil
+}
+
+// record 追加一条状态流转记录，调用方需持有写锁
+func (r *MemoryRepository) record(id int64, from, to MessageStatus, reason string, at time.Time) {
+	r.transitions = append(r.transitions, StatusTransitionModel{
+		ID:        int64(len(r.transitions) + 1),
+		MessageID: id,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		CreatedAt: at,
+	})
+}
+
+// FindByID 按 ID 查询消息，返回副本
+func (r *MemoryRepository) FindByID(ctx context.Context, id int64) (*MessageModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.messages {
+		if r.messages[i].ID == id && !r.messages[i].DeletedAt.Valid {
+			msg := r.messages[i]
+			return &msg, nil
+		}
+	}
+	return nil, ErrMessageNotFound
+}
+
+// Transition 按状态机流转消息状态
+func (r *MemoryRepository) Transition(ctx context.Context, id int64, to MessageStatus, reason string) (*MessageModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.messages {
+		msg := &r.messages[i]
+		if msg.ID != id || msg.DeletedAt.Valid {
+			continue
+		}
+		from := msg.Status
+		if from != to {
+			if err := checkTransition(from, to); err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			msg.Status = to
+			msg.UpdatedAt = now
+			r.record(id, from, to, reason, now)
+		}
+		result := *msg
+		return &result, nil
+	}
+	return nil, ErrMessageNotFound
+}
+
+// SaveReceipt 记录投递回执
+func (r *MemoryRepository) SaveReceipt(ctx context.Context, id int64, receipt Receipt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.messages {
+		if r.messages[i].ID == id && !r.messages[i].DeletedAt.Valid {
+			r.messages[i].Provider = receipt.Provider
+			r.messages[i].ProviderMessageID = receipt.MessageID
+			r.messages[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return ErrMessageNotFound
+}
+
+// FindByReceipt 按投递回执查询消息，返回副本
+func (r *MemoryRepository) FindByReceipt(ctx context.Context, provider, providerMessageID string) (*MessageModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.messages {
+		if r.messages[i].Provider == provider && r.messages[i].ProviderMessageID == providerMessageID && !r.messages[i].DeletedAt.Valid {
+			msg := r.messages[i]
+			return &msg, nil
+		}
+	}
+	return nil, ErrMessageNotFound
+}
+
+// ListTransitions 列出消息的状态流转记录
+func (r *MemoryRepository) ListTransitions(ctx context.Context, id int64) ([]StatusTransitionModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var records []StatusTransitionModel
+	for _, t := range r.transitions {
+		if t.MessageID == id {
+			records = append(records, t)
+		}
+	}
+	return records, nil
+}
+
+// FindByPhoneNumber 查询发往指定手机号的短信
+func (r *MemoryRepository) FindByPhoneNumber(ctx context.Context, number string) ([]MessageModel, error) {
+	number = NormalizePhoneNumber(number)
+	return r.filter(func(m *MessageModel) bool {
+		return m.Type == Sms && m.PhoneNumber == number
+	}), nil
+}
+
+// FindByUserID 查询发给指定用户的站内信
+func (r *MemoryRepository) FindByUserID(ctx context.Context, userID int64) ([]MessageModel, error) {
+	return r.filter(func(m *MessageModel) bool {
+		return m.Type == Sitemessage && m.UserID == userID
+	}), nil
+}
+
+// FindByUserIDAfter 查询发给指定用户、ID 大于 afterID 的站内信，不含因通知偏好未送达的消息
+func (r *MemoryRepository) FindByUserIDAfter(ctx context.Context, userID, afterID int64) ([]MessageModel, error) {
+	return r.filter(func(m *MessageModel) bool {
+		return inInbox(m, userID) && m.ID > afterID
+	}), nil
+}
+
+// inInbox 判断消息是否在用户的收件箱中，因通知偏好未送达的站内信不在收件箱中
+func inInbox(m *MessageModel, userID int64) bool {
+	return m.Type == Sitemessage && m.UserID == userID && m.Status != Suppressed
+}
+
+// filter 返回满足条件的消息副本，与 gorm 的软删除一致，跳过已删除的消息
+func (r *MemoryRepository) filter(match func(*MessageModel) bool) []MessageModel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []MessageModel
+	for i := range r.messages {
+		if !r.messages[i].DeletedAt.Valid && match(&r.messages[i]) {
+			result = append(result, r.messages[i])
+		}
+	}
+	return result
+}
+
+// SetSubscription 设置订阅偏好，已有记录时更新
+func (r *MemoryRepository) SetSubscription(ctx context.Context, channel string, userID int64, subscribed bool) (*SubscriptionModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range r.subscriptions {
+		sub := &r.subscriptions[i]
+		if sub.Channel == channel && sub.UserID == userID {
+			sub.Subscribed = subscribed
+			sub.UpdatedAt = now
+			result := *sub
+			return &result, nil
+		}
+	}
+	sub := SubscriptionModel{
+		ID:         int64(len(r.subscriptions) + 1),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Channel:    channel,
+		UserID:     userID,
+		Subscribed: subscribed,
+	}
+	r.subscriptions = append(r.subscriptions, sub)
+	return &sub, nil
+}
+
+// FindSubscription 查询订阅偏好，返回副本
+func (r *MemoryRepository) FindSubscription(ctx context.Context, channel string, userID int64) (*SubscriptionModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.subscriptions {
+		if r.subscriptions[i].Channel == channel && r.subscriptions[i].UserID == userID {
+			sub := r.subscriptions[i]
+			return &sub, nil
+		}
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+// ListSubscribers 分页列出订阅中的用户
+func (r *MemoryRepository) ListSubscribers(ctx context.Context, channel string, afterUserID int64, limit int) ([]SubscriptionModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.subscribers(channel, afterUserID, limit), nil
+}
+
+// subscribers 按用户 ID 升序返回订阅中的用户，limit 不大于 0 时不限制条数，调用方需持有锁
+func (r *MemoryRepository) subscribers(channel string, afterUserID int64, limit int) []SubscriptionModel {
+	var subs []SubscriptionModel
+	for _, sub := range r.subscriptions {
+		if sub.Channel == channel && sub.Subscribed && sub.UserID > afterUserID {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].UserID < subs[j].UserID })
+	if limit > 0 && len(subs) > limit {
+		subs = subs[:limit]
+	}
+	return subs
+}
+
+// CountSubscribers 统计订阅中的用户数
+func (r *MemoryRepository) CountSubscribers(ctx context.Context, channel string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.subscribers(channel, 0, 0))), nil
+}
+
+// CreateFanout 创建扇出任务，已存在时返回已有的任务
+func (r *MemoryRepository) CreateFanout(ctx context.Context, broadcast *MessageModel) (*FanoutModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.fanouts {
+		if r.fanouts[i].BroadcastID == broadcast.ID {
+			fanout := r.fanouts[i]
+			return &fanout, nil
+		}
+	}
+	now := time.Now()
+	fanout := FanoutModel{
+		ID:          int64(len(r.fanouts) + 1),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		BroadcastID: broadcast.ID,
+		Channel:     broadcast.Channel,
+		Status:      FanoutRunning,
+		Total:       int64(len(r.subscribers(broadcast.Channel, 0, 0))),
+	}
+	r.fanouts = append(r.fanouts, fanout)
+	return &fanout, nil
+}
+
+// FindFanout 按广播 ID 查询扇出任务，返回副本
+func (r *MemoryRepository) FindFanout(ctx context.Context, broadcastID int64) (*FanoutModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.fanouts {
+		if r.fanouts[i].BroadcastID == broadcastID {
+			fanout := r.fanouts[i]
+			return &fanout, nil
+		}
+	}
+	return nil, ErrFanoutNotFound
+}
+
+// FanoutBatch 生成下一批站内信、写入发件箱并推进扇出进度，整个批次在写锁内完成
+func (r *MemoryRepository) FanoutBatch(ctx context.Context, broadcastID int64, size int) ([]MessageModel, *FanoutModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var fanout *FanoutModel
+	for i := range r.fanouts {
+		if r.fanouts[i].BroadcastID == broadcastID {
+			fanout = &r.fanouts[i]
+			break
+		}
+	}
+	if fanout == nil {
+		return nil, nil, ErrFanoutNotFound
+	}
+	if fanout.Status == FanoutCompleted {
+		result := *fanout
+		return nil, &result, nil
+	}
+
+	// 复制内容和类别，后续追加消息可能使切片扩容
+	content, category, found := "", "", false
+	for i := range r.messages {
+		if r.messages[i].ID == broadcastID {
+			content, category, found = r.messages[i].Content, r.messages[i].fanoutCategory(), true
+			break
+		}
+	}
+	if !found {
+		return nil, nil, ErrMessageNotFound
+	}
+
+	now := time.Now()
+	subs := r.subscribers(fanout.Channel, fanout.Cursor, size)
+	batch := make([]MessageModel, 0, len(subs))
+	for _, sub := range subs {
+		msg := MessageModel{
+			ID:          r.nextID,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Type:        Sitemessage,
+			Status:      Sending,
+			Content:     content,
+			UserID:      sub.UserID,
+			BroadcastID: broadcastID,
+			Category:    category,
+		}
+		r.nextID++
+		r.messages = append(r.messages, msg)
+		r.record(msg.ID, "", Sending, "fanout", now)
+		r.outbox = append(r.outbox, OutboxModel{ID: r.nextOutboxID, CreatedAt: now, MessageID: msg.ID})
+		r.nextOutboxID++
+		batch = append(batch, msg)
+	}
+
+	if len(subs) > 0 {
+		fanout.Cursor = subs[len(subs)-1].UserID
+	}
+	fanout.Delivered += int64(len(batch))
+	fanout.UpdatedAt = now
+	if len(subs) < size {
+		fanout.Status = FanoutCompleted
+		fanout.CompletedAt = &now
+	}
+	result := *fanout
+	return batch, &result, nil
+}
+
+// cloneTemplate 复制模板，避免调用方修改共享的切片和 map
+func cloneTemplate(tpl TemplateModel) TemplateModel {
+	tpl.Variables = append([]TemplateVariable(nil), tpl.Variables...)
+	variants := make(map[string]string, len(tpl.Variants))
+	for locale, body := range tpl.Variants {
+		variants[locale] = body
+	}
+	tpl.Variants = variants
+	return tpl
+}
+
+// CreateTemplate 保存新模板
+func (r *MemoryRepository) CreateTemplate(ctx context.Context, tpl *TemplateModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[tpl.ID]; ok {
+		return ErrTemplateExists
+	}
+	now := time.Now()
+	tpl.CreatedAt, tpl.UpdatedAt = now, now
+	r.templates[tpl.ID] = cloneTemplate(*tpl)
+	return nil
+}
+
+// FindTemplate 按 ID 查询模板，返回副本
+func (r *MemoryRepository) FindTemplate(ctx context.Context, id string) (*TemplateModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tpl, ok := r.templates[id]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	tpl = cloneTemplate(tpl)
+	return &tpl, nil
+}
+
+// ListTemplates 按 ID 升序列出所有模板
+func (r *MemoryRepository) ListTemplates(ctx context.Context) ([]TemplateModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := make([]TemplateModel, 0, len(r.templates))
+	for _, tpl := range r.templates {
+		templates = append(templates, cloneTemplate(tpl))
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates, nil
+}
+
+// UpdateTemplate 整体替换模板定义，保留创建时间
+func (r *MemoryRepository) UpdateTemplate(ctx context.Context, tpl *TemplateModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.templates[tpl.ID]
+	if !ok {
+		return ErrTemplateNotFound
+	}
+	tpl.CreatedAt = existing.CreatedAt
+	tpl.UpdatedAt = time.Now()
+	r.templates[tpl.ID] = cloneTemplate(*tpl)
+	return nil
+}
+
+// DeleteTemplate 删除模板
+func (r *MemoryRepository) DeleteTemplate(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[id]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+// CreateSchedule 保存新的定时任务并分配自增 ID
+func (r *MemoryRepository) CreateSchedule(ctx context.Context, s *ScheduleModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	s.ID = int64(len(r.schedules) + 1)
+	s.CreatedAt, s.UpdatedAt = now, now
+	r.schedules = append(r.schedules, *s)
+	return nil
+}
+
+// FindSchedule 按 ID 查询定时任务，返回副本
+func (r *MemoryRepository) FindSchedule(ctx context.Context, id int64) (*ScheduleModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id < 1 || id > int64(len(r.schedules)) {
+		return nil, ErrScheduleNotFound
+	}
+	s := r.schedules[id-1]
+	return &s, nil
+}
+
+// ListSchedules 按下次发送时间升序列出指定状态的定时任务
+func (r *MemoryRepository) ListSchedules(ctx context.Context, status ScheduleStatus) ([]ScheduleModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedSchedules(func(s *ScheduleModel) bool { return s.Status == status }, 0), nil
+}
+
+// CancelSchedule 取消待发送的定时任务
+func (r *MemoryRepository) CancelSchedule(ctx context.Context, id int64) (*ScheduleModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > int64(len(r.schedules)) {
+		return nil, ErrScheduleNotFound
+	}
+	s := &r.schedules[id-1]
+	if s.Status != SchedulePending {
+		return nil, ErrScheduleNotPending
+	}
+	s.Status = ScheduleCancelled
+	s.NextRunAt = nil
+	s.UpdatedAt = time.Now()
+	result := *s
+	return &result, nil
+}
+
+// DueSchedules 列出到期的待发送任务
+func (r *MemoryRepository) DueSchedules(ctx context.Context, now time.Time, limit int) ([]ScheduleModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedSchedules(func(s *ScheduleModel) bool {
+		return s.Status == SchedulePending && s.NextRunAt != nil && !s.NextRunAt.After(now)
+	}, limit), nil
+}
+
+// sortedSchedules 按下次发送时间升序返回满足条件的任务副本，没有下次时间的排在最后，调用方需持有读锁
+func (r *MemoryRepository) sortedSchedules(match func(*ScheduleModel) bool, limit int) []ScheduleModel {
+	result := make([]ScheduleModel, 0)
+	for i := range r.schedules {
+		if match(&r.schedules[i]) {
+			result = append(result, r.schedules[i])
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].NextRunAt, result[j].NextRunAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// FireSchedule 生成消息、写入发件箱并以 Runs 为条件推进任务
+func (r *MemoryRepository) FireSchedule(ctx context.Context, s *ScheduleModel, next *time.Time, at time.Time) (*MessageModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.ID < 1 || s.ID > int64(len(r.schedules)) {
+		return nil, ErrScheduleNotFound
+	}
+	stored := &r.schedules[s.ID-1]
+	if stored.Status != SchedulePending || stored.Runs != s.Runs {
+		return nil, ErrScheduleConflict
+	}
+
+	now := time.Now()
+	msg := stored.Message()
+	msg.ID = r.nextID
+	r.nextID++
+	msg.CreatedAt, msg.UpdatedAt = now, now
+	r.messages = append(r.messages, *msg)
+	r.record(msg.ID, "", msg.Status, "scheduled", now)
+	r.outbox = append(r.outbox, OutboxModel{ID: r.nextOutboxID, CreatedAt: now, MessageID: msg.ID})
+	r.nextOutboxID++
+
+	lastRunAt, messageID := at.UTC(), msg.ID
+	stored.Runs++
+	stored.LastRunAt = &lastRunAt
+	stored.LastMessageID = &messageID
+	stored.NextRunAt = next
+	if next == nil {
+		stored.Status = ScheduleCompleted
+	}
+	stored.UpdatedAt = now
+	*s = *stored
+	return msg, nil
+}
+
+// CreateOTP 检查冷却时间并保存验证码短信和验证码记录
+func (r *MemoryRepository) CreateOTP(ctx context.Context, otp *OTPModel, msg *MessageModel, cooldownSince time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.otps {
+		o := &r.otps[i]
+		if o.PhoneNumber == otp.PhoneNumber && o.Purpose == otp.Purpose && o.CreatedAt.After(cooldownSince) {
+			return ErrOTPCooldown
+		}
+	}
+
+	now := time.Now()
+	msg.ID = r.nextID
+	r.nextID++
+	msg.CreatedAt, msg.UpdatedAt = now, now
+	r.messages = append(r.messages, *msg)
+	r.record(msg.ID, "", msg.Status, "created", now)
+
+	otp.ID = int64(len(r.otps) + 1)
+	otp.MessageID = msg.ID
+	if otp.CreatedAt.IsZero() {
+		otp.CreatedAt = now
+	}
+	otp.UpdatedAt = now
+	r.otps = append(r.otps, *otp)
+	return nil
+}
+
+// FindLatestOTP 查询最近一次发送的验证码，返回副本
+func (r *MemoryRepository) FindLatestOTP(ctx context.Context, phoneNumber, purpose string) (*OTPModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *OTPModel
+	for i := range r.otps {
+		o := &r.otps[i]
+		if o.PhoneNumber != phoneNumber || o.Purpose != purpose {
+			continue
+		}
+		if latest == nil || !o.CreatedAt.Before(latest.CreatedAt) {
+			latest = o
+		}
+	}
+	if latest == nil {
+		return nil, ErrOTPNotFound
+	}
+	otp := *latest
+	return &otp, nil
+}
+
+// AttemptOTP 以校验次数为条件记录一次校验
+func (r *MemoryRepository) AttemptOTP(ctx context.Context, id int64, attempts int, verifiedAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > int64(len(r.otps)) {
+		return ErrOTPConflict
+	}
+	otp := &r.otps[id-1]
+	if otp.Attempts != attempts || otp.VerifiedAt != nil {
+		return ErrOTPConflict
+	}
+	otp.Attempts++
+	if verifiedAt != nil {
+		at := *verifiedAt
+		otp.VerifiedAt = &at
+	}
+	otp.UpdatedAt = time.Now()
+	return nil
+}
+
+// ConsumeRateLimits 为每个计数器计入一次发送，超出上限时不修改任何计数
+func (r *MemoryRepository) ConsumeRateLimits(ctx context.Context, counters []RateLimitCounter, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now = now.UTC()
+	for key, row := range r.rateLimits {
+		if !row.ExpiresAt.After(now) {
+			delete(r.rateLimits, key)
+		}
+	}
+
+	next := make(map[string]RateLimitModel, len(counters))
+	for _, c := range counters {
+		key := c.key()
+		row, ok := next[key]
+		if !ok {
+			row = r.rateLimits[key]
+		}
+		if start := c.windowStart(now); !row.WindowStart.Equal(start) {
+			row = RateLimitModel{Counter: key, WindowStart: start, ExpiresAt: start.Add(c.Window)}
+		}
+		row.Hits++
+		if row.Hits > c.Limit {
+			return &RateLimitError{RateLimitCounter: c, RetryAfter: row.ExpiresAt.Sub(now)}
+		}
+		next[key] = row
+	}
+	for key, row := range next {
+		r.rateLimits[key] = row
+	}
+	return nil
+}
+
+// inboxMessage 返回用户的一条站内信，调用方需持有锁
+func (r *MemoryRepository) inboxMessage(userID, id int64) *MessageModel {
+	for i := range r.messages {
+		msg := &r.messages[i]
+		if msg.ID == id && inInbox(msg, userID) && !msg.DeletedAt.Valid {
+			return msg
+		}
+	}
+	return nil
+}
+
+// ListInbox 按 ID 升序列出用户的站内信
+func (r *MemoryRepository) ListInbox(ctx context.Context, userID int64, filter InboxFilter) ([]MessageModel, error) {
+	messages := r.filter(func(m *MessageModel) bool {
+		return inInbox(m, userID) && m.ID > filter.AfterID &&
+			(m.ArchivedAt != nil) == filter.Archived &&
+			(filter.Status == "" || m.Status == filter.Status) &&
+			(!filter.Unread || isUnread(m.Status))
+	})
+	if len(messages) > filter.Limit {
+		messages = messages[:filter.Limit]
+	}
+	return messages, nil
+}
+
+// CountUnread 统计用户未归档的未读站内信
+func (r *MemoryRepository) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	unread := r.filter(func(m *MessageModel) bool {
+		return inInbox(m, userID) && m.ArchivedAt == nil && isUnread(m.Status)
+	})
+	return int64(len(unread)), nil
+}
+
+// MarkRead 将站内信标记为已读，返回副本
+func (r *MemoryRepository) MarkRead(ctx context.Context, userID, id int64, at time.Time) (*MessageModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.inboxMessage(userID, id)
+	if msg == nil {
+		return nil, ErrMessageNotFound
+	}
+	if err := r.markRead(msg, at); err != nil {
+		return nil, err
+	}
+	result := *msg
+	return &result, nil
+}
+
+// MarkAllRead 将用户所有未读的站内信标记为已读
+func (r *MemoryRepository) MarkAllRead(ctx context.Context, userID int64, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var updated int64
+	for i := range r.messages {
+		msg := &r.messages[i]
+		if !inInbox(msg, userID) || msg.DeletedAt.Valid || !isUnread(msg.Status) {
+			continue
+		}
+		if err := r.markRead(msg, at); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// markRead 按 readPath 标记已读并记录每一步状态流转，已读时不做修改，调用方需持有写锁
+func (r *MemoryRepository) markRead(msg *MessageModel, at time.Time) error {
+	path, err := readPath(msg.Status)
+	if err != nil || len(path) == 0 {
+		return err
+	}
+	from := msg.Status
+	readAt := at.UTC()
+	msg.Status = Read
+	msg.ReadAt = &readAt
+	msg.UpdatedAt = time.Now()
+	for _, to := range path {
+		r.record(msg.ID, from, to, "read", msg.UpdatedAt)
+		from = to
+	}
+	return nil
+}
+
+// SetArchived 归档或取消归档站内信，返回副本
+func (r *MemoryRepository) SetArchived(ctx context.Context, userID, id int64, at *time.Time) (*MessageModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.inboxMessage(userID, id)
+	if msg == nil {
+		return nil, ErrMessageNotFound
+	}
+	msg.ArchivedAt = nil
+	if at != nil {
+		archivedAt := at.UTC()
+		msg.ArchivedAt = &archivedAt
+	}
+	msg.UpdatedAt = time.Now()
+	result := *msg
+	return &result, nil
+}
+
+// DeleteSiteMessage 软删除站内信
+func (r *MemoryRepository) DeleteSiteMessage(ctx context.Context, userID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := r.inboxMessage(userID, id)
+	if msg == nil {
+		return ErrMessageNotFound
+	}
+	msg.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// CreateOutboxed 保存新消息并写入发件箱
+func (r *MemoryRepository) CreateOutboxed(ctx context.Context, msg *MessageModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	msg.ID = r.nextID
+	r.nextID++
+	msg.CreatedAt, msg.UpdatedAt = now, now
+	r.messages = append(r.messages, *msg)
+	r.record(msg.ID, "", msg.Status, "created", now)
+
+	r.outbox = append(r.outbox, OutboxModel{ID: r.nextOutboxID, CreatedAt: now, MessageID: msg.ID})
+	r.nextOutboxID++
+	return nil
+}
+
+// PendingOutbox 按 ID 升序列出待中继的发件箱记录
+func (r *MemoryRepository) PendingOutbox(ctx context.Context, limit int) ([]OutboxModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := min(limit, len(r.outbox))
+	return append([]OutboxModel(nil), r.outbox[:n]...), nil
+}
+
+// DeleteOutbox 删除已中继的发件箱记录
+func (r *MemoryRepository) DeleteOutbox(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if r.outbox[i].ID == id {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return ErrOutboxNotFound
+}
+
+// SearchMessages 按 ID 降序搜索消息，关键词按子串匹配
+func (r *MemoryRepository) SearchMessages(ctx context.Context, filter SearchFilter) ([]MessageModel, error) {
+	messages := r.filter(func(m *MessageModel) bool {
+		return (filter.Type == "" || m.Type == filter.Type) &&
+			(filter.Status == "" || m.Status == filter.Status) &&
+			(filter.Provider == "" || m.Provider == filter.Provider) &&
+			(filter.From.IsZero() || !m.CreatedAt.Before(filter.From)) &&
+			(filter.To.IsZero() || m.CreatedAt.Before(filter.To)) &&
+			(filter.BeforeID <= 0 || m.ID < filter.BeforeID) &&
+			matchTerms(m, filter.Terms)
+	})
+	slices.Reverse(messages)
+	if len(messages) > filter.Limit {
+		messages = messages[:filter.Limit]
+	}
+	return messages, nil
+}
+
+// MessageStats 按时间桶、类型和通道汇总消息
+func (r *MemoryRepository) MessageStats(ctx context.Context, filter StatsFilter) ([]MessageStatsBucket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats []MessageStat
+	index := make(map[int64]int)
+	for i := range r.messages {
+		m := &r.messages[i]
+		if m.CreatedAt.Before(filter.From) || !m.CreatedAt.Before(filter.To) ||
+			(filter.Type != "" && m.Type != filter.Type) ||
+			(filter.Provider != "" && m.Provider != filter.Provider) {
+			continue
+		}
+		index[m.ID] = len(stats)
+		stats = append(stats, MessageStat{ID: m.ID, Type: m.Type, Provider: m.Provider, Status: m.Status, CreatedAt: m.CreatedAt})
+	}
+	for _, t := range r.transitions {
+		i, ok := index[t.MessageID]
+		if !ok {
+			continue
+		}
+		at := t.CreatedAt
+		if t.To == Sent && stats[i].SentAt == nil {
+			stats[i].SentAt = &at
+		}
+		if t.To == Received && stats[i].ReceivedAt == nil {
+			stats[i].ReceivedAt = &at
+		}
+	}
+	return aggregateStats(stats, filter.Interval, filter.location()), nil
+}
@@ -0,0 +1,553 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/pkg/idempotency"
+)
+
+// setupTestDB 为每个测试创建独立命名的内存数据库
+func setupTestDB(t *testing.T) *gorm.DB {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	require.NoError(t, db.AutoMigrate(append(Models(), idempotency.Models()...)...))
+	require.NoError(t, MigrateSearch(db))
+	return db
+}
+
+// 两种实现跑同一组用例，保证行为一致
+func repositories(t *testing.T) map[string]MessageRepository {
+	return map[string]MessageRepository{
+		"gorm":   NewGormRepository(setupTestDB(t)),
+		"memory": NewMemoryRepository(),
+	}
+}
+
+func TestMessageRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			sms := FromSMS(SMSMessage{Content: "验证码：929253", PhoneNumber: "+86 138-0013-8000"})
+			sms.Status = Sending
+			require.NoError(t, repo.Create(ctx, sms))
+			assert.NotZero(t, sms.ID)
+			assert.Equal(t, "8613800138000", sms.PhoneNumber)
+
+			site := FromSiteMessage(SiteMessage{Content: "欢迎", UserId: 1001})
+			site.Status = Sending
+			require.NoError(t, repo.Create(ctx, site))
+			assert.Greater(t, site.ID, sms.ID)
+
+			found, err := repo.FindByPhoneNumber(ctx, "8613800138000")
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, sms.ID, found[0].ID)
+			assert.Equal(t, Sms, found[0].Type)
+
+			found, err = repo.FindByUserID(ctx, 1001)
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, "欢迎", found[0].Content)
+
+			found, err = repo.FindByUserID(ctx, 42)
+			require.NoError(t, err)
+			assert.Empty(t, found)
+
+			found, err = repo.FindByUserIDAfter(ctx, 1001, 0)
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			found, err = repo.FindByUserIDAfter(ctx, 1001, site.ID)
+			require.NoError(t, err)
+			assert.Empty(t, found)
+
+			byID, err := repo.FindByID(ctx, sms.ID)
+			require.NoError(t, err)
+			assert.Equal(t, sms.Content, byID.Content)
+			_, err = repo.FindByID(ctx, 9999)
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+
+			updated, err := repo.Transition(ctx, site.ID, Sent, "delivered")
+			require.NoError(t, err)
+			assert.Equal(t, Sent, updated.Status)
+			found, err = repo.FindByUserID(ctx, 1001)
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, Sent, found[0].Status)
+
+			// 重复流转到当前状态不报错，也不记录日志
+			_, err = repo.Transition(ctx, site.ID, Sent, "retry")
+			require.NoError(t, err)
+
+			// 不允许跳过状态或回退
+			_, err = repo.Transition(ctx, site.ID, Sending, "rollback")
+			var transitionErr *TransitionError
+			require.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, Sent, transitionErr.From)
+			assert.ErrorIs(t, err, ErrInvalidTransition)
+
+			_, err = repo.Transition(ctx, 9999, Sent, "")
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+
+			records, err := repo.ListTransitions(ctx, site.ID)
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			assert.Equal(t, MessageStatus(""), records[0].From)
+			assert.Equal(t, Sending, records[0].To)
+			assert.Equal(t, Sending, records[1].From)
+			assert.Equal(t, Sent, records[1].To)
+			assert.Equal(t, "delivered", records[1].Reason)
+
+			// 投递回执按通道名称和通道侧消息 ID 查回消息
+			require.NoError(t, repo.SaveReceipt(ctx, sms.ID, Receipt{Provider: "acme", MessageID: "gw-1"}))
+			byReceipt, err := repo.FindByReceipt(ctx, "acme", "gw-1")
+			require.NoError(t, err)
+			assert.Equal(t, sms.ID, byReceipt.ID)
+			assert.Equal(t, "gw-1", byReceipt.ProviderMessageID)
+			_, err = repo.FindByReceipt(ctx, "other", "gw-1")
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+			assert.ErrorIs(t, repo.SaveReceipt(ctx, 9999, Receipt{Provider: "acme", MessageID: "gw-2"}), ErrMessageNotFound)
+		})
+	}
+}
+
+func TestChannelRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for _, uid := range []int64{3, 1, 2, 4} {
+				_, err := repo.SetSubscription(ctx, "news", uid, true)
+				require.NoError(t, err)
+			}
+			_, err := repo.SetSubscription(ctx, "alert", 1, true)
+			require.NoError(t, err)
+
+			// 退订保留偏好，重复退订不报错
+			sub, err := repo.SetSubscription(ctx, "news", 4, false)
+			require.NoError(t, err)
+			assert.False(t, sub.Subscribed)
+			_, err = repo.SetSubscription(ctx, "news", 4, false)
+			require.NoError(t, err)
+			sub, err = repo.FindSubscription(ctx, "news", 4)
+			require.NoError(t, err)
+			assert.False(t, sub.Subscribed)
+			_, err = repo.FindSubscription(ctx, "promotion", 4)
+			assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+			subs, err := repo.ListSubscribers(ctx, "news", 1, 10)
+			require.NoError(t, err)
+			require.Len(t, subs, 2)
+			assert.Equal(t, int64(2), subs[0].UserID)
+			assert.Equal(t, int64(3), subs[1].UserID)
+			total, err := repo.CountSubscribers(ctx, "news")
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), total)
+
+			broadcast := FromBroadcast(BroadcastMessage{Content: "公告"})
+			broadcast.Channel = "news"
+			broadcast.Status = Sending
+			require.NoError(t, repo.Create(ctx, broadcast))
+
+			_, err = repo.FindFanout(ctx, broadcast.ID)
+			assert.ErrorIs(t, err, ErrFanoutNotFound)
+			fanout, err := repo.CreateFanout(ctx, broadcast)
+			require.NoError(t, err)
+			assert.Equal(t, FanoutRunning, fanout.Status)
+			assert.Equal(t, int64(3), fanout.Total)
+			again, err := repo.CreateFanout(ctx, broadcast)
+			require.NoError(t, err)
+			assert.Equal(t, fanout.ID, again.ID)
+
+			// 3 个订阅者按每批 2 个生成，第二批不足 2 个时完成
+			batch, fanout, err := repo.FanoutBatch(ctx, broadcast.ID, 2)
+			require.NoError(t, err)
+			require.Len(t, batch, 2)
+			assert.Equal(t, int64(1), batch[0].UserID)
+			assert.Equal(t, Sitemessage, batch[0].Type)
+			assert.Equal(t, Sending, batch[0].Status)
+			assert.Equal(t, broadcast.ID, batch[0].BroadcastID)
+			assert.Equal(t, "公告", batch[0].Content)
+			assert.NotZero(t, batch[0].ID)
+			assert.Equal(t, int64(2), fanout.Delivered)
+			assert.Equal(t, FanoutRunning, fanout.Status)
+			outbox, err := repo.PendingOutbox(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, outbox, 2)
+			assert.Equal(t, batch[0].ID, outbox[0].MessageID)
+
+			batch, fanout, err = repo.FanoutBatch(ctx, broadcast.ID, 2)
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+			assert.Equal(t, int64(3), batch[0].UserID)
+			assert.Equal(t, int64(3), fanout.Delivered)
+			assert.Equal(t, FanoutCompleted, fanout.Status)
+			assert.NotNil(t, fanout.CompletedAt)
+
+			// 完成后重复执行不会再生成
+			batch, fanout, err = repo.FanoutBatch(ctx, broadcast.ID, 2)
+			require.NoError(t, err)
+			assert.Empty(t, batch)
+			assert.Equal(t, int64(3), fanout.Delivered)
+
+			found, err := repo.FindByUserID(ctx, 3)
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			records, err := repo.ListTransitions(ctx, found[0].ID)
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			assert.Equal(t, "fanout", records[0].Reason)
+
+			_, _, err = repo.FanoutBatch(ctx, 9999, 2)
+			assert.ErrorIs(t, err, ErrFanoutNotFound)
+		})
+	}
+}
+
+func TestTemplateRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			tpl := &TemplateModel{
+				ID:            "welcome",
+				DefaultLocale: "zh-CN",
+				Variables:     []TemplateVariable{{Name: "name", Type: VariableString}},
+				Variants:      map[string]string{"zh-CN": "欢迎 {{name}}", "en": "Welcome {{name}}"},
+			}
+			require.NoError(t, repo.CreateTemplate(ctx, tpl))
+			assert.False(t, tpl.CreatedAt.IsZero())
+			assert.ErrorIs(t, repo.CreateTemplate(ctx, &TemplateModel{ID: "welcome", DefaultLocale: "en"}), ErrTemplateExists)
+			require.NoError(t, repo.CreateTemplate(ctx, &TemplateModel{ID: "otp", DefaultLocale: "en", Variants: map[string]string{"en": "code"}}))
+
+			found, err := repo.FindTemplate(ctx, "welcome")
+			require.NoError(t, err)
+			assert.Equal(t, tpl.Variants, found.Variants)
+			assert.Equal(t, tpl.Variables, found.Variables)
+			_, err = repo.FindTemplate(ctx, "missing")
+			assert.ErrorIs(t, err, ErrTemplateNotFound)
+
+			list, err := repo.ListTemplates(ctx)
+			require.NoError(t, err)
+			require.Len(t, list, 2)
+			assert.Equal(t, "otp", list[0].ID)
+			assert.Equal(t, "welcome", list[1].ID)
+
+			// 整体替换，创建时间保持不变
+			updated := &TemplateModel{ID: "welcome", DefaultLocale: "en", Variants: map[string]string{"en": "Hi"}}
+			require.NoError(t, repo.UpdateTemplate(ctx, updated))
+			assert.Equal(t, found.CreatedAt.Unix(), updated.CreatedAt.Unix())
+			found, err = repo.FindTemplate(ctx, "welcome")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"en": "Hi"}, found.Variants)
+			assert.Empty(t, found.Variables)
+			assert.ErrorIs(t, repo.UpdateTemplate(ctx, &TemplateModel{ID: "missing"}), ErrTemplateNotFound)
+
+			require.NoError(t, repo.DeleteTemplate(ctx, "welcome"))
+			assert.ErrorIs(t, repo.DeleteTemplate(ctx, "welcome"), ErrTemplateNotFound)
+		})
+	}
+}
+
+func TestScheduleRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+			at := func(d time.Duration) *time.Time {
+				t := now.Add(d)
+				return &t
+			}
+
+			once := &ScheduleModel{Type: Sms, Content: "提醒", PhoneNumber: "+8613800138000", Status: SchedulePending, NextRunAt: at(time.Hour)}
+			daily := &ScheduleModel{Type: Sitemessage, Content: "日报", UserID: 7, Recurrence: "@daily", Timezone: "UTC", Status: SchedulePending, NextRunAt: at(time.Minute)}
+			later := &ScheduleModel{Type: Sms, Content: "以后", PhoneNumber: "+8613800138000", Status: SchedulePending, NextRunAt: at(48 * time.Hour)}
+			for _, s := range []*ScheduleModel{once, daily, later} {
+				require.NoError(t, repo.CreateSchedule(ctx, s))
+				assert.NotZero(t, s.ID)
+			}
+
+			due, err := repo.DueSchedules(ctx, now.Add(2*time.Hour), 10)
+			require.NoError(t, err)
+			require.Len(t, due, 2)
+			assert.Equal(t, daily.ID, due[0].ID)
+			assert.Equal(t, once.ID, due[1].ID)
+
+			// 周期任务触发后推进到下次时间，消息记录来源
+			stale := due[0]
+			msg, err := repo.FireSchedule(ctx, &due[0], at(24*time.Hour), now.Add(2*time.Hour))
+			require.NoError(t, err)
+			assert.NotZero(t, msg.ID)
+			assert.Equal(t, "日报", msg.Content)
+			assert.Equal(t, int64(7), msg.UserID)
+			assert.Equal(t, daily.ID, msg.ScheduleID)
+			assert.Equal(t, Sending, msg.Status)
+			assert.Equal(t, int64(1), due[0].Runs)
+			assert.Equal(t, SchedulePending, due[0].Status)
+			require.NotNil(t, due[0].LastMessageID)
+			assert.Equal(t, msg.ID, *due[0].LastMessageID)
+
+			// 同一次触发重复执行时冲突，不会重复生成消息
+			_, err = repo.FireSchedule(ctx, &stale, at(24*time.Hour), now.Add(2*time.Hour))
+			assert.ErrorIs(t, err, ErrScheduleConflict)
+			transitions, err := repo.ListTransitions(ctx, msg.ID)
+			require.NoError(t, err)
+			assert.Len(t, transitions, 1)
+			outbox, err := repo.PendingOutbox(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, outbox, 1)
+			assert.Equal(t, msg.ID, outbox[0].MessageID)
+
+			// 一次性任务触发后完成
+			_, err = repo.FireSchedule(ctx, &due[1], nil, now.Add(2*time.Hour))
+			require.NoError(t, err)
+			found, err := repo.FindSchedule(ctx, once.ID)
+			require.NoError(t, err)
+			assert.Equal(t, ScheduleCompleted, found.Status)
+			assert.Nil(t, found.NextRunAt)
+
+			due, err = repo.DueSchedules(ctx, now.Add(2*time.Hour), 10)
+			require.NoError(t, err)
+			assert.Empty(t, due)
+
+			pending, err := repo.ListSchedules(ctx, SchedulePending)
+			require.NoError(t, err)
+			require.Len(t, pending, 2)
+			assert.Equal(t, daily.ID, pending[0].ID)
+			assert.Equal(t, later.ID, pending[1].ID)
+
+			cancelled, err := repo.CancelSchedule(ctx, later.ID)
+			require.NoError(t, err)
+			assert.Equal(t, ScheduleCancelled, cancelled.Status)
+			assert.Nil(t, cancelled.NextRunAt)
+			_, err = repo.CancelSchedule(ctx, later.ID)
+			assert.ErrorIs(t, err, ErrScheduleNotPending)
+			_, err = repo.CancelSchedule(ctx, once.ID)
+			assert.ErrorIs(t, err, ErrScheduleNotPending)
+			_, err = repo.CancelSchedule(ctx, 999)
+			assert.ErrorIs(t, err, ErrScheduleNotFound)
+			_, err = repo.FindSchedule(ctx, 999)
+			assert.ErrorIs(t, err, ErrScheduleNotFound)
+
+			// 取消后触发失败
+			_, err = repo.FireSchedule(ctx, later, nil, now.Add(72*time.Hour))
+			assert.ErrorIs(t, err, ErrScheduleConflict)
+		})
+	}
+}
+
+func TestOTPRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+			newOTP := func(at time.Time) (*OTPModel, *MessageModel) {
+				return &OTPModel{CreatedAt: at, PhoneNumber: "8613800138000", Purpose: "login", Salt: "s", CodeHash: "h", ExpiresAt: at.Add(5 * time.Minute), MaxAttempts: 3},
+					&MessageModel{Type: Sms, Status: Sending, Content: "123456", PhoneNumber: "8613800138000"}
+			}
+
+			_, err := repo.FindLatestOTP(ctx, "8613800138000", "login")
+			assert.ErrorIs(t, err, ErrOTPNotFound)
+
+			first, msg := newOTP(now)
+			require.NoError(t, repo.CreateOTP(ctx, first, msg, now.Add(-time.Minute)))
+			assert.NotZero(t, msg.ID)
+			assert.Equal(t, msg.ID, first.MessageID)
+
+			// 冷却时间内拒绝，不生成短信
+			again, againMsg := newOTP(now.Add(30 * time.Second))
+			assert.ErrorIs(t, repo.CreateOTP(ctx, again, againMsg, now.Add(-30*time.Second)), ErrOTPCooldown)
+			sms, err := repo.FindByPhoneNumber(ctx, "8613800138000")
+			require.NoError(t, err)
+			assert.Len(t, sms, 1)
+
+			second, msg := newOTP(now.Add(2 * time.Minute))
+			require.NoError(t, repo.CreateOTP(ctx, second, msg, now.Add(time.Minute)))
+			latest, err := repo.FindLatestOTP(ctx, "8613800138000", "login")
+			require.NoError(t, err)
+			assert.Equal(t, second.ID, latest.ID)
+
+			require.NoError(t, repo.AttemptOTP(ctx, latest.ID, 0, nil))
+			assert.ErrorIs(t, repo.AttemptOTP(ctx, latest.ID, 0, nil), ErrOTPConflict)
+			verifiedAt := now.Add(3 * time.Minute)
+			require.NoError(t, repo.AttemptOTP(ctx, latest.ID, 1, &verifiedAt))
+			latest, err = repo.FindLatestOTP(ctx, "8613800138000", "login")
+			require.NoError(t, err)
+			assert.Equal(t, 2, latest.Attempts)
+			require.NotNil(t, latest.VerifiedAt)
+
+			// 已校验的验证码不能再记录
+			assert.ErrorIs(t, repo.AttemptOTP(ctx, latest.ID, 2, nil), ErrOTPConflict)
+		})
+	}
+}
+
+func TestRateLimitRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2025, 6, 1, 8, 0, 30, 0, time.UTC)
+			perMinute := RateLimitCounter{RateLimit: RateLimit{Scope: ScopePhoneNumber, Limit: 2, Window: time.Minute}, Value: "8613800138000"}
+			perHour := RateLimitCounter{RateLimit: RateLimit{Scope: ScopePhoneNumber, Limit: 3, Window: time.Hour}, Value: "8613800138000"}
+			counters := []RateLimitCounter{perMinute, perHour}
+
+			require.NoError(t, repo.ConsumeRateLimits(ctx, counters, now))
+			require.NoError(t, repo.ConsumeRateLimits(ctx, counters, now.Add(10*time.Second)))
+
+			err := repo.ConsumeRateLimits(ctx, counters, now.Add(20*time.Second))
+			require.ErrorIs(t, err, ErrRateLimited)
+			var limitErr *RateLimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, perMinute, limitErr.RateLimitCounter)
+			assert.Equal(t, 10*time.Second, limitErr.RetryAfter)
+
+			// 被拒绝时小时窗口也没有计数，下一分钟还能发送一次
+			require.NoError(t, repo.ConsumeRateLimits(ctx, counters, now.Add(time.Minute)))
+			err = repo.ConsumeRateLimits(ctx, counters, now.Add(2*time.Minute))
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, perHour, limitErr.RateLimitCounter)
+			assert.Equal(t, 57*time.Minute+30*time.Second, limitErr.RetryAfter)
+
+			// 其他号码和下一个小时不受影响
+			other := perMinute
+			other.Value = "8613900139000"
+			require.NoError(t, repo.ConsumeRateLimits(ctx, []RateLimitCounter{other}, now.Add(2*time.Minute)))
+			require.NoError(t, repo.ConsumeRateLimits(ctx, counters, now.Add(time.Hour)))
+		})
+	}
+}
+
+func TestInboxRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+			var ids []int64
+			for _, msg := range []*MessageModel{
+				{Type: Sitemessage, Status: Sending, Content: "a", UserID: 1},
+				{Type: Sitemessage, Status: Sent, Content: "b", UserID: 1},
+				{Type: Sitemessage, Status: Received, Content: "c", UserID: 1},
+				{Type: Sitemessage, Status: Sending, Content: "d", UserID: 2},
+				{Type: Sms, Status: Sending, Content: "e", PhoneNumber: "8613800138000"},
+			} {
+				require.NoError(t, repo.Create(ctx, msg))
+				ids = append(ids, msg.ID)
+			}
+
+			unread, err := repo.CountUnread(ctx, 1)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, unread)
+
+			// 未回执的站内信标记为已读时按状态机补齐 sent、received
+			msg, err := repo.MarkRead(ctx, 1, ids[0], now)
+			require.NoError(t, err)
+			assert.Equal(t, Read, msg.Status)
+			require.NotNil(t, msg.ReadAt)
+			assert.True(t, now.Equal(*msg.ReadAt))
+			transitions, err := repo.ListTransitions(ctx, ids[0])
+			require.NoError(t, err)
+			require.Len(t, transitions, 4)
+			for i, want := range []MessageStatus{Sending, Sent, Received, Read} {
+				assert.Equal(t, want, transitions[i].To)
+			}
+			_, err = repo.MarkRead(ctx, 1, ids[3], now)
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+			_, err = repo.MarkRead(ctx, 1, ids[4], now)
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+
+			msg, err = repo.SetArchived(ctx, 1, ids[1], &now)
+			require.NoError(t, err)
+			require.NotNil(t, msg.ArchivedAt)
+			unread, err = repo.CountUnread(ctx, 1)
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, unread)
+
+			inbox, err := repo.ListInbox(ctx, 1, InboxFilter{Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, inbox, 2)
+			assert.Equal(t, ids[0], inbox[0].ID)
+			inbox, err = repo.ListInbox(ctx, 1, InboxFilter{Unread: true, Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, inbox, 1)
+			assert.Equal(t, ids[2], inbox[0].ID)
+			inbox, err = repo.ListInbox(ctx, 1, InboxFilter{Archived: true, Status: Sent, Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, inbox, 1)
+			assert.Equal(t, ids[1], inbox[0].ID)
+			inbox, err = repo.ListInbox(ctx, 1, InboxFilter{AfterID: ids[0], Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, inbox, 1)
+			assert.Equal(t, ids[2], inbox[0].ID)
+
+			// 全部已读包括已归档的消息
+			updated, err := repo.MarkAllRead(ctx, 1, now)
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, updated)
+			updated, err = repo.MarkAllRead(ctx, 1, now)
+			require.NoError(t, err)
+			assert.Zero(t, updated)
+			transitions, err = repo.ListTransitions(ctx, ids[1])
+			require.NoError(t, err)
+			require.Len(t, transitions, 3)
+			assert.Equal(t, Sent, transitions[1].From)
+			assert.Equal(t, Received, transitions[1].To)
+			assert.Equal(t, Received, transitions[2].From)
+			assert.Equal(t, Read, transitions[2].To)
+
+			// 投递失败的站内信不是未读，也不能标记为已读
+			failed := &MessageModel{Type: Sitemessage, Status: Failed, Content: "f", UserID: 3}
+			require.NoError(t, repo.Create(ctx, failed))
+			_, err = repo.MarkRead(ctx, 3, failed.ID, now)
+			assert.ErrorIs(t, err, ErrInvalidTransition)
+			updated, err = repo.MarkAllRead(ctx, 3, now)
+			require.NoError(t, err)
+			assert.Zero(t, updated)
+			unread, err = repo.CountUnread(ctx, 3)
+			require.NoError(t, err)
+			assert.Zero(t, unread)
+			transitions, err = repo.ListTransitions(ctx, failed.ID)
+			require.NoError(t, err)
+			assert.Len(t, transitions, 1)
+
+			// 删除后其他查询都看不到该消息
+			require.NoError(t, repo.DeleteSiteMessage(ctx, 1, ids[2]))
+			assert.ErrorIs(t, repo.DeleteSiteMessage(ctx, 1, ids[2]), ErrMessageNotFound)
+			assert.ErrorIs(t, repo.DeleteSiteMessage(ctx, 2, ids[0]), ErrMessageNotFound)
+			_, err = repo.FindByID(ctx, ids[2])
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+			found, err := repo.FindByUserID(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, found, 2)
+		})
+	}
+}
@@ -2,25 +2,186 @@ package message
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/idempotency"
+	"github.com/twotwo/go-blueprint/pkg/lease"
+	"github.com/twotwo/go-blueprint/pkg/module"
+	"github.com/twotwo/go-blueprint/pkg/pubsub"
+	"github.com/twotwo/go-blueprint/pkg/queue"
 )
 
-// RegisterRoutes 注册消息相关路由
-func RegisterRoutes(r chi.Router) {
+// ensure that we've conformed to the `module.Module` with a compile-time check
+var _ module.Module = (*Server)(nil)
+
+// ensure that we've conformed to the generated `StrictServerInterface` with a compile-time check
+var _ StrictServerInterface = (*Server)(nil)
+
+// Config 消息模块配置
+type Config struct {
+	// Providers 各消息类型的投递通道，为 nil 时只保存消息不投递
+	Providers *Registry
+
+	// Queue 投递队列，为 nil 时在请求中同步投递
+	// 队列的 worker 由调用方通过 Queue.Run 启动
+	Queue *queue.Queue
+
+	// CallbackSecrets 通道名称 -> 投递回执的签名密钥，未配置密钥的通道不接收回执
+	CallbackSecrets map[string]string
+
+	// Hub 站内信实时推送的发布/订阅中心，为 nil 时创建模块私有的实例
+	Hub *pubsub.Hub[MessageModel]
+
+	// StreamHeartbeat SSE/WebSocket 连接的心跳间隔，默认 DefaultStreamHeartbeat
+	StreamHeartbeat time.Duration
+
+	// FanoutBatchSize 广播扇出每批生成的站内信数，默认 DefaultFanoutBatchSize
+	FanoutBatchSize int
+
+	// Leases 定时发送调度器和发件箱中继的选主租约，多个副本中只有持有者扫描到期任务；
+	// New 默认使用 deps.DB，NewServer 为 nil 时不选主，适用于单副本
+	// 调度器由调用方通过 Server.RunScheduler 启动，生成的消息由发件箱中继投递
+	Leases *lease.Manager
 
-	// 消息相关路由
-	r.Route("/message", func(r chi.Router) {
-		// POST /message - 创建新消息（需要JWT认证）
-		r.With(AuthMiddleware).Post("/", CreateMessage)
+	// SchedulerInterval 调度器扫描到期任务的间隔，默认 DefaultSchedulerInterval
+	SchedulerInterval time.Duration
 
-		// GET /message/sms/{number} - 根据手机号查询短信
-		r.Get("/sms/{number}", FindMessagesByNumber)
+	// OutboxInterval 发件箱中继扫描的间隔，默认 DefaultOutboxInterval
+	// 中继由调用方通过 Server.RunOutboxRelay 启动
+	OutboxInterval time.Duration
 
-		// GET /message/sitemessage/{uid} - 根据用户ID查询站内消息
-		r.Get("/sitemessage/{uid}", FindMessagesByUID)
+	// Idempotency 创建消息和发送验证码的 Idempotency-Key 支持，带相同键的重试返回第一次的响应；
+	// New 默认使用 deps.DB，NewServer 为 nil 时忽略该请求头
+	Idempotency *idempotency.Store
+
+	// OTP 短信验证码配置
+	OTP OTPConfig
+
+	// RateLimits 发送频率限制，为空时不限流；计数保存在消息仓储中，多个副本共享
+	// 定时任务在创建时计入一次，验证码短信计入手机号维度
+	RateLimits []RateLimit
+
+	// QuietHours 短信免打扰时段，时段内的非紧急短信推迟到时段结束时发送
+	// 只作用于立即发送的短信，定时任务按调用方指定的时间发送
+	QuietHours QuietHours
+
+	// Preferences 投递前查询接收方的通知偏好，不接收的消息标记为 suppressed；为 nil 时全部投递
+	// 验证码短信的类别为 CategorySecurity，扇出的站内信沿用广播的类别，广播未指定类别时以频道为类别
+	Preferences PreferenceFunc
+
+	// Login 校验 /auth/login 的用户名和密码，为 nil 时登录接口返回 501
+	Login LoginFunc
+}
+
+// DefaultStreamHeartbeat 推送连接的默认心跳间隔，需小于代理的空闲超时
+const DefaultStreamHeartbeat = 15 * time.Second
+
+func (c Config) withDefaults() Config {
+	if c.Hub == nil {
+		c.Hub = pubsub.New[MessageModel](pubsub.DefaultBuffer)
+	}
+	if c.StreamHeartbeat <= 0 {
+		c.StreamHeartbeat = DefaultStreamHeartbeat
+	}
+	if c.FanoutBatchSize <= 0 {
+		c.FanoutBatchSize = DefaultFanoutBatchSize
+	}
+	if c.SchedulerInterval <= 0 {
+		c.SchedulerInterval = DefaultSchedulerInterval
+	}
+	if c.OutboxInterval <= 0 {
+		c.OutboxInterval = DefaultOutboxInterval
+	}
+	c.OTP = c.OTP.withDefaults()
+	return c
+}
+
+// New 创建基于 deps.DB 持久化的消息模块，表结构见 Models
+func New(deps module.Deps, cfg Config) *Server {
+	if cfg.Leases == nil {
+		cfg.Leases = lease.New(deps)
+	}
+	if cfg.Idempotency == nil {
+		cfg.Idempotency = idempotency.New(deps, idempotency.Config{})
+	}
+	return NewServer(NewGormRepository(deps.DB), deps, cfg)
+}
+
+// Routes 注册消息相关路由，路由和参数解析由 api.yaml 生成
+// api.yaml 中声明了 security 的接口由 authenticate 认证，创建消息和发送验证码支持 Idempotency-Key
+func (h *Server) Routes(r chi.Router) {
+	var si ServerInterface = NewStrictHandlerWithOptions(h, []StrictMiddlewareFunc{withRequest}, StrictHTTPServerOptions{
+		RequestErrorHandlerFunc:  errors.HandleRequestError,
+		ResponseErrorHandlerFunc: errors.HandleResponseError(h.logger),
 	})
+	if h.idempotency != nil {
+		si = idempotentServer{ServerInterface: si, store: h.idempotency}
+	}
+	HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter:       r,
+		Middlewares:      []MiddlewareFunc{h.authenticate},
+		ErrorHandlerFunc: paramError,
+	})
+}
+
+// idempotentServer 按 Idempotency-Key 重放创建消息和发送验证码的响应，带相同键的重试不会重复发送
+type idempotentServer struct {
+	ServerInterface
+	store *idempotency.Store
+}
+
+func (s idempotentServer) CreateMessage(w http.ResponseWriter, r *http.Request, params CreateMessageParams) {
+	s.store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.ServerInterface.CreateMessage(w, r, params)
+	})).ServeHTTP(w, r)
+}
+
+func (s idempotentServer) SendOTP(w http.ResponseWriter, r *http.Request, params SendOTPParams) {
+	s.store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.ServerInterface.SendOTP(w, r, params)
+	})).ServeHTTP(w, r)
+}
+
+// authenticate 按生成的代码在上下文中标记的安全方案认证请求：
+// MySecurity 校验 Bearer 令牌，CallbackSignature 校验投递回执的签名
+func (h *Server) authenticate(next http.Handler) http.Handler {
+	bearer := AuthMiddleware(next)
+	callback := h.verifyCallback(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Context().Value(MySecurityScopes) != nil:
+			bearer.ServeHTTP(w, r)
+		case r.Context().Value(CallbackSignatureScopes) != nil:
+			callback.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// paramError 将生成代码的参数解析错误转换为统一的错误响应
+func paramError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		required       *RequiredParamError
+		requiredHeader *RequiredHeaderError
+		invalid        *InvalidParamFormatError
+	)
+	switch {
+	case stderrors.As(err, &required):
+		errors.WriteJSON(w, errors.BadRequest(fmt.Sprintf("缺少参数: %s", required.ParamName)))
+	case stderrors.As(err, &requiredHeader):
+		errors.WriteJSON(w, errors.BadRequest(fmt.Sprintf("缺少参数: %s", requiredHeader.ParamName)))
+	case stderrors.As(err, &invalid):
+		errors.WriteJSON(w, errors.BadRequest(fmt.Sprintf("无效的参数: %s", invalid.ParamName)))
+	default:
+		errors.WriteJSON(w, errors.BadRequest("无效的请求参数"))
+	}
 }
 
 // AuthMiddleware 是一个简单的JWT认证中间件
@@ -0,0 +1,401 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/cron"
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/lease"
+)
+
+const (
+	// SchedulerLease 调度器在租约表中的名称，多个副本中只有持有者扫描到期任务
+	SchedulerLease = "message.scheduler"
+
+	// DefaultSchedulerInterval 调度器扫描到期任务的默认间隔
+	DefaultSchedulerInterval = time.Second
+
+	// scheduleBatchSize 调度器每次读取的到期任务数
+	scheduleBatchSize = 100
+)
+
+var (
+	// ErrScheduleNotFound 表示定时任务不存在
+	ErrScheduleNotFound = stderrors.New("schedule not found")
+
+	// ErrScheduleNotPending 表示定时任务已完成或已取消
+	ErrScheduleNotPending = stderrors.New("schedule is not pending")
+
+	// ErrScheduleConflict 表示定时任务的同一次触发已被其他调度器处理
+	ErrScheduleConflict = stderrors.New("schedule fired concurrently")
+
+	// ErrInvalidSchedule 表示 send_at、recurrence 或 timezone 不合法
+	ErrInvalidSchedule = stderrors.New("invalid schedule")
+)
+
+// ScheduleModel 定时发送任务：保存待发送的消息内容，到期时生成一条新消息
+// 周期任务每次触发后按 cron 表达式计算下次时间；Runs 兼作版本号，保证每次触发只生成一条消息
+type ScheduleModel struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Type        MessageType `gorm:"size:20;not null" json:"type"`
+	Content     string      `gorm:"type:text;not null" json:"content"`
+	PhoneNumber string      `gorm:"size:20" json:"phone_number,omitempty"`
+	UserID      int64       `json:"user_id,omitempty"`
+	Channel     string      `gorm:"size:20" json:"channel,omitempty"`
+	TemplateID  string      `gorm:"size:64" json:"template_id,omitempty"`
+	Sender      string      `gorm:"size:64" json:"sender,omitempty"`
+	Category    string      `gorm:"size:50" json:"category,omitempty"`
+
+	OpenID           string            `gorm:"column:openid;size:64" json:"openid,omitempty"`
+	WechatTemplateID string            `gorm:"size:64" json:"wechat_template_id,omitempty"`
+	WechatURL        string            `gorm:"size:512" json:"wechat_url,omitempty"`
+	WechatData       map[string]string `gorm:"serializer:json;type:text" json:"wechat_data,omitempty"`
+
+	Email       string              `gorm:"size:254" json:"email,omitempty"`
+	Subject     string              `gorm:"size:255" json:"subject,omitempty"`
+	HTMLBody    string              `json:"html_body,omitempty"`
+	Attachments []MessageAttachment `gorm:"serializer:json" json:"attachments,omitempty"`
+
+	Recurrence string `gorm:"size:100" json:"recurrence,omitempty"` // cron 表达式，为空表示只发送一次
+	Timezone   string `gorm:"size:64" json:"timezone,omitempty"`    // 计算 Recurrence 使用的时区
+
+	Status        ScheduleStatus `gorm:"size:20;not null;index:idx_schedules_due,priority:1" json:"status"`
+	NextRunAt     *time.Time     `gorm:"index:idx_schedules_due,priority:2" json:"next_run_at,omitempty"` // 任务结束后为空
+	Runs          int64          `gorm:"not null;default:0" json:"runs"`
+	LastRunAt     *time.Time     `json:"last_run_at,omitempty"`
+	LastMessageID *int64         `json:"last_message_id,omitempty"`
+}
+
+// TableName 指定定时任务表名
+func (ScheduleModel) TableName() string {
+	return "message_schedules"
+}
+
+// NewSchedule 为已校验的消息创建定时任务
+// 只有 sendAt 时在该时间发送一次；有 recurrence 时在 sendAt 及之后（省略 sendAt 时为 now 之后）的第一个匹配时间首次发送
+func NewSchedule(msg *MessageModel, sendAt *time.Time, recurrence, timezone string, now time.Time) (*ScheduleModel, error) {
+	s := &ScheduleModel{
+		Type:        msg.Type,
+		Content:     msg.Content,
+		PhoneNumber: msg.PhoneNumber,
+		UserID:      msg.UserID,
+		Channel:     msg.Channel,
+		TemplateID:  msg.TemplateID,
+		Sender:      msg.Sender,
+		Category:    msg.Category,
+		Recurrence:  recurrence,
+		Timezone:    timezone,
+		Status:      SchedulePending,
+
+		OpenID:           msg.OpenID,
+		WechatTemplateID: msg.WechatTemplateID,
+		WechatURL:        msg.WechatURL,
+		WechatData:       msg.WechatData,
+
+		Email:       msg.Email,
+		Subject:     msg.Subject,
+		HTMLBody:    msg.HTMLBody,
+		Attachments: msg.Attachments,
+	}
+	if sendAt != nil && sendAt.Before(now) {
+		return nil, fmt.Errorf("%w: send_at %s is in the past", ErrInvalidSchedule, sendAt.Format(time.RFC3339))
+	}
+
+	if recurrence == "" {
+		if timezone != "" {
+			return nil, fmt.Errorf("%w: timezone requires recurrence", ErrInvalidSchedule)
+		}
+		if sendAt == nil {
+			return nil, fmt.Errorf("%w: send_at or recurrence is required", ErrInvalidSchedule)
+		}
+		at := sendAt.UTC()
+		s.NextRunAt = &at
+		return s, nil
+	}
+
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	start := now
+	if sendAt != nil {
+		// Next 不包含起点本身，提前一秒使恰好落在匹配时间的 send_at 也能触发
+		start = sendAt.Add(-time.Second)
+	}
+	next, err := s.nextAfter(start)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, fmt.Errorf("%w: recurrence %q never fires", ErrInvalidSchedule, recurrence)
+	}
+	s.NextRunAt = next
+	return s, nil
+}
+
+// nextAfter 返回 after 之后的下一次发送时间（UTC），一次性任务或 cron 不再触发时返回 nil
+func (s *ScheduleModel) nextAfter(after time.Time) (*time.Time, error) {
+	if s.Recurrence == "" {
+		return nil, nil
+	}
+	spec, err := cron.Parse(s.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchedule, err)
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, s.Timezone)
+	}
+	next := spec.Next(after.In(loc))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// Message 按任务内容生成一条待投递的消息
+func (s *ScheduleModel) Message() *MessageModel {
+	return &MessageModel{
+		Type:        s.Type,
+		Status:      Sending,
+		Content:     s.Content,
+		PhoneNumber: s.PhoneNumber,
+		UserID:      s.UserID,
+		Channel:     s.Channel,
+		TemplateID:  s.TemplateID,
+		Sender:      s.Sender,
+		Category:    s.Category,
+		ScheduleID:  s.ID,
+
+		OpenID:           s.OpenID,
+		WechatTemplateID: s.WechatTemplateID,
+		WechatURL:        s.WechatURL,
+		WechatData:       s.WechatData,
+
+		Email:       s.Email,
+		Subject:     s.Subject,
+		HTMLBody:    s.HTMLBody,
+		Attachments: s.Attachments,
+	}
+}
+
+// ToAPI 将定时任务转换为API模型
+func (s *ScheduleModel) ToAPI() Schedule {
+	api := Schedule{
+		Id:            s.ID,
+		Type:          s.Type,
+		Content:       s.Content,
+		Status:        s.Status,
+		NextRunAt:     s.NextRunAt,
+		Runs:          s.Runs,
+		LastRunAt:     s.LastRunAt,
+		LastMessageId: s.LastMessageID,
+		CreatedAt:     s.CreatedAt,
+	}
+	optional := func(v string) *string {
+		if v == "" {
+			return nil
+		}
+		return &v
+	}
+	api.PhoneNumber = optional(s.PhoneNumber)
+	api.Channel = optional(s.Channel)
+	api.Openid = optional(s.OpenID)
+	api.Email = optional(s.Email)
+	api.Subject = optional(s.Subject)
+	api.TemplateId = optional(s.TemplateID)
+	api.Sender = optional(s.Sender)
+	api.Category = optional(s.Category)
+	api.Recurrence = optional(s.Recurrence)
+	api.Timezone = optional(s.Timezone)
+	if s.UserID != 0 {
+		uid := s.UserID
+		api.UserId = &uid
+	}
+	return api
+}
+
+// ScheduleRepository 定时发送任务的数据访问接口
+type ScheduleRepository interface {
+	// CreateSchedule 保存新的定时任务并回填 ID 和时间戳
+	CreateSchedule(ctx context.Context, s *ScheduleModel) error
+
+	// FindSchedule 按 ID 查询定时任务，不存在时返回 ErrScheduleNotFound
+	FindSchedule(ctx context.Context, id int64) (*ScheduleModel, error)
+
+	// ListSchedules 按下次发送时间升序列出指定状态的定时任务
+	ListSchedules(ctx context.Context, status ScheduleStatus) ([]ScheduleModel, error)
+
+	// CancelSchedule 取消待发送的定时任务，已完成或已取消时返回 ErrScheduleNotPending
+	CancelSchedule(ctx context.Context, id int64) (*ScheduleModel, error)
+
+	// DueSchedules 按下次发送时间升序列出 now 之前到期的待发送任务，至多 limit 条
+	DueSchedules(ctx context.Context, now time.Time, limit int) ([]ScheduleModel, error)
+
+	// FireSchedule 生成任务的一条消息并写入发件箱，同时推进任务：next 为 nil 时任务完成，否则更新下次发送时间
+	// 三者在同一事务中完成，并以读到的 Runs 为条件，任务已被并发触发或取消时返回 ErrScheduleConflict；
+	// 成功时更新 s 并返回生成的消息，消息由发件箱中继投递
+	FireSchedule(ctx context.Context, s *ScheduleModel, next *time.Time, at time.Time) (*MessageModel, error)
+}
+
+// scheduleRequest 根据创建消息请求中的 send_at、recurrence 和 timezone 创建定时任务，
+// 都没有提供时返回 nil
+func (h *Server) scheduleRequest(msg Message, model *MessageModel) (*ScheduleModel, error) {
+	recurrence, timezone := stringValue(msg.Recurrence), stringValue(msg.Timezone)
+	if msg.SendAt == nil && recurrence == "" && timezone == "" {
+		return nil, nil
+	}
+
+	schedule, err := NewSchedule(model, msg.SendAt, recurrence, timezone, h.clock.Now())
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("定时发送设置无效: %s", err))
+	}
+	return schedule, nil
+}
+
+// ListSchedules 列出定时发送任务，默认只列出待发送的任务
+func (h *Server) ListSchedules(ctx context.Context, request ListSchedulesRequestObject) (ListSchedulesResponseObject, error) {
+	status := SchedulePending
+	if request.Params.Status != nil {
+		status = *request.Params.Status
+		switch status {
+		case SchedulePending, ScheduleCompleted, ScheduleCancelled:
+		default:
+			return nil, errors.BadRequest("无效的任务状态")
+		}
+	}
+
+	models, err := h.messages.ListSchedules(ctx, status)
+	if err != nil {
+		return nil, errors.InternalServer("查询定时任务失败")
+	}
+	list := ListSchedules200JSONResponse{Schedules: make([]Schedule, 0, len(models))}
+	for i := range models {
+		list.Schedules = append(list.Schedules, models[i].ToAPI())
+	}
+	return list, nil
+}
+
+// GetSchedule 查询定时发送任务
+func (h *Server) GetSchedule(ctx context.Context, request GetScheduleRequestObject) (GetScheduleResponseObject, error) {
+	schedule, err := h.messages.FindSchedule(ctx, request.Id)
+	if err != nil {
+		if stderrors.Is(err, ErrScheduleNotFound) {
+			return nil, errors.NotFound("定时任务不存在")
+		}
+		return nil, errors.InternalServer("查询定时任务失败")
+	}
+	return GetSchedule200JSONResponse(schedule.ToAPI()), nil
+}
+
+// CancelSchedule 取消待发送的定时任务，已生成的消息不受影响
+func (h *Server) CancelSchedule(ctx context.Context, request CancelScheduleRequestObject) (CancelScheduleResponseObject, error) {
+	schedule, err := h.messages.CancelSchedule(ctx, request.Id)
+	if err != nil {
+		switch {
+		case stderrors.Is(err, ErrScheduleNotFound):
+			return nil, errors.NotFound("定时任务不存在")
+		case stderrors.Is(err, ErrScheduleNotPending):
+			return nil, errors.New(http.StatusConflict, "定时任务已完成或已取消")
+		default:
+			return nil, errors.InternalServer("取消定时任务失败")
+		}
+	}
+	return CancelSchedule200JSONResponse(schedule.ToAPI()), nil
+}
+
+// RunScheduler 启动定时发送的调度循环，阻塞到 ctx 取消
+// 配置了租约时只有租约持有者扫描到期任务，持有者退出或崩溃后由其他副本接手
+func (h *Server) RunScheduler(ctx context.Context) {
+	h.runElected(ctx, SchedulerLease, h.schedulerInterval, "处理定时任务失败", scheduleBatchSize, h.RunSchedulerOnce)
+}
+
+// runElected 每隔 interval 调用 once，直到一次处理的数量不足 batch，阻塞到 ctx 取消
+// 配置了租约时只有名为 name 的租约的持有者执行，退出时释放租约
+func (h *Server) runElected(ctx context.Context, name string, interval time.Duration, failure string, batch int, once func(context.Context) (int, error)) {
+	holder := lease.NewHolder()
+	ttl := 5 * interval
+	defer func() {
+		if h.leases != nil {
+			// ctx 已取消，使用新的上下文释放租约
+			if err := h.leases.Release(context.Background(), name, holder); err != nil {
+				h.logger.Warn("释放租约失败", "lease", name, "error", err)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if h.leases != nil {
+			ok, err := h.leases.Acquire(ctx, name, holder, ttl)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error("获取租约失败", "lease", name, "error", err)
+				}
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		// 一次扫描处理完所有待处理的记录
+		for {
+			n, err := once(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error(failure, "error", err)
+				}
+				break
+			}
+			if n < batch {
+				break
+			}
+		}
+	}
+}
+
+// RunSchedulerOnce 为至多一批到期的定时任务生成消息，返回生成的消息数
+// 消息与任务推进在同一事务中写入发件箱，由 RunOutboxRelay 投递，调度器在提交后崩溃也不会丢失消息；
+// 周期任务错过的时间点（如服务停机期间）只补发一次，下次时间从当前时间算起
+func (h *Server) RunSchedulerOnce(ctx context.Context) (int, error) {
+	now := h.clock.Now().UTC()
+	due, err := h.messages.DueSchedules(ctx, now, scheduleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	fired := 0
+	for i := range due {
+		schedule := &due[i]
+		next, err := schedule.nextAfter(now)
+		if err != nil {
+			// 创建时已校验，只有时区数据缺失等环境问题会走到这里，结束任务以免反复失败
+			h.logger.Error("计算下次发送时间失败，任务将结束", "schedule", schedule.ID, "error", err)
+			next = nil
+		}
+
+		if _, err := h.messages.FireSchedule(ctx, schedule, next, now); err != nil {
+			if stderrors.Is(err, ErrScheduleConflict) {
+				continue
+			}
+			return fired, err
+		}
+		fired++
+	}
+	return fired, nil
+}
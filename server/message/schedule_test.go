@@ -0,0 +1,330 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/twotwo/go-blueprint/pkg/lease"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// testClock 可手动拨动的时钟
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// setupScheduleRouter 创建使用可拨动时钟的消息模块，当前时间为 2025-06-02 08:00 UTC（周一）
+func setupScheduleRouter(t *testing.T) (*chi.Mux, *Server, *testClock) {
+	clock := &testClock{now: time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)}
+	h := NewServer(NewGormRepository(setupTestDB(t)), module.Deps{Clock: clock}, Config{})
+	r := chi.NewRouter()
+	h.Routes(r)
+	return r, h, clock
+}
+
+// createSchedule 创建定时消息并返回任务
+func createSchedule(t *testing.T, r http.Handler, body string) Schedule {
+	resp := postMessage(r, body)
+	require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+	var s Schedule
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &s))
+	return s
+}
+
+func TestNewSchedule(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	msg := &MessageModel{Type: Sms, Content: "x", PhoneNumber: "+8613800138000"}
+	at := func(s string) *time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return &t
+	}
+
+	s, err := NewSchedule(msg, at("2025-06-02T18:30:00+08:00"), "", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 2, 10, 30, 0, 0, time.UTC), *s.NextRunAt)
+	assert.Equal(t, SchedulePending, s.Status)
+
+	// 周期任务按时区计算，send_at 恰好匹配时首次就在 send_at 发送
+	s, err = NewSchedule(msg, nil, "0 9 * * *", "Asia/Shanghai", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 3, 1, 0, 0, 0, time.UTC), *s.NextRunAt)
+	s, err = NewSchedule(msg, at("2025-06-05T09:00:00+08:00"), "0 9 * * *", "Asia/Shanghai", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 5, 1, 0, 0, 0, time.UTC), *s.NextRunAt)
+	s, err = NewSchedule(msg, nil, "*/30 * * * *", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", s.Timezone)
+	assert.Equal(t, time.Date(2025, 6, 2, 8, 30, 0, 0, time.UTC), *s.NextRunAt)
+
+	for name, c := range map[string]struct {
+		sendAt               *time.Time
+		recurrence, timezone string
+	}{
+		"past send_at":        {sendAt: at("2025-06-02T07:59:00Z")},
+		"timezone only":       {sendAt: at("2025-06-03T00:00:00Z"), timezone: "UTC"},
+		"invalid cron":        {recurrence: "every day"},
+		"unknown timezone":    {recurrence: "@daily", timezone: "Mars/Olympus"},
+		"never fires":         {recurrence: "0 0 30 2 *"},
+		"nothing to schedule": {},
+	} {
+		_, err := NewSchedule(msg, c.sendAt, c.recurrence, c.timezone, now)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, name)
+	}
+}
+
+func TestCreateScheduledMessage(t *testing.T) {
+	t.Parallel()
+
+	r, h, clock := setupScheduleRouter(t)
+	ctx := context.Background()
+
+	s := createSchedule(t, r, `{"type":"sms","content":"活动明天开始","phone_number":"+8613800138000","send_at":"2025-06-02T09:00:00Z","category":"promotion"}`)
+	assert.Equal(t, SchedulePending, s.Status)
+	assert.Equal(t, "promotion", *s.Category)
+	assert.Equal(t, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC), s.NextRunAt.UTC())
+	assert.Nil(t, s.Recurrence)
+
+	// 未到期时不生成消息
+	fired, err := h.RunSchedulerOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, fired)
+
+	clock.Advance(time.Hour)
+	fired, err = h.RunSchedulerOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	fired, err = h.RunSchedulerOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, fired)
+
+	msgs, err := h.messages.FindByPhoneNumber(ctx, "+8613800138000")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "活动明天开始", msgs[0].Content)
+	assert.Equal(t, s.Id, msgs[0].ScheduleID)
+	assert.Equal(t, "promotion", msgs[0].Category)
+
+	// 消息与任务推进在同一事务中写入发件箱，由中继投递
+	pending, err := h.messages.PendingOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, msgs[0].ID, pending[0].MessageID)
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/message/schedules/%d", s.Id), nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	var completed Schedule
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &completed))
+	assert.Equal(t, ScheduleCompleted, completed.Status)
+	assert.Equal(t, int64(1), completed.Runs)
+	assert.Equal(t, msgs[0].ID, *completed.LastMessageId)
+	assert.Nil(t, completed.NextRunAt)
+
+	for _, body := range []string{
+		`{"type":"sms","content":"x","phone_number":"+8613800138000","send_at":"明天"}`,
+		`{"type":"sms","content":"x","phone_number":"+8613800138000","send_at":"2025-06-01T09:00:00Z"}`,
+		`{"type":"sms","content":"x","phone_number":"+8613800138000","recurrence":"* * *"}`,
+		`{"type":"sms","content":"x","phone_number":"+8613800138000","recurrence":1}`,
+		`{"type":"sms","content":"x","send_at":"2025-06-03T09:00:00Z"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, postMessage(r, body).Code, body)
+	}
+}
+
+func TestRecurringSchedule(t *testing.T) {
+	t.Parallel()
+
+	r, h, clock := setupScheduleRouter(t)
+	ctx := context.Background()
+
+	s := createSchedule(t, r, `{"type":"sitemessage","content":"早安","user_id":42,"recurrence":"0 9 * * MON-FRI","timezone":"Asia/Shanghai"}`)
+	require.NotNil(t, s.Recurrence)
+	assert.Equal(t, time.Date(2025, 6, 3, 1, 0, 0, 0, time.UTC), s.NextRunAt.UTC())
+
+	// 订阅推送，定时生成的站内信同样实时推送
+	sub := h.hub.Subscribe(siteMessageTopic(42))
+	defer sub.Close()
+
+	clock.Advance(17 * time.Hour) // 周二 09:00 上海
+	fired, err := h.RunSchedulerOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	relayed, err := h.RelayOutboxOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	pushed := <-sub.C()
+	assert.Equal(t, "早安", pushed.Content)
+
+	found, err := h.messages.FindSchedule(ctx, s.Id)
+	require.NoError(t, err)
+	assert.Equal(t, SchedulePending, found.Status)
+	assert.Equal(t, time.Date(2025, 6, 4, 1, 0, 0, 0, time.UTC), found.NextRunAt.UTC())
+
+	// 停机错过多个时间点时只补发一次，下次时间从当前算起：周五 10:00 上海之后是下周一
+	clock.Advance(3*24*time.Hour + time.Hour)
+	fired, err = h.RunSchedulerOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	found, err = h.messages.FindSchedule(ctx, s.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), found.Runs)
+	assert.Equal(t, time.Date(2025, 6, 9, 1, 0, 0, 0, time.UTC), found.NextRunAt.UTC())
+
+	msgs, err := h.messages.FindByUserID(ctx, 42)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 2)
+}
+
+func TestListAndCancelSchedules(t *testing.T) {
+	t.Parallel()
+
+	r, h, clock := setupScheduleRouter(t)
+	ctx := context.Background()
+
+	later := createSchedule(t, r, `{"type":"broadcast","content":"大促","channel":"promotion","send_at":"2025-06-10T00:00:00Z"}`)
+	sooner := createSchedule(t, r, `{"type":"sms","content":"提醒","phone_number":"+8613800138000","send_at":"2025-06-03T00:00:00Z"}`)
+
+	list := func(query string) ScheduleList {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/message/schedules"+query, nil))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var l ScheduleList
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &l))
+		return l
+	}
+	pending := list("")
+	require.Len(t, pending.Schedules, 2)
+	assert.Equal(t, sooner.Id, pending.Schedules[0].Id)
+	assert.Equal(t, later.Id, pending.Schedules[1].Id)
+	assert.Equal(t, "promotion", *pending.Schedules[1].Channel)
+
+	cancel := func(id int64, auth bool) int {
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/message/schedules/%d", id), nil)
+		if auth {
+			authed(req)
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, cancel(later.Id, false))
+	assert.Equal(t, http.StatusOK, cancel(later.Id, true))
+	assert.Equal(t, http.StatusConflict, cancel(later.Id, true))
+	assert.Equal(t, http.StatusNotFound, cancel(999, true))
+
+	require.Len(t, list("").Schedules, 1)
+	cancelled := list("?status=cancelled")
+	require.Len(t, cancelled.Schedules, 1)
+	assert.Equal(t, later.Id, cancelled.Schedules[0].Id)
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/message/schedules?status=done", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// 已取消的任务到期后不会发送
+	clock.Advance(30 * 24 * time.Hour)
+	fired, err := h.RunSchedulerOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+}
+
+// setupFileDB 使用临时文件数据库，多个调度器并发写入时由 busy_timeout 排队
+func setupFileDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "message.db") + "?_busy_timeout=5000&_journal_mode=WAL"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(append(Models(), lease.Models()...)...))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestRunSchedulerAcrossReplicas(t *testing.T) {
+	t.Parallel()
+
+	db := setupFileDB(t)
+	now := time.Now().UTC()
+	deps := module.Deps{DB: db}
+	cfg := Config{SchedulerInterval: 10 * time.Millisecond}
+
+	// 多个副本共享数据库，每个到期任务只生成一条消息
+	replicas := make([]*Server, 3)
+	for i := range replicas {
+		replicas[i] = New(deps, cfg)
+	}
+	const count = 5
+	for i := 0; i < count; i++ {
+		at := now.Add(-time.Minute)
+		require.NoError(t, replicas[0].messages.CreateSchedule(context.Background(), &ScheduleModel{
+			Type: Sitemessage, Content: fmt.Sprintf("第%d条", i), UserID: 1, Status: SchedulePending, NextRunAt: &at,
+		}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, h := range replicas {
+		wg.Add(1)
+		go func(h *Server) {
+			defer wg.Done()
+			h.RunScheduler(ctx)
+		}(h)
+	}
+
+	repo := NewGormRepository(db)
+	assert.Eventually(t, func() bool {
+		pending, err := repo.ListSchedules(context.Background(), SchedulePending)
+		return err == nil && len(pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 只有一个副本持有租约
+	l, err := lease.New(deps).Find(context.Background(), SchedulerLease)
+	require.NoError(t, err)
+	require.NotNil(t, l)
+
+	cancel()
+	wg.Wait()
+
+	msgs, err := repo.FindByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, msgs, count)
+
+	// 退出时释放租约
+	l, err = lease.New(deps).Find(context.Background(), SchedulerLease)
+	require.NoError(t, err)
+	assert.Nil(t, l)
+}
@@ -0,0 +1,320 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// defaultSearchPageSize 搜索结果每页的默认条数
+	defaultSearchPageSize = 50
+
+	// maxSearchPageSize 搜索结果每页的最大条数
+	maxSearchPageSize = 200
+
+	// searchTable SQLite 中消息内容的 trigram 索引表，由触发器与消息表保持同步
+	searchTable = "message_fts"
+)
+
+// SearchFilter 消息搜索的查询条件，零值字段不作为条件
+type SearchFilter struct {
+	Terms    []string      // 内容需包含的关键词，均为小写字母和数字，按子串匹配；有关键词时不匹配安全类消息
+	Type     MessageType   // 消息类型
+	Status   MessageStatus // 消息状态
+	Provider string        // 投递通道名称
+	From     time.Time     // 创建时间不早于 From
+	To       time.Time     // 创建时间早于 To
+	BeforeID int64         // 只列出 ID 更小的消息
+	Limit    int           // 至多返回的条数
+}
+
+// SearchRepository 消息搜索的数据访问接口
+type SearchRepository interface {
+	// SearchMessages 按 ID 降序列出满足条件的消息，不含已删除的站内信
+	// Gorm 实现依赖 MigrateSearch 创建的索引，没有索引时退化为 LIKE 匹配
+	SearchMessages(ctx context.Context, filter SearchFilter) ([]MessageModel, error)
+}
+
+// searchTerms 把检索词切分为关键词：按字母和数字以外的字符切分并转为小写
+// 关键词只含字母和数字，可以直接拼进各数据库的全文检索语法而无需转义
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// MigrateSearch 为消息内容创建 n-gram 索引，支持中文等不以空格分词的文本按子串检索，
+// 需在消息表迁移之后调用，可以重复调用
+//
+//	SQLite    使用 sqlite_fts5 构建标签编译驱动时（Makefile 默认带上该标签），创建 trigram 分词的 FTS5 索引表，由触发器与消息表同步，
+//	          首次创建时导入已有消息；未编译 FTS5 时不创建索引，搜索时退化为 LIKE 匹配
+//	Postgres  启用 pg_trgm 扩展，为内容创建 gin_trgm_ops 的 GIN 索引；不使用 tsvector，
+//	          因为内置的文本搜索配置按空格和标点分词，中文整句成为一个词，无法按子串检索
+//	MySQL     增加使用 ngram 分词器的 FULLTEXT 索引
+//
+// 其他数据库不创建索引，搜索时退化为 LIKE 匹配
+func MigrateSearch(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return migrateSQLiteSearch(db)
+	case "postgres":
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range []string{
+				`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+				// 早期版本使用 simple 分词的 tsvector 列，无法检索中文子串
+				`ALTER TABLE messages DROP COLUMN IF EXISTS content_tsv`,
+				`CREATE INDEX IF NOT EXISTS idx_messages_content_trgm ON messages USING GIN (content gin_trgm_ops)`,
+			} {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "mysql":
+		if db.Migrator().HasIndex(&MessageModel{}, "idx_messages_content_ft") {
+			return nil
+		}
+		return db.Exec(`CREATE FULLTEXT INDEX idx_messages_content_ft ON messages (content) WITH PARSER ngram`).Error
+	default:
+		return nil
+	}
+}
+
+// sqliteSearchTriggers 保持索引表与消息表同步的触发器
+var sqliteSearchTriggers = map[string]string{
+	"messages_fts_insert": `AFTER INSERT ON messages BEGIN
+		INSERT INTO message_fts(rowid, content) VALUES (new.id, new.content);
+	END`,
+	"messages_fts_update": `AFTER UPDATE OF content ON messages BEGIN
+		DELETE FROM message_fts WHERE rowid = old.id;
+		INSERT INTO message_fts(rowid, content) VALUES (new.id, new.content);
+	END`,
+	"messages_fts_delete": `AFTER DELETE ON messages BEGIN
+		DELETE FROM message_fts WHERE rowid = old.id;
+	END`,
+}
+
+// migrateSQLiteSearch 创建 SQLite 的 trigram 索引表和同步触发器
+// 早期版本创建的 unicode61 分词索引无法检索中文子串，迁移时删除后重建
+func migrateSQLiteSearch(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// mattn/go-sqlite3 只在使用 sqlite_fts5 构建标签时编译 FTS5
+		var fts5 bool
+		if err := tx.Raw(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5).Error; err != nil {
+			return err
+		}
+
+		var ddl string
+		if err := tx.Raw(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, searchTable).Scan(&ddl).Error; err != nil {
+			return err
+		}
+		if ddl != "" && (!fts5 || !strings.Contains(ddl, "trigram")) {
+			for name := range sqliteSearchTriggers {
+				if err := tx.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec("DROP TABLE " + searchTable).Error; err != nil {
+				return err
+			}
+			ddl = ""
+		}
+		if !fts5 {
+			return nil
+		}
+
+		if ddl == "" {
+			create := fmt.Sprintf(`CREATE VIRTUAL TABLE %s USING fts5(content, tokenize='trigram')`, searchTable)
+			if err := tx.Exec(create).Error; err != nil {
+				return err
+			}
+			backfill := fmt.Sprintf(`INSERT INTO %s(rowid, content) SELECT id, content FROM messages`, searchTable)
+			if err := tx.Exec(backfill).Error; err != nil {
+				return err
+			}
+		}
+		for name, body := range sqliteSearchTriggers {
+			if err := tx.Exec(fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s %s", name, body)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// matchContent 为查询加上内容包含所有关键词的条件，关键词按子串匹配且不区分大小写
+// 关键词只含字母和数字，不含 LIKE 的通配符，无需转义；安全类消息（如验证码）不参与内容匹配
+func matchContent(query *gorm.DB, terms []string) *gorm.DB {
+	if len(terms) == 0 {
+		return query
+	}
+	query = query.Where("COALESCE(category, '') <> ?", CategorySecurity)
+	switch query.Dialector.Name() {
+	case "sqlite":
+		if !query.Migrator().HasTable(searchTable) {
+			break
+		}
+		// trigram 索引只能匹配至少三个字符的子串，更短的关键词直接在消息表上 LIKE 匹配
+		var short []string
+		var sub *gorm.DB
+		for _, term := range terms {
+			if utf8.RuneCountInString(term) < 3 {
+				short = append(short, term)
+				continue
+			}
+			if sub == nil {
+				sub = query.Session(&gorm.Session{NewDB: true}).Table(searchTable).Select("rowid")
+			}
+			sub = sub.Where("content LIKE ?", "%"+term+"%")
+		}
+		if sub != nil {
+			query = query.Where("id IN (?)", sub)
+		}
+		terms = short
+	case "postgres":
+		// ILIKE 使用 pg_trgm 的 GIN 索引
+		for _, term := range terms {
+			query = query.Where("content ILIKE ?", "%"+term+"%")
+		}
+		return query
+	case "mysql":
+		return query.Where("MATCH(content) AGAINST (? IN BOOLEAN MODE)", "+"+strings.Join(terms, " +"))
+	}
+	for _, term := range terms {
+		query = query.Where("LOWER(content) LIKE ?", "%"+term+"%")
+	}
+	return query
+}
+
+// matchTerms 内存实现的关键词匹配：内容包含每个关键词，不区分大小写；安全类消息不参与内容匹配
+func matchTerms(m *MessageModel, terms []string) bool {
+	if len(terms) > 0 && m.Category == CategorySecurity {
+		return false
+	}
+	content := strings.ToLower(m.Content)
+	for _, term := range terms {
+		if !strings.Contains(content, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// recipient 返回消息的接收方，没有接收方时返回 nil
+func (m *MessageModel) recipient() *string {
+	var v string
+	switch m.Type {
+	case Sms:
+		v = m.PhoneNumber
+	case Sitemessage:
+		v = strconv.FormatInt(m.UserID, 10)
+	case Broadcast:
+		v = m.Channel
+	case Wechat:
+		v = m.OpenID
+	case Email:
+		v = m.Email
+	}
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// ToSummary 将数据库模型转换为搜索结果中的消息摘要
+func (m *MessageModel) ToSummary() MessageSummary {
+	summary := MessageSummary{
+		Id:        m.ID,
+		Type:      m.Type,
+		Status:    m.Status,
+		Content:   m.apiContent(),
+		Recipient: m.recipient(),
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: &m.UpdatedAt,
+	}
+	if m.Provider != "" {
+		summary.Provider = &m.Provider
+	}
+	return summary
+}
+
+// timeParam 返回时间参数的 UTC 时间，未提供时返回零值
+func timeParam(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// parseMessageFilters 校验 type、status 和 provider 过滤参数
+func parseMessageFilters(msgType, status, provider *string) (MessageType, MessageStatus, string, error) {
+	t := MessageType(stringValue(msgType))
+	switch t {
+	case "", Sms, Sitemessage, Broadcast, Wechat, Email:
+	default:
+		return "", "", "", errors.BadRequest("无效的 type 参数")
+	}
+	s := MessageStatus(stringValue(status))
+	if s != "" && !s.Valid() {
+		return "", "", "", errors.BadRequest("无效的 status 参数")
+	}
+	return t, s, stringValue(provider), nil
+}
+
+// SearchMessages 按内容全文检索消息，并按类型、状态、通道和创建时间过滤
+func (h *Server) SearchMessages(ctx context.Context, request SearchMessagesRequestObject) (SearchMessagesResponseObject, error) {
+	params := request.Params
+	filter := SearchFilter{Limit: defaultSearchPageSize}
+
+	if q := stringValue(params.Q); q != "" {
+		filter.Terms = searchTerms(q)
+		if len(filter.Terms) == 0 {
+			return nil, errors.BadRequest("q 中没有可检索的关键词")
+		}
+	}
+	var err error
+	if filter.Type, filter.Status, filter.Provider, err = parseMessageFilters(params.Type, params.Status, params.Provider); err != nil {
+		return nil, err
+	}
+	filter.From, filter.To = timeParam(params.From), timeParam(params.To)
+	if params.Before != nil {
+		if filter.BeforeID = *params.Before; filter.BeforeID <= 0 {
+			return nil, errors.BadRequest("无效的 before 参数")
+		}
+	}
+	if params.Limit != nil {
+		if filter.Limit = *params.Limit; filter.Limit <= 0 || filter.Limit > maxSearchPageSize {
+			return nil, errors.BadRequest("无效的 limit 参数")
+		}
+	}
+
+	// 多取一条判断是否还有下一页
+	limit := filter.Limit
+	filter.Limit++
+	models, err := h.messages.SearchMessages(ctx, filter)
+	if err != nil {
+		h.logger.Error("搜索消息失败", "error", err)
+		return nil, errors.InternalServer("搜索消息失败")
+	}
+
+	result := SearchMessages200JSONResponse{Messages: make([]MessageSummary, 0, len(models))}
+	if len(models) > limit {
+		models = models[:limit]
+		next := models[len(models)-1].ID
+		result.NextBefore = &next
+	}
+	for i := range models {
+		result.Messages = append(result.Messages, models[i].ToSummary())
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+func TestSearchTerms(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"验证码", "929253"}, searchTerms("验证码：929253"))
+	assert.Equal(t, []string{"order", "and", "1001"}, searchTerms(` "Order" AND  1001* `))
+	assert.Empty(t, searchTerms(`"*:()`))
+}
+
+func TestSearchRepository(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			create := func(msg *MessageModel, at time.Time) *MessageModel {
+				msg.Status, msg.CreatedAt = Sending, at
+				require.NoError(t, repo.Create(ctx, msg))
+				return msg
+			}
+			otp := create(&MessageModel{Type: Sms, PhoneNumber: "8613800138000", Content: "验证码：929253，10分钟内有效"}, base)
+			order := create(&MessageModel{Type: Sms, PhoneNumber: "8613800138001", Content: "Your order 1001 has shipped"}, base.Add(time.Hour))
+			site := create(&MessageModel{Type: Sitemessage, UserID: 7, Content: "Order 1002 shipped"}, base.Add(2*time.Hour))
+			require.NoError(t, repo.SaveReceipt(ctx, order.ID, Receipt{Provider: "acme", MessageID: "gw-1"}))
+
+			search := func(filter SearchFilter) []int64 {
+				filter.Limit = 10
+				messages, err := repo.SearchMessages(ctx, filter)
+				require.NoError(t, err)
+				ids := make([]int64, 0, len(messages))
+				for _, m := range messages {
+					ids = append(ids, m.ID)
+				}
+				return ids
+			}
+
+			// 按 ID 降序，关键词按子串匹配且不区分大小写
+			assert.Equal(t, []int64{site.ID, order.ID, otp.ID}, search(SearchFilter{}))
+			assert.Equal(t, []int64{site.ID, order.ID}, search(SearchFilter{Terms: []string{"ship"}}))
+			assert.Equal(t, []int64{order.ID}, search(SearchFilter{Terms: []string{"order", "1001"}}))
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Terms: []string{"验证"}}))
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Terms: []string{"929253"}}))
+			assert.Equal(t, []int64{site.ID, order.ID}, search(SearchFilter{Terms: []string{"hip"}}))
+
+			// 中文不分词，任意子串都能检索到
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Terms: []string{"有效"}}))
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Terms: []string{"分钟"}}))
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Terms: searchTerms("分钟内 有效")}))
+			assert.Empty(t, search(SearchFilter{Terms: []string{"无效"}}))
+			assert.Empty(t, search(SearchFilter{Terms: []string{"refund"}}))
+
+			assert.Equal(t, []int64{order.ID, otp.ID}, search(SearchFilter{Type: Sms}))
+			assert.Equal(t, []int64{order.ID}, search(SearchFilter{Provider: "acme"}))
+			assert.Equal(t, []int64{order.ID}, search(SearchFilter{From: base.Add(time.Hour), To: base.Add(2 * time.Hour)}))
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{BeforeID: order.ID}))
+
+			_, err := repo.Transition(ctx, otp.ID, Failed, "gateway error")
+			require.NoError(t, err)
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Status: Failed}))
+
+			// 已删除的站内信不再出现
+			require.NoError(t, repo.DeleteSiteMessage(ctx, site.UserID, site.ID))
+			assert.Equal(t, []int64{order.ID}, search(SearchFilter{Terms: []string{"shipped"}}))
+
+			// 安全类消息不参与内容匹配，检索词不能用来试探验证码
+			security := create(&MessageModel{Type: Sms, PhoneNumber: "8613800138002", Content: "验证码：482913", Category: CategorySecurity}, base.Add(3*time.Hour))
+			assert.Empty(t, search(SearchFilter{Terms: []string{"482913"}}))
+			assert.Equal(t, []int64{otp.ID}, search(SearchFilter{Terms: []string{"验证码"}}))
+			assert.Equal(t, []int64{security.ID}, search(SearchFilter{From: base.Add(3 * time.Hour)}))
+		})
+	}
+}
+
+func TestSearchMessagesHandler(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	for _, body := range []string{
+		`{"type":"sms","content":"验证码：111111","phone_number":"13800138000"}`,
+		`{"type":"sms","content":"验证码：222222","phone_number":"13800138001"}`,
+		`{"type":"sitemessage","content":"您的验证码已发送","user_id":9}`,
+	} {
+		resp := postMessage(r, body)
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, authed(httptest.NewRequest(http.MethodGet, "/message/search?"+query, nil)))
+		return resp
+	}
+
+	resp := get("q=验证码&type=sms&limit=1")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var page MessageSearchResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &page))
+	require.Len(t, page.Messages, 1)
+	assert.Equal(t, "验证码：222222", page.Messages[0].Content)
+	require.NotNil(t, page.Messages[0].Recipient)
+	assert.Equal(t, "13800138001", *page.Messages[0].Recipient)
+	require.NotNil(t, page.NextBefore)
+
+	resp = get("q=验证码&type=sms&limit=1&before=" + strconv.FormatInt(*page.NextBefore, 10))
+	require.Equal(t, http.StatusOK, resp.Code)
+	page = MessageSearchResult{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &page))
+	require.Len(t, page.Messages, 1)
+	assert.Equal(t, "验证码：111111", page.Messages[0].Content)
+	assert.Nil(t, page.NextBefore)
+
+	for _, query := range []string{"q=***", "type=fax", "status=lost", "from=yesterday", "limit=0", "before=x"} {
+		assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+	}
+
+	unauthenticated := httptest.NewRecorder()
+	r.ServeHTTP(unauthenticated, httptest.NewRequest(http.MethodGet, "/message/search", nil))
+	assert.Equal(t, http.StatusUnauthorized, unauthenticated.Code)
+}
+
+func TestMigrateSearchBackfills(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.Exec("DROP TABLE IF EXISTS "+searchTable).Error)
+	require.NoError(t, db.Exec("DROP TRIGGER IF EXISTS messages_fts_insert").Error)
+	// 早期版本创建的 unicode61 分词索引在迁移时重建
+	require.NoError(t, db.Exec("CREATE VIRTUAL TABLE "+searchTable+" USING fts4(content, tokenize=unicode61)").Error)
+	repo := NewGormRepository(db)
+	ctx := context.Background()
+	msg := &MessageModel{Type: Sitemessage, UserID: 1, Content: "迁移前的消息", Status: Sending}
+	require.NoError(t, repo.Create(ctx, msg))
+
+	// 重新创建索引时导入已有消息，重复调用不报错
+	require.NoError(t, MigrateSearch(db))
+	require.NoError(t, MigrateSearch(db))
+	found, err := repo.SearchMessages(ctx, SearchFilter{Terms: searchTerms("迁移前的消息"), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, msg.ID, found[0].ID)
+	found, err = repo.SearchMessages(ctx, SearchFilter{Terms: []string{"前的"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	r := chi.NewRouter()
+	New(module.Deps{DB: db}, Config{}).Routes(r)
+	resp := postMessage(r, `{"type":"sitemessage","content":"迁移后的消息","user_id":1}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	found, err = repo.SearchMessages(ctx, SearchFilter{Terms: searchTerms("迁移后的消息"), Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
@@ -0,0 +1,280 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// StatsHour 按小时划分时间桶
+	StatsHour = "hour"
+
+	// StatsDay 按天划分时间桶
+	StatsDay = "day"
+
+	// maxStatsBuckets 一次统计的最大时间桶数，限制统计的时间范围
+	maxStatsBuckets = 1000
+)
+
+// StatsFilter 消息统计的查询条件，统计创建时间在 [From, To) 内的消息
+type StatsFilter struct {
+	From     time.Time
+	To       time.Time
+	Type     MessageType    // 为空时不限
+	Provider string         // 为空时不限
+	Interval string         // 时间桶的长度，StatsHour 或 StatsDay
+	Location *time.Location // 划分时间桶的时区，nil 时为 UTC
+}
+
+// location 返回划分时间桶的时区
+func (f StatsFilter) location() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
+}
+
+// step 返回时间桶在当地时间中的长度
+func (f StatsFilter) step() time.Duration {
+	if f.Interval == StatsDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// MessageStat 统计用的消息摘要
+type MessageStat struct {
+	ID         int64
+	Type       MessageType
+	Provider   string
+	Status     MessageStatus
+	CreatedAt  time.Time
+	SentAt     *time.Time // 首次进入 sent 的时间，即通道接收消息的时间
+	ReceivedAt *time.Time // 首次进入 received 的时间，即送达接收方的时间
+}
+
+// StatsRepository 消息统计的数据访问接口
+type StatsRepository interface {
+	// MessageStats 按时间桶、类型和通道汇总满足条件的消息，包括用户已删除的站内信
+	// 结果按起始时间、类型、通道排序
+	MessageStats(ctx context.Context, filter StatsFilter) ([]MessageStatsBucket, error)
+}
+
+// statsKey 统计分组：时间桶、消息类型和投递通道
+type statsKey struct {
+	start    time.Time
+	msgType  MessageType
+	provider string
+}
+
+// statsGroup 一个分组的累计值
+type statsGroup struct {
+	bucket   MessageStatsBucket
+	send     []time.Duration
+	delivery []time.Duration
+}
+
+// bucketStart 返回 t 在 loc 时区所属时间桶的起始时间
+func bucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if interval == StatsDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+}
+
+// aggregateStats 按时间桶、类型和通道汇总消息摘要，结果按起始时间、类型、通道排序
+func aggregateStats(stats []MessageStat, interval string, loc *time.Location) []MessageStatsBucket {
+	groups := make(map[statsKey]*statsGroup)
+	for i := range stats {
+		s := &stats[i]
+		key := statsKey{start: bucketStart(s.CreatedAt, interval, loc), msgType: s.Type, provider: s.Provider}
+		g, ok := groups[key]
+		if !ok {
+			g = &statsGroup{bucket: MessageStatsBucket{
+				Start:    key.start,
+				Type:     s.Type,
+				Provider: s.Provider,
+				Statuses: make(map[string]int64),
+			}}
+			groups[key] = g
+		}
+		g.bucket.Total++
+		g.bucket.Statuses[string(s.Status)]++
+		if s.SentAt != nil {
+			g.send = append(g.send, s.SentAt.Sub(s.CreatedAt))
+		}
+		if s.ReceivedAt != nil {
+			g.delivery = append(g.delivery, s.ReceivedAt.Sub(s.CreatedAt))
+		}
+	}
+
+	buckets := make([]MessageStatsBucket, 0, len(groups))
+	for _, g := range groups {
+		g.bucket.SendLatency = percentiles(g.send)
+		g.bucket.DeliveryLatency = percentiles(g.delivery)
+		buckets = append(buckets, g.bucket)
+	}
+	sortStatsBuckets(buckets)
+	return buckets
+}
+
+// sortStatsBuckets 按起始时间、类型、通道排序
+func sortStatsBuckets(buckets []MessageStatsBucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		a, b := buckets[i], buckets[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Provider < b.Provider
+	})
+}
+
+// latencyPercentiles 统计耗时时使用的分位数
+var latencyPercentiles = []int{50, 90, 99}
+
+// percentileRank 最近秩法中第 p 分位数对应的样本序号，即第 ceil(p% * n) 个，从 1 开始
+func percentileRank(p int, n int64) int64 {
+	return max((int64(p)*n+99)/100, 1)
+}
+
+// setPercentile 记录第 p 分位数的耗时
+func (l *LatencyPercentiles) setPercentile(p int, ms int64) {
+	switch p {
+	case 50:
+		l.P50Ms = ms
+	case 90:
+		l.P90Ms = ms
+	case 99:
+		l.P99Ms = ms
+	}
+}
+
+// percentiles 按最近秩法计算耗时的分位数，没有样本时返回 nil
+func percentiles(samples []time.Duration) *LatencyPercentiles {
+	if len(samples) == 0 {
+		return nil
+	}
+	slices.Sort(samples)
+	n := int64(len(samples))
+	result := &LatencyPercentiles{Count: n, MaxMs: samples[n-1].Milliseconds()}
+	for _, p := range latencyPercentiles {
+		result.setPercentile(p, samples[percentileRank(p, n)-1].Milliseconds())
+	}
+	return result
+}
+
+// statsDialect 统计查询中与数据库相关的 SQL 片段
+type statsDialect struct {
+	millis string // 把时间列转为 Unix 毫秒数的表达式，%s 为列名
+	div    string // 整数除法运算符
+}
+
+// statsDialects 支持在数据库中汇总统计的数据库，需要支持窗口函数和 WITH 子句
+var statsDialects = map[string]statsDialect{
+	// julianday 内部以整数毫秒保存，解析时截断毫秒以下的部分，耗时可能比实际少 1 毫秒
+	"sqlite":   {millis: "CAST(ROUND((julianday(%s) - 2440587.5) * 86400000) AS INTEGER)", div: "/"},
+	"postgres": {millis: "CAST(FLOOR(EXTRACT(EPOCH FROM %s) * 1000) AS BIGINT)", div: "/"},
+	"mysql":    {millis: "CAST(FLOOR(UNIX_TIMESTAMP(%s) * 1000) AS SIGNED)", div: "DIV"},
+}
+
+// bucketExpr 返回 col 所属时间桶编号的 SQL 表达式及其参数：换算为当地时间的毫秒数后整除桶长
+// [filter.From, filter.To) 内 UTC 偏移有变化（如夏令时）时按段换算
+func (d statsDialect) bucketExpr(col string, filter StatsFilter) (string, []interface{}) {
+	loc := filter.location()
+	var offset string
+	var cases strings.Builder
+	var args []interface{}
+	for t := filter.From.In(loc); ; {
+		_, seconds := t.Zone()
+		_, end := t.ZoneBounds()
+		if end.IsZero() || !end.Before(filter.To) {
+			offset = strconv.FormatInt(int64(seconds)*1000, 10)
+			if cases.Len() > 0 {
+				offset = fmt.Sprintf("CASE%s ELSE %s END", cases.String(), offset)
+			}
+			break
+		}
+		fmt.Fprintf(&cases, " WHEN %s < ? THEN %d", col, int64(seconds)*1000)
+		args = append(args, end.UTC())
+		t = end.In(loc)
+	}
+	return fmt.Sprintf("(%s + (%s)) %s %d", fmt.Sprintf(d.millis, col), offset, d.div, filter.step().Milliseconds()), args
+}
+
+// bucketTime 返回时间桶编号对应的起始时间
+func bucketTime(index int64, filter StatsFilter) time.Time {
+	// 编号乘以桶长即为当地时间的毫秒数，按 UTC 读出的年月日时就是当地的时间
+	wall := time.UnixMilli(index * filter.step().Milliseconds()).UTC()
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), 0, 0, 0, filter.location())
+}
+
+// GetMessageStats 按时间桶、类型和通道统计消息数及投递耗时
+func (h *Server) GetMessageStats(ctx context.Context, request GetMessageStatsRequestObject) (GetMessageStatsResponseObject, error) {
+	params := request.Params
+
+	interval := stringValue(params.Interval)
+	var step time.Duration
+	switch interval {
+	case "", StatsHour:
+		interval, step = StatsHour, time.Hour
+	case StatsDay:
+		step = 24 * time.Hour
+	default:
+		return nil, errors.BadRequest("interval 必须是 hour 或 day")
+	}
+	timezone := stringValue(params.Timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("无效的时区: %s", timezone))
+	}
+
+	filter := StatsFilter{Interval: interval, Location: loc}
+	msgType, status, provider, err := parseMessageFilters(params.Type, params.Status, params.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		return nil, errors.BadRequest("统计结果已按状态分组，不支持 status 参数")
+	}
+	filter.Type, filter.Provider = msgType, provider
+	if filter.To = timeParam(params.To); filter.To.IsZero() {
+		filter.To = h.clock.Now().UTC()
+	}
+	if filter.From = timeParam(params.From); filter.From.IsZero() {
+		filter.From = filter.To.Add(-24 * time.Hour)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, errors.BadRequest("from 必须早于 to")
+	}
+	if filter.To.Sub(filter.From) > maxStatsBuckets*step {
+		return nil, errors.BadRequest(fmt.Sprintf("统计范围不能超过 %d 个时间桶", maxStatsBuckets))
+	}
+
+	buckets, err := h.messages.MessageStats(ctx, filter)
+	if err != nil {
+		h.logger.Error("统计消息失败", "error", err)
+		return nil, errors.InternalServer("统计消息失败")
+	}
+
+	return GetMessageStats200JSONResponse{
+		From:     filter.From,
+		To:       filter.To,
+		Interval: interval,
+		Timezone: timezone,
+		Buckets:  buckets,
+	}, nil
+}
@@ -0,0 +1,273 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+func TestPercentiles(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, percentiles(nil))
+
+	samples := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	p := percentiles(samples)
+	assert.Equal(t, &LatencyPercentiles{Count: 100, P50Ms: 50, P90Ms: 90, P99Ms: 99, MaxMs: 100}, p)
+
+	p = percentiles([]time.Duration{3 * time.Second})
+	assert.Equal(t, &LatencyPercentiles{Count: 1, P50Ms: 3000, P90Ms: 3000, P99Ms: 3000, MaxMs: 3000}, p)
+}
+
+func TestAggregateStats(t *testing.T) {
+	t.Parallel()
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return v
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	stats := []MessageStat{
+		// 北京时间 6 月 2 日
+		{Type: Sms, Provider: "acme", Status: Sent, CreatedAt: at("2025-06-01T16:30:00Z"), SentAt: ptr(at("2025-06-01T16:30:02Z"))},
+		{Type: Sms, Provider: "acme", Status: Received, CreatedAt: at("2025-06-02T01:00:00Z"),
+			SentAt: ptr(at("2025-06-02T01:00:01Z")), ReceivedAt: ptr(at("2025-06-02T01:00:05Z"))},
+		{Type: Sms, Provider: "acme", Status: Failed, CreatedAt: at("2025-06-02T02:00:00Z")},
+		{Type: Sms, Provider: "other", Status: Failed, CreatedAt: at("2025-06-02T03:00:00Z")},
+		// 北京时间 6 月 1 日
+		{Type: Sms, Provider: "acme", Status: Failed, CreatedAt: at("2025-06-01T15:59:59Z")},
+	}
+	buckets := aggregateStats(stats, StatsDay, shanghai)
+	require.Len(t, buckets, 3)
+
+	assert.True(t, buckets[0].Start.Equal(at("2025-05-31T16:00:00Z")))
+	assert.Equal(t, "acme", buckets[0].Provider)
+	assert.EqualValues(t, 1, buckets[0].Total)
+
+	day := buckets[1]
+	assert.True(t, day.Start.Equal(at("2025-06-01T16:00:00Z")))
+	assert.Equal(t, Sms, day.Type)
+	assert.Equal(t, "acme", day.Provider)
+	assert.EqualValues(t, 3, day.Total)
+	assert.Equal(t, map[string]int64{"sent": 1, "received": 1, "failed": 1}, day.Statuses)
+	require.NotNil(t, day.SendLatency)
+	assert.Equal(t, &LatencyPercentiles{Count: 2, P50Ms: 1000, P90Ms: 2000, P99Ms: 2000, MaxMs: 2000}, day.SendLatency)
+	require.NotNil(t, day.DeliveryLatency)
+	assert.EqualValues(t, 5000, day.DeliveryLatency.P50Ms)
+
+	assert.Equal(t, "other", buckets[2].Provider)
+	assert.Equal(t, map[string]int64{"failed": 1}, buckets[2].Statuses)
+	assert.Nil(t, buckets[2].SendLatency)
+
+	hourly := aggregateStats(stats, StatsHour, time.UTC)
+	assert.Len(t, hourly, 5)
+}
+
+func TestStatsRepository(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			create := func(msgType MessageType, at time.Time) *MessageModel {
+				msg := &MessageModel{Type: msgType, Content: "x", Status: Sending, CreatedAt: at, UserID: 1}
+				require.NoError(t, repo.Create(ctx, msg))
+				return msg
+			}
+			sent := create(Sms, base)
+			failed := create(Sms, base.Add(time.Minute))
+			site := create(Sitemessage, base.Add(2*time.Minute))
+			create(Sms, base.Add(-time.Minute))
+
+			require.NoError(t, repo.SaveReceipt(ctx, sent.ID, Receipt{Provider: "acme", MessageID: "gw-1"}))
+			_, err := repo.Transition(ctx, sent.ID, Sent, "delivered")
+			require.NoError(t, err)
+			_, err = repo.Transition(ctx, sent.ID, Received, "delivery report")
+			require.NoError(t, err)
+			require.NoError(t, repo.SaveReceipt(ctx, failed.ID, Receipt{Provider: "acme"}))
+			_, err = repo.Transition(ctx, failed.ID, Failed, "gateway error")
+			require.NoError(t, err)
+			// 用户删除的站内信仍计入统计
+			require.NoError(t, repo.DeleteSiteMessage(ctx, 1, site.ID))
+
+			buckets, err := repo.MessageStats(ctx, StatsFilter{From: base, To: base.Add(time.Hour), Interval: StatsHour})
+			require.NoError(t, err)
+			require.Len(t, buckets, 2)
+			assert.True(t, buckets[0].Start.Equal(base))
+			assert.Equal(t, Sitemessage, buckets[0].Type)
+			assert.Equal(t, map[string]int64{"sending": 1}, buckets[0].Statuses)
+			sms := buckets[1]
+			assert.Equal(t, Sms, sms.Type)
+			assert.Equal(t, "acme", sms.Provider)
+			assert.EqualValues(t, 2, sms.Total)
+			assert.Equal(t, map[string]int64{"received": 1, "failed": 1}, sms.Statuses)
+			require.NotNil(t, sms.SendLatency)
+			require.NotNil(t, sms.DeliveryLatency)
+			assert.EqualValues(t, 1, sms.SendLatency.Count)
+			assert.GreaterOrEqual(t, sms.DeliveryLatency.P50Ms, sms.SendLatency.P50Ms)
+
+			buckets, err = repo.MessageStats(ctx, StatsFilter{From: base, To: base.Add(time.Hour), Type: Sms, Provider: "acme", Interval: StatsDay})
+			require.NoError(t, err)
+			require.Len(t, buckets, 1)
+			assert.EqualValues(t, 2, buckets[0].Total)
+		})
+	}
+}
+
+// 数据库中汇总的结果与按消息摘要在内存中汇总的结果一致，包括跨越夏令时和非整点时区
+func TestGormMessageStatsMatchesAggregate(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestDB(t)
+	repo := NewGormRepository(db)
+	base := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+	var stats []MessageStat
+	for i := 0; i < 120; i++ {
+		created := base.Add(time.Duration(i) * 47 * time.Minute)
+		msg := &MessageModel{Type: Sms, Status: Sending, Content: "x", PhoneNumber: "8613800138000", CreatedAt: created}
+		if i%3 == 0 {
+			msg.Type, msg.PhoneNumber, msg.UserID = Sitemessage, "", 1
+		}
+		if i%4 != 0 {
+			msg.Provider = "acme"
+		}
+		require.NoError(t, db.Create(msg).Error)
+		stat := MessageStat{ID: msg.ID, Type: msg.Type, Provider: msg.Provider, Status: Sending, CreatedAt: created}
+
+		if i%5 != 0 {
+			sentAt := created.Add(time.Duration(i%7+1)*time.Second + time.Duration(i)*time.Millisecond)
+			require.NoError(t, db.Create(&StatusTransitionModel{MessageID: msg.ID, From: Sending, To: Sent, CreatedAt: sentAt}).Error)
+			stat.Status, stat.SentAt = Sent, &sentAt
+			if i%2 == 0 {
+				receivedAt := sentAt.Add(time.Duration(i%11) * time.Second)
+				require.NoError(t, db.Create(&StatusTransitionModel{MessageID: msg.ID, From: Sent, To: Received, CreatedAt: receivedAt}).Error)
+				stat.Status, stat.ReceivedAt = Received, &receivedAt
+			}
+		}
+		require.NoError(t, db.Model(msg).Update("status", stat.Status).Error)
+		stats = append(stats, stat)
+	}
+
+	for _, tc := range []struct {
+		timezone string
+		interval string
+	}{
+		{"America/New_York", StatsDay},
+		{"America/New_York", StatsHour},
+		{"Asia/Kolkata", StatsHour},
+		{"UTC", StatsDay},
+	} {
+		loc, err := time.LoadLocation(tc.timezone)
+		require.NoError(t, err)
+		filter := StatsFilter{From: base, To: base.Add(5 * 24 * time.Hour), Interval: tc.interval, Location: loc}
+		buckets, err := repo.MessageStats(context.Background(), filter)
+		require.NoError(t, err)
+		expected := aggregateStats(stats, tc.interval, loc)
+		require.Len(t, buckets, len(expected), "%s %s", tc.timezone, tc.interval)
+		for i, want := range expected {
+			got := buckets[i]
+			assert.Equal(t, want.Start, got.Start, "%s %s", tc.timezone, tc.interval)
+			assert.Equal(t, want.Type, got.Type)
+			assert.Equal(t, want.Provider, got.Provider)
+			assert.Equal(t, want.Total, got.Total)
+			assert.Equal(t, want.Statuses, got.Statuses)
+			assertLatency(t, want.SendLatency, got.SendLatency)
+			assertLatency(t, want.DeliveryLatency, got.DeliveryLatency)
+		}
+	}
+}
+
+// assertLatency 比较耗时分位数，SQLite 解析时间时截断毫秒以下的部分，允许相差 1 毫秒
+func assertLatency(t *testing.T, want, got *LatencyPercentiles) {
+	t.Helper()
+	if want == nil {
+		assert.Nil(t, got)
+		return
+	}
+	require.NotNil(t, got)
+	assert.Equal(t, want.Count, got.Count)
+	assert.InDelta(t, want.P50Ms, got.P50Ms, 1)
+	assert.InDelta(t, want.P90Ms, got.P90Ms, 1)
+	assert.InDelta(t, want.P99Ms, got.P99Ms, 1)
+	assert.InDelta(t, want.MaxMs, got.MaxMs, 1)
+}
+
+func TestGetMessageStatsHandler(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 2, 8, 30, 0, 0, time.UTC)
+	registry := NewRegistry()
+	registry.Register(Sms, NewHTTPProvider(HTTPProviderConfig{Name: "acme", URL: newFakeGateway(t, http.StatusBadGateway).URL}))
+	registry.Register(Sitemessage, NewLoopback(nil))
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t), Clock: &testClock{now: now}}, Config{Providers: registry}).Routes(r)
+
+	for _, body := range []string{
+		`{"type":"sms","content":"a","phone_number":"13800138000"}`,
+		`{"type":"sms","content":"b","phone_number":"13800138001"}`,
+		`{"type":"sitemessage","content":"c","user_id":1}`,
+	} {
+		resp := postMessage(r, body)
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, authed(httptest.NewRequest(http.MethodGet, "/message/stats?"+query, nil)))
+		return resp
+	}
+
+	// 消息的创建时间取自数据库，统计包含当前时间前后一天
+	from := time.Now().UTC().Add(-24 * time.Hour).Format(time.RFC3339)
+	to := time.Now().UTC().Add(24 * time.Hour).Format(time.RFC3339)
+	resp := get("type=sms&interval=day&from=" + from + "&to=" + to)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var stats MessageStats
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, StatsDay, stats.Interval)
+	assert.Equal(t, "UTC", stats.Timezone)
+	var failedByProvider int64
+	for _, b := range stats.Buckets {
+		assert.Equal(t, Sms, b.Type)
+		assert.Equal(t, "acme", b.Provider, "投递失败的短信也应记录通道")
+		failedByProvider += b.Statuses[string(Failed)]
+	}
+	assert.EqualValues(t, 2, failedByProvider)
+
+	// 默认统计到当前时间为止的 24 小时
+	resp = get("")
+	require.Equal(t, http.StatusOK, resp.Code)
+	stats = MessageStats{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.True(t, stats.To.Equal(now))
+	assert.True(t, stats.From.Equal(now.Add(-24*time.Hour)))
+	assert.Equal(t, StatsHour, stats.Interval)
+
+	for _, query := range []string{
+		"interval=week",
+		"timezone=Mars/Base",
+		"status=failed",
+		"from=2025-06-02T00:00:00Z&to=2025-06-01T00:00:00Z",
+		"from=2020-01-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+		"type=fax",
+	} {
+		assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+	}
+}
@@ -0,0 +1,124 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition 表示状态机不允许的状态流转
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError 描述一次被拒绝的状态流转
+type TransitionError struct {
+	From MessageStatus
+	To   MessageStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid status transition: %s -> %s", e.From, e.To)
+}
+
+// Is 使 errors.Is(err, ErrInvalidTransition) 成立
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// transitions 消息状态机：当前状态 -> 允许进入的状态
+//
+//	sending  -> sent, failed, suppressed
+//	sent     -> received, failed
+//	received -> read
+//	failed   -> sending（重新投递）
+//	read 和 suppressed（接收方的通知偏好不接收）为终态
+var transitions = map[MessageStatus][]MessageStatus{
+	Sending:  {Sent, Failed, Suppressed},
+	Sent:     {Received, Failed},
+	Received: {Read},
+	Failed:   {Sending},
+}
+
+// readSteps 站内信标记为已读时每个状态的下一步，见 readPath
+var readSteps = map[MessageStatus]MessageStatus{
+	Sending:  Sent,
+	Sent:     Received,
+	Received: Read,
+}
+
+// readPath 返回站内信从 from 标记为已读依次进入的状态，已读时返回空
+// 用户在收件箱中读到即说明已经送达，尚未回执的 sent、received 按状态机补齐；
+// failed 和 suppressed 的站内信不能标记为已读，返回 *TransitionError
+func readPath(from MessageStatus) ([]MessageStatus, error) {
+	var path []MessageStatus
+	for status := from; status != Read; {
+		next, ok := readSteps[status]
+		if !ok {
+			return nil, &TransitionError{From: from, To: Read}
+		}
+		if err := checkTransition(status, next); err != nil {
+			return nil, err
+		}
+		path = append(path, next)
+		status = next
+	}
+	return path, nil
+}
+
+// Valid 判断是否为已定义的消息状态
+func (s MessageStatus) Valid() bool {
+	switch s {
+	case Sending, Sent, Received, Read, Failed, Suppressed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo 判断能否从当前状态进入 next
+func (s MessageStatus) CanTransitionTo(next MessageStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransition 校验状态流转，不允许时返回 *TransitionError
+func checkTransition(from, to MessageStatus) error {
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// StatusTransitionModel 消息状态流转记录，创建消息时记录一条 From 为空的初始状态
+type StatusTransitionModel struct {
+	ID        int64         `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID int64         `gorm:"not null;index" json:"message_id"`
+	From      MessageStatus `gorm:"column:from_status;size:20" json:"from,omitempty"`
+	To        MessageStatus `gorm:"column:to_status;size:20;not null" json:"to"`
+	Reason    string        `gorm:"size:255" json:"reason,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// TableName 指定状态流转记录表名
+func (StatusTransitionModel) TableName() string {
+	return "message_status_transitions"
+}
+
+// ToAPI 将状态流转记录转换为API模型
+func (t *StatusTransitionModel) ToAPI() StatusTransition {
+	item := StatusTransition{
+		To:        t.To,
+		CreatedAt: t.CreatedAt,
+	}
+	if t.From != "" {
+		from := t.From
+		item.From = &from
+	}
+	if t.Reason != "" {
+		reason := t.Reason
+		item.Reason = &reason
+	}
+	return item
+}
@@ -0,0 +1,128 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from, to MessageStatus
+		want     bool
+	}{
+		{Sending, Sent, true},
+		{Sending, Failed, true},
+		{Sending, Received, false},
+		{Sent, Received, true},
+		{Sent, Failed, true},
+		{Sent, Read, false},
+		{Received, Read, true},
+		{Received, Failed, false},
+		{Failed, Sending, true},
+		{Failed, Sent, false},
+		{Read, Sending, false},
+		{Read, Failed, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.from.CanTransitionTo(tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestReadPath(t *testing.T) {
+	t.Parallel()
+
+	path, err := readPath(Sending)
+	require.NoError(t, err)
+	assert.Equal(t, []MessageStatus{Sent, Received, Read}, path)
+	path, err = readPath(Received)
+	require.NoError(t, err)
+	assert.Equal(t, []MessageStatus{Read}, path)
+	path, err = readPath(Read)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	for _, from := range []MessageStatus{Failed, Suppressed} {
+		_, err = readPath(from)
+		var transitionErr *TransitionError
+		require.ErrorAs(t, err, &transitionErr, from)
+		assert.Equal(t, Read, transitionErr.To)
+	}
+}
+
+func postStatus(r http.Handler, id int64, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/message/%d/status", id), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", testToken)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestUpdateMessageStatusHandler(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+
+	resp := postMessage(r, `{"type":"sms","content":"回执","phone_number":"8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+
+	resp = postStatus(r, sms.Id, `{"status":"sent"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	resp = postStatus(r, sms.Id, `{"status":"received","reason":"DELIVRD"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.Equal(t, Received, sms.Status)
+
+	// 不允许回退
+	resp = postStatus(r, sms.Id, `{"status":"sending"}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), `received -\u003e sending`)
+
+	// 重复回调幂等
+	resp = postStatus(r, sms.Id, `{"status":"received"}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = postStatus(r, sms.Id, `{"status":"delivered"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = postStatus(r, 9999, `{"status":"sent"}`)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/message/%d/status", sms.Id), strings.NewReader(`{"status":"read"}`))
+	unauth := httptest.NewRecorder()
+	r.ServeHTTP(unauth, req)
+	assert.Equal(t, http.StatusUnauthorized, unauth.Code)
+
+	// 流转记录：创建、sent、received
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/message/%d/transitions", sms.Id), nil)
+	list := httptest.NewRecorder()
+	r.ServeHTTP(list, req)
+	require.Equal(t, http.StatusOK, list.Code)
+
+	var transitions StatusTransitionList
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &transitions))
+	require.Len(t, *transitions.Transitions, 3)
+	first, last := (*transitions.Transitions)[0], (*transitions.Transitions)[2]
+	assert.Nil(t, first.From)
+	assert.Equal(t, Sending, first.To)
+	assert.Equal(t, Sent, *last.From)
+	assert.Equal(t, Received, last.To)
+	assert.Equal(t, "DELIVRD", *last.Reason)
+	assert.False(t, last.CreatedAt.IsZero())
+
+	req = httptest.NewRequest(http.MethodGet, "/message/9999/transitions", nil)
+	missing := httptest.NewRecorder()
+	r.ServeHTTP(missing, req)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
@@ -0,0 +1,269 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/pubsub"
+)
+
+const (
+	// streamRetry 建议 SSE 客户端断线后重连的等待时间
+	streamRetry = 3 * time.Second
+
+	// wsWriteWait WebSocket 单次写入的超时时间
+	wsWriteWait = 10 * time.Second
+)
+
+// upgrader 站内信推送的 WebSocket 升级配置，只允许同源连接
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// siteMessageTopic 用户站内信在 Hub 中的主题
+func siteMessageTopic(userID int64) string {
+	return "sitemessage:" + strconv.FormatInt(userID, 10)
+}
+
+// publish 把新建的站内信推送给该用户在线的连接
+func (h *Server) publish(model *MessageModel) {
+	if model.Type != Sitemessage {
+		return
+	}
+	h.hub.Publish(siteMessageTopic(model.UserID), *model)
+}
+
+// siteMessageStream 一个推送连接：先订阅再查询错过的消息，按 ID 去重，保证不丢不重
+type siteMessageStream struct {
+	sub     *pubsub.Subscription[MessageModel]
+	backlog []MessageModel // 客户端断线期间错过的消息
+	lastID  int64          // 已推送的最大消息 ID
+}
+
+// accept 判断消息是否需要推送，并记录为已推送
+func (s *siteMessageStream) accept(m *MessageModel) bool {
+	if m.ID <= s.lastID {
+		return false
+	}
+	s.lastID = m.ID
+	return true
+}
+
+// openStream 订阅用户的站内信，lastID 不为 nil 时查询断线期间错过的消息
+// 调用方负责关闭 stream.sub
+func (h *Server) openStream(ctx context.Context, uid int64, lastID *int64) (*siteMessageStream, error) {
+	stream := &siteMessageStream{}
+	if lastID != nil {
+		if *lastID < 0 {
+			return nil, errors.BadRequest("无效的 Last-Event-ID")
+		}
+		stream.lastID = *lastID
+	}
+
+	stream.sub = h.hub.Subscribe(siteMessageTopic(uid))
+	if lastID != nil {
+		var err error
+		stream.backlog, err = h.messages.FindByUserIDAfter(ctx, uid, stream.lastID)
+		if err != nil {
+			stream.sub.Close()
+			return nil, errors.InternalServer("查询站内消息失败")
+		}
+	}
+	return stream, nil
+}
+
+// writeSSE 以 SSE 事件格式写入一条站内信，事件 ID 为消息 ID
+func writeSSE(w io.Writer, m *MessageModel) error {
+	data, err := json.Marshal(m.ToSiteMessage())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: sitemessage\ndata: %s\n\n", m.ID, data)
+	return err
+}
+
+// StreamSiteMessages 通过 Server-Sent Events 实时推送用户的站内信
+// 带 Last-Event-ID 重连时先补发断线期间的消息；订阅者处理过慢被移出 Hub 时结束响应，由客户端重连续传
+func (h *Server) StreamSiteMessages(ctx context.Context, request StreamSiteMessagesRequestObject) (StreamSiteMessagesResponseObject, error) {
+	// SSE 重连时浏览器自动带上 Last-Event-ID 头，无法设置请求头的客户端使用 last_event_id 查询参数
+	lastID := request.Params.LastEventID
+	if lastID == nil {
+		lastID = request.Params.LastEventId
+	}
+	stream, err := h.openStream(ctx, request.Uid, lastID)
+	if err != nil {
+		return nil, err
+	}
+	return sseResponse{h: h, ctx: ctx, stream: stream}, nil
+}
+
+// sseResponse 站内信的 SSE 推送响应，持续写出事件直到请求结束
+type sseResponse struct {
+	h      *Server
+	ctx    context.Context
+	stream *siteMessageStream
+}
+
+func (resp sseResponse) VisitStreamSiteMessagesResponse(w http.ResponseWriter) error {
+	h, stream := resp.h, resp.stream
+	defer stream.sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	w.WriteHeader(http.StatusOK)
+
+	// 响应头已经写出，之后的写入错误说明连接已断开，不再返回给错误处理
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return nil
+	}
+	for i := range stream.backlog {
+		if stream.accept(&stream.backlog[i]) {
+			if err := writeSSE(w, &stream.backlog[i]); err != nil {
+				return nil
+			}
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.Warn("响应不支持流式输出", "error", err)
+		return nil
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-resp.ctx.Done():
+			return nil
+		case m, ok := <-stream.sub.C():
+			if !ok {
+				return nil
+			}
+			if !stream.accept(&m) {
+				continue
+			}
+			if err := writeSSE(w, &m); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			// 注释行作为心跳，防止代理因空闲断开连接
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return nil
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// requestKey 上下文中保存原始请求的键
+type requestKey struct{}
+
+// withRequest 在上下文中保存原始请求的 strict 中间件，WebSocket 升级需要读取原始请求
+func withRequest(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+	if operationID != "WebSocketSiteMessages" {
+		return f
+	}
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return f(context.WithValue(ctx, requestKey{}, r), w, r, request)
+	}
+}
+
+// WebSocketSiteMessages 通过 WebSocket 实时推送用户的站内信，每条消息为一个 JSON 文本帧
+// 续传使用 last_event_id 查询参数；订阅者处理过慢时以 1013 (Try Again Later) 关闭连接
+func (h *Server) WebSocketSiteMessages(ctx context.Context, request WebSocketSiteMessagesRequestObject) (WebSocketSiteMessagesResponseObject, error) {
+	r, ok := ctx.Value(requestKey{}).(*http.Request)
+	if !ok {
+		return nil, stderrors.New("message: websocket request not found in context")
+	}
+	stream, err := h.openStream(ctx, request.Uid, request.Params.LastEventId)
+	if err != nil {
+		return nil, err
+	}
+	return wsResponse{h: h, r: r, stream: stream}, nil
+}
+
+// wsResponse 站内信的 WebSocket 推送响应，升级连接后持续推送直到连接关闭
+type wsResponse struct {
+	h      *Server
+	r      *http.Request
+	stream *siteMessageStream
+}
+
+func (resp wsResponse) VisitWebSocketSiteMessagesResponse(w http.ResponseWriter) error {
+	h, stream := resp.h, resp.stream
+	defer stream.sub.Close()
+
+	conn, err := upgrader.Upgrade(w, resp.r, nil)
+	if err != nil {
+		// Upgrade 已写入错误响应
+		return nil
+	}
+	defer conn.Close()
+
+	// 客户端不发送数据，读循环只负责处理 pong 和关闭帧，超过两个心跳周期没有响应视为断线
+	done := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(m *MessageModel) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(m.ToSiteMessage())
+	}
+	for i := range stream.backlog {
+		if stream.accept(&stream.backlog[i]) {
+			if err := send(&stream.backlog[i]); err != nil {
+				return nil
+			}
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case m, ok := <-stream.sub.C():
+			if !ok {
+				closing := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber lagging")
+				conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(wsWriteWait))
+				return nil
+			}
+			if !stream.accept(&m) {
+				continue
+			}
+			if err := send(&m); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package message
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// setupStreamServer 启动真实的 HTTP 服务，SSE 和 WebSocket 需要完整的连接
+func setupStreamServer(t *testing.T, cfg Config) (*httptest.Server, *chi.Mux) {
+	r := chi.NewRouter()
+	New(module.Deps{DB: setupTestDB(t)}, cfg).Routes(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, r
+}
+
+// createSiteMessage 创建一条站内信并返回其 ID
+func createSiteMessage(t *testing.T, r http.Handler, uid int64, content string) int64 {
+	resp := postMessage(r, fmt.Sprintf(`{"type":"sitemessage","content":%q,"user_id":%d}`, content, uid))
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var msg SiteMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &msg))
+	return msg.Id
+}
+
+// sseEvent 解析出的一个 SSE 事件，心跳注释行以 Comment 返回
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+// readEvent 读取下一个 SSE 事件，跳过 retry 设置
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var ev sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if ev != (sseEvent{}) {
+				return ev
+			}
+		case strings.HasPrefix(line, ":"):
+			ev.Comment = strings.TrimSpace(line[1:])
+		case strings.HasPrefix(line, "id: "):
+			ev.ID = line[len("id: "):]
+		case strings.HasPrefix(line, "event: "):
+			ev.Event = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			ev.Data = line[len("data: "):]
+		}
+	}
+}
+
+// openSSE 连接 SSE 推送，lastEventID 为空时不续传
+func openSSE(t *testing.T, srv *httptest.Server, uid int64, lastEventID string) *bufio.Reader {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/message/sitemessage/%d/stream", srv.URL, uid), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+func TestStreamSiteMessages(t *testing.T) {
+	t.Parallel()
+
+	srv, r := setupStreamServer(t, Config{})
+	stream := openSSE(t, srv, 1001, "")
+
+	// 其他用户和其他类型的消息不会推送
+	createSiteMessage(t, r, 2002, "给别人的")
+	postMessage(r, `{"type":"sms","content":"短信","phone_number":"8613800138000"}`)
+	id := createSiteMessage(t, r, 1001, "欢迎")
+
+	ev := readEvent(t, stream)
+	assert.Equal(t, strconv.FormatInt(id, 10), ev.ID)
+	assert.Equal(t, "sitemessage", ev.Event)
+	var msg SiteMessage
+	require.NoError(t, json.Unmarshal([]byte(ev.Data), &msg))
+	assert.Equal(t, "欢迎", msg.Content)
+	assert.Equal(t, int64(1001), msg.UserId)
+}
+
+func TestStreamSiteMessagesResume(t *testing.T) {
+	t.Parallel()
+
+	srv, r := setupStreamServer(t, Config{})
+	first := createSiteMessage(t, r, 1001, "第一条")
+	second := createSiteMessage(t, r, 1001, "第二条")
+
+	// 断线期间错过的消息先补发，之后继续实时推送
+	stream := openSSE(t, srv, 1001, strconv.FormatInt(first, 10))
+	assert.Equal(t, strconv.FormatInt(second, 10), readEvent(t, stream).ID)
+
+	third := createSiteMessage(t, r, 1001, "第三条")
+	assert.Equal(t, strconv.FormatInt(third, 10), readEvent(t, stream).ID)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/message/sitemessage/1001/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "abc")
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStreamSiteMessagesHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := setupStreamServer(t, Config{StreamHeartbeat: 10 * time.Millisecond})
+	stream := openSSE(t, srv, 1001, "")
+	assert.Equal(t, "ping", readEvent(t, stream).Comment)
+}
+
+func TestWebSocketSiteMessages(t *testing.T) {
+	t.Parallel()
+
+	srv, r := setupStreamServer(t, Config{})
+	first := createSiteMessage(t, r, 1001, "第一条")
+	second := createSiteMessage(t, r, 1001, "第二条")
+
+	url := fmt.Sprintf("ws%s/message/sitemessage/1001/ws?last_event_id=%d", strings.TrimPrefix(srv.URL, "http"), first)
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var msg SiteMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, second, msg.Id)
+
+	third := createSiteMessage(t, r, 1001, "第三条")
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, third, msg.Id)
+	assert.Equal(t, "第三条", msg.Content)
+
+	// 不是 WebSocket 握手时返回 400
+	plain, err := srv.Client().Get(srv.URL + "/message/sitemessage/1001/ws")
+	require.NoError(t, err)
+	plain.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, plain.StatusCode)
+}
@@ -0,0 +1,343 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTemplateNotFound 表示模板不存在
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrTemplateExists 表示模板ID已被使用
+	ErrTemplateExists = errors.New("template already exists")
+
+	// ErrInvalidTemplate 表示模板定义不合法
+	ErrInvalidTemplate = errors.New("invalid template")
+
+	// ErrInvalidParams 表示渲染模板的参数不合法
+	ErrInvalidParams = errors.New("invalid template params")
+)
+
+var (
+	// templateIDPattern 模板ID的格式
+	templateIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+	// variableNamePattern 变量名的格式
+	variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// placeholderPattern 模板内容中的变量占位符，如 {{code}}，花括号内允许空格
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// TemplateModel 消息模板，各语言的内容通过 {{变量名}} 引用声明过的变量
+type TemplateModel struct {
+	ID            string             `gorm:"primaryKey;size:64" json:"id"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Type          MessageType        `gorm:"size:20" json:"type,omitempty"` // 为空时可用于所有类型的消息
+	Description   string             `gorm:"size:255" json:"description,omitempty"`
+	DefaultLocale string             `gorm:"size:20;not null" json:"default_locale"`
+	Variables     []TemplateVariable `gorm:"serializer:json;type:text" json:"variables"`
+	Variants      map[string]string  `gorm:"serializer:json;type:text;not null" json:"variants"` // 语言 -> 模板内容
+}
+
+// TableName 指定模板表名
+func (TemplateModel) TableName() string {
+	return "message_templates"
+}
+
+// FromTemplate 从模板API模型创建数据库模型
+func FromTemplate(t Template) *TemplateModel {
+	m := &TemplateModel{
+		ID:            t.Id,
+		DefaultLocale: t.DefaultLocale,
+		Variants:      t.Variants,
+	}
+	if t.Type != nil {
+		m.Type = *t.Type
+	}
+	if t.Description != nil {
+		m.Description = *t.Description
+	}
+	if t.Variables != nil {
+		m.Variables = *t.Variables
+	}
+	return m
+}
+
+// ToAPI 将模板转换为API模型
+func (t *TemplateModel) ToAPI() Template {
+	variables := t.Variables
+	if variables == nil {
+		variables = []TemplateVariable{}
+	}
+	tpl := Template{
+		Id:            t.ID,
+		DefaultLocale: t.DefaultLocale,
+		Variables:     &variables,
+		Variants:      t.Variants,
+		CreatedAt:     &t.CreatedAt,
+		UpdatedAt:     &t.UpdatedAt,
+	}
+	if t.Type != "" {
+		msgType := t.Type
+		tpl.Type = &msgType
+	}
+	if t.Description != "" {
+		description := t.Description
+		tpl.Description = &description
+	}
+	return tpl
+}
+
+// Validate 校验模板定义：ID 与变量名格式、变量类型与默认值、默认语言存在，
+// 以及各语言内容只引用声明过的变量
+func (t *TemplateModel) Validate() error {
+	if !templateIDPattern.MatchString(t.ID) {
+		return fmt.Errorf("%w: id %q must match %s", ErrInvalidTemplate, t.ID, templateIDPattern)
+	}
+	switch t.Type {
+	case "", Sms, Sitemessage, Broadcast, Wechat, Email:
+	default:
+		return fmt.Errorf("%w: unsupported message type %q", ErrInvalidTemplate, t.Type)
+	}
+
+	declared := make(map[string]bool, len(t.Variables))
+	for _, v := range t.Variables {
+		if !variableNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("%w: variable name %q must match %s", ErrInvalidTemplate, v.Name, variableNamePattern)
+		}
+		if declared[v.Name] {
+			return fmt.Errorf("%w: variable %q declared twice", ErrInvalidTemplate, v.Name)
+		}
+		declared[v.Name] = true
+
+		switch v.Type {
+		case VariableString, VariableInteger, VariableNumber, VariableBoolean:
+		default:
+			return fmt.Errorf("%w: variable %q has unsupported type %q", ErrInvalidTemplate, v.Name, v.Type)
+		}
+		if v.Default != nil && *v.Default != nil {
+			if _, err := formatValue(v, *v.Default); err != nil {
+				return fmt.Errorf("%w: default of variable %q: %s", ErrInvalidTemplate, v.Name, err)
+			}
+		}
+	}
+
+	if len(t.Variants) == 0 {
+		return fmt.Errorf("%w: at least one locale variant is required", ErrInvalidTemplate)
+	}
+	if _, ok := t.Variants[t.DefaultLocale]; !ok {
+		return fmt.Errorf("%w: default locale %q has no variant", ErrInvalidTemplate, t.DefaultLocale)
+	}
+	for locale, body := range t.Variants {
+		if strings.TrimSpace(body) == "" {
+			return fmt.Errorf("%w: variant %q is empty", ErrInvalidTemplate, locale)
+		}
+		for _, match := range placeholderPattern.FindAllStringSubmatch(body, -1) {
+			if !declared[match[1]] {
+				return fmt.Errorf("%w: variant %q references undeclared variable %q", ErrInvalidTemplate, locale, match[1])
+			}
+		}
+		// 去掉合法占位符后仍有 {{ 说明写法有误，例如 {{ code 或 {{1}}
+		if strings.Contains(placeholderPattern.ReplaceAllString(body, ""), "{{") {
+			return fmt.Errorf("%w: variant %q has a malformed placeholder", ErrInvalidTemplate, locale)
+		}
+	}
+	return nil
+}
+
+// Render 按语言偏好选择内容并代入参数，返回实际使用的语言和渲染结果
+// 未声明的参数、缺少的必填参数以及类型不符都返回 ErrInvalidParams
+func (t *TemplateModel) Render(locales []string, params map[string]interface{}) (string, string, error) {
+	values := make(map[string]string, len(t.Variables))
+	declared := make(map[string]bool, len(t.Variables))
+	for _, v := range t.Variables {
+		declared[v.Name] = true
+
+		value, ok := params[v.Name]
+		if !ok || value == nil {
+			switch {
+			case v.Default != nil && *v.Default != nil:
+				value = *v.Default
+			case v.Required != nil && *v.Required:
+				return "", "", fmt.Errorf("%w: missing required parameter %q", ErrInvalidParams, v.Name)
+			default:
+				values[v.Name] = ""
+				continue
+			}
+		}
+
+		formatted, err := formatValue(v, value)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: parameter %q: %s", ErrInvalidParams, v.Name, err)
+		}
+		values[v.Name] = formatted
+	}
+
+	// 拼写错误的参数名会被静默忽略，因此拒绝未声明的参数
+	unknown := make([]string, 0)
+	for name := range params {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", "", fmt.Errorf("%w: unknown parameters %s", ErrInvalidParams, strings.Join(unknown, ", "))
+	}
+
+	locale := t.matchLocale(locales)
+	content := placeholderPattern.ReplaceAllStringFunc(t.Variants[locale], func(placeholder string) string {
+		return values[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+	})
+	return locale, content, nil
+}
+
+// matchLocale 按偏好顺序选择语言：先精确匹配（不区分大小写，_ 视同 -），
+// 再按主语言匹配（如 en-GB 匹配 en-US），都没有时使用默认语言
+func (t *TemplateModel) matchLocale(prefs []string) string {
+	available := make([]string, 0, len(t.Variants))
+	for locale := range t.Variants {
+		available = append(available, locale)
+	}
+	sort.Strings(available)
+
+	for _, pref := range prefs {
+		for _, locale := range available {
+			if normalizeLocale(locale) == normalizeLocale(pref) {
+				return locale
+			}
+		}
+	}
+	for _, pref := range prefs {
+		for _, locale := range available {
+			if baseLanguage(locale) == baseLanguage(pref) {
+				return locale
+			}
+		}
+	}
+	return t.DefaultLocale
+}
+
+// normalizeLocale 统一语言标签的大小写和分隔符
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// baseLanguage 返回语言标签的主语言部分，如 zh-CN -> zh
+func baseLanguage(locale string) string {
+	base, _, _ := strings.Cut(normalizeLocale(locale), "-")
+	return base
+}
+
+// parseAcceptLanguage 解析 Accept-Language 请求头，按权重从高到低返回语言标签
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var prefs []weighted
+	for _, part := range strings.Split(header, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			q = parsed
+		}
+		prefs = append(prefs, weighted{locale: locale, q: q})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	locales := make([]string, 0, len(prefs))
+	for _, p := range prefs {
+		locales = append(locales, p.locale)
+	}
+	return locales
+}
+
+// formatValue 按变量类型校验取值并格式化为文本
+// 参数来自 JSON 解码，数字为 float64；Go 代码直接调用时也接受整数类型
+func formatValue(v TemplateVariable, value interface{}) (string, error) {
+	switch v.Type {
+	case VariableString:
+		s, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("expected string, got %T", value)
+		}
+		return s, nil
+	case VariableInteger:
+		switch n := value.(type) {
+		case int:
+			return strconv.Itoa(n), nil
+		case int64:
+			return strconv.FormatInt(n, 10), nil
+		case json.Number:
+			if _, err := n.Int64(); err != nil {
+				return "", fmt.Errorf("expected integer, got %s", n)
+			}
+			return n.String(), nil
+		case float64:
+			if n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+				return "", fmt.Errorf("expected integer, got %v", n)
+			}
+			return strconv.FormatInt(int64(n), 10), nil
+		}
+		return "", fmt.Errorf("expected integer, got %T", value)
+	case VariableNumber:
+		switch n := value.(type) {
+		case int:
+			return strconv.Itoa(n), nil
+		case int64:
+			return strconv.FormatInt(n, 10), nil
+		case json.Number:
+			if _, err := n.Float64(); err != nil {
+				return "", fmt.Errorf("expected number, got %s", n)
+			}
+			return n.String(), nil
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("expected number, got %T", value)
+	case VariableBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("expected boolean, got %T", value)
+		}
+		return strconv.FormatBool(b), nil
+	}
+	return "", fmt.Errorf("unsupported type %q", v.Type)
+}
+
+// TemplateRepository 消息模板的数据访问接口
+type TemplateRepository interface {
+	// CreateTemplate 保存新模板，ID 已存在时返回 ErrTemplateExists
+	CreateTemplate(ctx context.Context, tpl *TemplateModel) error
+
+	// FindTemplate 按 ID 查询模板，不存在时返回 ErrTemplateNotFound
+	FindTemplate(ctx context.Context, id string) (*TemplateModel, error)
+
+	// ListTemplates 按 ID 升序列出所有模板
+	ListTemplates(ctx context.Context) ([]TemplateModel, error)
+
+	// UpdateTemplate 整体替换模板定义并回填时间戳，不存在时返回 ErrTemplateNotFound
+	UpdateTemplate(ctx context.Context, tpl *TemplateModel) error
+
+	// DeleteTemplate 删除模板，不存在时返回 ErrTemplateNotFound
+	DeleteTemplate(ctx context.Context, id string) error
+}
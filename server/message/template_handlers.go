@@ -0,0 +1,145 @@
+package message
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+// applyTemplate 用请求中的 template_id、params 和 locale 渲染消息内容，
+// 未指定 locale 时按 acceptLanguage 选择语言
+func (h *Server) applyTemplate(ctx context.Context, req *createRequest, msgType MessageType, acceptLanguage string) (string, error) {
+	templateID := stringValue(req.TemplateId)
+	tpl, err := h.messages.FindTemplate(ctx, templateID)
+	if err != nil {
+		if stderrors.Is(err, ErrTemplateNotFound) {
+			return "", errors.Validation(errors.FieldError{Field: "template_id", Message: fmt.Sprintf("模板不存在: %s", templateID)})
+		}
+		return "", errors.InternalServer("查询模板失败")
+	}
+	if tpl.Type != "" && tpl.Type != msgType {
+		return "", errors.Validation(errors.FieldError{Field: "template_id", Message: fmt.Sprintf("模板 %s 只能用于 %s 消息", tpl.ID, tpl.Type)})
+	}
+
+	var params map[string]interface{}
+	if req.Params != nil {
+		params = *req.Params
+	}
+	_, content, err := tpl.Render(localePreferences(acceptLanguage, stringValue(req.Locale)), params)
+	if err != nil {
+		return "", errors.Validation(errors.FieldError{Field: "params", Message: fmt.Sprintf("模板参数无效: %s", err)})
+	}
+	return content, nil
+}
+
+// localePreferences 返回语言偏好：请求中明确指定的 locale 优先，其次是 Accept-Language
+func localePreferences(acceptLanguage, locale string) []string {
+	prefs := parseAcceptLanguage(acceptLanguage)
+	if locale != "" {
+		prefs = append([]string{locale}, prefs...)
+	}
+	return prefs
+}
+
+// decodeTemplate 校验请求体中的模板定义，id 不为空时覆盖请求体中的模板ID
+func decodeTemplate(body Template, id string) (*TemplateModel, error) {
+	tpl := FromTemplate(body)
+	if id != "" {
+		tpl.ID = id
+	}
+	if err := tpl.Validate(); err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("模板定义无效: %s", err))
+	}
+	return tpl, nil
+}
+
+// ListTemplates 列出所有消息模板
+func (h *Server) ListTemplates(ctx context.Context, request ListTemplatesRequestObject) (ListTemplatesResponseObject, error) {
+	models, err := h.messages.ListTemplates(ctx)
+	if err != nil {
+		return nil, errors.InternalServer("查询模板失败")
+	}
+
+	templates := make([]Template, 0, len(models))
+	for i := range models {
+		templates = append(templates, models[i].ToAPI())
+	}
+	return ListTemplates200JSONResponse{Templates: templates}, nil
+}
+
+// CreateTemplate 创建消息模板
+func (h *Server) CreateTemplate(ctx context.Context, request CreateTemplateRequestObject) (CreateTemplateResponseObject, error) {
+	tpl, err := decodeTemplate(*request.Body, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.messages.CreateTemplate(ctx, tpl); err != nil {
+		if stderrors.Is(err, ErrTemplateExists) {
+			return nil, errors.New(http.StatusConflict, fmt.Sprintf("模板已存在: %s", tpl.ID))
+		}
+		h.logger.Error("保存模板失败", "id", tpl.ID, "error", err)
+		return nil, errors.InternalServer("保存模板失败")
+	}
+	return CreateTemplate201JSONResponse(tpl.ToAPI()), nil
+}
+
+// GetTemplate 查询消息模板
+func (h *Server) GetTemplate(ctx context.Context, request GetTemplateRequestObject) (GetTemplateResponseObject, error) {
+	tpl, err := h.messages.FindTemplate(ctx, request.Id)
+	if err != nil {
+		return nil, templateError(err)
+	}
+	return GetTemplate200JSONResponse(tpl.ToAPI()), nil
+}
+
+// UpdateTemplate 整体替换消息模板，已创建的消息不受影响
+func (h *Server) UpdateTemplate(ctx context.Context, request UpdateTemplateRequestObject) (UpdateTemplateResponseObject, error) {
+	tpl, err := decodeTemplate(*request.Body, request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.messages.UpdateTemplate(ctx, tpl); err != nil {
+		return nil, templateError(err)
+	}
+	return UpdateTemplate200JSONResponse(tpl.ToAPI()), nil
+}
+
+// DeleteTemplate 删除消息模板
+func (h *Server) DeleteTemplate(ctx context.Context, request DeleteTemplateRequestObject) (DeleteTemplateResponseObject, error) {
+	if err := h.messages.DeleteTemplate(ctx, request.Id); err != nil {
+		return nil, templateError(err)
+	}
+	return DeleteTemplate204Response{}, nil
+}
+
+// PreviewTemplate 渲染模板但不创建消息，语言选择和参数校验与创建消息相同
+func (h *Server) PreviewTemplate(ctx context.Context, request PreviewTemplateRequestObject) (PreviewTemplateResponseObject, error) {
+	tpl, err := h.messages.FindTemplate(ctx, request.Id)
+	if err != nil {
+		return nil, templateError(err)
+	}
+
+	var params map[string]interface{}
+	if request.Body.Params != nil {
+		params = *request.Body.Params
+	}
+	locale := stringValue(request.Body.Locale)
+	used, content, err := tpl.Render(localePreferences(stringValue(request.Params.AcceptLanguage), locale), params)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("模板参数无效: %s", err))
+	}
+	return PreviewTemplate200JSONResponse{TemplateId: tpl.ID, Locale: used, Content: content}, nil
+}
+
+// templateError 将模板查询和修改的错误映射为 API 错误
+func templateError(err error) error {
+	if stderrors.Is(err, ErrTemplateNotFound) {
+		return errors.NotFound("模板不存在")
+	}
+	return errors.InternalServer("操作模板失败")
+}
@@ -0,0 +1,225 @@
+package message
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verificationTemplate 验证码模板，包含必填、可选和带默认值的变量
+func verificationTemplate() *TemplateModel {
+	required := true
+	minutes := interface{}(float64(5))
+	return &TemplateModel{
+		ID:            "verification-code",
+		Type:          Sms,
+		DefaultLocale: "zh-CN",
+		Variables: []TemplateVariable{
+			{Name: "code", Type: VariableString, Required: &required},
+			{Name: "minutes", Type: VariableInteger, Default: &minutes},
+			{Name: "app", Type: VariableString},
+		},
+		Variants: map[string]string{
+			"zh-CN": "{{app}}验证码：{{ code }}，{{minutes}}分钟内有效",
+			"en-US": "{{app}} code: {{code}}, valid for {{minutes}} minutes",
+		},
+	}
+}
+
+func TestTemplateValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, verificationTemplate().Validate())
+
+	cases := map[string]func(*TemplateModel){
+		"invalid id":            func(m *TemplateModel) { m.ID = "Bad ID" },
+		"unsupported type":      func(m *TemplateModel) { m.Type = "fax" },
+		"invalid variable name": func(m *TemplateModel) { m.Variables[0].Name = "1code" },
+		"duplicate variable":    func(m *TemplateModel) { m.Variables[1].Name = "code" },
+		"unsupported var type":  func(m *TemplateModel) { m.Variables[0].Type = "date" },
+		"default type mismatch": func(m *TemplateModel) {
+			v := interface{}("five")
+			m.Variables[1].Default = &v
+		},
+		"no variants":         func(m *TemplateModel) { m.Variants = nil },
+		"missing default":     func(m *TemplateModel) { m.DefaultLocale = "fr" },
+		"empty variant":       func(m *TemplateModel) { m.Variants["en-US"] = " " },
+		"undeclared variable": func(m *TemplateModel) { m.Variants["en-US"] = "{{user}}" },
+		"malformed":           func(m *TemplateModel) { m.Variants["en-US"] = "{{ code" },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			tpl := verificationTemplate()
+			mutate(tpl)
+			assert.ErrorIs(t, tpl.Validate(), ErrInvalidTemplate)
+		})
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	t.Parallel()
+
+	tpl := verificationTemplate()
+
+	locale, content, err := tpl.Render(nil, map[string]interface{}{"code": "929253"})
+	require.NoError(t, err)
+	assert.Equal(t, "zh-CN", locale)
+	assert.Equal(t, "验证码：929253，5分钟内有效", content)
+
+	locale, content, err = tpl.Render([]string{"en-GB"}, map[string]interface{}{"code": "1234", "minutes": float64(10), "app": "Shop"})
+	require.NoError(t, err)
+	assert.Equal(t, "en-US", locale)
+	assert.Equal(t, "Shop code: 1234, valid for 10 minutes", content)
+
+	_, _, err = tpl.Render(nil, map[string]interface{}{})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+	_, _, err = tpl.Render(nil, map[string]interface{}{"code": 1234})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+	_, _, err = tpl.Render(nil, map[string]interface{}{"code": "1", "minutes": 1.5})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+	_, _, err = tpl.Render(nil, map[string]interface{}{"code": "1", "cdoe": "1"})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+}
+
+func TestTemplateLocale(t *testing.T) {
+	t.Parallel()
+
+	tpl := &TemplateModel{DefaultLocale: "en", Variants: map[string]string{"en": "", "zh-CN": "", "zh-TW": ""}}
+
+	assert.Equal(t, "zh-TW", tpl.matchLocale([]string{"zh_tw"}))
+	assert.Equal(t, "zh-CN", tpl.matchLocale([]string{"fr", "zh-cn"}))
+	assert.Equal(t, "zh-CN", tpl.matchLocale([]string{"zh-HK"}))
+	assert.Equal(t, "en", tpl.matchLocale([]string{"fr"}))
+	assert.Equal(t, "en", tpl.matchLocale(nil))
+
+	assert.Equal(t, []string{"zh-TW", "en-US", "en"},
+		parseAcceptLanguage("en;q=0.5, zh-TW, *, en-US;q=0.8, fr;q=0"))
+	assert.Empty(t, parseAcceptLanguage(""))
+}
+
+// doTemplateRequest 以认证身份发送模板管理请求
+func doTemplateRequest(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", testToken)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+const verificationTemplateJSON = `{
+	"id": "verification-code",
+	"type": "sms",
+	"default_locale": "zh-CN",
+	"variables": [
+		{"name": "code", "type": "string", "required": true},
+		{"name": "minutes", "type": "integer", "default": 5}
+	],
+	"variants": {
+		"zh-CN": "验证码：{{code}}，{{minutes}}分钟内有效",
+		"en": "Your code is {{code}}, valid for {{minutes}} minutes"
+	}
+}`
+
+func TestTemplateHandlers(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+
+	// 创建需要认证
+	req := httptest.NewRequest(http.MethodPost, "/message/templates", strings.NewReader(verificationTemplateJSON))
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates", verificationTemplateJSON)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var tpl Template
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &tpl))
+	assert.Equal(t, "verification-code", tpl.Id)
+	assert.Len(t, *tpl.Variables, 2)
+	assert.NotNil(t, tpl.CreatedAt)
+
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates", verificationTemplateJSON)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates", `{"id":"bad","default_locale":"en","variants":{"en":"{{x}}"}}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = doTemplateRequest(r, http.MethodGet, "/message/templates", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var list TemplateList
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	require.Len(t, list.Templates, 1)
+
+	// 预览：locale 优先于 Accept-Language
+	req = httptest.NewRequest(http.MethodPost, "/message/templates/verification-code/preview", strings.NewReader(`{"params":{"code":"1234"}}`))
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var preview TemplatePreview
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &preview))
+	assert.Equal(t, "en", preview.Locale)
+	assert.Equal(t, "Your code is 1234, valid for 5 minutes", preview.Content)
+
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates/verification-code/preview", `{"params":{"code":"1234"},"locale":"zh-CN"}`)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &preview))
+	assert.Equal(t, "验证码：1234，5分钟内有效", preview.Content)
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates/verification-code/preview", `{"params":{}}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doTemplateRequest(r, http.MethodPost, "/message/templates/missing/preview", `{}`)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	// 更新时以路径中的 ID 为准
+	resp = doTemplateRequest(r, http.MethodPut, "/message/templates/verification-code",
+		`{"id":"other","type":"sms","default_locale":"en","variables":[{"name":"code","type":"string"}],"variants":{"en":"Code {{code}}"}}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var updated Template
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &updated))
+	assert.Equal(t, "verification-code", updated.Id)
+	assert.Equal(t, map[string]string{"en": "Code {{code}}"}, updated.Variants)
+	resp = doTemplateRequest(r, http.MethodPut, "/message/templates/missing", `{"default_locale":"en","variants":{"en":"x"}}`)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = doTemplateRequest(r, http.MethodDelete, "/message/templates/verification-code", "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	resp = doTemplateRequest(r, http.MethodGet, "/message/templates/verification-code", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestCreateMessageFromTemplate(t *testing.T) {
+	t.Parallel()
+
+	r := setupRouter(t)
+	resp := doTemplateRequest(r, http.MethodPost, "/message/templates", verificationTemplateJSON)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	resp = postMessage(r, `{"type":"sms","template_id":"verification-code","params":{"code":"929253"},"locale":"en","phone_number":"+8613800138000"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var sms SMSMessage
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &sms))
+	assert.Equal(t, "Your code is 929253, valid for 5 minutes", sms.Content)
+	require.NotNil(t, sms.TemplateId)
+	assert.Equal(t, "verification-code", *sms.TemplateId)
+
+	// 查询时保留模板ID
+	resp = doTemplateRequest(r, http.MethodGet, "/message/sms/+8613800138000", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), `"template_id":"verification-code"`)
+
+	for name, body := range map[string]string{
+		"unknown template":  `{"type":"sms","template_id":"missing","phone_number":"+8613800138000"}`,
+		"content and tpl":   `{"type":"sms","template_id":"verification-code","content":"x","params":{"code":"1"},"phone_number":"+8613800138000"}`,
+		"invalid params":    `{"type":"sms","template_id":"verification-code","params":{"code":1},"phone_number":"+8613800138000"}`,
+		"params not object": `{"type":"sms","template_id":"verification-code","params":[1],"phone_number":"+8613800138000"}`,
+		"type mismatch":     `{"type":"sitemessage","template_id":"verification-code","params":{"code":"1"},"user_id":1}`,
+	} {
+		resp := postMessage(r, body)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, name)
+	}
+}
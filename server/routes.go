@@ -3,18 +3,16 @@ package server
 import (
 	"github.com/go-chi/chi/v5"
 
-	"github.com/twotwo/go-blueprint/server/message"
-	"github.com/twotwo/go-blueprint/server/user"
+	"github.com/twotwo/go-blueprint/pkg/module"
 )
 
-// RegisterRoutes 注册所有API路由
-func RegisterRoutes(r chi.Router) {
+// RegisterRoutes 在 API 版本前缀下挂载各资源模块的路由
+// 模块由调用方（cmd/server/main.go）按依赖显式创建
+func RegisterRoutes(r chi.Router, modules ...module.Module) {
 	// API版本前缀
 	r.Route("/api/v1", func(r chi.Router) {
-		// 注册用户资源路由
-		user.RegisterRoutes(r)
-
-		// 注册消息资源路由
-		message.RegisterRoutes(r)
+		for _, m := range modules {
+			m.Routes(r)
+		}
 	})
 }
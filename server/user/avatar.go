@@ -0,0 +1,229 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+const (
+	// DefaultMaxAvatarSize 头像文件的默认最大字节数
+	DefaultMaxAvatarSize int64 = 2 << 20
+
+	// MaxAvatarDimension 头像宽高的最大像素数，防止解码超大图片耗尽内存
+	MaxAvatarDimension = 4096
+
+	// AvatarThumbnailSize 缩略图边长（像素）
+	AvatarThumbnailSize = 128
+
+	// DefaultAvatarURLExpiry 签名下载地址的默认有效期
+	DefaultAvatarURLExpiry = 15 * time.Minute
+)
+
+// avatarTypes 允许上传的图片类型及其扩展名
+var avatarTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+}
+
+// avatarKey 返回头像原图的存储 key
+func avatarKey(userID uint, ext string) string {
+	return fmt.Sprintf("avatars/%d/original.%s", userID, ext)
+}
+
+// thumbnailKey 返回与原图对应的缩略图 key
+func thumbnailKey(originalKey string) string {
+	return path.Dir(originalKey) + "/thumbnail.png"
+}
+
+// maxAvatarFormField 头像表单中非文件字段的最大字节数
+const maxAvatarFormField = 64 << 10
+
+// UploadUserAvatar 上传用户头像，同时生成缩略图
+func (h *Server) UploadUserAvatar(ctx context.Context, request UploadUserAvatarRequestObject) (UploadUserAvatarResponseObject, error) {
+	if h.cfg.AvatarStorage == nil {
+		return nil, errors.New(http.StatusNotImplemented, "未配置头像存储")
+	}
+
+	user, err := h.findUser(ctx, request.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := h.readAvatarFile(request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 根据文件内容而不是客户端声明判断类型
+	contentType := http.DetectContentType(data)
+	ext, ok := avatarTypes[contentType]
+	if !ok {
+		return nil, errors.New(http.StatusUnsupportedMediaType, "仅支持 PNG、JPEG、GIF 格式的图片")
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.BadRequest("无效的图片文件")
+	}
+	if cfg.Width > MaxAvatarDimension || cfg.Height > MaxAvatarDimension {
+		return nil, errors.BadRequest(fmt.Sprintf("图片尺寸不能超过 %dx%d", MaxAvatarDimension, MaxAvatarDimension))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.BadRequest("无效的图片文件")
+	}
+
+	var thumb bytes.Buffer
+	if err := png.Encode(&thumb, thumbnail(img, AvatarThumbnailSize)); err != nil {
+		return nil, errors.InternalServer("生成缩略图失败")
+	}
+
+	key := avatarKey(user.ID, ext)
+
+	if err := h.cfg.AvatarStorage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, errors.InternalServer("保存头像失败")
+	}
+	if err := h.cfg.AvatarStorage.Put(ctx, thumbnailKey(key), &thumb, int64(thumb.Len()), "image/png"); err != nil {
+		return nil, errors.InternalServer("保存头像失败")
+	}
+
+	// 换了图片格式时清理旧的原图，缩略图 key 不变已被覆盖
+	if user.Avatar != "" && user.Avatar != key {
+		if err := h.cfg.AvatarStorage.Delete(ctx, user.Avatar); err != nil {
+			h.logger.Warn("删除旧头像失败", "key", user.Avatar, "error", err)
+		}
+	}
+
+	user.Avatar = key
+	if err := h.users.Save(ctx, user); err != nil {
+		return nil, errors.InternalServer("更新用户信息失败")
+	}
+
+	expiresAt := h.clock.Now().Add(h.cfg.AvatarURLExpiry).UTC()
+	originalURL, err := h.cfg.AvatarStorage.SignedURL(ctx, key, h.cfg.AvatarURLExpiry)
+	if err != nil {
+		return nil, errors.InternalServer("生成下载地址失败")
+	}
+	thumbnailURL, err := h.cfg.AvatarStorage.SignedURL(ctx, thumbnailKey(key), h.cfg.AvatarURLExpiry)
+	if err != nil {
+		return nil, errors.InternalServer("生成下载地址失败")
+	}
+
+	return UploadUserAvatar200JSONResponse{
+		Url:          originalURL,
+		ThumbnailUrl: thumbnailURL,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// readAvatarFile 读取 multipart 表单中的 file 字段，文件和其他字段都有大小上限
+func (h *Server) readAvatarFile(form *multipart.Reader) ([]byte, error) {
+	for {
+		part, err := form.NextPart()
+		if err == io.EOF {
+			return nil, errors.BadRequest("缺少头像文件")
+		}
+		if err != nil {
+			return nil, errors.BadRequest("无效的表单数据")
+		}
+
+		limit := int64(maxAvatarFormField)
+		if part.FormName() == "file" {
+			limit = h.cfg.MaxAvatarSize
+		}
+		data, err := io.ReadAll(io.LimitReader(part, limit+1))
+		part.Close()
+		if err != nil {
+			return nil, errors.BadRequest("读取头像文件失败")
+		}
+		if int64(len(data)) > limit {
+			return nil, errors.New(http.StatusRequestEntityTooLarge, "头像文件过大")
+		}
+		if part.FormName() == "file" {
+			return data, nil
+		}
+	}
+}
+
+// GetUserAvatar 重定向到头像的签名下载地址
+func (h *Server) GetUserAvatar(ctx context.Context, request GetUserAvatarRequestObject) (GetUserAvatarResponseObject, error) {
+	if h.cfg.AvatarStorage == nil {
+		return nil, errors.New(http.StatusNotImplemented, "未配置头像存储")
+	}
+
+	user, err := h.findUser(ctx, request.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Avatar == "" {
+		return nil, errors.NotFound("用户未上传头像")
+	}
+
+	key := user.Avatar
+	if size := request.Params.Size; size != nil {
+		switch *size {
+		case Original:
+		case Thumbnail:
+			key = thumbnailKey(key)
+		default:
+			return nil, errors.BadRequest("无效的头像尺寸")
+		}
+	}
+
+	signed, err := h.cfg.AvatarStorage.SignedURL(ctx, key, h.cfg.AvatarURLExpiry)
+	if err != nil {
+		return nil, errors.InternalServer("生成下载地址失败")
+	}
+
+	return GetUserAvatar302Response{Headers: GetUserAvatar302ResponseHeaders{Location: signed}}, nil
+}
+
+// thumbnail 居中裁剪为正方形后按区域平均缩放到 size x size
+func thumbnail(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0 := y0 + y*side/size
+		sy1 := max(y0+(y+1)*side/size, sy0+1)
+
+		for x := 0; x < size; x++ {
+			sx0 := x0 + x*side/size
+			sx1 := max(x0+(x+1)*side/size, sx0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,146 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/pkg/storage"
+)
+
+func setupAvatarRouter(t *testing.T, cfg Config) (*chi.Mux, *gorm.DB, *storage.Local) {
+	store, err := storage.NewLocal(t.TempDir(), "http://localhost/files", []byte("test-secret"))
+	require.NoError(t, err)
+	cfg.AvatarStorage = store
+
+	r, db := setupRouter(t, cfg)
+	return r, db, store
+}
+
+func pngImage(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func uploadAvatar(r http.Handler, username string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "avatar.png")
+	fw.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/user/"+username+"/avatar", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestUploadUserAvatarHandler(t *testing.T) {
+	t.Parallel()
+
+	r, db, store := setupAvatarRouter(t, Config{})
+	createUserForTest(r, t, "avatarme")
+
+	resp := uploadAvatar(r, "avatarme", pngImage(t, 300, 200))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var avatar Avatar
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &avatar))
+	assert.Contains(t, avatar.Url, "original.png")
+	assert.Contains(t, avatar.ThumbnailUrl, "thumbnail.png")
+
+	// 通过签名地址读取缩略图并检查尺寸
+	u, err := url.Parse(avatar.ThumbnailUrl)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, u.RequestURI(), nil)
+	file := httptest.NewRecorder()
+	http.StripPrefix("/files", store.Handler()).ServeHTTP(file, req)
+	require.Equal(t, http.StatusOK, file.Code)
+
+	cfg, err := png.DecodeConfig(file.Body)
+	require.NoError(t, err)
+	assert.Equal(t, AvatarThumbnailSize, cfg.Width)
+	assert.Equal(t, AvatarThumbnailSize, cfg.Height)
+
+	user, err := FindUserByUsername(db, "avatarme")
+	require.NoError(t, err)
+	assert.Equal(t, avatarKey(user.ID, "png"), user.Avatar)
+}
+
+func TestUploadUserAvatarValidation(t *testing.T) {
+	t.Parallel()
+
+	r, _, _ := setupAvatarRouter(t, Config{MaxAvatarSize: 128})
+	createUserForTest(r, t, "picky")
+
+	resp := uploadAvatar(r, "picky", []byte("definitely not an image"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+
+	resp = uploadAvatar(r, "picky", pngImage(t, 64, 64))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+
+	resp = uploadAvatar(r, "nobody", pngImage(t, 4, 4))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestGetUserAvatarHandler(t *testing.T) {
+	t.Parallel()
+
+	r, _, _ := setupAvatarRouter(t, Config{})
+	createUserForTest(r, t, "redirectme")
+
+	req := httptest.NewRequest(http.MethodGet, "/user/redirectme/avatar", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	require.Equal(t, http.StatusOK, uploadAvatar(r, "redirectme", pngImage(t, 32, 32)).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/user/redirectme/avatar?size=thumbnail", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusFound, resp.Code)
+	assert.Contains(t, resp.Header().Get("Location"), "thumbnail.png")
+	assert.Contains(t, resp.Header().Get("Location"), "signature=")
+}
+
+func TestThumbnailCropsToSquare(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{A: 255}
+			if x >= 10 && x < 30 {
+				c.R = 255
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	dst := thumbnail(src, 8)
+	assert.Equal(t, image.Rect(0, 0, 8, 8), dst.Bounds())
+	// 居中裁剪后只保留红色区域
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, dst.RGBAAt(7, 7))
+}
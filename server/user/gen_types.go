@@ -1,51 +0,0 @@
-// Package user provides primitives to interact with the openapi HTTP API.
-//
-// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
-package user
-
-// Error defines model for Error.
-type Error struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-}
-
-// User defines model for User.
-type User struct {
-	Email     *string `json:"email,omitempty"`
-	FirstName *string `json:"firstName,omitempty"`
-	Id        *int64  `json:"id,omitempty"`
-	LastName  *string `json:"lastName,omitempty"`
-	Password  *string `json:"password,omitempty"`
-	Phone     *string `json:"phone,omitempty"`
-
-	// UserStatus User Status
-	UserStatus *int32  `json:"userStatus,omitempty"`
-	Username   *string `json:"username,omitempty"`
-}
-
-// CreateUsersWithListInputJSONBody defines parameters for CreateUsersWithListInput.
-type CreateUsersWithListInputJSONBody = []User
-
-// LoginUserParams defines parameters for LoginUser.
-type LoginUserParams struct {
-	// Username The user name for login
-	Username *string `form:"username,omitempty" json:"username,omitempty"`
-
-	// Password The password for login in clear text
-	Password *string `form:"password,omitempty" json:"password,omitempty"`
-}
-
-// CreateUserJSONRequestBody defines body for CreateUser for application/json ContentType.
-type CreateUserJSONRequestBody = User
-
-// CreateUserFormdataRequestBody defines body for CreateUser for application/x-www-form-urlencoded ContentType.
-type CreateUserFormdataRequestBody = User
-
-// CreateUsersWithListInputJSONRequestBody defines body for CreateUsersWithListInput for application/json ContentType.
-type CreateUsersWithListInputJSONRequestBody = CreateUsersWithListInputJSONBody
-
-// UpdateUserJSONRequestBody defines body for UpdateUser for application/json ContentType.
-type UpdateUserJSONRequestBody = User
-
-// UpdateUserFormdataRequestBody defines body for UpdateUser for application/x-www-form-urlencoded ContentType.
-type UpdateUserFormdataRequestBody = User
@@ -1,327 +1,272 @@
 package user
 
 import (
-	"encoding/json"
+	"context"
+	stderrors "errors"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"strings"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"gorm.io/gorm"
-
 	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/module"
 )
 
-// DB 是一个全局数据库连接
-// 在实际应用中，应该通过依赖注入或上下文来传递
-var DB *gorm.DB
-
-// CreateUser 处理创建用户的请求
-func CreateUser(w http.ResponseWriter, r *http.Request) {
-	var apiUser User
+// ErrInvalidCredentials 用户名或密码错误
+var ErrInvalidCredentials = stderrors.New("user: invalid credentials")
 
-	// 根据content-type解码请求体
-	contentType := r.Header.Get("Content-Type")
+// Server 用户资源的 HTTP 处理器
+type Server struct {
+	users  UserRepository
+	cfg    Config
+	logger *slog.Logger
+	clock  module.Clock
+}
 
-	if strings.Contains(contentType, "application/json") {
-		if err := json.NewDecoder(r.Body).Decode(&apiUser); err != nil {
-			apiErr := errors.BadRequest("无效的JSON格式")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
-	} else if strings.Contains(contentType, "application/x-www-form-urlencoded") {
-		if err := r.ParseForm(); err != nil {
-			apiErr := errors.BadRequest("无效的表单数据")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+// NewServer 使用指定的仓储创建用户处理器，未设置的配置项取默认值
+func NewServer(users UserRepository, deps module.Deps, cfg Config) *Server {
+	deps = deps.WithDefaults()
+	return &Server{
+		users:  users,
+		cfg:    cfg.withDefaults(),
+		logger: deps.Logger,
+		clock:  deps.Clock,
+	}
+}
 
-		// 解析表单数据
-		if r.Form.Get("username") != "" {
-			username := r.Form.Get("username")
-			apiUser.Username = &username
-		}
-		// 添加其他表单字段...
+// CreateUser 处理创建用户的请求，请求体可以是 JSON 或表单
+func (h *Server) CreateUser(ctx context.Context, request CreateUserRequestObject) (CreateUserResponseObject, error) {
+	apiUser, err := userBody(request.JSONBody, request.FormdataBody)
+	if err != nil {
+		return nil, err
 	}
 
 	// 验证必要字段
 	if apiUser.Username == nil || *apiUser.Username == "" {
-		apiErr := errors.BadRequest("用户名不能为空")
-		errors.WriteJSON(w, apiErr)
-		return
+		return nil, errors.BadRequest("用户名不能为空")
 	}
-
 	if apiUser.Password == nil || *apiUser.Password == "" {
-		apiErr := errors.BadRequest("密码不能为空")
-		errors.WriteJSON(w, apiErr)
-		return
+		return nil, errors.BadRequest("密码不能为空")
+	}
+	if err := h.validateProfile(apiUser); err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("用户资料校验失败: %s", err))
 	}
 
 	// 创建用户
-	user, err := Create(DB, apiUser)
-	if err != nil {
+	user := &UserModel{}
+	if err := FromAPI(user, apiUser); err != nil {
+		return nil, errors.InternalServer("创建用户失败")
+	}
+
+	if err := h.users.Create(ctx, user); err != nil {
 		// 检查是否是唯一性约束错误
-		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
-			apiErr := errors.BadRequest("用户名已存在")
-			errors.WriteJSON(w, apiErr)
-			return
+		if stderrors.Is(err, ErrUsernameTaken) {
+			return nil, errors.BadRequest("用户名已存在")
 		}
-
-		apiErr := errors.InternalServer("创建用户失败")
-		errors.WriteJSON(w, apiErr)
-		return
+		return nil, errors.InternalServer("创建用户失败")
 	}
 
-	// 返回创建的用户
-	apiResponse := user.ToAPI()
+	return CreateUser200JSONResponse(ToAPI(user)), nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(apiResponse)
+// userBody 返回 JSON 或表单格式的用户请求体，暂不支持 XML
+func userBody(jsonBody, formBody *User) (User, error) {
+	switch {
+	case jsonBody != nil:
+		return *jsonBody, nil
+	case formBody != nil:
+		return *formBody, nil
+	default:
+		return User{}, errors.New(http.StatusUnsupportedMediaType, "请求体必须是 JSON 或表单格式")
+	}
 }
 
-// CreateUsersWithListInput 处理批量创建用户的请求
-func CreateUsersWithListInput(w http.ResponseWriter, r *http.Request) {
-	var apiUsers []User
+// ListUsers 查询用户列表，支持按资料属性过滤
+func (h *Server) ListUsers(ctx context.Context, request ListUsersRequestObject) (ListUsersResponseObject, error) {
+	var filters []ProfileFilter
+	if request.Params.Profile != nil {
+		var err error
+		if filters, err = ProfileFilters(*request.Params.Profile); err != nil {
+			return nil, errors.BadRequest(err.Error())
+		}
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&apiUsers); err != nil {
-		apiErr := errors.BadRequest("无效的JSON格式")
-		errors.WriteJSON(w, apiErr)
-		return
+	users, err := h.users.List(ctx, filters)
+	if err != nil {
+		return nil, errors.InternalServer("查询用户列表失败")
 	}
 
-	// 开始事务
-	tx := DB.Begin()
+	apiResponse := make(ListUsers200JSONResponse, 0, len(users))
+	for i := range users {
+		apiResponse = append(apiResponse, ToAPI(&users[i]))
+	}
+	return apiResponse, nil
+}
+
+// CreateUsersWithListInput 处理批量创建用户的请求
+func (h *Server) CreateUsersWithListInput(ctx context.Context, request CreateUsersWithListInputRequestObject) (CreateUsersWithListInputResponseObject, error) {
+	if len(*request.Body) == 0 {
+		return nil, errors.BadRequest("用户列表不能为空")
+	}
 
-	var createdUsers []UserModel
+	var createdUsers []*UserModel
 
-	for _, apiUser := range apiUsers {
+	for _, apiUser := range *request.Body {
 		if apiUser.Username == nil || *apiUser.Username == "" {
-			tx.Rollback()
-			apiErr := errors.BadRequest("用户名不能为空")
-			errors.WriteJSON(w, apiErr)
-			return
+			return nil, errors.BadRequest("用户名不能为空")
 		}
-
 		if apiUser.Password == nil || *apiUser.Password == "" {
-			tx.Rollback()
-			apiErr := errors.BadRequest("密码不能为空")
-			errors.WriteJSON(w, apiErr)
-			return
+			return nil, errors.BadRequest("密码不能为空")
+		}
+		if err := h.validateProfile(apiUser); err != nil {
+			return nil, errors.BadRequest(fmt.Sprintf("用户 %s 的资料校验失败: %s", *apiUser.Username, err))
 		}
 
-		user, err := Create(tx, apiUser)
-		if err != nil {
-			tx.Rollback()
-
-			// 检查是否是唯一性约束错误
-			if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
-				apiErr := errors.BadRequest(fmt.Sprintf("用户名 %s 已存在", *apiUser.Username))
-				errors.WriteJSON(w, apiErr)
-				return
-			}
-
-			apiErr := errors.InternalServer("创建用户失败")
-			errors.WriteJSON(w, apiErr)
-			return
+		user := &UserModel{}
+		if err := FromAPI(user, apiUser); err != nil {
+			return nil, errors.InternalServer("创建用户失败")
 		}
 
-		createdUsers = append(createdUsers, *user)
+		createdUsers = append(createdUsers, user)
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		apiErr := errors.InternalServer("创建用户失败")
-		errors.WriteJSON(w, apiErr)
-		return
+	// 所有用户在同一个事务中创建
+	if err := h.users.Create(ctx, createdUsers...); err != nil {
+		// 检查是否是唯一性约束错误
+		var dupErr *DuplicateUsernameError
+		if stderrors.As(err, &dupErr) {
+			return nil, errors.BadRequest(fmt.Sprintf("用户名 %s 已存在", dupErr.Username))
+		}
+		return nil, errors.InternalServer("创建用户失败")
 	}
 
 	// 返回第一个创建的用户作为响应（按照API定义）
-	if len(createdUsers) > 0 {
-		apiResponse := createdUsers[0].ToAPI()
+	return CreateUsersWithListInput200JSONResponse(ToAPI(createdUsers[0])), nil
+}
+
+// loginToken 登录成功后返回的令牌（在实际应用中应该生成JWT）
+const loginToken = "logged-in-token-would-go-here"
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(apiResponse)
-		return
+// Login 校验用户名和密码并返回登录令牌，用户不存在或密码错误时返回 ErrInvalidCredentials
+func (h *Server) Login(ctx context.Context, username, password string) (string, error) {
+	user, err := h.users.FindByUsername(ctx, username)
+	if err != nil {
+		if stderrors.Is(err, ErrUserNotFound) {
+			return "", ErrInvalidCredentials
+		}
+		return "", err
+	}
+	if err := user.CheckPassword(password); err != nil {
+		return "", ErrInvalidCredentials
 	}
 
-	// 如果没有创建任何用户，返回空响应
-	w.WriteHeader(http.StatusOK)
+	// 迁移遗留的明文密码，失败不影响本次登录
+	if user.NeedsRehash() {
+		if err := user.SetPassword(password); err == nil {
+			if err := h.users.Save(ctx, user); err != nil {
+				h.logger.Warn("迁移明文密码失败", "username", username, "error", err)
+			}
+		}
+	}
+	return loginToken, nil
 }
 
 // LoginUser 处理用户登录
-func LoginUser(w http.ResponseWriter, r *http.Request) {
-	// 从查询参数获取用户名和密码
-	username := r.URL.Query().Get("username")
-	password := r.URL.Query().Get("password")
-
-	if username == "" || password == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+func (h *Server) LoginUser(ctx context.Context, request LoginUserRequestObject) (LoginUserResponseObject, error) {
+	username, password := request.Params.Username, request.Params.Password
+	if username == nil || *username == "" || password == nil || *password == "" {
+		return LoginUser400Response{}, nil
 	}
 
-	// 在数据库中查找用户
-	var user UserModel
-	result := DB.Where("username = ?", username).First(&user)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		apiErr := errors.InternalServer("查询用户信息失败")
-		errors.WriteJSON(w, apiErr)
-		return
+	token, err := h.Login(ctx, *username, *password)
+	if stderrors.Is(err, ErrInvalidCredentials) {
+		return LoginUser400Response{}, nil
 	}
-
-	// 验证密码（在实际应用中应该使用哈希比较）
-	if user.Password != password {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+	if err != nil {
+		return nil, errors.InternalServer("查询用户信息失败")
 	}
 
-	// 设置响应头
-	w.Header().Set("X-Rate-Limit", "100")
-	expiresTime := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
-	w.Header().Set("X-Expires-After", expiresTime)
-
-	// 返回登录令牌（在实际应用中应该生成JWT）
-	token := "logged-in-token-would-go-here"
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(fmt.Sprintf("\"%s\"", token)))
+	return LoginUser200JSONResponse{
+		Body: token,
+		Headers: LoginUser200ResponseHeaders{
+			XRateLimit:    100,
+			XExpiresAfter: h.clock.Now().Add(24 * time.Hour).UTC(),
+		},
+	}, nil
 }
 
 // LogoutUser 处理用户登出
-func LogoutUser(w http.ResponseWriter, r *http.Request) {
+func (h *Server) LogoutUser(ctx context.Context, request LogoutUserRequestObject) (LogoutUserResponseObject, error) {
 	// 在实际应用中，可能需要使登录令牌失效
-	w.WriteHeader(http.StatusOK)
+	return LogoutUser200Response{}, nil
 }
 
 // GetUserByName 根据用户名获取用户
-func GetUserByName(w http.ResponseWriter, r *http.Request) {
-	username := chi.URLParam(r, "username")
-
-	if username == "" {
-		apiErr := errors.BadRequest("用户名不能为空")
-		errors.WriteJSON(w, apiErr)
-		return
-	}
-
-	user, err := FindUserByUsername(DB, username)
+func (h *Server) GetUserByName(ctx context.Context, request GetUserByNameRequestObject) (GetUserByNameResponseObject, error) {
+	user, err := h.findUser(ctx, request.Username)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			apiErr := errors.NotFound("用户不存在")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
-
-		apiErr := errors.InternalServer("查询用户信息失败")
-		errors.WriteJSON(w, apiErr)
-		return
+		return nil, err
 	}
-
-	apiResponse := user.ToAPI()
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(apiResponse)
+	return GetUserByName200JSONResponse(ToAPI(user)), nil
 }
 
 // UpdateUser 更新用户信息
-func UpdateUser(w http.ResponseWriter, r *http.Request) {
-	username := chi.URLParam(r, "username")
-
-	if username == "" {
-		apiErr := errors.BadRequest("用户名不能为空")
-		errors.WriteJSON(w, apiErr)
-		return
-	}
-
+func (h *Server) UpdateUser(ctx context.Context, request UpdateUserRequestObject) (UpdateUserResponseObject, error) {
 	// 首先检查用户是否存在
-	_, err := FindUserByUsername(DB, username)
+	user, err := h.findUser(ctx, request.Username)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			apiErr := errors.NotFound("用户不存在")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
-
-		apiErr := errors.InternalServer("查询用户信息失败")
-		errors.WriteJSON(w, apiErr)
-		return
+		return nil, err
 	}
 
-	var apiUser User
-
-	// 根据content-type解码请求体
-	contentType := r.Header.Get("Content-Type")
-
-	if strings.Contains(contentType, "application/json") {
-		if err := json.NewDecoder(r.Body).Decode(&apiUser); err != nil {
-			apiErr := errors.BadRequest("无效的JSON格式")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
-	} else if strings.Contains(contentType, "application/x-www-form-urlencoded") {
-		if err := r.ParseForm(); err != nil {
-			apiErr := errors.BadRequest("无效的表单数据")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
-
-		// 解析表单数据
-		if r.Form.Get("username") != "" {
-			formUsername := r.Form.Get("username")
-			apiUser.Username = &formUsername
-		}
-		// 添加其他表单字段...
+	apiUser, err := userBody(request.JSONBody, request.FormdataBody)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.validateProfile(apiUser); err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("用户资料校验失败: %s", err))
 	}
 
 	// 更新用户
-	err = Update(DB, username, apiUser)
-	if err != nil {
-		apiErr := errors.InternalServer("更新用户信息失败")
-		errors.WriteJSON(w, apiErr)
-		return
+	if err := FromAPI(user, apiUser); err != nil {
+		return nil, errors.InternalServer("更新用户信息失败")
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if err := h.users.Save(ctx, user); err != nil {
+		if stderrors.Is(err, ErrUsernameTaken) {
+			return nil, errors.BadRequest("用户名已存在")
+		}
+		return nil, errors.InternalServer("更新用户信息失败")
+	}
+
+	return UpdateUser200Response{}, nil
 }
 
 // DeleteUser 删除用户
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
-	username := chi.URLParam(r, "username")
+func (h *Server) DeleteUser(ctx context.Context, request DeleteUserRequestObject) (DeleteUserResponseObject, error) {
+	// 首先检查用户是否存在
+	if _, err := h.findUser(ctx, request.Username); err != nil {
+		return nil, err
+	}
 
-	if username == "" {
-		apiErr := errors.BadRequest("用户名不能为空")
-		errors.WriteJSON(w, apiErr)
-		return
+	// 删除用户
+	if err := h.users.Delete(ctx, request.Username); err != nil {
+		return nil, errors.InternalServer("删除用户失败")
 	}
 
-	// 首先检查用户是否存在
-	_, err := FindUserByUsername(DB, username)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			apiErr := errors.NotFound("用户不存在")
-			errors.WriteJSON(w, apiErr)
-			return
-		}
+	return DeleteUser200Response{}, nil
+}
 
-		apiErr := errors.InternalServer("查询用户信息失败")
-		errors.WriteJSON(w, apiErr)
-		return
+// findUser 查找用户名对应的用户，失败时返回对应的 API 错误
+func (h *Server) findUser(ctx context.Context, username string) (*UserModel, error) {
+	if username == "" {
+		return nil, errors.BadRequest("用户名不能为空")
 	}
-
-	// 删除用户
-	err = Delete(DB, username)
+	user, err := h.users.FindByUsername(ctx, username)
 	if err != nil {
-		apiErr := errors.InternalServer("删除用户失败")
-		errors.WriteJSON(w, apiErr)
-		return
+		if stderrors.Is(err, ErrUserNotFound) {
+			return nil, errors.NotFound("用户不存在")
+		}
+		return nil, errors.InternalServer("查询用户信息失败")
 	}
-
-	w.WriteHeader(http.StatusOK)
+	return user, nil
 }
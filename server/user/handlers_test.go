@@ -10,23 +10,27 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
-)
 
-// 使用 sqlite :memory: 作为测试数据库
-func SetDB(db *gorm.DB) {
-	DB = db
-}
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
 
-func setupRouterWithDB(t *testing.T) *chi.Mux {
+// setupRouter 为每个测试创建独立的数据库和用户模块，测试之间可以并行
+func setupRouter(t *testing.T, cfg Config) (*chi.Mux, *gorm.DB) {
 	db := setupTestDB(t)
-	SetDB(db) // 你需要确保你的 handler 能使用这个 DB 实例，或者你可以在 handler 内部注入
 
 	r := chi.NewRouter()
-	RegisterRoutes(r)
+	New(module.Deps{DB: db}, cfg).Routes(r)
+	return r, db
+}
+
+func setupRouterWithDB(t *testing.T) *chi.Mux {
+	r, _ := setupRouter(t, Config{})
 	return r
 }
 
 func TestCreateUserHandler(t *testing.T) {
+	t.Parallel()
+
 	r := setupRouterWithDB(t)
 
 	body := User{
@@ -49,6 +53,8 @@ func TestCreateUserHandler(t *testing.T) {
 }
 
 func TestGetUserByNameHandler(t *testing.T) {
+	t.Parallel()
+
 	r := setupRouterWithDB(t)
 
 	// 先创建用户
@@ -63,6 +69,8 @@ func TestGetUserByNameHandler(t *testing.T) {
 }
 
 func TestUpdateUserHandler(t *testing.T) {
+	t.Parallel()
+
 	r := setupRouterWithDB(t)
 
 	createUserForTest(r, t, "updateme")
@@ -79,6 +87,8 @@ func TestUpdateUserHandler(t *testing.T) {
 }
 
 func TestDeleteUserHandler(t *testing.T) {
+	t.Parallel()
+
 	r := setupRouterWithDB(t)
 
 	createUserForTest(r, t, "deleteme")
@@ -107,3 +117,26 @@ func createUserForTest(r http.Handler, t *testing.T, username string) {
 		t.Fatalf("createUserForTest failed: %s", resp.Body.String())
 	}
 }
+
+func TestLoginUserHandler(t *testing.T) {
+	t.Parallel()
+
+	r, db := setupRouter(t, Config{})
+
+	createUserForTest(r, t, "loginme")
+
+	// 密码以哈希形式保存
+	user, err := FindUserByUsername(db, "loginme")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "pass", user.PasswordEncrypted)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/login?username=loginme&password=pass", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/user/login?username=loginme&password=wrong", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -1,36 +1,19 @@
 package user
 
 import (
-	"time"
+	"encoding/json"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
-)
 
-// UserModel 定义用户在数据库中的表示
-// 实现了 User API 模型到数据库模型的映射
-type UserModel struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	"github.com/twotwo/go-blueprint/domain"
+)
 
-	// 用户基本信息
-	Username   string `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	FirstName  string `gorm:"size:50" json:"firstName"`
-	LastName   string `gorm:"size:50" json:"lastName"`
-	Email      string `gorm:"size:100" json:"email"`
-	Password   string `gorm:"size:100;not null" json:"-"` // 密码不应在JSON中暴露
-	Phone      string `gorm:"size:20" json:"phone"`
-	UserStatus int32  `gorm:"default:0" json:"userStatus"` // 用户状态，0=非活跃, 1=活跃
-}
-
-// TableName 指定用户表名
-func (UserModel) TableName() string {
-	return "users"
-}
+// UserModel 用户在数据库中的表示，即统一的用户领域模型
+type UserModel = domain.User
 
 // ToAPI 将数据库模型转换为API模型
-func (u *UserModel) ToAPI() User {
+func ToAPI(u *UserModel) User {
 	id := int64(u.ID)
 	return User{
 		Id:         &id,
@@ -40,12 +23,22 @@ func (u *UserModel) ToAPI() User {
 		Email:      &u.Email,
 		Phone:      &u.Phone,
 		UserStatus: &u.UserStatus,
+		Profile:    profileMap(u),
 		// 注意：不返回密码
 	}
 }
 
-// FromAPI 将API模型转换为数据库模型
-func (u *UserModel) FromAPI(apiUser User) {
+// profileMap 将 Profile 列解码为 map，无法解码时返回空 map
+func profileMap(u *UserModel) *map[string]interface{} {
+	profile := map[string]interface{}{}
+	if len(u.Profile) > 0 {
+		_ = json.Unmarshal(u.Profile, &profile)
+	}
+	return &profile
+}
+
+// FromAPI 将API模型转换为数据库模型，密码会被哈希后保存
+func FromAPI(u *UserModel, apiUser User) error {
 	if apiUser.Id != nil {
 		u.ID = uint(*apiUser.Id)
 	}
@@ -62,7 +55,9 @@ func (u *UserModel) FromAPI(apiUser User) {
 		u.Email = *apiUser.Email
 	}
 	if apiUser.Password != nil {
-		u.Password = *apiUser.Password // 在实际应用中应该先哈希处理
+		if err := u.SetPassword(*apiUser.Password); err != nil {
+			return err
+		}
 	}
 	if apiUser.Phone != nil {
 		u.Phone = *apiUser.Phone
@@ -70,6 +65,12 @@ func (u *UserModel) FromAPI(apiUser User) {
 	if apiUser.UserStatus != nil {
 		u.UserStatus = *apiUser.UserStatus
 	}
+	if apiUser.Profile != nil {
+		// map[string]interface{} 来自 JSON 解码，序列化不会失败
+		profile, _ := json.Marshal(*apiUser.Profile)
+		u.Profile = datatypes.JSON(profile)
+	}
+	return nil
 }
 
 // FindUserByUsername 根据用户名查找用户
@@ -85,10 +86,9 @@ func FindUserByUsername(db *gorm.DB, username string) (*UserModel, error) {
 // CreateUser 创建新用户
 func Create(db *gorm.DB, apiUser User) (*UserModel, error) {
 	var user UserModel
-	user.FromAPI(apiUser)
-
-	// 在实际应用中应该进行密码哈希处理
-	// user.Password = hashPassword(user.Password)
+	if err := FromAPI(&user, apiUser); err != nil {
+		return nil, err
+	}
 
 	result := db.Create(&user)
 	if result.Error != nil {
@@ -104,7 +104,9 @@ func Update(db *gorm.DB, username string, apiUser User) error {
 		return err
 	}
 
-	user.FromAPI(apiUser)
+	if err := FromAPI(user, apiUser); err != nil {
+		return err
+	}
 	return db.Save(user).Error
 }
 
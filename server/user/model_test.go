@@ -1,6 +1,8 @@
 package user
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,17 +10,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// setupTestDB 为每个测试创建独立命名的内存数据库
+// 普通的 :memory: 每个连接各自一个库，连接池扩容后会看不到已建的表
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	assert.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
 
-	err = db.AutoMigrate(&UserModel{})
+	err = db.AutoMigrate(append([]interface{}{&UserModel{}}, Models()...)...)
 	assert.NoError(t, err)
 
 	return db
 }
 
 func TestCreateUser(t *testing.T) {
+	t.Parallel()
+
 	db := setupTestDB(t)
 
 	u := User{
@@ -36,6 +48,8 @@ func TestCreateUser(t *testing.T) {
 }
 
 func TestFindUserByUsername(t *testing.T) {
+	t.Parallel()
+
 	db := setupTestDB(t)
 
 	u := User{Username: ptr("janedoe"), Password: ptr("pwd")}
@@ -47,6 +61,8 @@ func TestFindUserByUsername(t *testing.T) {
 }
 
 func TestUpdateUser(t *testing.T) {
+	t.Parallel()
+
 	db := setupTestDB(t)
 
 	_, _ = Create(db, User{Username: ptr("changeme"), Email: ptr("old@e.com")})
@@ -58,6 +74,8 @@ func TestUpdateUser(t *testing.T) {
 }
 
 func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+
 	db := setupTestDB(t)
 
 	_, _ = Create(db, User{Username: ptr("tobedeleted")})
@@ -69,6 +87,8 @@ func TestDeleteUser(t *testing.T) {
 }
 
 func TestListUsers(t *testing.T) {
+	t.Parallel()
+
 	db := setupTestDB(t)
 
 	_, _ = Create(db, User{Username: ptr("a")})
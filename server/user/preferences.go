@@ -0,0 +1,204 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+)
+
+// DefaultMandatoryCategories 默认不能关闭的通知类别：安全提醒，如验证码和异地登录提醒
+var DefaultMandatoryCategories = []string{"security"}
+
+// notificationMedia 可以选择的送达媒介，与消息模块中面向用户的消息类型一致
+var notificationMedia = []string{"sms", "sitemessage", "email", "wechat"}
+
+// maxNotificationCategoryLength 类别名的最大长度，与消息表的 category 列一致
+const maxNotificationCategoryLength = 50
+
+// NotificationPreferencesModel 用户的通知偏好，每个用户一条记录
+// Media 是各媒介的总开关，未列出的媒介默认接收；Categories 指定某类消息只通过哪些媒介送达，
+// 空列表表示不接收该类消息，未列出的类别只受 Media 约束
+type NotificationPreferencesModel struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint                `gorm:"not null;uniqueIndex"`
+	Media      map[string]bool     `gorm:"serializer:json;type:text"`
+	Categories map[string][]string `gorm:"serializer:json;type:text"`
+}
+
+// TableName 指定通知偏好表名
+func (NotificationPreferencesModel) TableName() string {
+	return "user_notification_preferences"
+}
+
+// Allows 判断偏好是否允许通过 medium 送达 category 类别的消息，不考虑强制类别
+func (p *NotificationPreferencesModel) Allows(category, medium string) bool {
+	if enabled, ok := p.Media[medium]; ok && !enabled {
+		return false
+	}
+	media, ok := p.Categories[category]
+	if category == "" || !ok {
+		return true
+	}
+	return slices.Contains(media, medium)
+}
+
+// clone 返回不与 p 共享 map 和切片的副本
+func (p *NotificationPreferencesModel) clone() *NotificationPreferencesModel {
+	c := *p
+	c.Media = maps.Clone(p.Media)
+	if p.Categories != nil {
+		c.Categories = make(map[string][]string, len(p.Categories))
+		for category, media := range p.Categories {
+			c.Categories[category] = slices.Clone(media)
+		}
+	}
+	return &c
+}
+
+// ToAPI 将通知偏好转换为API模型，mandatory 为不能关闭的类别
+func (p *NotificationPreferencesModel) ToAPI(mandatory []string) NotificationPreferences {
+	media := p.Media
+	if media == nil {
+		media = map[string]bool{}
+	}
+	categories := p.Categories
+	if categories == nil {
+		categories = map[string][]string{}
+	}
+	api := NotificationPreferences{
+		Media:      &media,
+		Categories: &categories,
+		Mandatory:  &mandatory,
+	}
+	if !p.UpdatedAt.IsZero() {
+		api.UpdatedAt = &p.UpdatedAt
+	}
+	return api
+}
+
+// FromNotificationPreferencesAPI 校验API模型并转换为通知偏好，mandatory 中的类别不能出现在 categories 中
+func FromNotificationPreferencesAPI(userID uint, api NotificationPreferences, mandatory []string) (*NotificationPreferencesModel, []errors.FieldError) {
+	p := &NotificationPreferencesModel{UserID: userID, Media: map[string]bool{}, Categories: map[string][]string{}}
+	var fields []errors.FieldError
+	if api.Media != nil {
+		for _, medium := range slices.Sorted(maps.Keys(*api.Media)) {
+			if !slices.Contains(notificationMedia, medium) {
+				fields = append(fields, errors.FieldError{Field: "media." + medium, Message: fmt.Sprintf("不支持的媒介: %s", medium)})
+				continue
+			}
+			p.Media[medium] = (*api.Media)[medium]
+		}
+	}
+	if api.Categories != nil {
+		for _, category := range slices.Sorted(maps.Keys(*api.Categories)) {
+			field := "categories." + category
+			switch {
+			case category == "" || utf8.RuneCountInString(category) > maxNotificationCategoryLength:
+				fields = append(fields, errors.FieldError{Field: field, Message: fmt.Sprintf("类别名长度必须在 1 到 %d 之间", maxNotificationCategoryLength)})
+				continue
+			case slices.Contains(mandatory, category):
+				fields = append(fields, errors.FieldError{Field: field, Message: fmt.Sprintf("%s 类消息不能关闭", category)})
+				continue
+			}
+			media := make([]string, 0, len((*api.Categories)[category]))
+			for i, medium := range (*api.Categories)[category] {
+				if !slices.Contains(notificationMedia, medium) {
+					fields = append(fields, errors.FieldError{Field: fmt.Sprintf("%s[%d]", field, i), Message: fmt.Sprintf("不支持的媒介: %s", medium)})
+					continue
+				}
+				if !slices.Contains(media, medium) {
+					media = append(media, medium)
+				}
+			}
+			p.Categories[category] = media
+		}
+	}
+	if len(fields) > 0 {
+		return nil, fields
+	}
+	return p, nil
+}
+
+// NotificationTarget 消息的接收方，依次按 UserID、Phone、Email 确定是哪个用户
+type NotificationTarget struct {
+	UserID uint
+	Phone  string
+	Email  string
+}
+
+// AllowsNotification 判断是否通过 medium 向接收方发送 category 类别的消息
+// 强制类别总是允许；接收方不是已注册的用户或没有设置过偏好时也允许
+func (h *Server) AllowsNotification(ctx context.Context, target NotificationTarget, category, medium string) (bool, error) {
+	if slices.Contains(h.cfg.MandatoryCategories, category) {
+		return true, nil
+	}
+
+	userID := target.UserID
+	if userID == 0 {
+		if target.Phone == "" && target.Email == "" {
+			return true, nil
+		}
+		user, err := h.users.FindByContact(ctx, target.Phone, target.Email)
+		if stderrors.Is(err, ErrUserNotFound) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		userID = user.ID
+	}
+
+	prefs, err := h.users.FindNotificationPreferences(ctx, userID)
+	if stderrors.Is(err, ErrPreferencesNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return prefs.Allows(category, medium), nil
+}
+
+// GetNotificationPreferences 查询用户的通知偏好，未设置过时返回全部接收的默认偏好
+func (h *Server) GetNotificationPreferences(ctx context.Context, request GetNotificationPreferencesRequestObject) (GetNotificationPreferencesResponseObject, error) {
+	user, err := h.findUser(ctx, request.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := h.users.FindNotificationPreferences(ctx, user.ID)
+	if stderrors.Is(err, ErrPreferencesNotFound) {
+		prefs, err = &NotificationPreferencesModel{UserID: user.ID}, nil
+	}
+	if err != nil {
+		return nil, errors.InternalServer("查询通知偏好失败")
+	}
+
+	return GetNotificationPreferences200JSONResponse(prefs.ToAPI(h.cfg.MandatoryCategories)), nil
+}
+
+// UpdateNotificationPreferences 覆盖用户的通知偏好
+func (h *Server) UpdateNotificationPreferences(ctx context.Context, request UpdateNotificationPreferencesRequestObject) (UpdateNotificationPreferencesResponseObject, error) {
+	user, err := h.findUser(ctx, request.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, fields := FromNotificationPreferencesAPI(user.ID, *request.Body, h.cfg.MandatoryCategories)
+	if len(fields) > 0 {
+		return nil, errors.Validation(fields...)
+	}
+	if err := h.users.SaveNotificationPreferences(ctx, prefs); err != nil {
+		h.logger.Error("保存通知偏好失败", "user", user.ID, "error", err)
+		return nil, errors.InternalServer("保存通知偏好失败")
+	}
+
+	return UpdateNotificationPreferences200JSONResponse(prefs.ToAPI(h.cfg.MandatoryCategories)), nil
+}
@@ -0,0 +1,190 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+func TestNotificationPreferencesAllows(t *testing.T) {
+	t.Parallel()
+
+	prefs := &NotificationPreferencesModel{
+		Media:      map[string]bool{"sms": false, "email": true},
+		Categories: map[string][]string{"news": {"sitemessage"}, "promotion": {}},
+	}
+	assert.False(t, prefs.Allows("billing", "sms"), "关闭的媒介不接收任何类别")
+	assert.True(t, prefs.Allows("billing", "email"))
+	assert.True(t, prefs.Allows("", "wechat"), "未列出的媒介默认接收")
+	assert.True(t, prefs.Allows("news", "sitemessage"))
+	assert.False(t, prefs.Allows("news", "email"))
+	assert.False(t, prefs.Allows("promotion", "sitemessage"))
+	assert.True(t, (&NotificationPreferencesModel{}).Allows("promotion", "sms"))
+}
+
+func TestNotificationPreferencesRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			alice := &UserModel{Username: "alice", Phone: "13800138000", Email: "Alice@Example.com"}
+			bob := &UserModel{Username: "bob", Phone: "13800138001"}
+			require.NoError(t, repo.Create(ctx, alice, bob))
+
+			found, err := repo.FindByContact(ctx, "13800138001", "")
+			require.NoError(t, err)
+			assert.Equal(t, bob.ID, found.ID)
+			found, err = repo.FindByContact(ctx, "", "alice@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, alice.ID, found.ID)
+			_, err = repo.FindByContact(ctx, "13900000000", "alice@example.com")
+			assert.ErrorIs(t, err, ErrUserNotFound, "提供手机号时只按手机号匹配")
+
+			// 手机号保存和查找时都会规范化，不同写法能匹配到同一个用户
+			carol := &UserModel{Username: "carol", Phone: "+86 138-0013-8002"}
+			require.NoError(t, repo.Create(ctx, carol))
+			assert.Equal(t, "8613800138002", carol.Phone)
+			for _, phone := range []string{"8613800138002", "+8613800138002", "+86 138 0013 8002"} {
+				found, err = repo.FindByContact(ctx, phone, "")
+				require.NoError(t, err, phone)
+				assert.Equal(t, carol.ID, found.ID, phone)
+			}
+			bob.Phone = "+86 139-0013-9001"
+			require.NoError(t, repo.Save(ctx, bob))
+			found, err = repo.FindByContact(ctx, "8613900139001", "")
+			require.NoError(t, err)
+			assert.Equal(t, bob.ID, found.ID)
+
+			_, err = repo.FindNotificationPreferences(ctx, alice.ID)
+			assert.ErrorIs(t, err, ErrPreferencesNotFound)
+
+			prefs := &NotificationPreferencesModel{UserID: alice.ID, Media: map[string]bool{"sms": false}}
+			require.NoError(t, repo.SaveNotificationPreferences(ctx, prefs))
+			stored, err := repo.FindNotificationPreferences(ctx, alice.ID)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]bool{"sms": false}, stored.Media)
+
+			// 再次保存时覆盖原有偏好
+			require.NoError(t, repo.SaveNotificationPreferences(ctx, &NotificationPreferencesModel{
+				UserID:     alice.ID,
+				Media:      map[string]bool{},
+				Categories: map[string][]string{"news": {"email"}},
+			}))
+			stored, err = repo.FindNotificationPreferences(ctx, alice.ID)
+			require.NoError(t, err)
+			assert.Empty(t, stored.Media)
+			assert.Equal(t, map[string][]string{"news": {"email"}}, stored.Categories)
+			assert.False(t, stored.UpdatedAt.IsZero())
+
+			_, err = repo.FindNotificationPreferences(ctx, bob.ID)
+			assert.ErrorIs(t, err, ErrPreferencesNotFound)
+		})
+	}
+}
+
+func TestNotificationPreferencesHandler(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMemoryRepository(nil)
+	require.NoError(t, repo.Create(context.Background(), &UserModel{Username: "alice"}))
+	r := chi.NewRouter()
+	NewServer(repo, module.Deps{}, Config{}).Routes(r)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return resp
+	}
+
+	// 未设置过时全部接收
+	resp := do(http.MethodGet, "/user/alice/notification-preferences", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var prefs NotificationPreferences
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &prefs))
+	assert.Empty(t, *prefs.Media)
+	assert.Empty(t, *prefs.Categories)
+	assert.Equal(t, []string{"security"}, *prefs.Mandatory)
+	assert.Nil(t, prefs.UpdatedAt)
+
+	resp = do(http.MethodPut, "/user/alice/notification-preferences",
+		`{"media":{"sms":false},"categories":{"promotion":[],"news":["sitemessage","sitemessage"]}}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	resp = do(http.MethodGet, "/user/alice/notification-preferences", "")
+	prefs = NotificationPreferences{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &prefs))
+	assert.Equal(t, map[string]bool{"sms": false}, *prefs.Media)
+	assert.Equal(t, map[string][]string{"promotion": {}, "news": {"sitemessage"}}, *prefs.Categories)
+	assert.NotNil(t, prefs.UpdatedAt)
+
+	// 强制类别不能关闭，不支持的媒介按字段报告
+	resp = do(http.MethodPut, "/user/alice/notification-preferences",
+		`{"media":{"fax":true},"categories":{"security":[],"news":["pager"]}}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	var apiErr errors.APIError
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &apiErr))
+	assert.Equal(t, []errors.FieldError{
+		{Field: "media.fax", Message: "不支持的媒介: fax"},
+		{Field: "categories.news[0]", Message: "不支持的媒介: pager"},
+		{Field: "categories.security", Message: "security 类消息不能关闭"},
+	}, apiErr.Fields)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/user/alice/notification-preferences", `[]`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/user/nobody/notification-preferences", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/user/nobody/notification-preferences", `{}`).Code)
+}
+
+func TestAllowsNotification(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := NewMemoryRepository(nil)
+	alice := &UserModel{Username: "alice", Phone: "13800138000", Email: "alice@example.com"}
+	dave := &UserModel{Username: "dave", Phone: "+86 138-0013-8009"}
+	require.NoError(t, repo.Create(ctx, alice, dave))
+	require.NoError(t, repo.SaveNotificationPreferences(ctx, &NotificationPreferencesModel{
+		UserID:     alice.ID,
+		Media:      map[string]bool{"sms": false},
+		Categories: map[string][]string{"promotion": {}},
+	}))
+	require.NoError(t, repo.SaveNotificationPreferences(ctx, &NotificationPreferencesModel{
+		UserID:     dave.ID,
+		Categories: map[string][]string{"promotion": {}},
+	}))
+	h := NewServer(repo, module.Deps{}, Config{MandatoryCategories: []string{"security", "account"}})
+
+	for _, tc := range []struct {
+		target   NotificationTarget
+		category string
+		medium   string
+		allowed  bool
+	}{
+		{NotificationTarget{Phone: "13800138000"}, "billing", "sms", false},
+		{NotificationTarget{Phone: "13800138000"}, "security", "sms", true},
+		{NotificationTarget{Phone: "13800138000"}, "account", "sms", true},
+		{NotificationTarget{Email: "ALICE@example.com"}, "billing", "email", true},
+		// 消息模块传入规范化后的手机号，与用户保存时的写法不同也能找到偏好
+		{NotificationTarget{Phone: "8613800138009"}, "promotion", "sms", false},
+		{NotificationTarget{Phone: "8613800138009"}, "news", "sms", true},
+		{NotificationTarget{UserID: alice.ID}, "promotion", "sitemessage", false},
+		{NotificationTarget{UserID: alice.ID}, "news", "sitemessage", true},
+		// 未注册的接收方和没有偏好的用户不受约束
+		{NotificationTarget{Phone: "13900000000"}, "promotion", "sms", true},
+		{NotificationTarget{UserID: dave.ID + 1}, "promotion", "sitemessage", true},
+		{NotificationTarget{}, "promotion", "wechat", true},
+	} {
+		allowed, err := h.AllowsNotification(ctx, tc.target, tc.category, tc.medium)
+		require.NoError(t, err)
+		assert.Equal(t, tc.allowed, allowed, "%+v %s %s", tc.target, tc.category, tc.medium)
+	}
+}
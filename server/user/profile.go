@@ -0,0 +1,90 @@
+package user
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/pkg/jsonschema"
+)
+
+// profileKeyPattern 限制过滤路径中每一段的字符，避免构造出非法的 JSON 路径
+var profileKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateProfile 按 schema 校验用户资料，schema 为 nil 或未提供资料时直接通过
+func ValidateProfile(schema *jsonschema.Schema, apiUser User) error {
+	if schema == nil || apiUser.Profile == nil {
+		return nil
+	}
+	return schema.Validate(map[string]interface{}(*apiUser.Profile))
+}
+
+// validateProfile 按模块配置的 ProfileSchema 校验用户资料
+func (h *Server) validateProfile(apiUser User) error {
+	return ValidateProfile(h.cfg.ProfileSchema, apiUser)
+}
+
+// ProfileFilter 表示对资料中某个路径的等值过滤
+type ProfileFilter struct {
+	Keys  []string // JSON 路径，例如 address.city 对应 ["address", "city"]
+	Value string   // 查询参数中的原始比较值
+}
+
+// ProfileFilters 将按 deepObject 解析的 profile 查询参数（资料路径 -> 比较值）转换为过滤条件
+func ProfileFilters(profile map[string]string) ([]ProfileFilter, error) {
+	filters := make([]ProfileFilter, 0, len(profile))
+	for path, value := range profile {
+		keys, err := profileKeys(path)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, ProfileFilter{Keys: keys, Value: value})
+	}
+	return filters, nil
+}
+
+// profileKeys 将 address.city 形式的资料路径拆分为各段并校验
+func profileKeys(path string) ([]string, error) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if !profileKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("无效的资料路径: %s", path)
+		}
+	}
+	return keys, nil
+}
+
+// profileFilterValue 将查询字符串转换为对应方言可比较的值
+// Postgres 的 json_extract_path_text 返回文本，直接比较原始字符串；
+// SQLite/MySQL 的 JSON_EXTRACT 会保留类型，需要按类型比较才能命中数值和布尔值
+func profileFilterValue(dialect, s string) interface{} {
+	if dialect == "postgres" {
+		return s
+	}
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// FindUsersByProfile 查询资料满足所有过滤条件的用户
+func FindUsersByProfile(db *gorm.DB, filters []ProfileFilter) ([]UserModel, error) {
+	query := db.Model(&UserModel{})
+	for _, f := range filters {
+		value := profileFilterValue(db.Dialector.Name(), f.Value)
+		query = query.Where(datatypes.JSONQuery("profile").Equals(value, f.Keys...))
+	}
+
+	var users []UserModel
+	if err := query.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,128 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twotwo/go-blueprint/pkg/jsonschema"
+)
+
+func TestFindUsersByProfile(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestDB(t)
+
+	_, err := Create(db, User{Username: ptr("bj"), Profile: &map[string]interface{}{
+		"vip": true, "level": 3, "address": map[string]interface{}{"city": "Beijing"},
+	}})
+	require.NoError(t, err)
+	_, err = Create(db, User{Username: ptr("sh"), Profile: &map[string]interface{}{
+		"vip": false, "level": 3, "address": map[string]interface{}{"city": "Shanghai"},
+	}})
+	require.NoError(t, err)
+	_, err = Create(db, User{Username: ptr("none")})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		profile map[string]string
+		want    []string
+	}{
+		{"无过滤", nil, []string{"bj", "sh", "none"}},
+		{"嵌套路径", map[string]string{"address.city": "Beijing"}, []string{"bj"}},
+		{"数值", map[string]string{"level": "3"}, []string{"bj", "sh"}},
+		{"布尔值", map[string]string{"vip": "false"}, []string{"sh"}},
+		{"多个条件", map[string]string{"level": "3", "vip": "true"}, []string{"bj"}},
+		{"无匹配", map[string]string{"address.city": "Guangzhou"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := ProfileFilters(tt.profile)
+			require.NoError(t, err)
+
+			users, err := FindUsersByProfile(db, filters)
+			require.NoError(t, err)
+
+			var names []string
+			for _, u := range users {
+				names = append(names, u.Username)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestProfileFiltersRejectsInvalidPath(t *testing.T) {
+	t.Parallel()
+
+	_, err := ProfileFilters(map[string]string{"a'b": "x"})
+	assert.Error(t, err)
+}
+
+func TestProfileRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestDB(t)
+
+	_, err := Create(db, User{Username: ptr("profiled"), Profile: &map[string]interface{}{"nickname": "pp"}})
+	require.NoError(t, err)
+
+	found, err := FindUserByUsername(db, "profiled")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"nickname": "pp"}, *ToAPI(found).Profile)
+
+	// 未设置资料时返回空对象
+	_, err = Create(db, User{Username: ptr("plain")})
+	require.NoError(t, err)
+	found, err = FindUserByUsername(db, "plain")
+	require.NoError(t, err)
+	assert.Empty(t, *ToAPI(found).Profile)
+}
+
+func TestProfileSchemaValidationHandler(t *testing.T) {
+	t.Parallel()
+
+	schema, err := jsonschema.Parse([]byte(`{
+		"type": "object",
+		"properties": {"age": {"type": "integer", "minimum": 0}},
+		"additionalProperties": false
+	}`))
+	require.NoError(t, err)
+	r, _ := setupRouter(t, Config{ProfileSchema: schema})
+
+	post := func(profile map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(User{Username: ptr("schema"), Password: ptr("pwd"), Profile: &profile})
+		req := httptest.NewRequest(http.MethodPost, "/user", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := post(map[string]interface{}{"age": -1})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "$.age")
+
+	resp = post(map[string]interface{}{"unknown": 1})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = post(map[string]interface{}{"age": 20})
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/user?profile[age]=20", nil)
+	list := httptest.NewRecorder()
+	r.ServeHTTP(list, req)
+	require.Equal(t, http.StatusOK, list.Code)
+
+	var users []User
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &users))
+	require.Len(t, users, 1)
+	assert.Equal(t, "schema", *users[0].Username)
+}
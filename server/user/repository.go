@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("user: not found")
+
+	// ErrUsernameTaken 用户名已被占用
+	ErrUsernameTaken = errors.New("user: username already exists")
+
+	// ErrPreferencesNotFound 用户从未设置过通知偏好
+	ErrPreferencesNotFound = errors.New("user: notification preferences not found")
+)
+
+// DuplicateUsernameError 指出是哪个用户名冲突，可以用 errors.Is(err, ErrUsernameTaken) 判断
+type DuplicateUsernameError struct {
+	Username string
+}
+
+// Error 实现error接口
+func (e *DuplicateUsernameError) Error() string {
+	return fmt.Sprintf("user: username %q already exists", e.Username)
+}
+
+// Is 使 errors.Is(err, ErrUsernameTaken) 成立
+func (e *DuplicateUsernameError) Is(target error) bool {
+	return target == ErrUsernameTaken
+}
+
+// UserRepository 用户数据访问接口
+// 处理器只依赖该接口，可以替换为不同的存储实现
+type UserRepository interface {
+	// FindByUsername 根据用户名查找用户，不存在时返回 ErrUserNotFound
+	FindByUsername(ctx context.Context, username string) (*UserModel, error)
+
+	// List 查询资料满足所有过滤条件的用户，按 ID 升序
+	List(ctx context.Context, filters []ProfileFilter) ([]UserModel, error)
+
+	// Create 创建一个或多个用户，任一失败则全部不生效
+	// 用户名冲突时返回 *DuplicateUsernameError
+	Create(ctx context.Context, users ...*UserModel) error
+
+	// Save 保存已存在用户的所有字段，新用户名已被占用时返回 *DuplicateUsernameError
+	Save(ctx context.Context, user *UserModel) error
+
+	// Delete 根据用户名删除用户
+	Delete(ctx context.Context, username string) error
+
+	// FindByContact 按联系方式查找用户：phone 非空时按 domain.NormalizePhone 规范化后精确匹配，否则按邮箱不区分大小写匹配
+	// 用户的手机号在保存时已经规范化
+	// 有多个匹配时返回 ID 最小的用户，不存在时返回 ErrUserNotFound
+	FindByContact(ctx context.Context, phone, email string) (*UserModel, error)
+
+	// FindNotificationPreferences 查询用户的通知偏好，未设置过时返回 ErrPreferencesNotFound
+	FindNotificationPreferences(ctx context.Context, userID uint) (*NotificationPreferencesModel, error)
+
+	// SaveNotificationPreferences 创建或覆盖用户的通知偏好
+	SaveNotificationPreferences(ctx context.Context, prefs *NotificationPreferencesModel) error
+}
@@ -0,0 +1,124 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/twotwo/go-blueprint/domain"
+)
+
+// AfterCreateFunc 在创建用户的事务中、用户写入后调用，返回错误时整个事务回滚
+// 用于原子地写入关联数据，例如通过 message.CreateInTx 发送欢迎消息
+type AfterCreateFunc func(tx *gorm.DB, user *UserModel) error
+
+// GormRepository 基于 gorm 的 UserRepository 实现，支持 SQLite/MySQL/Postgres
+type GormRepository struct {
+	db          *gorm.DB
+	afterCreate []AfterCreateFunc
+}
+
+// ensure that we've conformed to the `UserRepository` with a compile-time check
+var _ UserRepository = (*GormRepository)(nil)
+
+// NewGormRepository 创建基于 gorm 的用户仓储，afterCreate 按顺序在创建每个用户的事务中调用
+func NewGormRepository(db *gorm.DB, afterCreate ...AfterCreateFunc) *GormRepository {
+	return &GormRepository{db: db, afterCreate: afterCreate}
+}
+
+// FindByUsername 根据用户名查找用户
+func (r *GormRepository) FindByUsername(ctx context.Context, username string) (*UserModel, error) {
+	user, err := FindUserByUsername(r.db.WithContext(ctx), username)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// List 按资料过滤条件查询用户
+func (r *GormRepository) List(ctx context.Context, filters []ProfileFilter) ([]UserModel, error) {
+	return FindUsersByProfile(r.db.WithContext(ctx), filters)
+}
+
+// Create 在同一个事务中创建所有用户并调用 afterCreate，任一步失败时全部回滚
+func (r *GormRepository) Create(ctx context.Context, users ...*UserModel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, u := range users {
+			if err := tx.Create(u).Error; err != nil {
+				if isDuplicateError(err) {
+					return &DuplicateUsernameError{Username: u.Username}
+				}
+				return err
+			}
+			for _, fn := range r.afterCreate {
+				if err := fn(tx, u); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Save 保存用户的所有字段
+func (r *GormRepository) Save(ctx context.Context, user *UserModel) error {
+	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
+		if isDuplicateError(err) {
+			return &DuplicateUsernameError{Username: user.Username}
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete 根据用户名删除用户
+func (r *GormRepository) Delete(ctx context.Context, username string) error {
+	return Delete(r.db.WithContext(ctx), username)
+}
+
+// FindByContact 按手机号或邮箱查找用户，手机号规范化后按 users.phone 索引查找
+func (r *GormRepository) FindByContact(ctx context.Context, phone, email string) (*UserModel, error) {
+	query := r.db.WithContext(ctx)
+	if phone != "" {
+		query = query.Where("phone = ?", domain.NormalizePhone(phone))
+	} else {
+		query = query.Where("LOWER(email) = ?", strings.ToLower(email))
+	}
+	var user UserModel
+	if err := query.Order("id").First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindNotificationPreferences 查询用户的通知偏好
+func (r *GormRepository) FindNotificationPreferences(ctx context.Context, userID uint) (*NotificationPreferencesModel, error) {
+	var prefs NotificationPreferencesModel
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&prefs).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreferencesNotFound
+		}
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// SaveNotificationPreferences 按用户ID创建或覆盖通知偏好
+func (r *GormRepository) SaveNotificationPreferences(ctx context.Context, prefs *NotificationPreferencesModel) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"media", "categories", "updated_at"}),
+	}).Create(prefs).Error
+}
+
+// isDuplicateError 检查是否是唯一性约束错误，各数据库驱动的错误信息不同
+func isDuplicateError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate")
+}
@@ -0,0 +1,203 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/twotwo/go-blueprint/domain"
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// MemoryRepository 内存版的 UserRepository，用于测试和本地开发
+// 返回值都是副本，调用方修改后需要通过 Save 写回
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	clock  module.Clock
+	users  map[string]UserModel // key 为用户名
+	nextID uint
+
+	preferences       map[uint]NotificationPreferencesModel // key 为用户ID
+	nextPreferencesID int64
+}
+
+// ensure that we've conformed to the `UserRepository` with a compile-time check
+var _ UserRepository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository 创建内存版用户仓储，clock 为 nil 时使用 module.SystemClock
+func NewMemoryRepository(clock module.Clock) *MemoryRepository {
+	if clock == nil {
+		clock = module.SystemClock
+	}
+	return &MemoryRepository{
+		clock:       clock,
+		users:       map[string]UserModel{},
+		nextID:      1,
+		preferences: map[uint]NotificationPreferencesModel{},
+	}
+}
+
+// FindByUsername 根据用户名查找用户
+func (r *MemoryRepository) FindByUsername(ctx context.Context, username string) (*UserModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// List 按资料过滤条件查询用户
+func (r *MemoryRepository) List(ctx context.Context, filters []ProfileFilter) ([]UserModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]UserModel, 0, len(r.users))
+	for _, u := range r.users {
+		if matchProfile(u, filters) {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Create 创建用户，先检查所有用户名再写入，保证全部成功或全部失败
+func (r *MemoryRepository) Create(ctx context.Context, users ...*UserModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := map[string]bool{}
+	for _, u := range users {
+		if _, exists := r.users[u.Username]; exists || seen[u.Username] {
+			return &DuplicateUsernameError{Username: u.Username}
+		}
+		seen[u.Username] = true
+	}
+
+	now := r.clock.Now()
+	for _, u := range users {
+		u.Phone = domain.NormalizePhone(u.Phone)
+		u.ID = r.nextID
+		r.nextID++
+		u.CreatedAt, u.UpdatedAt = now, now
+		if len(u.Profile) == 0 {
+			u.Profile = []byte("{}")
+		}
+		r.users[u.Username] = *u
+	}
+	return nil
+}
+
+// Save 保存用户的所有字段，支持修改用户名
+func (r *MemoryRepository) Save(ctx context.Context, user *UserModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, existing := range r.users {
+		if existing.ID != user.ID {
+			continue
+		}
+		if name != user.Username {
+			if _, taken := r.users[user.Username]; taken {
+				return &DuplicateUsernameError{Username: user.Username}
+			}
+			delete(r.users, name)
+		}
+		user.Phone = domain.NormalizePhone(user.Phone)
+		user.UpdatedAt = r.clock.Now()
+		r.users[user.Username] = *user
+		return nil
+	}
+	return ErrUserNotFound
+}
+
+// Delete 根据用户名删除用户
+func (r *MemoryRepository) Delete(ctx context.Context, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, username)
+	return nil
+}
+
+// FindByContact 按手机号或邮箱查找用户
+func (r *MemoryRepository) FindByContact(ctx context.Context, phone, email string) (*UserModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *UserModel
+	for _, u := range r.users {
+		match := u.Phone == domain.NormalizePhone(phone)
+		if phone == "" {
+			match = strings.EqualFold(u.Email, email)
+		}
+		if match && (found == nil || u.ID < found.ID) {
+			found = &u
+		}
+	}
+	if found == nil {
+		return nil, ErrUserNotFound
+	}
+	return found, nil
+}
+
+// FindNotificationPreferences 查询用户的通知偏好
+func (r *MemoryRepository) FindNotificationPreferences(ctx context.Context, userID uint) (*NotificationPreferencesModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prefs, ok := r.preferences[userID]
+	if !ok {
+		return nil, ErrPreferencesNotFound
+	}
+	return prefs.clone(), nil
+}
+
+// SaveNotificationPreferences 按用户ID创建或覆盖通知偏好
+func (r *MemoryRepository) SaveNotificationPreferences(ctx context.Context, prefs *NotificationPreferencesModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if existing, ok := r.preferences[prefs.UserID]; ok {
+		prefs.ID, prefs.CreatedAt = existing.ID, existing.CreatedAt
+	} else {
+		r.nextPreferencesID++
+		prefs.ID, prefs.CreatedAt = r.nextPreferencesID, now
+	}
+	prefs.UpdatedAt = now
+	r.preferences[prefs.UserID] = *prefs.clone()
+	return nil
+}
+
+// matchProfile 按与 SQLite 相同的规则比较资料中的值
+func matchProfile(u UserModel, filters []ProfileFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	var profile map[string]interface{}
+	if err := json.Unmarshal(u.Profile, &profile); err != nil {
+		return false
+	}
+
+	for _, f := range filters {
+		var current interface{} = profile
+		for _, key := range f.Keys {
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			current = obj[key]
+		}
+		if current != profileFilterValue("memory", f.Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/twotwo/go-blueprint/pkg/module"
+)
+
+// 两种实现跑同一组用例，保证行为一致
+func repositories(t *testing.T) map[string]UserRepository {
+	return map[string]UserRepository{
+		"gorm":   NewGormRepository(setupTestDB(t)),
+		"memory": NewMemoryRepository(nil),
+	}
+}
+
+func TestUserRepository(t *testing.T) {
+	t.Parallel()
+
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			a := &UserModel{Username: "alice", Profile: datatypes.JSON(`{"city":"Beijing","vip":true}`)}
+			b := &UserModel{Username: "bob", Profile: datatypes.JSON(`{"city":"Shanghai","vip":false}`)}
+			require.NoError(t, repo.Create(ctx, a, b))
+			assert.NotZero(t, a.ID)
+			assert.NotEqual(t, a.ID, b.ID)
+
+			// 批量创建中有重复用户名时整体失败
+			err := repo.Create(ctx, &UserModel{Username: "carol"}, &UserModel{Username: "alice"})
+			var dupErr *DuplicateUsernameError
+			require.ErrorAs(t, err, &dupErr)
+			assert.Equal(t, "alice", dupErr.Username)
+			assert.True(t, errors.Is(err, ErrUsernameTaken))
+			_, err = repo.FindByUsername(ctx, "carol")
+			assert.ErrorIs(t, err, ErrUserNotFound)
+
+			found, err := repo.FindByUsername(ctx, "alice")
+			require.NoError(t, err)
+			found.Email = "alice@example.com"
+			require.NoError(t, repo.Save(ctx, found))
+
+			found, err = repo.FindByUsername(ctx, "alice")
+			require.NoError(t, err)
+			assert.Equal(t, "alice@example.com", found.Email)
+
+			// 改名为已存在的用户名时返回重复错误
+			found.Username = "bob"
+			err = repo.Save(ctx, found)
+			require.ErrorAs(t, err, &dupErr)
+			assert.Equal(t, "bob", dupErr.Username)
+
+			filters, err := ProfileFilters(map[string]string{"vip": "true"})
+			require.NoError(t, err)
+			users, err := repo.List(ctx, filters)
+			require.NoError(t, err)
+			require.Len(t, users, 1)
+			assert.Equal(t, "alice", users[0].Username)
+
+			users, err = repo.List(ctx, nil)
+			require.NoError(t, err)
+			assert.Len(t, users, 2)
+
+			require.NoError(t, repo.Delete(ctx, "bob"))
+			_, err = repo.FindByUsername(ctx, "bob")
+			assert.ErrorIs(t, err, ErrUserNotFound)
+		})
+	}
+}
+
+// 处理器只依赖 UserRepository，使用内存实现即可测试，不需要 SQLite
+func TestHandlerWithMemoryRepository(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	NewServer(NewMemoryRepository(nil), module.Deps{}, Config{}).Routes(r)
+
+	createUserForTest(r, t, "inmemory")
+
+	req := httptest.NewRequest(http.MethodGet, "/user/inmemory", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "inmemory")
+
+	req = httptest.NewRequest(http.MethodGet, "/user/login?username=inmemory&password=pass", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/user/inmemory", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/user/inmemory", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestGormRepositoryAfterCreate(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestDB(t)
+	ctx := context.Background()
+	var seen []string
+	errRejected := errors.New("rejected")
+	repo := NewGormRepository(db, func(tx *gorm.DB, u *UserModel) error {
+		// 钩子在同一事务中执行，能读到刚写入的用户
+		var found UserModel
+		if err := tx.First(&found, u.ID).Error; err != nil {
+			return err
+		}
+		seen = append(seen, found.Username)
+		if u.Username == "mallory" {
+			return errRejected
+		}
+		return nil
+	})
+
+	require.NoError(t, repo.Create(ctx, &UserModel{Username: "alice"}, &UserModel{Username: "bob"}))
+	assert.Equal(t, []string{"alice", "bob"}, seen)
+
+	// 钩子失败时同一批创建的用户全部回滚
+	err := repo.Create(ctx, &UserModel{Username: "carol"}, &UserModel{Username: "mallory"})
+	require.ErrorIs(t, err, errRejected)
+	for _, name := range []string{"carol", "mallory"} {
+		_, err := repo.FindByUsername(ctx, name)
+		assert.ErrorIs(t, err, ErrUserNotFound, name)
+	}
+
+	// 通过 Config.AfterCreate 接入处理器，钩子失败时创建请求失败
+	r := chi.NewRouter()
+	New(module.Deps{DB: db}, Config{AfterCreate: func(tx *gorm.DB, u *UserModel) error {
+		return errRejected
+	}}).Routes(r)
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"username":"dave","password":"pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	_, err = repo.FindByUsername(ctx, "dave")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
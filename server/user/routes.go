@@ -1,34 +1,99 @@
 package user
 
 import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/go-chi/chi/v5"
+
+	"github.com/twotwo/go-blueprint/pkg/errors"
+	"github.com/twotwo/go-blueprint/pkg/jsonschema"
+	"github.com/twotwo/go-blueprint/pkg/module"
+	"github.com/twotwo/go-blueprint/pkg/storage"
 )
 
-// RegisterRoutes 注册用户相关路由
-func RegisterRoutes(r chi.Router) {
-	r.Route("/user", func(r chi.Router) {
-		// POST /user - 创建单个用户
-		r.Post("/", CreateUser)
+// Config 用户模块配置
+type Config struct {
+	// AvatarStorage 头像文件的存储后端，为 nil 时头像接口返回 501
+	AvatarStorage storage.Storage
+
+	// ProfileSchema 用户资料的 JSON Schema，为 nil 时不做校验
+	ProfileSchema *jsonschema.Schema
+
+	// MaxAvatarSize 头像文件的最大字节数，为 0 时使用 DefaultMaxAvatarSize
+	MaxAvatarSize int64
+
+	// AvatarURLExpiry 签名下载地址的有效期，为 0 时使用 DefaultAvatarURLExpiry
+	AvatarURLExpiry time.Duration
+
+	// AfterCreate 在创建用户的同一事务中调用，返回错误时用户不会被创建，
+	// 例如通过 message.CreateInTx 写入欢迎消息，事务提交后才会发出；只对 New 创建的 gorm 存储生效
+	AfterCreate AfterCreateFunc
+
+	// MandatoryCategories 不能关闭的通知类别，这些类别的消息不受通知偏好约束，为 nil 时使用 DefaultMandatoryCategories
+	MandatoryCategories []string
+}
 
-		// POST /user/createWithList - 批量创建用户
-		r.Post("/createWithList", CreateUsersWithListInput)
+// withDefaults 为未设置的配置项填充默认值
+func (c Config) withDefaults() Config {
+	if c.MaxAvatarSize <= 0 {
+		c.MaxAvatarSize = DefaultMaxAvatarSize
+	}
+	if c.AvatarURLExpiry <= 0 {
+		c.AvatarURLExpiry = DefaultAvatarURLExpiry
+	}
+	if c.MandatoryCategories == nil {
+		c.MandatoryCategories = DefaultMandatoryCategories
+	}
+	return c
+}
 
-		// GET /user/login - 用户登录
-		r.Get("/login", LoginUser)
+// ensure that we've conformed to the `module.Module` with a compile-time check
+var _ module.Module = (*Server)(nil)
 
-		// GET /user/logout - 用户登出
-		r.Get("/logout", LogoutUser)
+// ensure that we've conformed to the generated `StrictServerInterface` with a compile-time check
+var _ StrictServerInterface = (*Server)(nil)
 
-		// 针对特定用户名的操作
-		r.Route("/{username}", func(r chi.Router) {
-			// GET /user/{username} - 获取用户信息
-			r.Get("/", GetUserByName)
+// Models 返回用户模块需要迁移的模型，users 表由 domain.MigrateUsers 单独迁移
+func Models() []interface{} {
+	return []interface{}{&NotificationPreferencesModel{}}
+}
 
-			// PUT /user/{username} - 更新用户信息
-			r.Put("/", UpdateUser)
+// New 创建基于 deps.DB 持久化的用户模块
+func New(deps module.Deps, cfg Config) *Server {
+	var hooks []AfterCreateFunc
+	if cfg.AfterCreate != nil {
+		hooks = append(hooks, cfg.AfterCreate)
+	}
+	return NewServer(NewGormRepository(deps.DB, hooks...), deps, cfg)
+}
 
-			// DELETE /user/{username} - 删除用户
-			r.Delete("/", DeleteUser)
-		})
+// Routes 注册用户相关路由，路由和参数解析由 api.yaml 生成
+func (h *Server) Routes(r chi.Router) {
+	strict := NewStrictHandlerWithOptions(h, nil, StrictHTTPServerOptions{
+		RequestErrorHandlerFunc:  errors.HandleRequestError,
+		ResponseErrorHandlerFunc: errors.HandleResponseError(h.logger),
 	})
+	HandlerWithOptions(strict, ChiServerOptions{
+		BaseRouter:       r,
+		ErrorHandlerFunc: paramError,
+	})
+}
+
+// paramError 将生成代码的参数解析错误转换为统一的错误响应
+func paramError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		required *RequiredParamError
+		invalid  *InvalidParamFormatError
+	)
+	switch {
+	case stderrors.As(err, &required):
+		errors.WriteJSON(w, errors.BadRequest(fmt.Sprintf("缺少参数: %s", required.ParamName)))
+	case stderrors.As(err, &invalid):
+		errors.WriteJSON(w, errors.BadRequest(fmt.Sprintf("无效的参数: %s", invalid.ParamName)))
+	default:
+		errors.WriteJSON(w, errors.BadRequest("无效的请求参数"))
+	}
 }